@@ -1,15 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/takadao/banking/docs"
+	"github.com/takadao/banking/internal/bankfile"
+	"github.com/takadao/banking/internal/breach"
 	"github.com/takadao/banking/internal/config"
 	"github.com/takadao/banking/internal/handlers"
+	"github.com/takadao/banking/internal/mail"
 	"github.com/takadao/banking/internal/middleware"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/realtime"
 	"github.com/takadao/banking/internal/repository"
 	"github.com/takadao/banking/internal/routes"
 	"github.com/takadao/banking/internal/service"
@@ -47,26 +53,99 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	// Initialize Redis connection
+	redisClient := config.NewRedisConnection(cfg)
+	defer redisClient.Close()
+
+	// Fan out real-time notifications to clients connected to this instance
+	hub := realtime.NewHub(redisClient)
+	go hub.Run(context.Background())
+
+	// JWTs and mailed tokens are signed with the JWT secret
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		log.Fatal("JWT_SECRET environment variable is required")
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	transactionRepo := repository.NewTransactionRepository(db)
+	profileRepo := repository.NewProfileRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	standingOrderRepo := repository.NewStandingOrderRepository(db)
+	paymentRequestRepo := repository.NewPaymentRequestRepository(db)
+	holdRepo := repository.NewHoldRepository(db, transactionRepo)
+	pricingRepo := repository.NewPricingRepository(db)
+	interestRepo := repository.NewInterestRepository(db, transactionRepo)
+	accountRepo := repository.NewAccountRepository(db)
+	approvalRepo := repository.NewApprovalRepository(db, transactionRepo)
+	changeRequestRepo := repository.NewChangeRequestRepository(db)
+	adjustmentRepo := repository.NewAdjustmentRepository(db, transactionRepo)
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	bankImportRepo := repository.NewBankImportRepository(db, transactionRepo)
+	payoutRepo := repository.NewPayoutRepository(db)
+	beneficiaryRepo := repository.NewBeneficiaryRepository(db)
+	privacyRepo := repository.NewPrivacyRepository(db)
+	userTokenRepo := repository.NewUserTokenRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	serviceAccountRepo := repository.NewServiceAccountRepository(db)
 
 	// Initialize services
-	userService := service.NewUserService(userRepo)
-	transactionService := service.NewTransactionService(transactionRepo)
+	webhookService := service.NewWebhookService(webhookRepo)
+	passwordService := service.NewPasswordService(userRepo, cfg.PasswordPolicy(), newBreachChecker(cfg))
+	sessionService := service.NewSessionService(sessionRepo)
+	serviceAccountService := service.NewServiceAccountService(serviceAccountRepo, userRepo)
+	userTokenService := service.NewUserTokenService(userTokenRepo, userRepo, passwordService, sessionService, newMailer(cfg), []byte(jwtSecret), cfg.AppBaseURL)
+	userService := service.NewUserService(userRepo, userTokenService, passwordService)
+	kycService := service.NewKYCService(profileRepo)
+	pricingService := service.NewPricingService(pricingRepo, transactionRepo)
+	accountService := service.NewAccountService(accountRepo, transactionRepo, userRepo)
+	recipientService := service.NewRecipientService(userRepo, profileRepo)
+	transactionService := service.NewTransactionService(transactionRepo, userRepo, kycService, pricingService, accountService, recipientService)
+	approvalService := service.NewApprovalService(approvalRepo, accountService, transactionService)
+	standingOrderService := service.NewStandingOrderService(standingOrderRepo, approvalService, redisClient)
+	paymentRequestService := service.NewPaymentRequestService(paymentRequestRepo, approvalService)
+	holdService := service.NewHoldService(holdRepo, transactionService)
+	interestService := service.NewInterestService(interestRepo, transactionRepo, redisClient)
+	adjustmentService := service.NewAdjustmentService(adjustmentRepo, userRepo)
+	lifecycleService := service.NewUserLifecycleService(userRepo, accountRepo, transactionService)
+	privacyService := service.NewPrivacyService(privacyRepo, userRepo, models.DefaultRetentionPolicy)
+	changeRequestService := service.NewChangeRequestService(changeRequestRepo, userRepo, transactionService, adjustmentService, privacyService, passwordService, holdService, userService, sessionService)
+	reconciliationService := service.NewReconciliationService(reconciliationRepo, redisClient)
+	bankImportService := service.NewBankImportService(bankImportRepo, accountRepo, userRepo, kycService)
+	beneficiaryService := service.NewBeneficiaryService(beneficiaryRepo, userRepo, accountRepo, profileRepo)
+	payoutService := service.NewPayoutService(payoutRepo, redisClient, bankfile.Debtor{
+		Name: cfg.PayoutDebtorName,
+		IBAN: cfg.PayoutDebtorIBAN,
+		BIC:  cfg.PayoutDebtorBIC,
+	})
 
 	// Initialize JWT middleware
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET environment variable is required")
-	}
-	authMiddleware := middleware.NewAuthMiddleware(jwtSecret)
+	authMiddleware := middleware.NewAuthMiddleware(jwtSecret, userRepo, sessionRepo, serviceAccountRepo)
 
 	// Setup routes
 	router := routes.SetupRouter(
-		handlers.NewAuthHandler(userService, authMiddleware),
-		handlers.NewUserHandler(userService, transactionRepo),
-		handlers.NewTransactionHandler(transactionService),
+		handlers.NewAuthHandler(userService, userTokenService, sessionService, changeRequestService, authMiddleware),
+		handlers.NewUserHandler(userService, lifecycleService, transactionRepo, changeRequestService, sessionService),
+		handlers.NewTransactionHandler(transactionService, approvalService, beneficiaryService, recipientService),
+		handlers.NewKYCHandler(kycService),
+		handlers.NewWebhookHandler(webhookService),
+		handlers.NewEventHandler(hub),
+		handlers.NewStandingOrderHandler(standingOrderService),
+		handlers.NewPaymentRequestHandler(paymentRequestService),
+		handlers.NewHoldHandler(holdService, changeRequestService),
+		handlers.NewPricingHandler(pricingService),
+		handlers.NewInterestHandler(interestService),
+		handlers.NewAccountHandler(accountService),
+		handlers.NewApprovalHandler(approvalService),
+		handlers.NewChangeRequestHandler(changeRequestService),
+		handlers.NewAdjustmentHandler(adjustmentService, changeRequestService),
+		handlers.NewReconciliationHandler(reconciliationService),
+		handlers.NewBankImportHandler(bankImportService),
+		handlers.NewPayoutHandler(payoutService),
+		handlers.NewBeneficiaryHandler(beneficiaryService),
+		handlers.NewPrivacyHandler(privacyService, changeRequestService),
+		handlers.NewServiceAccountHandler(serviceAccountService, authMiddleware),
 		authMiddleware,
 	)
 
@@ -85,3 +164,31 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// newMailer creates the mailer configured by MAILER
+func newMailer(cfg *config.Config) mail.Mailer {
+	switch cfg.Mailer {
+	case "smtp":
+		return mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	case "file":
+		return mail.NewFileMailer(cfg.MailDir, cfg.MailFrom)
+	case "log":
+		return mail.NewLogMailer()
+	default:
+		log.Fatalf("Unknown MAILER %q: use smtp, file or log", cfg.Mailer)
+		return nil
+	}
+}
+
+// newBreachChecker checks passwords against the range files in
+// BREACHED_PASSWORDS_DIR, or returns nil when none is configured
+func newBreachChecker(cfg *config.Config) breach.Checker {
+	if cfg.BreachedPasswordsDir == "" {
+		return nil
+	}
+	checker, err := breach.NewRangeDirectory(cfg.BreachedPasswordsDir)
+	if err != nil {
+		log.Fatalf("Invalid BREACHED_PASSWORDS_DIR: %v", err)
+	}
+	return checker
+}
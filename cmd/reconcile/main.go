@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/takadao/banking/internal/config"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"github.com/takadao/banking/internal/service"
+)
+
+// reconcile recomputes every balance from the transaction history and
+// compares it with the stored balances and the per currency totals. The run
+// and its discrepancies are recorded like the worker's daily run; the command
+// exits with status 1 if any were found.
+//
+//	go run cmd/reconcile/main.go
+func main() {
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize database connection
+	db, err := config.NewDatabaseConnection(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	redisClient := config.NewRedisConnection(cfg)
+	defer redisClient.Close()
+
+	reconciliationService := service.NewReconciliationService(repository.NewReconciliationRepository(db), redisClient)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	run, err := reconciliationService.Run(ctx)
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+	if run.Status == models.ReconciliationFailed {
+		log.Fatalf("Reconciliation run %s failed: %s", run.ID, run.Error)
+	}
+
+	for _, total := range run.Totals {
+		log.Printf("%s: stored %.2f, computed %.2f", total.Currency, total.Stored, total.Computed)
+	}
+	for _, d := range run.Discrepancies {
+		owner := "total"
+		if d.UserID != nil {
+			owner = d.UserID.String()
+		}
+		log.Printf("Discrepancy %s %s: stored %.2f, computed %.2f, difference %.2f", owner, d.Currency, d.Stored, d.Computed, d.Difference)
+	}
+	log.Printf("Reconciliation run %s: %d balances checked, %d discrepancies", run.ID, run.BalancesChecked, run.DiscrepancyCount)
+	if run.DiscrepancyCount > 0 {
+		os.Exit(1)
+	}
+}
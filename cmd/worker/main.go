@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/takadao/banking/internal/bankfile"
+	"github.com/takadao/banking/internal/config"
+	"github.com/takadao/banking/internal/events"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/realtime"
+	"github.com/takadao/banking/internal/repository"
+	"github.com/takadao/banking/internal/service"
+)
+
+const (
+	pollInterval       = 5 * time.Second
+	outboxBatch        = 100
+	webhookBatch       = 50
+	orderBatch         = 50
+	expiryBatch        = 500
+	interestDays       = 1
+	reconciliationRuns = 1
+	payoutBatch        = 1000
+	retentionBatch     = 50
+	webhooksGroup      = "webhooks"
+	realtimeGroup      = "realtime"
+)
+
+// The worker runs background jobs that must not block API requests
+func main() {
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize database connection
+	db, err := config.NewDatabaseConnection(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	redisClient := config.NewRedisConnection(cfg)
+	defer redisClient.Close()
+
+	outboxRelay := service.NewOutboxRelay(repository.NewOutboxRepository(db), events.NewPublisher(redisClient, events.DefaultStream))
+	webhookService := service.NewWebhookService(repository.NewWebhookRepository(db))
+	notificationService := service.NewNotificationService(realtime.NewBroadcaster(redisClient))
+	transactionRepo := repository.NewTransactionRepository(db)
+	kycService := service.NewKYCService(repository.NewProfileRepository(db))
+	pricingService := service.NewPricingService(repository.NewPricingRepository(db), transactionRepo)
+	accountService := service.NewAccountService(repository.NewAccountRepository(db), transactionRepo, repository.NewUserRepository(db))
+	recipientService := service.NewRecipientService(repository.NewUserRepository(db), repository.NewProfileRepository(db))
+	transactionService := service.NewTransactionService(transactionRepo, repository.NewUserRepository(db), kycService, pricingService, accountService, recipientService)
+	lifecycleService := service.NewUserLifecycleService(repository.NewUserRepository(db), repository.NewAccountRepository(db), transactionService)
+	approvalService := service.NewApprovalService(repository.NewApprovalRepository(db, transactionRepo), accountService, transactionService)
+	standingOrderService := service.NewStandingOrderService(repository.NewStandingOrderRepository(db), approvalService, redisClient)
+	paymentRequestService := service.NewPaymentRequestService(repository.NewPaymentRequestRepository(db), approvalService)
+	holdService := service.NewHoldService(repository.NewHoldRepository(db, transactionRepo), transactionService)
+	interestService := service.NewInterestService(repository.NewInterestRepository(db, transactionRepo), transactionRepo, redisClient)
+	privacyService := service.NewPrivacyService(repository.NewPrivacyRepository(db), repository.NewUserRepository(db), models.DefaultRetentionPolicy)
+	sessionService := service.NewSessionService(repository.NewSessionRepository(db))
+	changeRequestExpiry := service.NewChangeRequestExpiry(repository.NewChangeRequestRepository(db))
+	reconciliationService := service.NewReconciliationService(repository.NewReconciliationRepository(db), redisClient)
+	payoutService := service.NewPayoutService(repository.NewPayoutRepository(db), redisClient, bankfile.Debtor{
+		Name: cfg.PayoutDebtorName,
+		IBAN: cfg.PayoutDebtorIBAN,
+		BIC:  cfg.PayoutDebtorBIC,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	consumerName := workerName()
+	var wg sync.WaitGroup
+	run := func(name string, job func(ctx context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Printf("Starting %s", name)
+			job(ctx)
+			log.Printf("Stopped %s", name)
+		}()
+	}
+
+	run("outbox relay", func(ctx context.Context) {
+		poll(ctx, "outbox relay", outboxBatch, outboxRelay.RelayBatch)
+	})
+	run("webhook fan-out", func(ctx context.Context) {
+		consumer := events.NewConsumer(redisClient, events.DefaultStream, webhooksGroup, consumerName, webhookService.HandleEvent)
+		if err := consumer.Run(ctx); err != nil {
+			log.Printf("webhook fan-out stopped: %v", err)
+		}
+	})
+	run("real-time notifications", func(ctx context.Context) {
+		consumer := events.NewConsumer(redisClient, events.DefaultStream, realtimeGroup, consumerName, notificationService.HandleEvent)
+		if err := consumer.Run(ctx); err != nil {
+			log.Printf("real-time notifications stopped: %v", err)
+		}
+	})
+	run("webhook deliveries", func(ctx context.Context) {
+		poll(ctx, "webhook deliveries", webhookBatch, webhookService.DeliverDue)
+	})
+	run("standing order scheduler", func(ctx context.Context) {
+		poll(ctx, "standing order scheduler", orderBatch, standingOrderService.RunDue)
+	})
+	run("payment request expiry", func(ctx context.Context) {
+		poll(ctx, "payment request expiry", expiryBatch, paymentRequestService.ExpireDue)
+	})
+	run("hold expiry", func(ctx context.Context) {
+		poll(ctx, "hold expiry", expiryBatch, holdService.ExpireDue)
+	})
+	run("pending payment expiry", func(ctx context.Context) {
+		poll(ctx, "pending payment expiry", expiryBatch, approvalService.ExpireDue)
+	})
+	run("approved payment execution", func(ctx context.Context) {
+		poll(ctx, "approved payment execution", expiryBatch, approvalService.ExecuteApproved)
+	})
+	run("change request expiry", func(ctx context.Context) {
+		poll(ctx, "change request expiry", expiryBatch, changeRequestExpiry.ExpireDue)
+	})
+	run("dormancy", func(ctx context.Context) {
+		poll(ctx, "dormancy", expiryBatch, lifecycleService.MarkDormant)
+	})
+	run("data retention", func(ctx context.Context) {
+		poll(ctx, "data retention", retentionBatch, privacyService.PurgeDue)
+	})
+	run("session cleanup", func(ctx context.Context) {
+		poll(ctx, "session cleanup", expiryBatch, sessionService.DeleteStale)
+	})
+	run("interest accrual", func(ctx context.Context) {
+		poll(ctx, "interest accrual", interestDays, interestService.RunDue)
+	})
+	run("ledger reconciliation", func(ctx context.Context) {
+		poll(ctx, "ledger reconciliation", reconciliationRuns, reconciliationService.RunDue)
+	})
+	if cfg.PayoutDebtorName != "" && cfg.PayoutDebtorIBAN != "" {
+		run("payout batching", func(ctx context.Context) {
+			poll(ctx, "payout batching", payoutBatch, payoutService.BatchDue)
+		})
+	} else {
+		log.Printf("Payout batching disabled: %v", service.ErrPayoutsNotConfigured)
+	}
+
+	wg.Wait()
+}
+
+// poll calls job every poll interval, draining full batches back to back,
+// until the context is cancelled
+func poll(ctx context.Context, name string, batch int, job func(ctx context.Context, limit int) (int, error)) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := job(ctx, batch)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("%s failed: %v", name, err)
+			}
+			if n < batch || err != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// workerName identifies this process within consumer groups
+func workerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
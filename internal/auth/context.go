@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ErrUnauthorized is returned when the request carries no usable identity
+var ErrUnauthorized = errors.New("unauthorized")
+
+// GetUserID returns the authenticated user's ID set by the auth middleware
+func GetUserID(c *gin.Context) (uuid.UUID, error) {
+	value, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, ErrUnauthorized
+	}
+	id, ok := value.(string)
+	if !ok {
+		return uuid.Nil, ErrUnauthorized
+	}
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, ErrUnauthorized
+	}
+	return userID, nil
+}
+
+// GetSessionID returns the ID of the session the request's token is bound to
+func GetSessionID(c *gin.Context) (uuid.UUID, error) {
+	value, exists := c.Get("session_id")
+	if !exists {
+		return uuid.Nil, ErrUnauthorized
+	}
+	id, ok := value.(string)
+	if !ok {
+		return uuid.Nil, ErrUnauthorized
+	}
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, ErrUnauthorized
+	}
+	return sessionID, nil
+}
+
+// GetRole returns the authenticated user's role set by the auth middleware
+func GetRole(c *gin.Context) string {
+	role, _ := c.Get("role")
+	if r, ok := role.(string); ok {
+		return r
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+// Package bankfile reads and writes the files exchanged with our bank:
+// notifications of incoming payments, pain.001 payout batches and their
+// pain.002 status reports
+package bankfile
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/takadao/banking/internal/models"
+)
+
+// Parse reads the credit entries of a bank file in the given format. Without
+// a format, XML content is read as camt.054 and anything else as CSV.
+func Parse(format models.BankFileFormat, data []byte) (models.BankFileFormat, []models.BankEntry, error) {
+	if format == "" {
+		format = Detect(data)
+	}
+	var (
+		entries []models.BankEntry
+		err     error
+	)
+	switch format {
+	case models.BankFileCSV:
+		entries, err = ParseCSV(bytes.NewReader(data))
+	case models.BankFileCamt054:
+		entries, err = ParseCamt054(bytes.NewReader(data))
+	default:
+		return format, nil, models.ErrUnsupportedBankFile
+	}
+	return format, entries, err
+}
+
+// Detect guesses the format of a bank file from its content
+func Detect(data []byte) models.BankFileFormat {
+	if bytes.HasPrefix(bytes.TrimLeft(data, "\ufeff \t\r\n"), []byte("<")) {
+		return models.BankFileCamt054
+	}
+	return models.BankFileCSV
+}
+
+// parseAmount reads a decimal amount, rounded to cents
+func parseAmount(value string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return math.Round(amount*100) / 100, nil
+}
@@ -0,0 +1,134 @@
+package bankfile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/takadao/banking/internal/models"
+)
+
+const camtSample = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.054.001.08">
+  <BkToCstmrDbtCdtNtfctn>
+    <Ntfctn>
+      <Ntry>
+        <NtryRef>N1</NtryRef>
+        <Amt Ccy="EUR">150.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><Dt>2024-05-02</Dt></BookgDt>
+        <AcctSvcrRef>BANK-1</AcctSvcrRef>
+        <NtryDtls>
+          <TxDtls>
+            <Refs><AcctSvcrRef>BANK-1-A</AcctSvcrRef></Refs>
+            <Amt Ccy="EUR">100.00</Amt>
+            <CdtDbtInd>CRDT</CdtDbtInd>
+            <RltdPties>
+              <Dbtr><Pty><Nm>Jane Doe</Nm></Pty></Dbtr>
+              <DbtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></DbtrAcct>
+            </RltdPties>
+            <RmtInf><Ustrd>Top up XT00 TAKA 0000000000</Ustrd></RmtInf>
+          </TxDtls>
+          <TxDtls>
+            <Amt Ccy="EUR">50.00</Amt>
+            <CdtDbtInd>CRDT</CdtDbtInd>
+            <RmtInf><Strd><CdtrRefInf><Ref>RF18539007547034</Ref></CdtrRefInf></Strd></RmtInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">20.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><Dt>2024-05-02</Dt></BookgDt>
+        <AcctSvcrRef>BANK-2</AcctSvcrRef>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">30.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>PDNG</Cd></Sts>
+        <BookgDt><Dt>2024-05-02</Dt></BookgDt>
+        <AcctSvcrRef>BANK-3</AcctSvcrRef>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="USD">12.5</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><DtTm>2024-05-03T09:30:00+02:00</DtTm></BookgDt>
+        <AcctSvcrRef>BANK-4</AcctSvcrRef>
+        <AddtlNtryInf>Refund</AddtlNtryInf>
+      </Ntry>
+    </Ntfctn>
+  </BkToCstmrDbtCdtNtfctn>
+</Document>`
+
+func TestParseCamt054(t *testing.T) {
+	format, entries, err := Parse("", []byte(camtSample))
+	require.NoError(t, err)
+	assert.Equal(t, models.BankFileCamt054, format)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "BANK-1-A", entries[0].EntryID)
+	assert.Equal(t, 100.0, entries[0].Amount)
+	assert.Equal(t, "EUR", entries[0].Currency)
+	assert.Equal(t, "Jane Doe", entries[0].DebtorName)
+	assert.Equal(t, "DE89370400440532013000", entries[0].DebtorAccount)
+	assert.Equal(t, "Top up XT00 TAKA 0000000000", entries[0].Reference)
+	assert.Equal(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), entries[0].BookingDate)
+
+	assert.Equal(t, "BANK-1/2", entries[1].EntryID)
+	assert.Equal(t, 50.0, entries[1].Amount)
+	assert.Equal(t, "RF18539007547034", entries[1].Reference)
+
+	assert.Equal(t, "BANK-4", entries[2].EntryID)
+	assert.Equal(t, 12.5, entries[2].Amount)
+	assert.Equal(t, "Refund", entries[2].Reference)
+	assert.Equal(t, time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC), entries[2].BookingDate)
+}
+
+func TestParseCSV(t *testing.T) {
+	data := "Entry_ID,booking_date,amount,currency,reference,debtor_name\n" +
+		"E1,2024-05-02,25.50,eur,Invoice 42,Jane Doe\n" +
+		"E2,2024-05-02,-10.00,EUR,Card fee,\n" +
+		"E3,2024-05-03,1000,EUR,\"Rent, May\",\n"
+
+	format, entries, err := Parse("", []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, models.BankFileCSV, format)
+	require.Len(t, entries, 2)
+	assert.Equal(t, models.BankEntry{
+		EntryID:     "E1",
+		BookingDate: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+		Amount:      25.5,
+		Currency:    "EUR",
+		Reference:   "Invoice 42",
+		DebtorName:  "Jane Doe",
+	}, entries[0])
+	assert.Equal(t, "Rent, May", entries[1].Reference)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format models.BankFileFormat
+		data   string
+		want   string
+	}{
+		{"missing column", models.BankFileCSV, "entry_id,amount,currency\nE1,10,EUR\n", "missing column booking_date"},
+		{"invalid amount", models.BankFileCSV, "entry_id,booking_date,amount,currency\nE1,2024-05-02,ten,EUR\n", `line 2: invalid amount "ten"`},
+		{"missing entry ID", models.BankFileCSV, "entry_id,booking_date,amount,currency\n,2024-05-02,10,EUR\n", "line 2: " + models.ErrInvalidBankEntryID.Error()},
+		{"only debits", models.BankFileCSV, "entry_id,booking_date,amount,currency\nE1,2024-05-02,-10,EUR\n", models.ErrEmptyBankFile.Error()},
+		{"malformed XML", models.BankFileCamt054, "<Document><BkToCstmrDbtCdtNtfctn>", "invalid camt.054 file"},
+		{"unknown format", "mt940", "", models.ErrUnsupportedBankFile.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Parse(tt.format, []byte(tt.data))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
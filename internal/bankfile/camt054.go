@@ -0,0 +1,168 @@
+package bankfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/takadao/banking/internal/models"
+)
+
+// camtDocument is the part of an ISO 20022 camt.054 (bank to customer debit
+// credit notification) we read. Elements are matched by local name, so any
+// version of the schema namespace is accepted.
+type camtDocument struct {
+	Notifications []struct {
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrDbtCdtNtfctn>Ntfctn"`
+}
+
+type camtEntry struct {
+	NtryRef     string     `xml:"NtryRef"`
+	AcctSvcrRef string     `xml:"AcctSvcrRef"`
+	Amt         camtAmount `xml:"Amt"`
+	CdtDbtInd   string     `xml:"CdtDbtInd"`
+	Sts         struct {
+		Text string `xml:",chardata"` // up to version 7
+		Cd   string `xml:"Cd"`        // from version 8
+	} `xml:"Sts"`
+	BookgDt      camtDate `xml:"BookgDt"`
+	AddtlNtryInf string   `xml:"AddtlNtryInf"`
+	TxDtls       []struct {
+		Refs struct {
+			AcctSvcrRef string `xml:"AcctSvcrRef"`
+		} `xml:"Refs"`
+		Amt       *camtAmount `xml:"Amt"`
+		CdtDbtInd string      `xml:"CdtDbtInd"`
+		RltdPties struct {
+			DbtrName    string `xml:"Dbtr>Nm"`
+			DbtrPtyName string `xml:"Dbtr>Pty>Nm"`
+			DbtrIBAN    string `xml:"DbtrAcct>Id>IBAN"`
+		} `xml:"RltdPties"`
+		RmtInf struct {
+			Ustrd []string `xml:"Ustrd"`
+			Ref   []string `xml:"Strd>CdtrRefInf>Ref"`
+		} `xml:"RmtInf"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Dt   string `xml:"Dt"`
+	DtTm string `xml:"DtTm"`
+}
+
+// ParseCamt054 reads the booked credit entries of a camt.054 notification.
+// An entry batching several payments gives one bank entry per payment.
+func ParseCamt054(r io.Reader) ([]models.BankEntry, error) {
+	var doc camtDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid camt.054 file: %w", err)
+	}
+
+	var entries []models.BankEntry
+	for _, notification := range doc.Notifications {
+		for n, ntry := range notification.Entries {
+			status := strings.TrimSpace(ntry.Sts.Cd)
+			if status == "" {
+				status = strings.TrimSpace(ntry.Sts.Text)
+			}
+			if ntry.CdtDbtInd != "CRDT" || status != "BOOK" {
+				continue
+			}
+			bookingDate, err := ntry.BookgDt.parse()
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: %w", n+1, err)
+			}
+			entryRef := ntry.AcctSvcrRef
+			if entryRef == "" {
+				entryRef = ntry.NtryRef
+			}
+
+			if len(ntry.TxDtls) == 0 {
+				entry, err := camtBankEntry(entryRef, bookingDate, ntry.Amt, ntry.AddtlNtryInf)
+				if err != nil {
+					return nil, fmt.Errorf("entry %d: %w", n+1, err)
+				}
+				entries = append(entries, *entry)
+				continue
+			}
+			for i, tx := range ntry.TxDtls {
+				if tx.CdtDbtInd != "" && tx.CdtDbtInd != "CRDT" {
+					continue
+				}
+				// A payment without its own reference is identified by its
+				// position in the entry
+				id := tx.Refs.AcctSvcrRef
+				if id == "" && len(ntry.TxDtls) == 1 {
+					id = entryRef
+				} else if id == "" && entryRef != "" {
+					id = fmt.Sprintf("%s/%d", entryRef, i+1)
+				}
+				amount := ntry.Amt
+				if tx.Amt != nil {
+					amount = *tx.Amt
+				}
+				reference := strings.Join(append(tx.RmtInf.Ref, tx.RmtInf.Ustrd...), " ")
+				if reference == "" {
+					reference = ntry.AddtlNtryInf
+				}
+
+				entry, err := camtBankEntry(id, bookingDate, amount, reference)
+				if err != nil {
+					return nil, fmt.Errorf("entry %d: %w", n+1, err)
+				}
+				entry.DebtorName = tx.RltdPties.DbtrName
+				if entry.DebtorName == "" {
+					entry.DebtorName = tx.RltdPties.DbtrPtyName
+				}
+				entry.DebtorAccount = tx.RltdPties.DbtrIBAN
+				entries = append(entries, *entry)
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil, models.ErrEmptyBankFile
+	}
+	return entries, nil
+}
+
+func camtBankEntry(id string, bookingDate time.Time, amount camtAmount, reference string) (*models.BankEntry, error) {
+	value, err := parseAmount(amount.Value)
+	if err != nil {
+		return nil, err
+	}
+	entry := &models.BankEntry{
+		EntryID:     strings.TrimSpace(id),
+		BookingDate: bookingDate,
+		Amount:      value,
+		Currency:    amount.Currency,
+		Reference:   strings.TrimSpace(reference),
+	}
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// parse returns the booking day; a date time is cut to its date
+func (d camtDate) parse() (time.Time, error) {
+	date := strings.TrimSpace(d.Dt)
+	if date == "" {
+		date = strings.TrimSpace(d.DtTm)
+		if len(date) > 10 {
+			date = date[:10]
+		}
+	}
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid booking date %q", date)
+	}
+	return t, nil
+}
@@ -0,0 +1,98 @@
+package bankfile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/takadao/banking/internal/models"
+)
+
+// csvColumns are the columns of a CSV bank file; the header row names them in
+// any order and the ones marked true are required
+var csvColumns = map[string]bool{
+	"entry_id":       true,
+	"booking_date":   true,
+	"amount":         true,
+	"currency":       true,
+	"reference":      false,
+	"debtor_name":    false,
+	"debtor_account": false,
+}
+
+// ParseCSV reads the credit entries of a CSV bank file. Rows with a negative
+// amount are debits and are skipped.
+func ParseCSV(r io.Reader) ([]models.BankEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, models.ErrEmptyBankFile
+		}
+		return nil, err
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := csvColumns[name]; ok {
+			index[name] = i
+		}
+	}
+	for name, required := range csvColumns {
+		if _, ok := index[name]; required && !ok {
+			return nil, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	var entries []models.BankEntry
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			i, ok := index[name]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		amount, err := parseAmount(field("amount"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if amount < 0 {
+			continue
+		}
+		bookingDate, err := time.Parse("2006-01-02", field("booking_date"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid booking_date, use YYYY-MM-DD", line)
+		}
+		entry := models.BankEntry{
+			EntryID:       field("entry_id"),
+			BookingDate:   bookingDate,
+			Amount:        amount,
+			Currency:      strings.ToUpper(field("currency")),
+			Reference:     field("reference"),
+			DebtorName:    field("debtor_name"),
+			DebtorAccount: strings.ReplaceAll(field("debtor_account"), " ", ""),
+		}
+		if err := entry.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, models.ErrEmptyBankFile
+	}
+	return entries, nil
+}
@@ -0,0 +1,161 @@
+package bankfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/takadao/banking/internal/models"
+)
+
+const pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.09"
+
+// Debtor is the bank account payouts are sent from
+type Debtor struct {
+	Name string
+	IBAN string
+	BIC  string
+}
+
+// PaymentInfoID identifies the payment information block of a currency in a
+// pain.001 message; status reports refer back to it
+func PaymentInfoID(messageID, currency string) string {
+	id := messageID + "-" + currency
+	if len(id) > 35 {
+		id = messageID[:35-len(currency)-1] + "-" + currency
+	}
+	return id
+}
+
+type pain001Document struct {
+	XMLName xml.Name        `xml:"Document"`
+	Xmlns   string          `xml:"xmlns,attr"`
+	GrpHdr  pain001GrpHdr   `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	PmtInf  []pain001PmtInf `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+type pain001GrpHdr struct {
+	MsgId    string `xml:"MsgId"`
+	CreDtTm  string `xml:"CreDtTm"`
+	NbOfTxs  int    `xml:"NbOfTxs"`
+	CtrlSum  string `xml:"CtrlSum"`
+	InitgPty string `xml:"InitgPty>Nm"`
+}
+
+type pain001PmtInf struct {
+	PmtInfId    string            `xml:"PmtInfId"`
+	PmtMtd      string            `xml:"PmtMtd"`
+	BtchBookg   bool              `xml:"BtchBookg"`
+	NbOfTxs     int               `xml:"NbOfTxs"`
+	CtrlSum     string            `xml:"CtrlSum"`
+	SvcLvl      string            `xml:"PmtTpInf>SvcLvl>Cd,omitempty"`
+	ReqdExctnDt string            `xml:"ReqdExctnDt>Dt"`
+	Dbtr        string            `xml:"Dbtr>Nm"`
+	DbtrIBAN    string            `xml:"DbtrAcct>Id>IBAN"`
+	DbtrAgt     pain001Agent      `xml:"DbtrAgt"`
+	ChrgBr      string            `xml:"ChrgBr"`
+	CdtTrfTxInf []pain001CdtTrfTx `xml:"CdtTrfTxInf"`
+}
+
+type pain001CdtTrfTx struct {
+	InstrId    string        `xml:"PmtId>InstrId"`
+	EndToEndId string        `xml:"PmtId>EndToEndId"`
+	Amt        pain001Amount `xml:"Amt>InstdAmt"`
+	CdtrAgt    *pain001Agent `xml:"CdtrAgt,omitempty"`
+	Cdtr       string        `xml:"Cdtr>Nm"`
+	CdtrIBAN   string        `xml:"CdtrAcct>Id>IBAN"`
+	Ustrd      string        `xml:"RmtInf>Ustrd,omitempty"`
+}
+
+// pain001Agent identifies a bank by BIC, or as not provided when unknown
+type pain001Agent struct {
+	BICFI string `xml:"FinInstnId>BICFI,omitempty"`
+	Othr  string `xml:"FinInstnId>Othr>Id,omitempty"`
+}
+
+func newAgent(bic string) pain001Agent {
+	if bic == "" {
+		return pain001Agent{Othr: "NOTPROVIDED"}
+	}
+	return pain001Agent{BICFI: bic}
+}
+
+type pain001Amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// Pain001 writes a pain.001 customer credit transfer initiation for the
+// payouts, with one payment information block per currency. Euro payments
+// are marked SEPA with shared charges at service level.
+func Pain001(messageID string, createdAt time.Time, debtor Debtor, payouts []models.Payout) ([]byte, error) {
+	byCurrency := map[string][]models.Payout{}
+	var currencies []string
+	total := 0.0
+	for _, payout := range payouts {
+		if byCurrency[payout.Currency] == nil {
+			currencies = append(currencies, payout.Currency)
+		}
+		byCurrency[payout.Currency] = append(byCurrency[payout.Currency], payout)
+		total += payout.Amount
+	}
+	sort.Strings(currencies)
+
+	doc := pain001Document{
+		Xmlns: pain001Namespace,
+		GrpHdr: pain001GrpHdr{
+			MsgId:    messageID,
+			CreDtTm:  createdAt.UTC().Format("2006-01-02T15:04:05Z"),
+			NbOfTxs:  len(payouts),
+			CtrlSum:  formatAmount(total),
+			InitgPty: debtor.Name,
+		},
+	}
+	for _, currency := range currencies {
+		info := pain001PmtInf{
+			PmtInfId:    PaymentInfoID(messageID, currency),
+			PmtMtd:      "TRF",
+			BtchBookg:   true,
+			ReqdExctnDt: createdAt.UTC().Format("2006-01-02"),
+			Dbtr:        debtor.Name,
+			DbtrIBAN:    debtor.IBAN,
+			DbtrAgt:     newAgent(debtor.BIC),
+			ChrgBr:      "SHAR",
+		}
+		if currency == "EUR" {
+			info.SvcLvl = "SEPA"
+			info.ChrgBr = "SLEV"
+		}
+		sum := 0.0
+		for _, payout := range byCurrency[currency] {
+			tx := pain001CdtTrfTx{
+				InstrId:    payout.EndToEndID,
+				EndToEndId: payout.EndToEndID,
+				Amt:        pain001Amount{Currency: currency, Value: formatAmount(payout.Amount)},
+				Cdtr:       payout.CreditorName,
+				CdtrIBAN:   payout.IBAN,
+				Ustrd:      payout.RemittanceInfo,
+			}
+			if payout.BIC != "" {
+				agent := newAgent(payout.BIC)
+				tx.CdtrAgt = &agent
+			}
+			info.CdtTrfTxInf = append(info.CdtTrfTxInf, tx)
+			sum += payout.Amount
+		}
+		info.NbOfTxs = len(info.CdtTrfTxInf)
+		info.CtrlSum = formatAmount(sum)
+		doc.PmtInf = append(doc.PmtInf, info)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func formatAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
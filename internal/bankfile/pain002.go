@@ -0,0 +1,119 @@
+package bankfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StatusReport is a pain.002 customer payment status report. A status can be
+// given for the whole message, a payment information block or a single
+// transaction; the most specific one applies.
+type StatusReport struct {
+	MessageID    string
+	GroupStatus  PaymentStatus
+	PaymentInfos map[string]PaymentStatus // by payment information ID
+	Transactions map[string]PaymentStatus // by end-to-end ID
+}
+
+// PaymentStatus is an ISO 20022 status code with its reason
+type PaymentStatus struct {
+	Code   string
+	Reason string
+}
+
+// Accepted reports whether the bank accepted the payment for execution
+func (s PaymentStatus) Accepted() bool {
+	switch s.Code {
+	case "ACCP", "ACSP", "ACSC", "ACCC", "ACWC":
+		return true
+	default:
+		return false
+	}
+}
+
+// Settled reports whether the payment was executed: the debtor's account was
+// debited (ACSC) or the creditor's credited (ACCC). Other accepted statuses
+// may still be followed by a rejection.
+func (s PaymentStatus) Settled() bool {
+	return s.Code == "ACSC" || s.Code == "ACCC"
+}
+
+// Rejected reports whether the bank rejected the payment
+func (s PaymentStatus) Rejected() bool {
+	return s.Code == "RJCT"
+}
+
+// StatusOf returns the status that applies to a transaction
+func (r *StatusReport) StatusOf(paymentInfoID, endToEndID string) PaymentStatus {
+	if status, ok := r.Transactions[endToEndID]; ok && status.Code != "" {
+		return status
+	}
+	if status, ok := r.PaymentInfos[paymentInfoID]; ok && status.Code != "" {
+		return status
+	}
+	return r.GroupStatus
+}
+
+type pain002Document struct {
+	Report struct {
+		OrgnlGrpInfAndSts struct {
+			OrgnlMsgId string          `xml:"OrgnlMsgId"`
+			GrpSts     string          `xml:"GrpSts"`
+			StsRsnInf  []pain002Reason `xml:"StsRsnInf"`
+		} `xml:"OrgnlGrpInfAndSts"`
+		OrgnlPmtInfAndSts []struct {
+			OrgnlPmtInfId string          `xml:"OrgnlPmtInfId"`
+			PmtInfSts     string          `xml:"PmtInfSts"`
+			StsRsnInf     []pain002Reason `xml:"StsRsnInf"`
+			TxInfAndSts   []struct {
+				OrgnlEndToEndId string          `xml:"OrgnlEndToEndId"`
+				TxSts           string          `xml:"TxSts"`
+				StsRsnInf       []pain002Reason `xml:"StsRsnInf"`
+			} `xml:"TxInfAndSts"`
+		} `xml:"OrgnlPmtInfAndSts"`
+	} `xml:"CstmrPmtStsRpt"`
+}
+
+type pain002Reason struct {
+	Code     string   `xml:"Rsn>Cd"`
+	AddtlInf []string `xml:"AddtlInf"`
+}
+
+// ParsePain002 reads a pain.002 status report
+func ParsePain002(r io.Reader) (*StatusReport, error) {
+	var doc pain002Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid pain.002 file: %w", err)
+	}
+	group := doc.Report.OrgnlGrpInfAndSts
+	if group.OrgnlMsgId == "" {
+		return nil, fmt.Errorf("invalid pain.002 file: missing OrgnlMsgId")
+	}
+
+	report := &StatusReport{
+		MessageID:    strings.TrimSpace(group.OrgnlMsgId),
+		GroupStatus:  newPaymentStatus(group.GrpSts, group.StsRsnInf),
+		PaymentInfos: map[string]PaymentStatus{},
+		Transactions: map[string]PaymentStatus{},
+	}
+	for _, info := range doc.Report.OrgnlPmtInfAndSts {
+		report.PaymentInfos[strings.TrimSpace(info.OrgnlPmtInfId)] = newPaymentStatus(info.PmtInfSts, info.StsRsnInf)
+		for _, tx := range info.TxInfAndSts {
+			report.Transactions[strings.TrimSpace(tx.OrgnlEndToEndId)] = newPaymentStatus(tx.TxSts, tx.StsRsnInf)
+		}
+	}
+	return report, nil
+}
+
+func newPaymentStatus(code string, reasons []pain002Reason) PaymentStatus {
+	var parts []string
+	for _, reason := range reasons {
+		text := strings.Join(append([]string{reason.Code}, reason.AddtlInf...), " ")
+		if text = strings.TrimSpace(text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return PaymentStatus{Code: strings.TrimSpace(code), Reason: strings.Join(parts, "; ")}
+}
@@ -0,0 +1,119 @@
+package bankfile
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/takadao/banking/internal/models"
+)
+
+func TestPain001(t *testing.T) {
+	payouts := []models.Payout{
+		{Amount: 100, Currency: "EUR", IBAN: "DE89370400440532013000", CreditorName: "Jane Doe", EndToEndID: "e2e1", RemittanceInfo: "Rent"},
+		{Amount: 20.5, Currency: "USD", IBAN: "GB29NWBK60161331926819", BIC: "NWBKGB2L", CreditorName: "John Roe", EndToEndID: "e2e2"},
+		{Amount: 50, Currency: "EUR", IBAN: "FR1420041010050500013M02606", CreditorName: "Marie Curie", EndToEndID: "e2e3"},
+	}
+	debtor := Debtor{Name: "TakaDAO Banking", IBAN: "DE02120300000000202051", BIC: "BYLADEM1001"}
+	createdAt := time.Date(2024, 5, 2, 14, 30, 0, 0, time.UTC)
+
+	data, err := Pain001("MSG1", createdAt, debtor, payouts)
+	require.NoError(t, err)
+	doc := string(data)
+
+	assert.True(t, strings.HasPrefix(doc, "<?xml"))
+	assert.Contains(t, doc, `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.09">`)
+	assert.Contains(t, doc, "<MsgId>MSG1</MsgId>")
+	assert.Contains(t, doc, "<CreDtTm>2024-05-02T14:30:00Z</CreDtTm>")
+	assert.Contains(t, doc, "<NbOfTxs>3</NbOfTxs>")
+	assert.Contains(t, doc, "<CtrlSum>170.50</CtrlSum>")
+	assert.Contains(t, doc, "<PmtInfId>MSG1-EUR</PmtInfId>")
+	assert.Contains(t, doc, "<CtrlSum>150.00</CtrlSum>")
+	assert.Contains(t, doc, "<Cd>SEPA</Cd>")
+	assert.Contains(t, doc, "<ChrgBr>SLEV</ChrgBr>")
+	assert.Contains(t, doc, "<PmtInfId>MSG1-USD</PmtInfId>")
+	assert.Contains(t, doc, "<ChrgBr>SHAR</ChrgBr>")
+	assert.Contains(t, doc, `<InstdAmt Ccy="USD">20.50</InstdAmt>`)
+	assert.Contains(t, doc, "<BICFI>NWBKGB2L</BICFI>")
+	assert.Contains(t, doc, "<Ustrd>Rent</Ustrd>")
+	assert.Equal(t, 2, strings.Count(doc, "<BICFI>BYLADEM1001</BICFI>"))
+	// Without a BIC the creditor agent is left out
+	assert.Equal(t, 1, strings.Count(doc, "<CdtrAgt>"))
+	assert.Less(t, strings.Index(doc, "MSG1-EUR"), strings.Index(doc, "MSG1-USD"))
+
+	data, err = Pain001("MSG2", createdAt, Debtor{Name: "TakaDAO Banking", IBAN: debtor.IBAN}, payouts[:1])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<Othr>\n            <Id>NOTPROVIDED</Id>")
+}
+
+func TestPaymentInfoID(t *testing.T) {
+	assert.Equal(t, "MSG1-EUR", PaymentInfoID("MSG1", "EUR"))
+	long := strings.Repeat("a", 32)
+	assert.Equal(t, strings.Repeat("a", 31)+"-EUR", PaymentInfoID(long, "EUR"))
+}
+
+const pain002Sample = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.10">
+  <CstmrPmtStsRpt>
+    <GrpHdr><MsgId>STS1</MsgId></GrpHdr>
+    <OrgnlGrpInfAndSts>
+      <OrgnlMsgId>MSG1</OrgnlMsgId>
+      <OrgnlMsgNmId>pain.001.001.09</OrgnlMsgNmId>
+      <GrpSts>PART</GrpSts>
+    </OrgnlGrpInfAndSts>
+    <OrgnlPmtInfAndSts>
+      <OrgnlPmtInfId>MSG1-EUR</OrgnlPmtInfId>
+      <PmtInfSts>ACSC</PmtInfSts>
+      <TxInfAndSts>
+        <OrgnlEndToEndId>e2e3</OrgnlEndToEndId>
+        <TxSts>RJCT</TxSts>
+        <StsRsnInf>
+          <Rsn><Cd>AC04</Cd></Rsn>
+          <AddtlInf>Account closed</AddtlInf>
+        </StsRsnInf>
+      </TxInfAndSts>
+    </OrgnlPmtInfAndSts>
+  </CstmrPmtStsRpt>
+</Document>`
+
+func TestParsePain002(t *testing.T) {
+	report, err := ParsePain002(strings.NewReader(pain002Sample))
+	require.NoError(t, err)
+	assert.Equal(t, "MSG1", report.MessageID)
+
+	tests := []struct {
+		name       string
+		pmtInfID   string
+		endToEndID string
+		want       PaymentStatus
+		accepted   bool
+		settled    bool
+		rejected   bool
+	}{
+		{"transaction status", "MSG1-EUR", "e2e3", PaymentStatus{Code: "RJCT", Reason: "AC04 Account closed"}, false, false, true},
+		{"payment information status", "MSG1-EUR", "e2e1", PaymentStatus{Code: "ACSC"}, true, true, false},
+		{"group status", "MSG1-USD", "e2e2", PaymentStatus{Code: "PART"}, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := report.StatusOf(tt.pmtInfID, tt.endToEndID)
+			assert.Equal(t, tt.want, status)
+			assert.Equal(t, tt.accepted, status.Accepted())
+			assert.Equal(t, tt.settled, status.Settled())
+			assert.Equal(t, tt.rejected, status.Rejected())
+		})
+	}
+
+	// Accepted for execution, but a rejection may still follow
+	for _, code := range []string{"ACCP", "ACSP", "ACWC"} {
+		status := PaymentStatus{Code: code}
+		assert.True(t, status.Accepted(), code)
+		assert.False(t, status.Settled(), code)
+	}
+
+	_, err = ParsePain002(strings.NewReader("<Document><CstmrPmtStsRpt></CstmrPmtStsRpt></Document>"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+// Package breach tells whether a password appeared in a known data breach,
+// from a local copy of the Pwned Passwords range files so passwords never
+// leave the server
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrIncomplete is returned for a directory missing range files. A full
+// mirror has one for every prefix, so a missing file means the check would
+// pass passwords it cannot see.
+var ErrIncomplete = errors.New("breached password directory is incomplete")
+
+// Checker tells whether a password is known to be breached
+type Checker interface {
+	Breached(password string) (bool, error)
+}
+
+// RangeDirectory checks passwords against a directory of range files in
+// k-anonymity style: the SHA-1 hash of a password is split into its first
+// five hex characters, which name the file, and the remaining 35, which are
+// listed in it one per line as SUFFIX:COUNT. This is the format of the Pwned
+// Passwords range API, so the files can be mirrored from it.
+type RangeDirectory struct {
+	dir string
+}
+
+// NewRangeDirectory creates a checker reading range files from dir. It
+// checks dir is a directory holding the first and last range files, so a
+// wrong or empty directory is found at startup rather than disabling the
+// check.
+func NewRangeDirectory(dir string) (*RangeDirectory, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	for _, prefix := range []string{"00000", "FFFFF"} {
+		if _, err := os.Stat(filepath.Join(dir, prefix+".txt")); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrIncomplete, err)
+		}
+	}
+	return &RangeDirectory{dir: dir}, nil
+}
+
+// Breached reports whether the password's hash is listed. A range file that
+// is missing or cannot be read is an error, never a pass.
+func (d *RangeDirectory) Breached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(d.dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("%w: %v", ErrIncomplete, err)
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		listed, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		// Padding entries of the range API have a count of zero
+		if strings.EqualFold(listed, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
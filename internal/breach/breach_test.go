@@ -0,0 +1,77 @@
+package breach
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rangeDirectory creates a directory with the first and last range files
+// and the given ones
+func rangeDirectory(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for _, prefix := range []string{"00000", "FFFFF"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, prefix+".txt"), nil, 0o600))
+	}
+	for prefix, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestRangeDirectoryBreached(t *testing.T) {
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8, of
+	// "correct horse battery staple" ABF7AAD6438836DBE526AA231ABDE2D0EEF74D42
+	dir := rangeDirectory(t, map[string]string{
+		"5BAA6": "003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365\r\n",
+		"ABF7A": "AD6438836DBE526AA231ABDE2D0EEF74D42:0\r\n",
+	})
+	checker, err := NewRangeDirectory(dir)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		password string
+		want     bool
+	}{
+		{"listed", "password", true},
+		{"padding entry", "correct horse battery staple", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breached, err := checker.Breached(tt.password)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, breached)
+		})
+	}
+
+	t.Run("range file missing", func(t *testing.T) {
+		_, err := checker.Breached("Password")
+		assert.ErrorIs(t, err, ErrIncomplete)
+	})
+	t.Run("range file unreadable", func(t *testing.T) {
+		// SHA-1 of "Password" starts with 8BE3C
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "8BE3C.txt"), 0o700))
+		_, err := checker.Breached("Password")
+		assert.Error(t, err)
+	})
+}
+
+func TestNewRangeDirectory(t *testing.T) {
+	_, err := NewRangeDirectory(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = NewRangeDirectory(t.TempDir())
+	assert.ErrorIs(t, err, ErrIncomplete)
+
+	file := filepath.Join(t.TempDir(), "00000.txt")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+	_, err = NewRangeDirectory(file)
+	assert.Error(t, err)
+
+	_, err = NewRangeDirectory(rangeDirectory(t, nil))
+	assert.NoError(t, err)
+}
@@ -3,10 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/redis/go-redis/v9"
+	"github.com/takadao/banking/internal/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -24,6 +26,30 @@ type Config struct {
 	RedisPort     string
 	RedisPassword string
 	RedisDB       int
+
+	// Account payouts to external IBANs are sent from
+	PayoutDebtorName string
+	PayoutDebtorIBAN string
+	PayoutDebtorBIC  string
+
+	// Mails to users go through MAILER: smtp, file (.eml files in MAIL_DIR)
+	// or log
+	Mailer       string
+	MailFrom     string
+	MailDir      string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	// Links in mails point to the app at this URL
+	AppBaseURL string
+
+	// New passwords must satisfy the password policy and, when a directory of
+	// Pwned Passwords range files is given, not be listed in it
+	PasswordMinLength    int
+	PasswordMinClasses   int
+	PasswordHistory      int
+	BreachedPasswordsDir string
 }
 
 func LoadConfig() (*Config, error) {
@@ -43,9 +69,36 @@ func LoadConfig() (*Config, error) {
 		RedisPort:     getEnv("REDIS_PORT", "6379"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		RedisDB:       0,
+
+		PayoutDebtorName: getEnv("PAYOUT_DEBTOR_NAME", ""),
+		PayoutDebtorIBAN: getEnv("PAYOUT_DEBTOR_IBAN", ""),
+		PayoutDebtorBIC:  getEnv("PAYOUT_DEBTOR_BIC", ""),
+
+		Mailer:       getEnv("MAILER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "TakaDAO Banking <no-reply@takadao.com>"),
+		MailDir:      getEnv("MAIL_DIR", "mail"),
+		SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		AppBaseURL:   getEnv("APP_BASE_URL", "http://localhost:3000"),
+
+		PasswordMinLength:    getEnvInt("PASSWORD_MIN_LENGTH", models.DefaultPasswordPolicy.MinLength),
+		PasswordMinClasses:   getEnvInt("PASSWORD_MIN_CLASSES", models.DefaultPasswordPolicy.MinClasses),
+		PasswordHistory:      getEnvInt("PASSWORD_HISTORY", models.DefaultPasswordPolicy.History),
+		BreachedPasswordsDir: getEnv("BREACHED_PASSWORDS_DIR", ""),
 	}, nil
 }
 
+// PasswordPolicy returns the configured password policy
+func (c *Config) PasswordPolicy() models.PasswordPolicy {
+	return models.PasswordPolicy{
+		MinLength:  c.PasswordMinLength,
+		MinClasses: c.PasswordMinClasses,
+		History:    c.PasswordHistory,
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -53,6 +106,15 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
 func NewDatabaseConnection(config *Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		config.DBHost, config.DBPort, config.DBUser, config.DBPassword, config.DBName, config.DBSSLMode)
@@ -0,0 +1,181 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Handler processes one event. Returning an error leaves the event
+// unacknowledged so it is retried.
+type Handler func(ctx context.Context, event Event) error
+
+// Consumer reads a stream as a member of a consumer group. The group's
+// acknowledged position is the checkpoint: an event is acknowledged only
+// after its handler succeeds, so delivery is at least once and handlers must
+// be idempotent.
+type Consumer struct {
+	client  *redis.Client
+	stream  string
+	group   string
+	name    string
+	handler Handler
+
+	// BatchSize is the number of events read per call
+	BatchSize int64
+	// Block is how long a read waits for new events
+	Block time.Duration
+	// ClaimIdle is how long an event may stay unacknowledged by any group
+	// member before this consumer claims and retries it
+	ClaimIdle time.Duration
+}
+
+// NewConsumer creates a consumer named name in group on stream
+func NewConsumer(client *redis.Client, stream, group, name string, handler Handler) *Consumer {
+	return &Consumer{
+		client:    client,
+		stream:    stream,
+		group:     group,
+		name:      name,
+		handler:   handler,
+		BatchSize: 50,
+		Block:     5 * time.Second,
+		ClaimIdle: time.Minute,
+	}
+}
+
+// Run consumes events until the context is cancelled
+func (c *Consumer) Run(ctx context.Context) error {
+	if err := c.ensureGroup(ctx); err != nil {
+		return err
+	}
+
+	// Resume events this consumer read but never acknowledged before a restart
+	if err := c.drainPending(ctx); err != nil {
+		return err
+	}
+
+	lastClaim := time.Now()
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= c.ClaimIdle {
+			if err := c.claimStale(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("events: %s/%s failed to claim stale events: %v", c.group, c.name, err)
+			}
+			lastClaim = time.Now()
+		}
+
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.name,
+			Streams:  []string{c.stream, ">"},
+			Count:    c.BatchSize,
+			Block:    c.Block,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			log.Printf("events: %s/%s read failed: %v", c.group, c.name, err)
+			sleep(ctx, time.Second)
+			continue
+		}
+
+		for _, stream := range streams {
+			c.process(ctx, stream.Messages)
+		}
+	}
+	return nil
+}
+
+func (c *Consumer) ensureGroup(ctx context.Context) error {
+	// Start new groups at the beginning so no relayed event is skipped
+	err := c.client.XGroupCreateMkStream(ctx, c.stream, c.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+func (c *Consumer) drainPending(ctx context.Context) error {
+	for ctx.Err() == nil {
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.name,
+			Streams:  []string{c.stream, "0"},
+			Count:    c.BatchSize,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil
+			}
+			return err
+		}
+
+		handled := 0
+		for _, stream := range streams {
+			handled += c.process(ctx, stream.Messages)
+		}
+		// Stop once nothing from the pending list could be acknowledged,
+		// the remainder is retried through claimStale
+		if handled == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (c *Consumer) claimStale(ctx context.Context) error {
+	start := "0-0"
+	for {
+		messages, next, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   c.stream,
+			Group:    c.group,
+			Consumer: c.name,
+			MinIdle:  c.ClaimIdle,
+			Start:    start,
+			Count:    c.BatchSize,
+		}).Result()
+		if err != nil {
+			return err
+		}
+		c.process(ctx, messages)
+		if next == "0-0" || len(messages) == 0 {
+			return nil
+		}
+		start = next
+	}
+}
+
+// process handles messages in order and returns how many were acknowledged
+func (c *Consumer) process(ctx context.Context, messages []redis.XMessage) int {
+	acked := 0
+	for _, message := range messages {
+		event, err := parseEvent(message.ID, message.Values)
+		if err != nil {
+			// A malformed message can never succeed, acknowledge it to
+			// keep it from blocking the group
+			log.Printf("events: %s/%s dropping message: %v", c.group, c.name, err)
+		} else if err := c.handler(ctx, event); err != nil {
+			log.Printf("events: %s/%s failed to handle %s (%s): %v", c.group, c.name, event.Type, event.ID, err)
+			continue
+		}
+
+		if err := c.client.XAck(ctx, c.stream, c.group, message.ID).Err(); err != nil {
+			log.Printf("events: %s/%s failed to acknowledge %s: %v", c.group, c.name, message.ID, err)
+			continue
+		}
+		acked++
+	}
+	return acked
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+)
+
+// DefaultStream is the Redis stream domain events are relayed to
+const DefaultStream = "banking:events"
+
+// Event is a domain event as carried on the stream
+type Event struct {
+	ID            uuid.UUID       `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   uuid.UUID       `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+
+	// StreamID is the position of the event in the stream; it is set on
+	// consumption and not part of the message itself
+	StreamID string `json:"-"`
+}
+
+// FromOutbox converts a stored outbox row into a stream event
+func FromOutbox(o *models.OutboxEvent) Event {
+	return Event{
+		ID:            o.ID,
+		Type:          o.EventType,
+		AggregateType: o.AggregateType,
+		AggregateID:   o.AggregateID,
+		OccurredAt:    o.CreatedAt,
+		Data:          json.RawMessage(o.Payload),
+	}
+}
+
+// Decode unmarshals the event data into v
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+func (e Event) values() map[string]interface{} {
+	return map[string]interface{}{
+		"id":             e.ID.String(),
+		"type":           e.Type,
+		"aggregate_type": e.AggregateType,
+		"aggregate_id":   e.AggregateID.String(),
+		"occurred_at":    e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		"data":           string(e.Data),
+	}
+}
+
+func parseEvent(streamID string, values map[string]interface{}) (Event, error) {
+	get := func(key string) string {
+		s, _ := values[key].(string)
+		return s
+	}
+
+	id, err := uuid.Parse(get("id"))
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid event id in message %s: %w", streamID, err)
+	}
+	aggregateID, err := uuid.Parse(get("aggregate_id"))
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid aggregate id in message %s: %w", streamID, err)
+	}
+	occurredAt, err := time.Parse(time.RFC3339Nano, get("occurred_at"))
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid occurred_at in message %s: %w", streamID, err)
+	}
+
+	return Event{
+		ID:            id,
+		Type:          get("type"),
+		AggregateType: get("aggregate_type"),
+		AggregateID:   aggregateID,
+		OccurredAt:    occurredAt,
+		Data:          json.RawMessage(get("data")),
+		StreamID:      streamID,
+	}, nil
+}
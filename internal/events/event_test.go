@@ -0,0 +1,40 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/takadao/banking/internal/models"
+)
+
+func TestEventStreamRoundTrip(t *testing.T) {
+	userID := uuid.New()
+	outbox, err := models.NewOutboxEvent(models.AggregateUser, userID, models.EventUserUpdated, models.UserEventData{
+		ID:    userID,
+		Email: "user@example.com",
+		Role:  "user",
+	})
+	assert.NoError(t, err)
+	outbox.ID = uuid.New()
+	outbox.CreatedAt = time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	event := FromOutbox(outbox)
+	parsed, err := parseEvent("1714566600000-0", event.values())
+	assert.NoError(t, err)
+	assert.Equal(t, outbox.ID, parsed.ID)
+	assert.Equal(t, models.EventUserUpdated, parsed.Type)
+	assert.Equal(t, userID, parsed.AggregateID)
+	assert.True(t, outbox.CreatedAt.Equal(parsed.OccurredAt))
+	assert.Equal(t, "1714566600000-0", parsed.StreamID)
+
+	var data models.UserEventData
+	assert.NoError(t, parsed.Decode(&data))
+	assert.Equal(t, "user@example.com", data.Email)
+}
+
+func TestParseEventRejectsMalformedMessage(t *testing.T) {
+	_, err := parseEvent("1-0", map[string]interface{}{"id": "not-a-uuid"})
+	assert.Error(t, err)
+}
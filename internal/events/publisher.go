@@ -0,0 +1,32 @@
+package events
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxStreamLength bounds the stream; consumers that fall further behind than
+// this lose the oldest events
+const maxStreamLength = 1000000
+
+// Publisher appends events to a Redis stream
+type Publisher struct {
+	client *redis.Client
+	stream string
+}
+
+// NewPublisher creates a publisher for the given stream
+func NewPublisher(client *redis.Client, stream string) *Publisher {
+	return &Publisher{client: client, stream: stream}
+}
+
+// Publish appends the event and returns its stream ID
+func (p *Publisher) Publish(ctx context.Context, event Event) (string, error) {
+	return p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		MaxLen: maxStreamLength,
+		Approx: true,
+		Values: event.values(),
+	}).Result()
+}
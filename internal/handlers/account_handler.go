@@ -0,0 +1,650 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// AccountHandler handles a user's named accounts: the main account, savings
+// pots and goals
+type AccountHandler struct {
+	accountService *service.AccountService
+}
+
+// NewAccountHandler creates a new AccountHandler instance
+func NewAccountHandler(accountService *service.AccountService) *AccountHandler {
+	return &AccountHandler{accountService: accountService}
+}
+
+type accountRequest struct {
+	Name         string   `json:"name" binding:"required,max=100" example:"Holiday"`
+	Type         string   `json:"type" binding:"required,oneof=savings goal" example:"goal"`
+	Currency     string   `json:"currency" binding:"required,len=3" example:"EUR"`
+	TargetAmount *float64 `json:"target_amount" binding:"omitempty,gt=0" example:"1500.00"`
+	TargetDate   string   `json:"target_date" example:"2025-07-01"`
+}
+
+type accountUpdateRequest struct {
+	Name         *string  `json:"name" binding:"omitempty,max=100" example:"Summer holiday"`
+	TargetAmount *float64 `json:"target_amount" binding:"omitempty,gt=0" example:"2000.00"`
+	TargetDate   *string  `json:"target_date" example:"2025-08-01"`
+}
+
+type invitationRequest struct {
+	Email      string   `json:"email" binding:"required,email" example:"partner@example.com"`
+	Role       string   `json:"role" binding:"required,oneof=co_owner spender viewer" example:"spender"`
+	SpendLimit *float64 `json:"spend_limit" binding:"omitempty,gt=0" example:"250.00"`
+}
+
+type memberUpdateRequest struct {
+	Role       string   `json:"role" binding:"required,oneof=co_owner spender viewer" example:"spender"`
+	SpendLimit *float64 `json:"spend_limit" binding:"omitempty,gt=0" example:"400.00"`
+}
+
+type moveRequest struct {
+	SourceAccountID      string  `json:"source_account_id" binding:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174002"`
+	DestinationAccountID string  `json:"destination_account_id" binding:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174003"`
+	Amount               float64 `json:"amount" binding:"required,gt=0" example:"200.00"`
+	Description          string  `json:"description" example:"Monthly savings"`
+}
+
+// CreateAccount godoc
+// @Summary      Open account
+// @Description  Opens a savings pot or a goal with a target amount and optional target date (YYYY-MM-DD). The main account of a currency is opened by its first credit.
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body accountRequest true "Account details"
+// @Success      201  {object}  models.Account
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /users/accounts [post]
+func (h *AccountHandler) CreateAccount(c *gin.Context) {
+	var req accountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	account := &models.Account{
+		UserID:       userID,
+		Name:         req.Name,
+		Type:         models.AccountType(req.Type),
+		Currency:     req.Currency,
+		TargetAmount: req.TargetAmount,
+	}
+	if req.TargetDate != "" {
+		targetDate, err := time.Parse("2006-01-02", req.TargetDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target_date format, use YYYY-MM-DD"})
+			return
+		}
+		account.TargetDate = &targetDate
+	}
+
+	if err := h.accountService.Open(account); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, account)
+}
+
+// ListAccounts godoc
+// @Summary      List accounts
+// @Description  Returns the accounts the authenticated user owns or is a member of, with their balances and the user's role on each, main accounts first
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.Account
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/accounts [get]
+func (h *AccountHandler) ListAccounts(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	accounts, err := h.accountService.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list accounts"})
+		return
+	}
+	c.JSON(http.StatusOK, accounts)
+}
+
+// GetAccount godoc
+// @Summary      Get account
+// @Description  Returns an account the authenticated user owns or is a member of, with its balance and the user's role
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Success      200  {object}  models.Account
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/accounts/{id} [get]
+func (h *AccountHandler) GetAccount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	account, err := h.accountService.Get(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
+// UpdateAccount godoc
+// @Summary      Update account
+// @Description  Renames an account or changes a goal's target amount and date (owner or co-owner)
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Param        request body accountUpdateRequest true "Fields to change"
+// @Success      200  {object}  models.Account
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/accounts/{id} [put]
+func (h *AccountHandler) UpdateAccount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	var req accountUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	update := service.AccountUpdate{
+		Name:         req.Name,
+		TargetAmount: req.TargetAmount,
+	}
+	if req.TargetDate != nil {
+		targetDate, err := time.Parse("2006-01-02", *req.TargetDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target_date format, use YYYY-MM-DD"})
+			return
+		}
+		update.TargetDate = &targetDate
+	}
+
+	account, err := h.accountService.Update(id, userID, update)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
+// CloseAccount godoc
+// @Summary      Close account
+// @Description  Closes an empty savings pot or goal; the main account cannot be closed
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/accounts/{id} [delete]
+func (h *AccountHandler) CloseAccount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.accountService.Close(id, userID); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "account closed"})
+}
+
+// ListAccountTransactions godoc
+// @Summary      List account transactions
+// @Description  Returns a paginated list of the transactions debiting or crediting an account the authenticated user has access to, optionally only those one member initiated
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Param        initiated_by query string false "User ID of the member who initiated the transactions"
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/accounts/{id}/transactions [get]
+func (h *AccountHandler) ListAccountTransactions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	page := 1
+	pageSize := 20
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	var initiatedBy *uuid.UUID
+	if v := c.Query("initiated_by"); v != "" {
+		parsed, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid initiated_by"})
+			return
+		}
+		initiatedBy = &parsed
+	}
+
+	transactions, total, err := h.accountService.ListTransactions(id, userID, initiatedBy, page, pageSize)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"transactions": transactions, "total": total})
+}
+
+// MoveMoney godoc
+// @Summary      Move money between accounts
+// @Description  Moves money between two accounts of the same owner in the same currency, free of charge. Members need to be allowed to spend from the source and pay into the destination.
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body moveRequest true "Move details"
+// @Success      201  {object}  models.Transaction
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/accounts/moves [post]
+func (h *AccountHandler) MoveMoney(c *gin.Context) {
+	var req moveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	transaction, err := h.accountService.Move(userID, uuid.MustParse(req.SourceAccountID), uuid.MustParse(req.DestinationAccountID), req.Amount, req.Description)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, transaction)
+}
+
+// ListAccountMembers godoc
+// @Summary      List account members
+// @Description  Returns the owner and members of an account the authenticated user has access to, and its open invitations to those who manage members
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Success      200  {object}  service.AccountMembers
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/accounts/{id}/members [get]
+func (h *AccountHandler) ListAccountMembers(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	members, err := h.accountService.ListMembers(id, userID)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// InviteAccountMember godoc
+// @Summary      Invite account member
+// @Description  Invites the holder of an email to an account as co_owner, spender (with a monthly spend_limit) or viewer. The owner may grant any role, co-owners only spender and viewer. Invitations expire after 14 days.
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Param        request body invitationRequest true "Invitation details"
+// @Success      201  {object}  models.AccountInvitation
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/accounts/{id}/invitations [post]
+func (h *AccountHandler) InviteAccountMember(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	var req invitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	invitation := &models.AccountInvitation{
+		Email:      req.Email,
+		Role:       models.AccountRole(req.Role),
+		SpendLimit: req.SpendLimit,
+	}
+	if err := h.accountService.Invite(id, userID, invitation); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// RevokeAccountInvitation godoc
+// @Summary      Revoke account invitation
+// @Description  Withdraws an open invitation to an account
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Param        invitation_id   path      string  true  "Invitation ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/accounts/{id}/invitations/{invitation_id} [delete]
+func (h *AccountHandler) RevokeAccountInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	invitationID, err := uuid.Parse(c.Param("invitation_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.accountService.RevokeInvitation(id, userID, invitationID); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "invitation revoked"})
+}
+
+// UpdateAccountMember godoc
+// @Summary      Update account member
+// @Description  Changes a member's role or spend limit; the caller must outrank both the member's current and new role
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Param        user_id   path      string  true  "Member user ID"
+// @Param        request body memberUpdateRequest true "New role"
+// @Success      200  {object}  models.AccountMember
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/accounts/{id}/members/{user_id} [put]
+func (h *AccountHandler) UpdateAccountMember(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var req memberUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	member, err := h.accountService.UpdateMember(id, userID, memberID, models.AccountRole(req.Role), req.SpendLimit)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveAccountMember godoc
+// @Summary      Remove account member
+// @Description  Revokes a member's access to an account. Members can remove themselves to leave an account.
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Param        user_id   path      string  true  "Member user ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/accounts/{id}/members/{user_id} [delete]
+func (h *AccountHandler) RemoveAccountMember(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.accountService.RemoveMember(id, userID, memberID); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "member removed"})
+}
+
+// ListMyAccountInvitations godoc
+// @Summary      List my account invitations
+// @Description  Returns the open invitations to shared accounts sent to the authenticated user's email
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.AccountInvitation
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/account-invitations [get]
+func (h *AccountHandler) ListMyAccountInvitations(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	invitations, err := h.accountService.ListInvitations(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list invitations"})
+		return
+	}
+	c.JSON(http.StatusOK, invitations)
+}
+
+// AcceptAccountInvitation godoc
+// @Summary      Accept account invitation
+// @Description  Joins the account an invitation sent to the authenticated user's email is for
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Invitation ID"
+// @Success      200  {object}  models.AccountMember
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/account-invitations/{id}/accept [post]
+func (h *AccountHandler) AcceptAccountInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	member, err := h.accountService.AcceptInvitation(id, userID)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// DeclineAccountInvitation godoc
+// @Summary      Decline account invitation
+// @Description  Turns down an invitation sent to the authenticated user's email
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Invitation ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/account-invitations/{id}/decline [post]
+func (h *AccountHandler) DeclineAccountInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.accountService.DeclineInvitation(id, userID); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "invitation declined"})
+}
+
+// accountErrorStatus maps account service errors to HTTP status codes
+func accountErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, models.ErrAccountNotFound), errors.Is(err, models.ErrInvitationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrAccountAccessDenied), errors.Is(err, models.ErrSpendLimitExceeded):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrAccountNotEmpty), errors.Is(err, models.ErrMainAccountRequired),
+		errors.Is(err, models.ErrAlreadyMember), errors.Is(err, models.ErrInvitationNotPending), errors.Is(err, models.ErrInvitationExpired):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
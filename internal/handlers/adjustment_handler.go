@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// AdjustmentHandler handles manual balance adjustments and their reason codes
+type AdjustmentHandler struct {
+	adjustmentService    *service.AdjustmentService
+	changeRequestService *service.ChangeRequestService
+}
+
+// NewAdjustmentHandler creates a new AdjustmentHandler instance
+func NewAdjustmentHandler(adjustmentService *service.AdjustmentService, changeRequestService *service.ChangeRequestService) *AdjustmentHandler {
+	return &AdjustmentHandler{
+		adjustmentService:    adjustmentService,
+		changeRequestService: changeRequestService,
+	}
+}
+
+type adjustmentRequest struct {
+	UserID      string   `json:"user_id" binding:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	AccountID   string   `json:"account_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174002"` // defaults to the main account
+	Direction   string   `json:"direction" binding:"required,oneof=credit debit" example:"credit"`
+	Amount      float64  `json:"amount" binding:"required,gt=0" example:"25.00"`
+	Currency    string   `json:"currency" binding:"required,len=3" example:"EUR"`
+	ReasonCode  string   `json:"reason_code" binding:"required" example:"BANK_ERROR"`
+	Note        string   `json:"note" binding:"required,max=1000" example:"Card settlement booked twice on 2024-05-02"`
+	Attachments []string `json:"attachments" binding:"max=10,dive,max=500" example:"ticket:OPS-1234"`
+}
+
+type adjustmentReasonRequest struct {
+	Code        string `json:"code" binding:"required" example:"GOODWILL"`
+	Description string `json:"description" binding:"required" example:"Goodwill credit approved by customer care"`
+	Direction   string `json:"direction" binding:"omitempty,oneof=credit debit" example:"credit"`
+}
+
+type adjustmentReasonUpdateRequest struct {
+	Description *string `json:"description" example:"Goodwill credit"`
+	Direction   *string `json:"direction" binding:"omitempty,oneof=credit debit" example:"credit"`
+	Active      *bool   `json:"active" example:"false"`
+}
+
+// CreateAdjustment godoc
+// @Summary      Request balance adjustment
+// @Description  Requests a manual credit or debit of a user's main account, or of account_id, with a reason code from the catalog, a note and optional attachment references. It is booked as an adjustment transaction once a different admin approves the change request.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body adjustmentRequest true "Adjustment details"
+// @Success      202  {object}  models.ChangeRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/adjustments [post]
+func (h *AdjustmentHandler) CreateAdjustment(c *gin.Context) {
+	var req adjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	adjustment := &models.AdjustmentRequest{
+		UserID:      uuid.MustParse(req.UserID),
+		AccountID:   optionalUUID(req.AccountID),
+		Direction:   models.AdjustmentDirection(req.Direction),
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		ReasonCode:  req.ReasonCode,
+		Note:        req.Note,
+		Attachments: req.Attachments,
+	}
+	request, err := h.changeRequestService.RequestAdjustment(adminID, adjustment)
+	if err != nil {
+		c.JSON(adjustmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, request)
+}
+
+// ListAdjustments godoc
+// @Summary      List adjustments
+// @Description  Returns booked adjustments with their transactions, newest first, optionally filtered by user and reason code
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user_id query string false "User ID"
+// @Param        reason_code query string false "Reason code"
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/adjustments [get]
+func (h *AdjustmentHandler) ListAdjustments(c *gin.Context) {
+	var userID *uuid.UUID
+	if v := c.Query("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		userID = &id
+	}
+
+	page := 1
+	pageSize := 20
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	adjustments, total, err := h.adjustmentService.List(userID, c.Query("reason_code"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list adjustments"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"adjustments": adjustments, "total": total})
+}
+
+// CreateAdjustmentReason godoc
+// @Summary      Create adjustment reason
+// @Description  Adds a reason code to the adjustment catalog, optionally restricted to credits or debits
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body adjustmentReasonRequest true "Reason details"
+// @Success      201  {object}  models.AdjustmentReason
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /admin/adjustment-reasons [post]
+func (h *AdjustmentHandler) CreateAdjustmentReason(c *gin.Context) {
+	var req adjustmentReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reason := &models.AdjustmentReason{
+		Code:        req.Code,
+		Description: req.Description,
+		Direction:   models.AdjustmentDirection(req.Direction),
+	}
+	if err := h.adjustmentService.CreateReason(reason); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, reason)
+}
+
+// ListAdjustmentReasons godoc
+// @Summary      List adjustment reasons
+// @Description  Returns the adjustment reason catalog, including deactivated codes unless active=true
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        active query bool false "Only active codes"
+// @Success      200  {array}   models.AdjustmentReason
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/adjustment-reasons [get]
+func (h *AdjustmentHandler) ListAdjustmentReasons(c *gin.Context) {
+	reasons, err := h.adjustmentService.ListReasons(c.Query("active") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list adjustment reasons"})
+		return
+	}
+	c.JSON(http.StatusOK, reasons)
+}
+
+// UpdateAdjustmentReason godoc
+// @Summary      Update adjustment reason
+// @Description  Changes the description or direction of a reason code, or deactivates it. Codes cannot be renamed.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Reason ID"
+// @Param        request body adjustmentReasonUpdateRequest true "Reason changes"
+// @Success      200  {object}  models.AdjustmentReason
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/adjustment-reasons/{id} [put]
+func (h *AdjustmentHandler) UpdateAdjustmentReason(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reason ID"})
+		return
+	}
+	var req adjustmentReasonUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := service.AdjustmentReasonUpdate{Description: req.Description, Active: req.Active}
+	if req.Direction != nil {
+		direction := models.AdjustmentDirection(*req.Direction)
+		update.Direction = &direction
+	}
+	reason, err := h.adjustmentService.UpdateReason(id, update)
+	if err != nil {
+		c.JSON(adjustmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reason)
+}
+
+// adjustmentErrorStatus maps adjustment service errors to HTTP status codes
+func adjustmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// ApprovalHandler handles approval policies of accounts and the payments
+// waiting for approval under them
+type ApprovalHandler struct {
+	approvalService *service.ApprovalService
+}
+
+// NewApprovalHandler creates a new ApprovalHandler instance
+func NewApprovalHandler(approvalService *service.ApprovalService) *ApprovalHandler {
+	return &ApprovalHandler{approvalService: approvalService}
+}
+
+type approvalPolicyRequest struct {
+	MinAmount         float64  `json:"min_amount" binding:"gte=0" example:"1000.00"`
+	MaxAmount         *float64 `json:"max_amount" binding:"omitempty,gt=0" example:"10000.00"`
+	RequiredApprovals int      `json:"required_approvals" binding:"required,gte=1" example:"2"`
+}
+
+type approvalDecisionRequest struct {
+	Comment string `json:"comment" binding:"max=500" example:"Invoice checked"`
+}
+
+// CreateApprovalPolicy godoc
+// @Summary      Create approval policy
+// @Description  Requires withdrawals and transfers from the account of at least min_amount and below max_amount, when given, to be approved by required_approvals owners or co-owners other than the user who made them. Only the account owner may set policies.
+// @Tags         approvals
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Param        request body approvalPolicyRequest true "Policy details"
+// @Success      201  {object}  models.ApprovalPolicy
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/accounts/{id}/approval-policies [post]
+func (h *ApprovalHandler) CreateApprovalPolicy(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	var req approvalPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	policy := &models.ApprovalPolicy{
+		MinAmount:         req.MinAmount,
+		MaxAmount:         req.MaxAmount,
+		RequiredApprovals: req.RequiredApprovals,
+	}
+	if err := h.approvalService.CreatePolicy(id, userID, policy); err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, policy)
+}
+
+// ListApprovalPolicies godoc
+// @Summary      List approval policies
+// @Description  Returns the approval policies of an account the authenticated user has access to
+// @Tags         approvals
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Success      200  {array}   models.ApprovalPolicy
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/accounts/{id}/approval-policies [get]
+func (h *ApprovalHandler) ListApprovalPolicies(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	policies, err := h.approvalService.ListPolicies(id, userID)
+	if err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policies)
+}
+
+// DeleteApprovalPolicy godoc
+// @Summary      Delete approval policy
+// @Description  Removes an approval policy of an account. Payments already waiting for approval keep the approvals they need.
+// @Tags         approvals
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Param        policy_id   path      string  true  "Policy ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/accounts/{id}/approval-policies/{policy_id} [delete]
+func (h *ApprovalHandler) DeleteApprovalPolicy(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	policyID, err := uuid.Parse(c.Param("policy_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid policy ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.approvalService.DeletePolicy(id, userID, policyID); err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "approval policy deleted"})
+}
+
+// ListPendingPayments godoc
+// @Summary      List pending payments
+// @Description  Returns the payments of an account held back for approval, newest first, optionally filtered by status
+// @Tags         approvals
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Account ID"
+// @Param        status   query     string  false  "Status (pending, approved, executed, failed, rejected, cancelled, expired)"
+// @Success      200  {array}   models.PendingPayment
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/accounts/{id}/pending-payments [get]
+func (h *ApprovalHandler) ListPendingPayments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	payments, err := h.approvalService.List(id, userID, models.PendingPaymentStatus(c.Query("status")))
+	if err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, payments)
+}
+
+// GetPendingPayment godoc
+// @Summary      Get pending payment
+// @Description  Returns a payment held back for approval with the decisions taken on it
+// @Tags         approvals
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Pending payment ID"
+// @Success      200  {object}  models.PendingPayment
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/pending-payments/{id} [get]
+func (h *ApprovalHandler) GetPendingPayment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	payment, err := h.approvalService.Get(id, userID)
+	if err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, payment)
+}
+
+// ApprovePendingPayment godoc
+// @Summary      Approve pending payment
+// @Description  Records the authenticated user's approval. Owners and co-owners other than the user who made the payment may approve. The payment is executed once its policy is satisfied; if execution fails it is returned with status failed and the error.
+// @Tags         approvals
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Pending payment ID"
+// @Param        request body approvalDecisionRequest false "Optional comment"
+// @Success      200  {object}  models.PendingPayment
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/pending-payments/{id}/approve [post]
+func (h *ApprovalHandler) ApprovePendingPayment(c *gin.Context) {
+	h.decide(c, h.approvalService.Approve)
+}
+
+// RejectPendingPayment godoc
+// @Summary      Reject pending payment
+// @Description  Turns down a payment held back for approval. A single rejection by an owner or co-owner closes it.
+// @Tags         approvals
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Pending payment ID"
+// @Param        request body approvalDecisionRequest false "Optional comment"
+// @Success      200  {object}  models.PendingPayment
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/pending-payments/{id}/reject [post]
+func (h *ApprovalHandler) RejectPendingPayment(c *gin.Context) {
+	h.decide(c, h.approvalService.Reject)
+}
+
+// CancelPendingPayment godoc
+// @Summary      Cancel pending payment
+// @Description  Withdraws a payment still waiting for approval. Only the user who made it may cancel it.
+// @Tags         approvals
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Pending payment ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/pending-payments/{id}/cancel [post]
+func (h *ApprovalHandler) CancelPendingPayment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.approvalService.Cancel(id, userID); err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "payment cancelled"})
+}
+
+// decide binds an approve or reject request and records the decision
+func (h *ApprovalHandler) decide(c *gin.Context, decide func(id, userID uuid.UUID, comment string) (*models.PendingPayment, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment ID"})
+		return
+	}
+	var req approvalDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	payment, err := decide(id, userID, req.Comment)
+	if err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, payment)
+}
+
+// approvalErrorStatus maps approval service errors to HTTP status codes
+func approvalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, models.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrAccountAccessDenied), errors.Is(err, models.ErrSelfApproval):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrPaymentNotPending), errors.Is(err, models.ErrPaymentExpired), errors.Is(err, models.ErrAlreadyDecided):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
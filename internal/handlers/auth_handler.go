@@ -1,31 +1,43 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
 	"github.com/takadao/banking/internal/middleware"
+	"github.com/takadao/banking/internal/models"
 	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
 )
 
 // AuthHandler handles authentication related requests
 type AuthHandler struct {
-	userService    *service.UserService
-	authMiddleware *middleware.AuthMiddleware
+	userService          *service.UserService
+	tokenService         *service.UserTokenService
+	sessionService       *service.SessionService
+	changeRequestService *service.ChangeRequestService
+	authMiddleware       *middleware.AuthMiddleware
 }
 
 // NewAuthHandler creates a new AuthHandler instance
-func NewAuthHandler(userService *service.UserService, authMiddleware *middleware.AuthMiddleware) *AuthHandler {
+func NewAuthHandler(userService *service.UserService, tokenService *service.UserTokenService, sessionService *service.SessionService, changeRequestService *service.ChangeRequestService, authMiddleware *middleware.AuthMiddleware) *AuthHandler {
 	return &AuthHandler{
-		userService:    userService,
-		authMiddleware: authMiddleware,
+		userService:          userService,
+		tokenService:         tokenService,
+		sessionService:       sessionService,
+		changeRequestService: changeRequestService,
+		authMiddleware:       authMiddleware,
 	}
 }
 
 // Common request/response types
 type loginRequest struct {
-	Email    string `json:"email" binding:"required,email" example:"user@example.com"`
-	Password string `json:"password" binding:"required" example:"password123"`
+	Email      string `json:"email" binding:"required,email" example:"user@example.com"`
+	Password   string `json:"password" binding:"required" example:"password123"`
+	DeviceName string `json:"device_name" binding:"max=100" example:"Jane's iPhone"` // derived from the user agent when empty
 }
 
 type loginResponse struct {
@@ -36,18 +48,32 @@ type loginResponse struct {
 // User registration request
 type userRegisterRequest struct {
 	Email    string `json:"email" binding:"required,email" example:"user@example.com"`
-	Password string `json:"password" binding:"required,min=6" example:"password123"`
+	Password string `json:"password" binding:"required" example:"c0rrect-Horse"`
 }
 
 // Admin registration request (requires admin token)
 type adminRegisterRequest struct {
 	Email    string `json:"email" binding:"required,email" example:"admin@example.com"`
-	Password string `json:"password" binding:"required,min=6" example:"admin123"`
+	Password string `json:"password" binding:"required" example:"Adm1n-Passw0rd"`
+	Reason   string `json:"reason" binding:"required,max=500" example:"New operations team member"`
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required" example:"n3w-Passw0rd!"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
 }
 
 // UserLogin godoc
 // @Summary      Login as user
-// @Description  Authenticates a regular user and returns a JWT token
+// @Description  Authenticates a regular user and returns a JWT token bound to a new session on the device. Logging in reactivates a dormant user; closed users cannot log in.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -55,6 +81,7 @@ type adminRegisterRequest struct {
 // @Success      200  {object}  loginResponse
 // @Failure      400  {object}  map[string]string
 // @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
 // @Router       /auth/user/login [post]
 func (h *AuthHandler) UserLogin(c *gin.Context) {
 	var req loginRequest
@@ -64,6 +91,10 @@ func (h *AuthHandler) UserLogin(c *gin.Context) {
 	}
 
 	user, err := h.userService.Authenticate(req.Email, req.Password)
+	if errors.Is(err, models.ErrUserClosed) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
@@ -75,18 +106,12 @@ func (h *AuthHandler) UserLogin(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authMiddleware.GenerateToken(user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate token"})
-		return
-	}
-
-	c.JSON(http.StatusOK, loginResponse{Token: token, Role: user.Role})
+	h.login(c, user, req.DeviceName)
 }
 
 // AdminLogin godoc
 // @Summary      Login as admin
-// @Description  Authenticates an admin user and returns a JWT token
+// @Description  Authenticates an admin user and returns a JWT token bound to a new session on the device
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -94,6 +119,7 @@ func (h *AuthHandler) UserLogin(c *gin.Context) {
 // @Success      200  {object}  loginResponse
 // @Failure      400  {object}  map[string]string
 // @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
 // @Router       /auth/admin/login [post]
 func (h *AuthHandler) AdminLogin(c *gin.Context) {
 	var req loginRequest
@@ -103,6 +129,10 @@ func (h *AuthHandler) AdminLogin(c *gin.Context) {
 	}
 
 	user, err := h.userService.Authenticate(req.Email, req.Password)
+	if errors.Is(err, models.ErrUserClosed) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
@@ -114,7 +144,18 @@ func (h *AuthHandler) AdminLogin(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authMiddleware.GenerateToken(user)
+	h.login(c, user, req.DeviceName)
+}
+
+// login starts a session on the device the user logged in from and responds
+// with a token bound to it
+func (h *AuthHandler) login(c *gin.Context, user *models.User, deviceName string) {
+	session, err := h.sessionService.Start(user, deviceName, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not start session"})
+		return
+	}
+	token, err := h.authMiddleware.GenerateToken(user, session)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate token"})
 		return
@@ -125,7 +166,7 @@ func (h *AuthHandler) AdminLogin(c *gin.Context) {
 
 // RegisterUser godoc
 // @Summary      Register new user
-// @Description  Creates a new regular user account
+// @Description  Creates a new regular user account and mails a link to verify the email. Transfers need a verified email.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -151,16 +192,17 @@ func (h *AuthHandler) RegisterUser(c *gin.Context) {
 
 // RegisterAdmin godoc
 // @Summary      Register new admin
-// @Description  Creates a new admin account (requires admin token)
+// @Description  Requests a new admin account (requires admin token). The account is created once a different admin approves the change request.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        request body adminRegisterRequest true "Admin registration details"
-// @Success      201  {object}  map[string]string
+// @Success      202  {object}  models.ChangeRequest
 // @Failure      400  {object}  map[string]string
 // @Failure      401  {object}  map[string]string
 // @Failure      403  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
 // @Router       /auth/admin/register [post]
 func (h *AuthHandler) RegisterAdmin(c *gin.Context) {
 	// Verify that the requester is an admin
@@ -176,11 +218,247 @@ func (h *AuthHandler) RegisterAdmin(c *gin.Context) {
 		return
 	}
 
-	_, err := h.userService.Register(req.Email, req.Password, "admin")
+	adminID, err := auth.GetUserID(c)
 	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	request, err := h.changeRequestService.RequestAdminCreation(adminID, req.Email, req.Password, req.Reason)
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, request)
+}
+
+// ForgotPassword godoc
+// @Summary      Forgot password
+// @Description  Mails a link to reset the password, valid for one hour, if a user who may log in has the email. The answer is the same whether or not one has. Only the latest link works.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body forgotPasswordRequest true "Email"
+// @Success      202  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Router       /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.tokenService.ForgotPassword(req.Email)
+	c.JSON(http.StatusAccepted, gin.H{"message": "if an account uses this email, a reset link was sent to it"})
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Sets a new password with the token from a password reset link. Each token works once. It also verifies the email the link was sent to and logs the user out everywhere.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body resetPasswordRequest true "Token and new password"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.tokenService.ResetPassword(req.Token, req.Password); err != nil {
+		c.JSON(userTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password reset successfully"})
+}
+
+// VerifyEmail godoc
+// @Summary      Verify email
+// @Description  Verifies the user's email with the token from a verification link, valid for 48 hours. A link sent to an email the user has since changed no longer works.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body verifyEmailRequest true "Token"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /auth/email/verify [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req verifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "admin registered successfully"})
+	user, err := h.tokenService.VerifyEmail(req.Token)
+	if err != nil {
+		c.JSON(userTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "email verified", "email": user.Email})
+}
+
+// ResendEmailVerification godoc
+// @Summary      Resend email verification
+// @Description  Mails the current user a new link to verify their email; earlier links stop working
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      202  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      502  {object}  map[string]string
+// @Router       /users/me/email/verification [post]
+func (h *AuthHandler) ResendEmailVerification(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.tokenService.ResendEmailVerification(userID); err != nil {
+		c.JSON(userTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification link sent"})
+}
+
+// Logout godoc
+// @Summary      Log out
+// @Description  Ends the session of the token, which stops working
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	sessionID, err := auth.GetSessionID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.sessionService.Revoke(sessionID, userID); err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// ListSessions godoc
+// @Summary      List sessions
+// @Description  Returns the devices the current user is logged in on, most recently used first, with the one of this request marked current. Sessions unused for 30 days end.
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.Session
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/me/sessions [get]
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	sessionID, _ := auth.GetSessionID(c)
+
+	sessions, err := h.sessionService.List(userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary      Revoke session
+// @Description  Logs the current user out of one of their devices; its token stops working
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Session ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/me/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.sessionService.Revoke(id, userID); err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+// RevokeAllSessions godoc
+// @Summary      Log out everywhere
+// @Description  Ends all sessions of the current user, this one included; every token issued to them stops working
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/me/sessions [delete]
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	revoked, err := h.sessionService.RevokeAll(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out everywhere", "revoked": revoked})
+}
+
+// sessionErrorStatus maps session service errors to HTTP status codes
+func sessionErrorStatus(err error) int {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// userTokenErrorStatus maps password reset and email verification errors to
+// HTTP status codes
+func userTokenErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrUserClosed):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrEmailAlreadyVerified):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrMailFailed):
+		return http.StatusBadGateway
+	default:
+		return http.StatusBadRequest
+	}
 }
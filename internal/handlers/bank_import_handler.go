@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// maxBankFileSize limits uploaded bank files
+const maxBankFileSize = 10 << 20
+
+// BankImportHandler handles bank file imports and the suspense queue
+type BankImportHandler struct {
+	bankImportService *service.BankImportService
+}
+
+// NewBankImportHandler creates a new BankImportHandler instance
+func NewBankImportHandler(bankImportService *service.BankImportService) *BankImportHandler {
+	return &BankImportHandler{bankImportService: bankImportService}
+}
+
+type allocateBankEntryRequest struct {
+	AccountNumber string `json:"account_number" binding:"required" example:"XT12TAKA0123456789"`
+	Note          string `json:"note" binding:"required,max=1000" example:"Payer confirmed by phone, reference had a typo"`
+}
+
+// ImportBankFile godoc
+// @Summary      Import bank file
+// @Description  Imports a bank notification file of incoming payments: CSV with a header row (entry_id, booking_date, amount, currency, reference, debtor_name, debtor_account) or ISO 20022 camt.054. Booked credit entries whose reference contains one of our account numbers are deposited into that account; the others go to the suspense queue. Entries are identified by the bank's entry ID, so importing a file again books nothing twice. (admin only)
+// @Tags         admin
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file   formData  file    true   "Bank file"
+// @Param        format formData  string  false  "csv or camt.054, detected from the content when empty"
+// @Success      201  {object}  models.BankFile
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/bank-files [post]
+func (h *BankImportHandler) ImportBankFile(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if header.Size > maxBankFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is larger than 10 MB"})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxBankFileSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+
+	file, err := h.bankImportService.Import(models.BankFileFormat(c.PostForm("format")), header.Filename, data, adminID)
+	if err != nil {
+		c.JSON(bankImportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, file)
+}
+
+// ListBankFiles godoc
+// @Summary      List bank files
+// @Description  Returns imported bank files with their counts of booked, suspended and duplicate entries, newest first (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/bank-files [get]
+func (h *BankImportHandler) ListBankFiles(c *gin.Context) {
+	page := 1
+	pageSize := 20
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	files, total, err := h.bankImportService.ListFiles(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list bank files"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"bank_files": files, "total": total})
+}
+
+// GetBankFile godoc
+// @Summary      Get bank file
+// @Description  Returns an imported bank file with the entries first imported from it (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Bank file ID"
+// @Success      200  {object}  models.BankFile
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/bank-files/{id} [get]
+func (h *BankImportHandler) GetBankFile(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bank file ID"})
+		return
+	}
+
+	file, err := h.bankImportService.GetFile(id)
+	if err != nil {
+		c.JSON(bankImportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// ListBankEntries godoc
+// @Summary      List bank entries
+// @Description  Returns imported bank entries, oldest booking first. Use status=suspense for the entries waiting for manual allocation. (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status query string false "booked, suspense or allocated"
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/bank-entries [get]
+func (h *BankImportHandler) ListBankEntries(c *gin.Context) {
+	page := 1
+	pageSize := 20
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	entries, total, err := h.bankImportService.ListEntries(models.BankEntryStatus(c.Query("status")), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list bank entries"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"bank_entries": entries, "total": total})
+}
+
+// GetBankEntry godoc
+// @Summary      Get bank entry
+// @Description  Returns an imported bank entry (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Bank entry ID"
+// @Success      200  {object}  models.BankEntry
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/bank-entries/{id} [get]
+func (h *BankImportHandler) GetBankEntry(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bank entry ID"})
+		return
+	}
+
+	entry, err := h.bankImportService.GetEntry(id)
+	if err != nil {
+		c.JSON(bankImportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// AllocateBankEntry godoc
+// @Summary      Allocate bank entry
+// @Description  Deposits an entry from the suspense queue into the account with the given number, in the entry's currency, recording the admin and the note (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Bank entry ID"
+// @Param        request body allocateBankEntryRequest true "Allocation"
+// @Success      200  {object}  models.BankEntry
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/bank-entries/{id}/allocate [post]
+func (h *BankImportHandler) AllocateBankEntry(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bank entry ID"})
+		return
+	}
+	var req allocateBankEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	entry, err := h.bankImportService.Allocate(id, req.AccountNumber, req.Note, adminID)
+	if err != nil {
+		c.JSON(bankImportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// bankImportErrorStatus maps bank import service errors to HTTP status codes
+func bankImportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, models.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrBankEntryNotInSuspense), errors.Is(err, models.ErrUserClosed):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
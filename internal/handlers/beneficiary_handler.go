@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// BeneficiaryHandler handles a user's beneficiary book
+type BeneficiaryHandler struct {
+	beneficiaryService *service.BeneficiaryService
+}
+
+// NewBeneficiaryHandler creates a new BeneficiaryHandler instance
+func NewBeneficiaryHandler(beneficiaryService *service.BeneficiaryService) *BeneficiaryHandler {
+	return &BeneficiaryHandler{beneficiaryService: beneficiaryService}
+}
+
+type beneficiaryRequest struct {
+	Nickname string `json:"nickname" binding:"required,max=50" example:"Landlord"`
+	IBAN     string `json:"iban" example:"DE89 3704 0044 0532 0130 00"`
+	BIC      string `json:"bic" example:"COBADEFFXXX"`
+	Name     string `json:"name" binding:"max=140" example:"Jane Doe"` // account holder, required for external IBANs
+	Email    string `json:"email" example:"friend@example.com"`
+	Phone    string `json:"phone" example:"+49 151 23456789"`
+}
+
+type beneficiaryUpdateRequest struct {
+	Nickname *string `json:"nickname" binding:"omitempty,max=50" example:"Old landlord"`
+	Name     *string `json:"name" binding:"omitempty,max=140" example:"Jane Doe"`
+}
+
+// CreateBeneficiary godoc
+// @Summary      Save beneficiary
+// @Description  Saves a payee under a nickname, given by exactly one of an IBAN, an email or a phone number. IBANs are checked for the country's length and the check digits, BICs for their format. An IBAN of another bank needs the account holder's name; an IBAN of this bank, an email or a phone number of a KYC verified customer is resolved to that customer.
+// @Tags         beneficiaries
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body beneficiaryRequest true "Beneficiary"
+// @Success      201  {object}  models.Beneficiary
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/beneficiaries [post]
+func (h *BeneficiaryHandler) CreateBeneficiary(c *gin.Context) {
+	var req beneficiaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	beneficiary := &models.Beneficiary{
+		UserID:   userID,
+		Nickname: req.Nickname,
+		IBAN:     req.IBAN,
+		BIC:      req.BIC,
+		Name:     req.Name,
+		Email:    req.Email,
+		Phone:    req.Phone,
+	}
+	if err := h.beneficiaryService.Create(beneficiary); err != nil {
+		c.JSON(beneficiaryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, beneficiary)
+}
+
+// ListBeneficiaries godoc
+// @Summary      List beneficiaries
+// @Description  Returns the user's saved beneficiaries by nickname
+// @Tags         beneficiaries
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.Beneficiary
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/beneficiaries [get]
+func (h *BeneficiaryHandler) ListBeneficiaries(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	beneficiaries, err := h.beneficiaryService.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list beneficiaries"})
+		return
+	}
+	c.JSON(http.StatusOK, beneficiaries)
+}
+
+// GetBeneficiary godoc
+// @Summary      Get beneficiary
+// @Description  Returns one of the user's saved beneficiaries
+// @Tags         beneficiaries
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Beneficiary ID"
+// @Success      200  {object}  models.Beneficiary
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/beneficiaries/{id} [get]
+func (h *BeneficiaryHandler) GetBeneficiary(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid beneficiary ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	beneficiary, err := h.beneficiaryService.Get(id, userID)
+	if err != nil {
+		c.JSON(beneficiaryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, beneficiary)
+}
+
+// UpdateBeneficiary godoc
+// @Summary      Update beneficiary
+// @Description  Renames a beneficiary or changes the account holder name. The IBAN, email or phone number cannot be changed; save a new beneficiary instead.
+// @Tags         beneficiaries
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Beneficiary ID"
+// @Param        request body beneficiaryUpdateRequest true "Changes"
+// @Success      200  {object}  models.Beneficiary
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/beneficiaries/{id} [put]
+func (h *BeneficiaryHandler) UpdateBeneficiary(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid beneficiary ID"})
+		return
+	}
+	var req beneficiaryUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	beneficiary, err := h.beneficiaryService.Update(id, userID, service.BeneficiaryUpdate{
+		Nickname: req.Nickname,
+		Name:     req.Name,
+	})
+	if err != nil {
+		c.JSON(beneficiaryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, beneficiary)
+}
+
+// DeleteBeneficiary godoc
+// @Summary      Delete beneficiary
+// @Description  Removes a beneficiary from the user's book
+// @Tags         beneficiaries
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Beneficiary ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/beneficiaries/{id} [delete]
+func (h *BeneficiaryHandler) DeleteBeneficiary(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid beneficiary ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.beneficiaryService.Delete(id, userID); err != nil {
+		c.JSON(beneficiaryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "beneficiary deleted"})
+}
+
+// beneficiaryErrorStatus maps beneficiary service errors to HTTP status codes
+func beneficiaryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, models.ErrBeneficiaryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrDuplicateNickname):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
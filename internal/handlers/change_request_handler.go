@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// ChangeRequestHandler handles the maker-checker flow of sensitive admin
+// actions and the audit trail
+type ChangeRequestHandler struct {
+	changeRequestService *service.ChangeRequestService
+}
+
+// NewChangeRequestHandler creates a new ChangeRequestHandler instance
+func NewChangeRequestHandler(changeRequestService *service.ChangeRequestService) *ChangeRequestHandler {
+	return &ChangeRequestHandler{changeRequestService: changeRequestService}
+}
+
+type reversalRequest struct {
+	Reason string `json:"reason" binding:"required,max=500" example:"Duplicate card settlement"`
+}
+
+type changeDecisionRequest struct {
+	Comment string `json:"comment" binding:"max=500" example:"Confirmed with the customer"`
+}
+
+// RequestReversal godoc
+// @Summary      Request transaction reversal
+// @Description  Requests a deposit, withdrawal, transfer or move be reversed together with its fees. The reversal is booked once a different admin approves the change request.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Transaction ID"
+// @Param        request body reversalRequest true "Reversal reason"
+// @Success      202  {object}  models.ChangeRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/transactions/{id}/reverse [post]
+func (h *ChangeRequestHandler) RequestReversal(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction ID"})
+		return
+	}
+	var req reversalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	request, err := h.changeRequestService.RequestReversal(adminID, id, req.Reason)
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, request)
+}
+
+// ListChangeRequests godoc
+// @Summary      List change requests
+// @Description  Returns change requests, newest first, optionally filtered by status (pending, executed, failed, rejected, cancelled, expired)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status query string false "Status"
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/change-requests [get]
+func (h *ChangeRequestHandler) ListChangeRequests(c *gin.Context) {
+	page := 1
+	pageSize := 20
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	requests, total, err := h.changeRequestService.List(models.ChangeRequestStatus(c.Query("status")), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list change requests"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"change_requests": requests, "total": total})
+}
+
+// GetChangeRequest godoc
+// @Summary      Get change request
+// @Description  Returns a change request with its audit trail
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Change request ID"
+// @Success      200  {object}  models.ChangeRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/change-requests/{id} [get]
+func (h *ChangeRequestHandler) GetChangeRequest(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid change request ID"})
+		return
+	}
+
+	request, err := h.changeRequestService.Get(id)
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+// ApproveChangeRequest godoc
+// @Summary      Approve change request
+// @Description  Approves a pending change request and applies it. The admin who requested it and the user it applies to cannot approve it. A change that cannot be applied is returned with status failed and the error.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Change request ID"
+// @Param        request body changeDecisionRequest false "Optional comment"
+// @Success      200  {object}  models.ChangeRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/change-requests/{id}/approve [post]
+func (h *ChangeRequestHandler) ApproveChangeRequest(c *gin.Context) {
+	h.decide(c, h.changeRequestService.Approve)
+}
+
+// RejectChangeRequest godoc
+// @Summary      Reject change request
+// @Description  Turns down a pending change request
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Change request ID"
+// @Param        request body changeDecisionRequest false "Optional comment"
+// @Success      200  {object}  models.ChangeRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/change-requests/{id}/reject [post]
+func (h *ChangeRequestHandler) RejectChangeRequest(c *gin.Context) {
+	h.decide(c, h.changeRequestService.Reject)
+}
+
+// CancelChangeRequest godoc
+// @Summary      Cancel change request
+// @Description  Withdraws a pending change request. Only the admin who requested it may cancel it.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Change request ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/change-requests/{id}/cancel [post]
+func (h *ChangeRequestHandler) CancelChangeRequest(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid change request ID"})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.changeRequestService.Cancel(id, adminID); err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "change request cancelled"})
+}
+
+// ListAuditLog godoc
+// @Summary      List audit log
+// @Description  Returns audit log entries, newest first, optionally filtered by the admin who acted, the change request or the user or transaction concerned
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        actor_id query string false "Admin user ID"
+// @Param        change_request_id query string false "Change request ID"
+// @Param        target_id query string false "User or transaction ID"
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 50)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/audit-log [get]
+func (h *ChangeRequestHandler) ListAuditLog(c *gin.Context) {
+	var filter repository.AuditFilter
+	for param, field := range map[string]**uuid.UUID{
+		"actor_id":          &filter.ActorID,
+		"change_request_id": &filter.ChangeRequestID,
+		"target_id":         &filter.TargetID,
+	} {
+		if v := c.Query(param); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+				return
+			}
+			*field = &id
+		}
+	}
+
+	page := 1
+	pageSize := 50
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	entries, total, err := h.changeRequestService.ListAudit(filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list audit log"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "total": total})
+}
+
+// decide binds an approve or reject request and records the decision
+func (h *ChangeRequestHandler) decide(c *gin.Context, decide func(id, adminID uuid.UUID, comment string) (*models.ChangeRequest, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid change request ID"})
+		return
+	}
+	var req changeDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	request, err := decide(id, adminID, req.Comment)
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+// changeRequestErrorStatus maps change request service errors to HTTP status codes
+func changeRequestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrChangeSelfApproval), errors.Is(err, models.ErrChangeRequestNotOwned):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrChangeRequestNotPending), errors.Is(err, models.ErrChangeRequestExpired),
+		errors.Is(err, models.ErrAlreadyReversed), errors.Is(err, models.ErrUserNotEmpty), errors.Is(err, models.ErrAlreadyErased),
+		errors.Is(err, models.ErrEmailInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/realtime"
+	"golang.org/x/net/websocket"
+)
+
+// heartbeatInterval keeps idle connections open through proxies
+const heartbeatInterval = 25 * time.Second
+
+// EventHandler streams real-time notifications to the authenticated user
+type EventHandler struct {
+	hub *realtime.Hub
+}
+
+// NewEventHandler creates a new EventHandler instance
+func NewEventHandler(hub *realtime.Hub) *EventHandler {
+	return &EventHandler{hub: hub}
+}
+
+// StreamEvents godoc
+// @Summary      Stream notifications (SSE)
+// @Description  Server-Sent Events stream of balance changes and incoming/outgoing transactions for the authenticated user. Reconnect with the Last-Event-ID header (or last_event_id query parameter) to resume. Browsers may pass the token as access_token query parameter.
+// @Tags         users
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        Last-Event-ID header string false "Resume after this event ID"
+// @Param        last_event_id query string false "Resume after this event ID"
+// @Success      200  {string}  string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /users/events [get]
+func (h *EventHandler) StreamEvents(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	if lastEventID != "" && !realtime.ValidStreamID(lastEventID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid last event ID"})
+		return
+	}
+
+	notifications, err := h.hub.Subscribe(c.Request.Context(), userID, lastEventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to subscribe to events"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case n, ok := <-notifications:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", n.ID, n.Type, n.Data); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// StreamEventsWebSocket godoc
+// @Summary      Stream notifications (WebSocket)
+// @Description  WebSocket stream of the same notifications as /users/events, one JSON message {id, type, data} per frame. Resume with the last_event_id query parameter.
+// @Tags         users
+// @Security     BearerAuth
+// @Param        last_event_id query string false "Resume after this event ID"
+// @Success      101  {string}  string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /users/events/ws [get]
+func (h *EventHandler) StreamEventsWebSocket(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	lastEventID := c.Query("last_event_id")
+	if lastEventID != "" && !realtime.ValidStreamID(lastEventID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid last event ID"})
+		return
+	}
+
+	server := websocket.Server{
+		// The caller is already authenticated by token, so any origin is accepted
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			ctx := ws.Request().Context()
+			notifications, err := h.hub.Subscribe(ctx, userID, lastEventID)
+			if err != nil {
+				return
+			}
+
+			heartbeat := time.NewTicker(heartbeatInterval)
+			defer heartbeat.Stop()
+
+			for {
+				select {
+				case n, ok := <-notifications:
+					if !ok {
+						return
+					}
+					message, err := json.Marshal(n)
+					if err != nil {
+						return
+					}
+					if err := websocket.Message.Send(ws, string(message)); err != nil {
+						return
+					}
+				case <-heartbeat.C:
+					if err := websocket.Message.Send(ws, `{"type":"heartbeat"}`); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// HoldHandler handles fund hold (authorization) requests
+type HoldHandler struct {
+	holdService          *service.HoldService
+	changeRequestService *service.ChangeRequestService
+}
+
+// NewHoldHandler creates a new HoldHandler instance
+func NewHoldHandler(holdService *service.HoldService, changeRequestService *service.ChangeRequestService) *HoldHandler {
+	return &HoldHandler{holdService: holdService, changeRequestService: changeRequestService}
+}
+
+type holdRequest struct {
+	UserID           string  `json:"user_id" binding:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Amount           float64 `json:"amount" binding:"required,gt=0" example:"80.00"`
+	Currency         string  `json:"currency" binding:"required,len=3" example:"EUR"`
+	AccountID        string  `json:"account_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174002"` // defaults to the user's main account
+	RecipientID      string  `json:"recipient_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174001"`
+	Reference        string  `json:"reference" binding:"max=100" example:"AUTH-482913"`
+	Description      string  `json:"description" example:"Hotel pre-authorization"`
+	ExpiresInMinutes int     `json:"expires_in_minutes" binding:"gte=0" example:"10080"`
+}
+
+type captureRequest struct {
+	Amount float64 `json:"amount" binding:"gte=0" example:"64.20"`
+	Reason string  `json:"reason" example:"Merchant settlement"` // required for holds with a recipient
+}
+
+// PlaceHold godoc
+// @Summary      Place a hold
+// @Description  Reserves funds on a user's balance, reducing the available balance until the hold is captured, released or expires (default 7 days, at most 30 days) (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body holdRequest true "Hold details"
+// @Success      201  {object}  models.Hold
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /admin/holds [post]
+func (h *HoldHandler) PlaceHold(c *gin.Context) {
+	var req holdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	hold := &models.Hold{
+		UserID:      uuid.MustParse(req.UserID),
+		AccountID:   optionalUUID(req.AccountID),
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		RecipientID: optionalUUID(req.RecipientID),
+		Reference:   req.Reference,
+		Description: req.Description,
+		CreatedBy:   adminID,
+	}
+	if err := h.holdService.Place(hold, time.Duration(req.ExpiresInMinutes)*time.Minute); err != nil {
+		c.JSON(holdErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, hold)
+}
+
+// ListHolds godoc
+// @Summary      List holds
+// @Description  Returns a paginated list of holds, optionally for one user and status (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user_id query string false "User ID"
+// @Param        status query string false "Hold status (active, captured, released, expired)"
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/holds [get]
+func (h *HoldHandler) ListHolds(c *gin.Context) {
+	var userID *uuid.UUID
+	if raw := c.Query("user_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		userID = &parsed
+	}
+	h.list(c, userID)
+}
+
+// ListMyHolds godoc
+// @Summary      List my holds
+// @Description  Returns the holds on the authenticated user's balances
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status query string false "Hold status (active, captured, released, expired)"
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/holds [get]
+func (h *HoldHandler) ListMyHolds(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	h.list(c, &userID)
+}
+
+func (h *HoldHandler) list(c *gin.Context, userID *uuid.UUID) {
+	page := 1
+	pageSize := 20
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	holds, total, err := h.holdService.List(userID, models.HoldStatus(c.Query("status")), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list holds"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"holds": holds, "total": total})
+}
+
+// GetHold godoc
+// @Summary      Get hold
+// @Description  Returns a hold by ID (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Hold ID"
+// @Success      200  {object}  models.Hold
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/holds/{id} [get]
+func (h *HoldHandler) GetHold(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid hold ID"})
+		return
+	}
+
+	hold, err := h.holdService.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "hold not found"})
+		return
+	}
+	c.JSON(http.StatusOK, hold)
+}
+
+// CaptureHold godoc
+// @Summary      Capture a hold
+// @Description  Books the full or a partial amount of an active hold as a withdrawal; any remainder is released (admin only). A hold with a recipient becomes a transfer, so its capture needs a reason and is only requested: it is booked once a different admin approves it, and the response is 202 with the change request.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Hold ID"
+// @Param        request body captureRequest false "Amount to capture (default: full hold)"
+// @Success      200  {object}  map[string]interface{}
+// @Success      202  {object}  models.ChangeRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/holds/{id}/capture [post]
+func (h *HoldHandler) CaptureHold(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid hold ID"})
+		return
+	}
+	var req captureRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	hold, err := h.holdService.Get(id)
+	if err != nil {
+		c.JSON(holdErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if hold.RecipientID != nil {
+		request, err := h.changeRequestService.RequestHoldCapture(adminID, id, req.Amount, req.Reason)
+		if err != nil {
+			c.JSON(holdErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, request)
+		return
+	}
+
+	hold, transaction, err := h.holdService.Capture(id, req.Amount)
+	if err != nil {
+		c.JSON(holdErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"hold": hold, "transaction": transaction})
+}
+
+// ReleaseHold godoc
+// @Summary      Release a hold
+// @Description  Lifts an active hold without booking anything, returning the funds to the available balance (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Hold ID"
+// @Success      200  {object}  models.Hold
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/holds/{id}/release [post]
+func (h *HoldHandler) ReleaseHold(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid hold ID"})
+		return
+	}
+
+	hold, err := h.holdService.Release(id)
+	if err != nil {
+		c.JSON(holdErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, hold)
+}
+
+func holdErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrHoldNotActive), errors.Is(err, models.ErrHoldExpired):
+		return http.StatusConflict
+	default:
+		return transactionErrorStatus(err)
+	}
+}
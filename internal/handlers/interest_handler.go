@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+)
+
+// InterestHandler handles interest rate and accrual requests
+type InterestHandler struct {
+	interestService *service.InterestService
+}
+
+// NewInterestHandler creates a new InterestHandler instance
+func NewInterestHandler(interestService *service.InterestService) *InterestHandler {
+	return &InterestHandler{interestService: interestService}
+}
+
+type interestRateRequest struct {
+	Currency      string                `json:"currency" binding:"required,len=3" example:"EUR"`
+	EffectiveFrom string                `json:"effective_from" binding:"required" example:"2024-07-01"`
+	DayCount      string                `json:"day_count" binding:"required,oneof=ACT/365 ACT/360 ACT/ACT" example:"ACT/365"`
+	Tiers         []models.InterestTier `json:"tiers" binding:"required,min=1"`
+}
+
+// CreateInterestRate godoc
+// @Summary      Create interest rate
+// @Description  Sets tiered annual interest rates for a currency from effective_from (YYYY-MM-DD) on. Each tier's rate applies to the band of the balance up to its up_to; leave up_to at 0 on the last tier to cover the rest. Rates cannot take effect on days already accrued (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body interestRateRequest true "Interest rate"
+// @Success      201  {object}  models.InterestRate
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/interest-rates [post]
+func (h *InterestHandler) CreateInterestRate(c *gin.Context) {
+	var req interestRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid effective_from format, use YYYY-MM-DD"})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	rate := &models.InterestRate{
+		Currency:      req.Currency,
+		EffectiveFrom: effectiveFrom,
+		DayCount:      models.DayCount(req.DayCount),
+		Tiers:         req.Tiers,
+		CreatedBy:     adminID,
+	}
+	if err := h.interestService.CreateRate(rate); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrRateNotInFuture) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, rate)
+}
+
+// ListInterestRates godoc
+// @Summary      List interest rates
+// @Description  Returns all interest rates by currency, latest effective first (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.InterestRate
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/interest-rates [get]
+func (h *InterestHandler) ListInterestRates(c *gin.Context) {
+	rates, err := h.interestService.ListRates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list interest rates"})
+		return
+	}
+	c.JSON(http.StatusOK, rates)
+}
+
+// GetMyInterest godoc
+// @Summary      Get my interest
+// @Description  Returns the interest accrued but not yet posted on a balance, with the last 31 daily accruals
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        currency query string false "Currency code (default: EUR)"
+// @Success      200  {object}  service.InterestSummary
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/interest [get]
+func (h *InterestHandler) GetMyInterest(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	summary, err := h.interestService.Summary(userID, c.DefaultQuery("currency", "EUR"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get interest"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
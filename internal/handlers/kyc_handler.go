@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// KYCHandler handles customer profile and KYC review requests
+type KYCHandler struct {
+	kycService *service.KYCService
+}
+
+// NewKYCHandler creates a new KYCHandler instance
+func NewKYCHandler(kycService *service.KYCService) *KYCHandler {
+	return &KYCHandler{kycService: kycService}
+}
+
+type profileRequest struct {
+	LegalName    string `json:"legal_name" binding:"required" example:"Jane Doe"`
+	DateOfBirth  string `json:"date_of_birth" binding:"required" example:"1990-04-21"`
+	Phone        string `json:"phone" example:"+31612345678"`
+	AddressLine1 string `json:"address_line1" binding:"required" example:"Damrak 1"`
+	AddressLine2 string `json:"address_line2" example:""`
+	City         string `json:"city" binding:"required" example:"Amsterdam"`
+	PostalCode   string `json:"postal_code" binding:"required" example:"1012 LG"`
+	Country      string `json:"country" binding:"required,len=2" example:"NL"`
+	Nationality  string `json:"nationality" binding:"required,len=2" example:"NL"`
+}
+
+type kycApproveRequest struct {
+	Level int `json:"level" example:"1"`
+}
+
+type kycRejectRequest struct {
+	Reason string `json:"reason" binding:"required" example:"Document does not match legal name"`
+}
+
+type profileResponse struct {
+	Profile *models.UserProfile `json:"profile"`
+	Limits  models.KYCLimits    `json:"limits"`
+}
+
+// GetMyProfile godoc
+// @Summary      Get current user's KYC profile
+// @Description  Returns the profile, KYC status and the limits granted by the current KYC level
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  profileResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/me/profile [get]
+func (h *KYCHandler) GetMyProfile(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	profile, err := h.kycService.GetProfile(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profileResponse{Profile: profile, Limits: models.LimitsForLevel(profile.EffectiveLevel())})
+}
+
+// SubmitMyProfile godoc
+// @Summary      Submit KYC profile
+// @Description  Stores the customer's identity data and queues it for KYC review
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body profileRequest true "Profile details"
+// @Success      200  {object}  models.UserProfile
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /users/me/profile [put]
+func (h *KYCHandler) SubmitMyProfile(c *gin.Context) {
+	var req profileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	dateOfBirth, err := time.Parse("2006-01-02", req.DateOfBirth)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date_of_birth format, use YYYY-MM-DD"})
+		return
+	}
+
+	profile, err := h.kycService.SubmitProfile(userID, &models.UserProfile{
+		LegalName:    req.LegalName,
+		DateOfBirth:  &dateOfBirth,
+		Phone:        req.Phone,
+		AddressLine1: req.AddressLine1,
+		AddressLine2: req.AddressLine2,
+		City:         req.City,
+		PostalCode:   req.PostalCode,
+		Country:      req.Country,
+		Nationality:  req.Nationality,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// ListKYCReviews godoc
+// @Summary      List KYC profiles
+// @Description  Returns profiles in the given KYC status, oldest submission first (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status query string false "KYC status (default: pending)"
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/kyc [get]
+func (h *KYCHandler) ListKYCReviews(c *gin.Context) {
+	status := models.KYCStatus(c.DefaultQuery("status", string(models.KYCStatusPending)))
+
+	page := 1
+	pageSize := 20
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	profiles, total, err := h.kycService.ListByStatus(status, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list profiles"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles, "total": total})
+}
+
+// GetKYCProfile godoc
+// @Summary      Get a user's KYC profile
+// @Description  Returns the profile and KYC status of a user (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user_id path string true "User ID"
+// @Success      200  {object}  models.UserProfile
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /admin/kyc/{user_id} [get]
+func (h *KYCHandler) GetKYCProfile(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	profile, err := h.kycService.GetProfile(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get profile"})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// ApproveKYC godoc
+// @Summary      Approve KYC profile
+// @Description  Marks a pending profile as verified at the given level (1 = basic, 2 = full) (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user_id path string true "User ID"
+// @Param        request body kycApproveRequest false "Granted level (default: 1)"
+// @Success      200  {object}  models.UserProfile
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/kyc/{user_id}/approve [post]
+func (h *KYCHandler) ApproveKYC(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var req kycApproveRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Level == 0 {
+		req.Level = int(models.KYCLevelBasic)
+	}
+	reviewerID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	profile, err := h.kycService.Approve(userID, reviewerID, models.KYCLevel(req.Level))
+	if err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// RejectKYC godoc
+// @Summary      Reject KYC profile
+// @Description  Marks a pending profile as rejected with a reason shown to the customer (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user_id path string true "User ID"
+// @Param        request body kycRejectRequest true "Rejection reason"
+// @Success      200  {object}  models.UserProfile
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/kyc/{user_id}/reject [post]
+func (h *KYCHandler) RejectKYC(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var req kycRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reviewerID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	profile, err := h.kycService.Reject(userID, reviewerID, req.Reason)
+	if err != nil {
+		c.JSON(kycErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func kycErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrKYCNotPending):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
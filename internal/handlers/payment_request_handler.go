@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// PaymentRequestHandler handles payment request and split bill requests
+type PaymentRequestHandler struct {
+	paymentRequestService *service.PaymentRequestService
+}
+
+// NewPaymentRequestHandler creates a new PaymentRequestHandler instance
+func NewPaymentRequestHandler(paymentRequestService *service.PaymentRequestService) *PaymentRequestHandler {
+	return &PaymentRequestHandler{paymentRequestService: paymentRequestService}
+}
+
+type paymentRequestRequest struct {
+	PayerID        string  `json:"payer_id" binding:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Amount         float64 `json:"amount" binding:"required,gt=0" example:"25.00"`
+	Currency       string  `json:"currency" binding:"required,len=3" example:"EUR"`
+	Memo           string  `json:"memo" example:"Concert tickets"`
+	ExpiresInHours int     `json:"expires_in_hours" binding:"gte=0" example:"72"`
+}
+
+type splitShareRequest struct {
+	PayerID string  `json:"payer_id" binding:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Amount  float64 `json:"amount" binding:"gte=0" example:"0"`
+}
+
+type splitBillRequest struct {
+	Total            float64             `json:"total" binding:"required,gt=0" example:"100.00"`
+	Currency         string              `json:"currency" binding:"required,len=3" example:"EUR"`
+	Memo             string              `json:"memo" example:"Dinner"`
+	ExpiresInHours   int                 `json:"expires_in_hours" binding:"gte=0" example:"72"`
+	IncludeRequester bool                `json:"include_requester" example:"true"`
+	Shares           []splitShareRequest `json:"shares" binding:"required,min=1,dive"`
+}
+
+// CreatePaymentRequest godoc
+// @Summary      Request money
+// @Description  Asks another user to pay an amount. The request expires after expires_in_hours (default 7 days, at most 90 days).
+// @Tags         payment-requests
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body paymentRequestRequest true "Payment request details"
+// @Success      201  {object}  models.PaymentRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /users/payment-requests [post]
+func (h *PaymentRequestHandler) CreatePaymentRequest(c *gin.Context) {
+	var req paymentRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	request := &models.PaymentRequest{
+		RequesterID: userID,
+		PayerID:     uuid.MustParse(req.PayerID),
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Memo:        req.Memo,
+	}
+	if err := h.paymentRequestService.Create(request, time.Duration(req.ExpiresInHours)*time.Hour); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, request)
+}
+
+// SplitBill godoc
+// @Summary      Split a bill
+// @Description  Sends a payment request to each participant for their share of the total. Leave share amounts at 0 to split equally (remainder cents go to the first share); with include_requester the requester keeps a share and is not charged.
+// @Tags         payment-requests
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body splitBillRequest true "Split bill details"
+// @Success      201  {array}   models.PaymentRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /users/payment-requests/split [post]
+func (h *PaymentRequestHandler) SplitBill(c *gin.Context) {
+	var req splitBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	shares := make([]service.SplitShare, len(req.Shares))
+	for i, share := range req.Shares {
+		shares[i] = service.SplitShare{PayerID: uuid.MustParse(share.PayerID), Amount: share.Amount}
+	}
+	ttl := time.Duration(req.ExpiresInHours) * time.Hour
+	requests, err := h.paymentRequestService.Split(userID, req.Total, req.Currency, req.Memo, ttl, shares, req.IncludeRequester)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, requests)
+}
+
+// ListPaymentRequests godoc
+// @Summary      List payment requests
+// @Description  Returns payment requests the authenticated user sent or received
+// @Tags         payment-requests
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        direction query string false "incoming, outgoing or empty for both"
+// @Param        status query string false "Filter by status (pending, accepted, declined, cancelled, expired)"
+// @Success      200  {array}   models.PaymentRequest
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/payment-requests [get]
+func (h *PaymentRequestHandler) ListPaymentRequests(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	requests, err := h.paymentRequestService.List(userID, c.Query("direction"), models.PaymentRequestStatus(c.Query("status")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list payment requests"})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// GetPaymentRequest godoc
+// @Summary      Get payment request
+// @Description  Returns a payment request the authenticated user sent or received
+// @Tags         payment-requests
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Payment request ID"
+// @Success      200  {object}  models.PaymentRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/payment-requests/{id} [get]
+func (h *PaymentRequestHandler) GetPaymentRequest(c *gin.Context) {
+	h.respond(c, h.paymentRequestService.Get)
+}
+
+// AcceptPaymentRequest godoc
+// @Summary      Accept payment request
+// @Description  Pays a pending request addressed to the authenticated user by transferring the amount to the requester. It is refused when an approval policy of the payer's main account covers the amount.
+// @Tags         payment-requests
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Payment request ID"
+// @Success      200  {object}  models.PaymentRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/payment-requests/{id}/accept [post]
+func (h *PaymentRequestHandler) AcceptPaymentRequest(c *gin.Context) {
+	h.respond(c, h.paymentRequestService.Accept)
+}
+
+// DeclinePaymentRequest godoc
+// @Summary      Decline payment request
+// @Description  Refuses a pending request addressed to the authenticated user
+// @Tags         payment-requests
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Payment request ID"
+// @Success      200  {object}  models.PaymentRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/payment-requests/{id}/decline [post]
+func (h *PaymentRequestHandler) DeclinePaymentRequest(c *gin.Context) {
+	h.respond(c, h.paymentRequestService.Decline)
+}
+
+// CancelPaymentRequest godoc
+// @Summary      Cancel payment request
+// @Description  Withdraws a pending request the authenticated user sent
+// @Tags         payment-requests
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Payment request ID"
+// @Success      200  {object}  models.PaymentRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/payment-requests/{id}/cancel [post]
+func (h *PaymentRequestHandler) CancelPaymentRequest(c *gin.Context) {
+	h.respond(c, h.paymentRequestService.Cancel)
+}
+
+// respond runs an action on the payment request in the path on behalf of the
+// authenticated user and writes the resulting request
+func (h *PaymentRequestHandler) respond(c *gin.Context, action func(id, userID uuid.UUID) (*models.PaymentRequest, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment request ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	request, err := action(id, userID)
+	if err != nil {
+		c.JSON(paymentRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+func paymentRequestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrRequestNotPending), errors.Is(err, models.ErrRequestExpired),
+		errors.Is(err, models.ErrApprovalRequired):
+		return http.StatusConflict
+	default:
+		return transactionErrorStatus(err)
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// PayoutHandler handles payouts to external accounts and their batch files
+type PayoutHandler struct {
+	payoutService *service.PayoutService
+}
+
+// NewPayoutHandler creates a new PayoutHandler instance
+func NewPayoutHandler(payoutService *service.PayoutService) *PayoutHandler {
+	return &PayoutHandler{payoutService: payoutService}
+}
+
+// ListPayouts godoc
+// @Summary      List payouts
+// @Description  Returns payouts of withdrawals to external accounts, newest first (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status query string false "pending, batched, completed or failed"
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/payouts [get]
+func (h *PayoutHandler) ListPayouts(c *gin.Context) {
+	page := 1
+	pageSize := 20
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	payouts, total, err := h.payoutService.List(models.PayoutStatus(c.Query("status")), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list payouts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"payouts": payouts, "total": total})
+}
+
+// GetPayout godoc
+// @Summary      Get payout
+// @Description  Returns a payout (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Payout ID"
+// @Success      200  {object}  models.Payout
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/payouts/{id} [get]
+func (h *PayoutHandler) GetPayout(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payout ID"})
+		return
+	}
+
+	payout, err := h.payoutService.Get(id)
+	if err != nil {
+		c.JSON(payoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, payout)
+}
+
+// ListPayoutBatches godoc
+// @Summary      List payout batches
+// @Description  Returns the pain.001 batch files written for pending payouts, newest first (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/payout-batches [get]
+func (h *PayoutHandler) ListPayoutBatches(c *gin.Context) {
+	page := 1
+	pageSize := 20
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	batches, total, err := h.payoutService.ListBatches(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list payout batches"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"payout_batches": batches, "total": total})
+}
+
+// GetPayoutBatch godoc
+// @Summary      Get payout batch
+// @Description  Returns a payout batch with its payouts (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Payout batch ID"
+// @Success      200  {object}  models.PayoutBatch
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/payout-batches/{id} [get]
+func (h *PayoutHandler) GetPayoutBatch(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payout batch ID"})
+		return
+	}
+
+	batch, err := h.payoutService.GetBatch(id)
+	if err != nil {
+		c.JSON(payoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
+
+// DownloadPayoutBatch godoc
+// @Summary      Download payout batch file
+// @Description  Returns the pain.001 credit transfer XML of a batch for upload to the bank (admin only)
+// @Tags         admin
+// @Produce      xml
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Payout batch ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/payout-batches/{id}/document [get]
+func (h *PayoutHandler) DownloadPayoutBatch(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payout batch ID"})
+		return
+	}
+
+	batch, err := h.payoutService.GetBatch(id)
+	if err != nil {
+		c.JSON(payoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="pain001-%s.xml"`, batch.MessageID))
+	c.Data(http.StatusOK, "application/xml", []byte(batch.Document))
+}
+
+// ImportPayoutStatusReport godoc
+// @Summary      Import payout status report
+// @Description  Imports a pain.002 payment status report from the bank. Payouts of the batch it refers to are completed when accepted; rejected payouts fail and their withdrawal is refunded with its fees. Payouts without a final status stay batched, and importing a report again changes nothing. (admin only)
+// @Tags         admin
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file formData file true "pain.002 status report"
+// @Success      200  {object}  models.PayoutBatch
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/payout-batches/status-reports [post]
+func (h *PayoutHandler) ImportPayoutStatusReport(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if header.Size > maxBankFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is larger than 10 MB"})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxBankFileSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+
+	batch, err := h.payoutService.ImportStatusReport(data, adminID)
+	if err != nil {
+		c.JSON(payoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
+
+// payoutErrorStatus maps payout service errors to HTTP status codes
+func payoutErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, models.ErrUnknownPayoutBatch):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// PricingHandler handles pricing plan administration
+type PricingHandler struct {
+	pricingService *service.PricingService
+}
+
+// NewPricingHandler creates a new PricingHandler instance
+func NewPricingHandler(pricingService *service.PricingService) *PricingHandler {
+	return &PricingHandler{pricingService: pricingService}
+}
+
+type feeRuleRequest struct {
+	Kind       string           `json:"kind" binding:"required,oneof=withdraw transfer" example:"transfer"`
+	Currency   string           `json:"currency" binding:"omitempty,len=3" example:"EUR"`
+	Flat       float64          `json:"flat" binding:"gte=0" example:"0.25"`
+	Percentage float64          `json:"percentage" binding:"gte=0,lte=100" example:"0.5"`
+	Tiers      []models.FeeTier `json:"tiers"`
+	MinFee     float64          `json:"min_fee" binding:"gte=0" example:"0.5"`
+	MaxFee     float64          `json:"max_fee" binding:"gte=0" example:"10"`
+}
+
+type pricingPlanRequest struct {
+	Name                  string           `json:"name" binding:"required,max=100" example:"standard"`
+	Description           string           `json:"description" example:"Standard retail pricing"`
+	IsDefault             bool             `json:"is_default" example:"true"`
+	FreeTransfersPerMonth int              `json:"free_transfers_per_month" binding:"gte=0" example:"5"`
+	Rules                 []feeRuleRequest `json:"rules" binding:"dive"`
+}
+
+type assignPlanRequest struct {
+	PlanID string `json:"plan_id" binding:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+}
+
+func (r *pricingPlanRequest) toModel() *models.PricingPlan {
+	plan := &models.PricingPlan{
+		Name:                  r.Name,
+		Description:           r.Description,
+		IsDefault:             r.IsDefault,
+		FreeTransfersPerMonth: r.FreeTransfersPerMonth,
+		Rules:                 make([]models.FeeRule, len(r.Rules)),
+	}
+	for i, rule := range r.Rules {
+		plan.Rules[i] = models.FeeRule{
+			Kind:       models.FeeKind(rule.Kind),
+			Currency:   rule.Currency,
+			Flat:       rule.Flat,
+			Percentage: rule.Percentage,
+			Tiers:      rule.Tiers,
+			MinFee:     rule.MinFee,
+			MaxFee:     rule.MaxFee,
+		}
+	}
+	return plan
+}
+
+// CreatePricingPlan godoc
+// @Summary      Create pricing plan
+// @Description  Creates a pricing plan with fee rules for withdrawals and transfers. Each rule charges flat + percentage of the amount (taken from the matching tier when tiers are set), clamped to min_fee/max_fee (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body pricingPlanRequest true "Pricing plan"
+// @Success      201  {object}  models.PricingPlan
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /admin/pricing-plans [post]
+func (h *PricingHandler) CreatePricingPlan(c *gin.Context) {
+	var req pricingPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan := req.toModel()
+	if err := h.pricingService.CreatePlan(plan); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, plan)
+}
+
+// ListPricingPlans godoc
+// @Summary      List pricing plans
+// @Description  Returns all pricing plans with their fee rules (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.PricingPlan
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/pricing-plans [get]
+func (h *PricingHandler) ListPricingPlans(c *gin.Context) {
+	plans, err := h.pricingService.ListPlans()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list pricing plans"})
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+// GetPricingPlan godoc
+// @Summary      Get pricing plan
+// @Description  Returns a pricing plan with its fee rules (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Pricing plan ID"
+// @Success      200  {object}  models.PricingPlan
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/pricing-plans/{id} [get]
+func (h *PricingHandler) GetPricingPlan(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pricing plan ID"})
+		return
+	}
+
+	plan, err := h.pricingService.GetPlan(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pricing plan not found"})
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// UpdatePricingPlan godoc
+// @Summary      Update pricing plan
+// @Description  Replaces a pricing plan's settings and fee rules (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Pricing plan ID"
+// @Param        request body pricingPlanRequest true "Pricing plan"
+// @Success      200  {object}  models.PricingPlan
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/pricing-plans/{id} [put]
+func (h *PricingHandler) UpdatePricingPlan(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pricing plan ID"})
+		return
+	}
+	var req pricingPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.pricingService.UpdatePlan(id, req.toModel())
+	if err != nil {
+		c.JSON(pricingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// AssignPricingPlan godoc
+// @Summary      Assign pricing plan
+// @Description  Puts a user on a pricing plan; users without a plan use the default plan (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Param        request body assignPlanRequest true "Pricing plan"
+// @Success      200  {object}  models.UserPricingPlan
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/users/{id}/pricing-plan [put]
+func (h *PricingHandler) AssignPricingPlan(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var req assignPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	assignment, err := h.pricingService.AssignPlan(userID, uuid.MustParse(req.PlanID), adminID)
+	if err != nil {
+		c.JSON(pricingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, assignment)
+}
+
+func pricingErrorStatus(err error) int {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
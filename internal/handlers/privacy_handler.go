@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// PrivacyHandler handles data exports and erasures
+type PrivacyHandler struct {
+	privacyService       *service.PrivacyService
+	changeRequestService *service.ChangeRequestService
+}
+
+// NewPrivacyHandler creates a new PrivacyHandler instance
+func NewPrivacyHandler(privacyService *service.PrivacyService, changeRequestService *service.ChangeRequestService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService:       privacyService,
+		changeRequestService: changeRequestService,
+	}
+}
+
+type erasureRequest struct {
+	Reason string `json:"reason" binding:"required,max=500" example:"Right to erasure"`
+}
+
+// ExportMe godoc
+// @Summary      Export my data
+// @Description  Returns a zip archive of the current user's data: one JSON file each for the user, their KYC profile, accounts, balances, transactions, beneficiaries, standing orders, payment requests and webhooks, and a manifest.json listing them with the retention policy
+// @Tags         users
+// @Produce      application/zip
+// @Security     BearerAuth
+// @Success      200  {file}    file
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/me/export [get]
+func (h *PrivacyHandler) ExportMe(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	archive, err := h.privacyService.Export(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export data"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%s.zip"`, time.Now().Format("20060102")))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// EraseMe godoc
+// @Summary      Erase my data
+// @Description  Closes the current user's account if it is still open, which needs all balances to be empty, and erases their personal data: the email is replaced, the handle and password removed, and beneficiaries, webhooks and pending account invitations deleted. KYC data and the details of financial records are kept for the retention period and purged once it ended. The user can no longer log in.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body erasureRequest true "Erasure"
+// @Success      200  {object}  models.DataErasure
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/me/erasure [post]
+func (h *PrivacyHandler) EraseMe(c *gin.Context) {
+	var req erasureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	erasure, err := h.privacyService.Erase(userID, &userID, req.Reason)
+	if err != nil {
+		c.JSON(privacyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, erasure)
+}
+
+// RequestErasure godoc
+// @Summary      Request user erasure
+// @Description  Requests a user's personal data be erased (admin only), as when they exercise their right to erasure outside the app. Deleted users may be erased too. The erasure is applied once a second admin approves the change request.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Param        request body erasureRequest true "Erasure"
+// @Success      202  {object}  models.ChangeRequest
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/users/{id}/erasure [post]
+func (h *PrivacyHandler) RequestErasure(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var req erasureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	request, err := h.changeRequestService.RequestUserErasure(adminID, userID, req.Reason)
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, request)
+}
+
+// ListErasures godoc
+// @Summary      List erasures
+// @Description  Returns data erasures, newest first, with when the data kept for the retention period is due to be purged and when it was (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/data-erasures [get]
+func (h *PrivacyHandler) ListErasures(c *gin.Context) {
+	page := 1
+	pageSize := 20
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	erasures, total, err := h.privacyService.ListErasures(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list erasures"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"erasures": erasures, "total": total})
+}
+
+// privacyErrorStatus maps privacy service errors to HTTP status codes
+func privacyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrAlreadyErased):
+		return http.StatusConflict
+	default:
+		return lifecycleErrorStatus(err)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// ReconciliationHandler handles ledger reconciliation runs
+type ReconciliationHandler struct {
+	reconciliationService *service.ReconciliationService
+}
+
+// NewReconciliationHandler creates a new ReconciliationHandler instance
+func NewReconciliationHandler(reconciliationService *service.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{reconciliationService: reconciliationService}
+}
+
+// RunReconciliation godoc
+// @Summary      Run reconciliation
+// @Description  Recomputes every balance from the transaction history now and compares it with the stored balance and the per currency totals. The worker runs this daily. (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      201  {object}  models.ReconciliationRun
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/reconciliation/runs [post]
+func (h *ReconciliationHandler) RunReconciliation(c *gin.Context) {
+	run, err := h.reconciliationService.Run(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrReconciliationRunning) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to run reconciliation"})
+		return
+	}
+	c.JSON(http.StatusCreated, run)
+}
+
+// ListReconciliationRuns godoc
+// @Summary      List reconciliation runs
+// @Description  Returns reconciliation runs with their per currency totals, newest first (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page query int false "Page number (default: 1)"
+// @Param        page_size query int false "Items per page (default: 20)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/reconciliation/runs [get]
+func (h *ReconciliationHandler) ListReconciliationRuns(c *gin.Context) {
+	page := 1
+	pageSize := 20
+	if p := c.Query("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		fmt.Sscanf(ps, "%d", &pageSize)
+	}
+
+	runs, total, err := h.reconciliationService.List(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list reconciliation runs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": runs, "total": total})
+}
+
+// GetReconciliationRun godoc
+// @Summary      Get reconciliation run
+// @Description  Returns a reconciliation run with its discrepancies, largest first. A discrepancy without user_id is a currency whose stored total differs. (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Run ID"
+// @Success      200  {object}  models.ReconciliationRun
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/reconciliation/runs/{id} [get]
+func (h *ReconciliationHandler) GetReconciliationRun(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid run ID"})
+		return
+	}
+
+	run, err := h.reconciliationService.Get(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "reconciliation run not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get reconciliation run"})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
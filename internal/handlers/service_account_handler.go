@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/middleware"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// ServiceAccountHandler handles the service accounts and API keys of users
+// and the OAuth2 token endpoint of machine clients
+type ServiceAccountHandler struct {
+	serviceAccountService *service.ServiceAccountService
+	authMiddleware        *middleware.AuthMiddleware
+}
+
+// NewServiceAccountHandler creates a new ServiceAccountHandler instance
+func NewServiceAccountHandler(serviceAccountService *service.ServiceAccountService, authMiddleware *middleware.AuthMiddleware) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		serviceAccountService: serviceAccountService,
+		authMiddleware:        authMiddleware,
+	}
+}
+
+type serviceAccountRequest struct {
+	Name string `json:"name" binding:"required,max=100" example:"Accounting sync"`
+}
+
+type apiKeyRequest struct {
+	Scopes        []string `json:"scopes" binding:"required,min=1" example:"accounts:read,transactions:read"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365" example:"90"` // 90 when omitted
+}
+
+type apiKeyResponse struct {
+	Key    string         `json:"key" example:"tk_3f9a0c7d21b4_Qm9n..."` // shown only once
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+type tokenRequest struct {
+	GrantType    string `form:"grant_type" json:"grant_type" binding:"required" example:"client_credentials"`
+	ClientID     string `form:"client_id" json:"client_id" example:"5f0c2a8e-7d1b-4a8e-9f3c-2b6d1e0a4c7f"`
+	ClientSecret string `form:"client_secret" json:"client_secret" example:"tk_3f9a0c7d21b4_Qm9n..."`
+	Scope        string `form:"scope" json:"scope" example:"transactions:read"` // space separated, all of the key's when omitted
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType   string `json:"token_type" example:"Bearer"`
+	ExpiresIn   int    `json:"expires_in" example:"3600"`
+	Scope       string `json:"scope" example:"transactions:read"`
+}
+
+// CreateServiceAccount godoc
+// @Summary      Create service account
+// @Description  Creates a service account, a machine client such as a backend integration that acts as the current user with the scopes of its API keys. Its ID is the OAuth2 client ID.
+// @Tags         service-accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body serviceAccountRequest true "Service account"
+// @Success      201  {object}  models.ServiceAccount
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /users/service-accounts [post]
+func (h *ServiceAccountHandler) CreateServiceAccount(c *gin.Context) {
+	var req serviceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	account, err := h.serviceAccountService.Create(userID, req.Name)
+	if err != nil {
+		c.JSON(serviceAccountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, account)
+}
+
+// ListServiceAccounts godoc
+// @Summary      List service accounts
+// @Description  Returns the current user's service accounts by name
+// @Tags         service-accounts
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.ServiceAccount
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/service-accounts [get]
+func (h *ServiceAccountHandler) ListServiceAccounts(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	accounts, err := h.serviceAccountService.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list service accounts"})
+		return
+	}
+	c.JSON(http.StatusOK, accounts)
+}
+
+// DeleteServiceAccount godoc
+// @Summary      Delete service account
+// @Description  Deletes a service account of the current user; its API keys and access tokens stop working
+// @Tags         service-accounts
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Service account ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/service-accounts/{id} [delete]
+func (h *ServiceAccountHandler) DeleteServiceAccount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service account ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.serviceAccountService.Delete(id, userID); err != nil {
+		c.JSON(serviceAccountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "service account deleted"})
+}
+
+// CreateAPIKey godoc
+// @Summary      Create API key
+// @Description  Creates an API key of a service account with the given scopes, valid for 1 to 365 days. The key is returned only now; only a hash of it is kept. Admin scopes are for admins only.
+// @Tags         service-accounts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Service account ID"
+// @Param        request body apiKeyRequest true "Scopes and lifetime"
+// @Success      201  {object}  apiKeyResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/service-accounts/{id}/keys [post]
+func (h *ServiceAccountHandler) CreateAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service account ID"})
+		return
+	}
+	var req apiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	key, raw, err := h.serviceAccountService.CreateKey(id, userID, req.Scopes, time.Duration(req.ExpiresInDays)*24*time.Hour)
+	if err != nil {
+		c.JSON(serviceAccountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, apiKeyResponse{Key: raw, APIKey: key})
+}
+
+// ListAPIKeys godoc
+// @Summary      List API keys
+// @Description  Returns the API keys of a service account, newest first, with revoked and expired ones. The keys themselves cannot be retrieved.
+// @Tags         service-accounts
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Service account ID"
+// @Success      200  {array}   models.APIKey
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/service-accounts/{id}/keys [get]
+func (h *ServiceAccountHandler) ListAPIKeys(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service account ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	keys, err := h.serviceAccountService.ListKeys(id, userID)
+	if err != nil {
+		c.JSON(serviceAccountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// RotateAPIKey godoc
+// @Summary      Rotate API key
+// @Description  Replaces an API key with a new one of the same scopes and lifetime. The old key keeps working for 24 hours, or until it expires if sooner, so clients can switch over.
+// @Tags         service-accounts
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true  "Service account ID"
+// @Param        key_id   path      string  true  "API key ID"
+// @Success      201  {object}  apiKeyResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/service-accounts/{id}/keys/{key_id}/rotate [post]
+func (h *ServiceAccountHandler) RotateAPIKey(c *gin.Context) {
+	id, keyID, ok := keyParams(c)
+	if !ok {
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	key, raw, err := h.serviceAccountService.RotateKey(id, keyID, userID)
+	if err != nil {
+		c.JSON(serviceAccountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, apiKeyResponse{Key: raw, APIKey: key})
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke API key
+// @Description  Revokes an API key of a service account at once, with the access tokens issued for it
+// @Tags         service-accounts
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true  "Service account ID"
+// @Param        key_id   path      string  true  "API key ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/service-accounts/{id}/keys/{key_id} [delete]
+func (h *ServiceAccountHandler) RevokeAPIKey(c *gin.Context) {
+	id, keyID, ok := keyParams(c)
+	if !ok {
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.serviceAccountService.RevokeKey(id, keyID, userID); err != nil {
+		c.JSON(serviceAccountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+// Token godoc
+// @Summary      Get access token
+// @Description  OAuth2 client credentials grant (RFC 6749, section 4.4). The client ID is the service account's ID and the client secret one of its API keys, sent as form fields or with HTTP Basic authentication. The access token is valid for an hour, never longer than the key, and has the requested scopes, which must be among the key's, or all of them.
+// @Tags         auth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type     formData  string  true   "client_credentials"
+// @Param        client_id      formData  string  false  "Service account ID"
+// @Param        client_secret  formData  string  false  "API key"
+// @Param        scope          formData  string  false  "Space separated scopes"
+// @Success      200  {object}  tokenResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /oauth/token [post]
+func (h *ServiceAccountHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	var req tokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+	if req.GrantType != "client_credentials" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported_grant_type"})
+		return
+	}
+	if clientID, secret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, req.ClientSecret = clientID, secret
+	}
+
+	key, scopes, err := h.serviceAccountService.AuthenticateClient(req.ClientID, req.ClientSecret, strings.Fields(req.Scope))
+	if errors.Is(err, models.ErrInvalidScope) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client", "error_description": err.Error()})
+		return
+	}
+
+	token, ttl, err := h.authMiddleware.GenerateClientToken(key, scopes, models.ClientTokenTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, tokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	})
+}
+
+// keyParams parses the service account and API key IDs of the route,
+// responding with 400 when either is invalid
+func keyParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service account ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	keyID, err := uuid.Parse(c.Param("key_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, keyID, true
+}
+
+// serviceAccountErrorStatus maps service account service errors to HTTP
+// status codes
+func serviceAccountErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrScopeNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrAPIKeyRevoked), errors.Is(err, models.ErrAPIKeyExpired):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
+)
+
+// StandingOrderHandler handles scheduled and recurring transfer requests
+type StandingOrderHandler struct {
+	standingOrderService *service.StandingOrderService
+}
+
+// NewStandingOrderHandler creates a new StandingOrderHandler instance
+func NewStandingOrderHandler(standingOrderService *service.StandingOrderService) *StandingOrderHandler {
+	return &StandingOrderHandler{standingOrderService: standingOrderService}
+}
+
+type standingOrderRequest struct {
+	RecipientID         string  `json:"recipient_id" binding:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Amount              float64 `json:"amount" binding:"required,gt=0" example:"850.00"`
+	Currency            string  `json:"currency" binding:"required,len=3" example:"EUR"`
+	Description         string  `json:"description" example:"Rent"`
+	Frequency           string  `json:"frequency" binding:"required,oneof=once daily weekly monthly" example:"monthly"`
+	StartDate           string  `json:"start_date" binding:"required" example:"2024-07-01"`
+	EndDate             string  `json:"end_date" example:"2025-06-30"`
+	MaxOccurrences      int     `json:"max_occurrences" binding:"gte=0" example:"12"`
+	OnInsufficientFunds string  `json:"on_insufficient_funds" binding:"omitempty,oneof=retry skip" example:"retry"`
+	MaxRetries          *int    `json:"max_retries" binding:"omitempty,gte=0" example:"3"`
+}
+
+type standingOrderUpdateRequest struct {
+	Amount              *float64 `json:"amount" binding:"omitempty,gt=0" example:"900.00"`
+	Description         *string  `json:"description" example:"Rent incl. parking"`
+	EndDate             *string  `json:"end_date" example:"2025-12-31"`
+	MaxOccurrences      *int     `json:"max_occurrences" binding:"omitempty,gte=0" example:"18"`
+	OnInsufficientFunds *string  `json:"on_insufficient_funds" binding:"omitempty,oneof=retry skip" example:"skip"`
+	MaxRetries          *int     `json:"max_retries" binding:"omitempty,gte=0" example:"2"`
+	Paused              *bool    `json:"paused" example:"false"`
+}
+
+// CreateStandingOrder godoc
+// @Summary      Create standing order
+// @Description  Schedules a one-off future or recurring (daily, weekly, monthly) transfer. Dates use YYYY-MM-DD; the schedule ends at end_date or after max_occurrences, whichever comes first.
+// @Tags         standing-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body standingOrderRequest true "Standing order details"
+// @Success      201  {object}  models.StandingOrder
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /users/standing-orders [post]
+func (h *StandingOrderHandler) CreateStandingOrder(c *gin.Context) {
+	var req standingOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date format, use YYYY-MM-DD"})
+		return
+	}
+	var endDate *time.Time
+	if req.EndDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date format, use YYYY-MM-DD"})
+			return
+		}
+		endDate = &parsed
+	}
+
+	order := &models.StandingOrder{
+		UserID:              userID,
+		RecipientID:         uuid.MustParse(req.RecipientID),
+		Amount:              req.Amount,
+		Currency:            req.Currency,
+		Description:         req.Description,
+		Frequency:           models.StandingOrderFrequency(req.Frequency),
+		StartDate:           startDate,
+		EndDate:             endDate,
+		MaxOccurrences:      req.MaxOccurrences,
+		OnInsufficientFunds: models.InsufficientFundsPolicy(req.OnInsufficientFunds),
+		MaxRetries:          3,
+	}
+	if req.MaxRetries != nil {
+		order.MaxRetries = *req.MaxRetries
+	}
+
+	if err := h.standingOrderService.Create(order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, order)
+}
+
+// ListStandingOrders godoc
+// @Summary      List standing orders
+// @Description  Returns the authenticated user's standing orders
+// @Tags         standing-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   models.StandingOrder
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /users/standing-orders [get]
+func (h *StandingOrderHandler) ListStandingOrders(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	orders, err := h.standingOrderService.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list standing orders"})
+		return
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetStandingOrder godoc
+// @Summary      Get standing order
+// @Description  Returns one of the authenticated user's standing orders with its execution history
+// @Tags         standing-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Standing order ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/standing-orders/{id} [get]
+func (h *StandingOrderHandler) GetStandingOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid standing order ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	order, err := h.standingOrderService.Get(id, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "standing order not found"})
+		return
+	}
+	executions, err := h.standingOrderService.ListExecutions(id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list executions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"standing_order": order, "executions": executions})
+}
+
+// UpdateStandingOrder godoc
+// @Summary      Update standing order
+// @Description  Changes amount, schedule end, retry policy or pauses/resumes an active standing order
+// @Tags         standing-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Standing order ID"
+// @Param        request body standingOrderUpdateRequest true "Fields to change"
+// @Success      200  {object}  models.StandingOrder
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/standing-orders/{id} [put]
+func (h *StandingOrderHandler) UpdateStandingOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid standing order ID"})
+		return
+	}
+	var req standingOrderUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	update := service.StandingOrderUpdate{
+		Amount:         req.Amount,
+		Description:    req.Description,
+		MaxOccurrences: req.MaxOccurrences,
+		MaxRetries:     req.MaxRetries,
+		Paused:         req.Paused,
+	}
+	if req.EndDate != nil {
+		endDate, err := time.Parse("2006-01-02", *req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date format, use YYYY-MM-DD"})
+			return
+		}
+		update.EndDate = &endDate
+	}
+	if req.OnInsufficientFunds != nil {
+		policy := models.InsufficientFundsPolicy(*req.OnInsufficientFunds)
+		update.OnInsufficientFunds = &policy
+	}
+
+	order, err := h.standingOrderService.Update(id, userID, update)
+	if err != nil {
+		c.JSON(standingOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelStandingOrder godoc
+// @Summary      Cancel standing order
+// @Description  Stops all future occurrences of a standing order
+// @Tags         standing-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Standing order ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/standing-orders/{id} [delete]
+func (h *StandingOrderHandler) CancelStandingOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid standing order ID"})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.standingOrderService.Cancel(id, userID); err != nil {
+		c.JSON(standingOrderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "standing order cancelled"})
+}
+
+func standingOrderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrOrderNotEditable):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
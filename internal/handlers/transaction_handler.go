@@ -1,23 +1,32 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/takadao/banking/internal/auth"
+	"github.com/takadao/banking/internal/models"
 	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
 )
 
 // TransactionHandler handles transaction-related requests
 type TransactionHandler struct {
 	transactionService *service.TransactionService
+	approvalService    *service.ApprovalService
+	beneficiaryService *service.BeneficiaryService
+	recipientService   *service.RecipientService
 }
 
 // NewTransactionHandler creates a new TransactionHandler instance
-func NewTransactionHandler(transactionService *service.TransactionService) *TransactionHandler {
+func NewTransactionHandler(transactionService *service.TransactionService, approvalService *service.ApprovalService, beneficiaryService *service.BeneficiaryService, recipientService *service.RecipientService) *TransactionHandler {
 	return &TransactionHandler{
 		transactionService: transactionService,
+		approvalService:    approvalService,
+		beneficiaryService: beneficiaryService,
+		recipientService:   recipientService,
 	}
 }
 
@@ -25,13 +34,30 @@ type depositWithdrawRequest struct {
 	Amount      float64 `json:"amount" binding:"required,gt=0" example:"100.50"`
 	Currency    string  `json:"currency" binding:"required,len=3" example:"EUR"`
 	Description string  `json:"description" example:"Initial deposit"`
+	AccountID   string  `json:"account_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174002"` // defaults to the main account
+}
+
+type withdrawRequest struct {
+	depositWithdrawRequest
+	Payout *models.PayoutDestination `json:"payout"` // external account to pay the withdrawal out to
 }
 
 type transferRequest struct {
-	RecipientID string  `json:"recipient_id" binding:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Amount      float64 `json:"amount" binding:"required,gt=0" example:"50.25"`
-	Currency    string  `json:"currency" binding:"required,len=3" example:"EUR"`
-	Description string  `json:"description" example:"Payment for services"`
+	RecipientID          string  `json:"recipient_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	RecipientEmail       string  `json:"recipient_email" binding:"omitempty,email" example:"friend@example.com"`                 // instead of recipient_id
+	RecipientHandle      string  `json:"recipient_handle" binding:"omitempty,max=31" example:"jane_doe"`                         // instead of recipient_id
+	BeneficiaryID        string  `json:"beneficiary_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174004"` // instead of recipient_id
+	Amount               float64 `json:"amount" binding:"required,gt=0" example:"50.25"`
+	Currency             string  `json:"currency" binding:"required,len=3" example:"EUR"`
+	Description          string  `json:"description" example:"Payment for services"`
+	SourceAccountID      string  `json:"source_account_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174002"`      // defaults to the sender's main account
+	DestinationAccountID string  `json:"destination_account_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174003"` // defaults to the recipient's main account
+}
+
+type quoteRequest struct {
+	Type     string  `json:"type" binding:"required,oneof=deposit withdraw transfer" example:"transfer"`
+	Amount   float64 `json:"amount" binding:"required,gt=0" example:"250.00"`
+	Currency string  `json:"currency" binding:"required,len=3" example:"EUR"`
 }
 
 // ListMyTransactions godoc
@@ -152,7 +178,7 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 
 // Deposit godoc
 // @Summary      Make a deposit
-// @Description  Deposits money into the user's account
+// @Description  Deposits money into the user's main account, or into account_id when given, which may be a shared account the user is a co-owner or spender of
 // @Tags         transactions
 // @Accept       json
 // @Produce      json
@@ -161,6 +187,7 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 // @Success      200  {object}  map[string]string
 // @Failure      400  {object}  map[string]string
 // @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
 // @Router       /transactions/deposit [post]
 func (h *TransactionHandler) Deposit(c *gin.Context) {
 	var req depositWithdrawRequest
@@ -173,8 +200,15 @@ func (h *TransactionHandler) Deposit(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
-	if err := h.transactionService.Deposit(userID, req.Amount, req.Currency, req.Description); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	transaction := &models.Transaction{
+		Type:                 models.TransactionTypeDeposit,
+		Amount:               req.Amount,
+		Currency:             req.Currency,
+		Description:          req.Description,
+		DestinationAccountID: optionalUUID(req.AccountID),
+	}
+	if err := h.transactionService.Initiate(userID, transaction); err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deposit successful"})
@@ -182,18 +216,20 @@ func (h *TransactionHandler) Deposit(c *gin.Context) {
 
 // Withdraw godoc
 // @Summary      Make a withdrawal
-// @Description  Withdraws money from the user's account
+// @Description  Withdraws money from the user's main account, or from account_id when given, which may be a shared account the user may spend from. With a payout destination (IBAN, optional BIC and account holder name) the money is sent to that external account in the next pain.001 batch; the withdrawal is refunded when the bank rejects the payout. When an approval policy of the account covers the amount, the withdrawal is held back for approval and returned as a pending payment.
 // @Tags         transactions
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        request body depositWithdrawRequest true "Withdrawal details"
+// @Param        request body withdrawRequest true "Withdrawal details"
 // @Success      200  {object}  map[string]string
+// @Success      202  {object}  models.PendingPayment
 // @Failure      400  {object}  map[string]string
 // @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
 // @Router       /transactions/withdraw [post]
 func (h *TransactionHandler) Withdraw(c *gin.Context) {
-	var req depositWithdrawRequest
+	var req withdrawRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -203,8 +239,24 @@ func (h *TransactionHandler) Withdraw(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
-	if err := h.transactionService.Withdraw(userID, req.Amount, req.Currency, req.Description); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	transaction := &models.Transaction{
+		Type:            models.TransactionTypeWithdraw,
+		Amount:          req.Amount,
+		Currency:        req.Currency,
+		Description:     req.Description,
+		SourceAccountID: optionalUUID(req.AccountID),
+	}
+	if req.Payout != nil {
+		req.Payout.Normalize()
+		transaction.Payout = models.NewPayout(*req.Payout)
+	}
+	payment, err := h.approvalService.Submit(userID, transaction)
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if payment != nil {
+		c.JSON(http.StatusAccepted, payment)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "withdrawal successful"})
@@ -212,15 +264,19 @@ func (h *TransactionHandler) Withdraw(c *gin.Context) {
 
 // Transfer godoc
 // @Summary      Transfer money
-// @Description  Transfers money to another user
+// @Description  Transfers money to another user, given by exactly one of recipient_id, recipient_email or recipient_handle, from source_account_id when given, which may be a shared account the user may spend from. Unknown and deleted recipients give 404, recipients who cannot receive transfers 422. Instead of a recipient a saved beneficiary_id can be given: a customer of this bank is paid by transfer, an external account by a withdrawal paid out to its IBAN. When an approval policy of the account covers the amount, the transfer is held back for approval and returned as a pending payment.
 // @Tags         transactions
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        request body transferRequest true "Transfer details"
 // @Success      200  {object}  map[string]string
+// @Success      202  {object}  models.PendingPayment
 // @Failure      400  {object}  map[string]string
 // @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      422  {object}  map[string]string
 // @Router       /transactions/transfer [post]
 func (h *TransactionHandler) Transfer(c *gin.Context) {
 	var req transferRequest
@@ -233,14 +289,151 @@ func (h *TransactionHandler) Transfer(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
-	recipientID, err := uuid.Parse(req.RecipientID)
+	var transaction *models.Transaction
+	if req.BeneficiaryID != "" {
+		if req.RecipientID != "" || req.RecipientEmail != "" || req.RecipientHandle != "" || req.DestinationAccountID != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "beneficiary_id cannot be combined with a recipient or destination_account_id"})
+			return
+		}
+		transaction, err = h.beneficiaryService.Transaction(uuid.MustParse(req.BeneficiaryID), userID, req.Amount, req.Currency, req.Description)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "beneficiary not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get beneficiary"})
+			return
+		}
+	} else {
+		recipientID, err := h.recipientService.Resolve(service.RecipientQuery{
+			ID:     optionalUUID(req.RecipientID),
+			Email:  req.RecipientEmail,
+			Handle: req.RecipientHandle,
+		})
+		if err != nil {
+			c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		transaction = &models.Transaction{
+			Type:                 models.TransactionTypeTransfer,
+			Amount:               req.Amount,
+			Currency:             req.Currency,
+			RecipientID:          &recipientID,
+			Description:          req.Description,
+			DestinationAccountID: optionalUUID(req.DestinationAccountID),
+		}
+	}
+	transaction.SourceAccountID = optionalUUID(req.SourceAccountID)
+	payment, err := h.approvalService.Submit(userID, transaction)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recipient_id"})
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	if err := h.transactionService.Transfer(userID, recipientID, req.Amount, req.Currency, req.Description); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if payment != nil {
+		c.JSON(http.StatusAccepted, payment)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "transfer successful"})
 }
+
+// PreviewRecipient godoc
+// @Summary      Confirm payee
+// @Description  Looks up a transfer recipient by exactly one of recipient_id, email or handle and returns a masked display name to confirm before paying: the legal name of KYC verified customers, the email otherwise.
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        recipient_id query string false "Recipient user ID"
+// @Param        email query string false "Recipient email"
+// @Param        handle query string false "Recipient handle"
+// @Success      200  {object}  models.PayeePreview
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      422  {object}  map[string]string
+// @Router       /transactions/recipients/preview [get]
+func (h *TransactionHandler) PreviewRecipient(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	query := service.RecipientQuery{Email: c.Query("email"), Handle: c.Query("handle")}
+	if id := c.Query("recipient_id"); id != "" {
+		recipientID, err := uuid.Parse(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recipient_id"})
+			return
+		}
+		query.ID = &recipientID
+	}
+
+	preview, err := h.recipientService.Preview(userID, query)
+	if err != nil {
+		c.JSON(transactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+// QuoteTransaction godoc
+// @Summary      Quote transaction fee
+// @Description  Returns the fee the authenticated user would be charged for a transaction under their pricing plan, and how many free transfers are left this month
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body quoteRequest true "Transaction to quote"
+// @Success      200  {object}  models.FeeQuote
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /transactions/quote [post]
+func (h *TransactionHandler) QuoteTransaction(c *gin.Context) {
+	var req quoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	quote, err := h.transactionService.Quote(userID, models.TransactionType(req.Type), req.Amount, req.Currency)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to quote transaction"})
+		return
+	}
+	c.JSON(http.StatusOK, quote)
+}
+
+// transactionErrorStatus maps transaction service errors to HTTP status codes
+func transactionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrKYCNotAllowed), errors.Is(err, models.ErrKYCLimitExceeded):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrAccountAccessDenied), errors.Is(err, models.ErrSpendLimitExceeded):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrUserFrozen), errors.Is(err, models.ErrUserDormant), errors.Is(err, models.ErrUserClosed):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrEmailNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrAccountNotFound), errors.Is(err, models.ErrRecipientNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrRecipientBlocked):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// optionalUUID parses an optional, already validated UUID field
+func optionalUUID(value string) *uuid.UUID {
+	if value == "" {
+		return nil
+	}
+	id := uuid.MustParse(value)
+	return &id
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -9,25 +10,70 @@ import (
 	"github.com/takadao/banking/internal/models"
 	"github.com/takadao/banking/internal/repository"
 	"github.com/takadao/banking/internal/service"
+	"gorm.io/gorm"
 )
 
 // UserHandler handles user-related requests
 type UserHandler struct {
-	userService     *service.UserService
-	transactionRepo *repository.TransactionRepository
+	userService          *service.UserService
+	lifecycleService     *service.UserLifecycleService
+	transactionRepo      *repository.TransactionRepository
+	changeRequestService *service.ChangeRequestService
+	sessionService       *service.SessionService
 }
 
 // NewUserHandler creates a new UserHandler instance
-func NewUserHandler(userService *service.UserService, transactionRepo *repository.TransactionRepository) *UserHandler {
+func NewUserHandler(userService *service.UserService, lifecycleService *service.UserLifecycleService, transactionRepo *repository.TransactionRepository, changeRequestService *service.ChangeRequestService, sessionService *service.SessionService) *UserHandler {
 	return &UserHandler{
-		userService:     userService,
-		transactionRepo: transactionRepo,
+		userService:          userService,
+		lifecycleService:     lifecycleService,
+		transactionRepo:      transactionRepo,
+		changeRequestService: changeRequestService,
+		sessionService:       sessionService,
 	}
 }
 
+type handleRequest struct {
+	Handle string `json:"handle" binding:"max=31" example:"jane_doe"` // empty to remove
+}
+
+type closeRequest struct {
+	Reason string                    `json:"reason" binding:"required,max=500" example:"Moving to another bank"`
+	Payout *models.PayoutDestination `json:"payout"` // external account the remaining balances are paid out to
+}
+
+type freezeRequest struct {
+	Scope  models.FreezeScope `json:"scope" binding:"required,oneof=outgoing all" example:"outgoing"`
+	Reason string             `json:"reason" binding:"required,max=500" example:"Suspected account takeover"`
+}
+
+type unfreezeRequest struct {
+	Reason string `json:"reason" binding:"required,max=500" example:"Customer verified by phone"`
+}
+
+type userUpdateRequest struct {
+	Email           string `json:"email" binding:"omitempty,email" example:"jane@example.com"`
+	Password        string `json:"password" example:"n3w-Passw0rd!"`
+	CurrentPassword string `json:"current_password" example:"password123"` // required to change the password
+}
+
+type adminUserUpdateRequest struct {
+	Email    string `json:"email" binding:"omitempty,email" example:"jane@example.com"`
+	Role     string `json:"role" binding:"omitempty,oneof=user admin" example:"admin"`
+	Password string `json:"password" example:"n3w-Passw0rd!"`
+	Reason   string `json:"reason" example:"Joined the operations team"` // required for email, role and password changes
+}
+
+type changeRequestsResponse struct {
+	User           *models.User            `json:"user"`
+	ChangeRequests []*models.ChangeRequest `json:"change_requests"`
+}
+
 type balanceResponse struct {
-	Currency string  `json:"currency" example:"EUR"`
-	Amount   float64 `json:"amount" example:"1000.50"`
+	Currency  string  `json:"currency" example:"EUR"`
+	Amount    float64 `json:"amount" example:"1000.50"`   // ledger balance
+	Held      float64 `json:"held" example:"80.00"`       // reserved by active holds
+	Available float64 `json:"available" example:"920.50"` // amount minus held
 }
 
 type balancesResponse struct {
@@ -36,7 +82,7 @@ type balancesResponse struct {
 
 // GetBalances godoc
 // @Summary      Get user balances
-// @Description  Retrieves all balances for the authenticated user
+// @Description  Retrieves the authenticated user's balance per currency, summed over all their accounts, with ledger, held and available amounts
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -52,9 +98,9 @@ func (h *UserHandler) GetBalances(c *gin.Context) {
 		return
 	}
 
-	// For simplicity, get all balances for the user
+	// Totals per currency; /users/accounts has the balance of each account
 	var balances []balanceResponse
-	if err := h.transactionRepo.GetDB().Raw("SELECT currency, amount FROM balances WHERE user_id = ?", userID).Scan(&balances).Error; err != nil {
+	if err := h.transactionRepo.GetDB().Raw("SELECT currency, SUM(amount) AS amount, SUM(held) AS held, SUM(amount - held) AS available FROM balances WHERE user_id = ? AND deleted_at IS NULL GROUP BY currency ORDER BY currency", userID).Scan(&balances).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get balances"})
 		return
 	}
@@ -84,38 +130,214 @@ func (h *UserHandler) GetMe(c *gin.Context) {
 
 // UpdateMe godoc
 // @Summary      Update current user profile
-// @Description  Updates the profile of the currently authenticated user
+// @Description  Updates the email or password of the currently authenticated user. A new password needs the current one and must satisfy the password policy: long enough, of enough character classes, not containing the email, not in a known data breach and not one of the user's recent passwords. Changing the password logs the user out of all other sessions.
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        request body models.User true "User update details"
+// @Param        request body userUpdateRequest true "User update details"
 // @Success      200  {object}  models.User
 // @Failure      400  {object}  map[string]string
 // @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
 // @Router       /users/me [put]
 func (h *UserHandler) UpdateMe(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req userUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Ensure user can only update their own profile
-	user.ID = uuid.MustParse(userID.(string))
-	// Prevent role modification
-	user.Role = "" // This will be ignored in the update
+	if req.Password != "" {
+		existing, err := h.userService.GetByID(userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if existing.CheckPassword(req.CurrentPassword) != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": models.ErrWrongPassword.Error()})
+			return
+		}
+	}
 
+	// Ensure user can only update their own profile; the role is kept
+	user := models.User{ID: userID, Email: req.Email, Password: req.Password}
 	updatedUser, err := h.userService.Update(&user)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Whoever knew the old password is logged out, but not this device
+	if req.Password != "" {
+		sessionID, _ := auth.GetSessionID(c)
+		if _, err := h.sessionService.RevokeOthers(userID, sessionID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to end other sessions"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, updatedUser)
 }
 
+// SetMyHandle godoc
+// @Summary      Set handle
+// @Description  Sets the unique handle others can pay the current user by: 3 to 30 letters, digits or underscores, stored in lower case. An empty handle removes it.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body handleRequest true "Handle"
+// @Success      200  {object}  models.User
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/me/handle [put]
+func (h *UserHandler) SetMyHandle(c *gin.Context) {
+	var req handleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	user, err := h.userService.SetHandle(userID, req.Handle)
+	if errors.Is(err, models.ErrHandleTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// CloseMe godoc
+// @Summary      Close account
+// @Description  Closes the current user's account for good. All balances must be zero and nothing held, unless a payout account is given: what remains on each account is then paid out to it without a fee. Standing orders are cancelled, the handle is released and the user can no longer log in, pay or be paid. Frozen accounts cannot be closed.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body closeRequest true "Closure"
+// @Success      200  {object}  models.User
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /users/me/close [post]
+func (h *UserHandler) CloseMe(c *gin.Context) {
+	var req closeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	user, err := h.lifecycleService.Close(userID, req.Reason, req.Payout)
+	if err != nil {
+		c.JSON(lifecycleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// FreezeUser godoc
+// @Summary      Freeze user
+// @Description  Freezes a user (admin only). Scope outgoing blocks withdrawals, transfers and other debits but lets the user be paid; scope all blocks every transaction, incoming transfers and deposits too. The user can still log in and see their accounts. Freezing a frozen user changes the scope.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Param        request body freezeRequest true "Freeze"
+// @Success      200  {object}  models.User
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/users/{id}/freeze [post]
+func (h *UserHandler) FreezeUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var req freezeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	user, err := h.lifecycleService.Freeze(userID, adminID, req.Scope, req.Reason)
+	if err != nil {
+		c.JSON(lifecycleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UnfreezeUser godoc
+// @Summary      Unfreeze user
+// @Description  Lifts a freeze, making the user active again (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Param        request body unfreezeRequest true "Unfreeze"
+// @Success      200  {object}  models.User
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /admin/users/{id}/unfreeze [post]
+func (h *UserHandler) UnfreezeUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var req unfreezeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	user, err := h.lifecycleService.Unfreeze(userID, adminID, req.Reason)
+	if err != nil {
+		c.JSON(lifecycleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
 // ListUsers godoc
 // @Summary      List all users
 // @Description  Returns a list of all users (admin only)
@@ -184,14 +406,15 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 
 // UpdateUser godoc
 // @Summary      Update user
-// @Description  Updates a specific user by ID (admin only)
+// @Description  Updates a specific user by ID (admin only). Email, role and password changes need a reason and are only requested: they are applied once a different admin approves them, and the response is 202 with the change requests.
 // @Tags         admin
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        id   path      string  true  "User ID"
-// @Param        request body models.User true "User update details"
+// @Param        request body adminUserUpdateRequest true "User update details"
 // @Success      200  {object}  models.User
+// @Success      202  {object}  changeRequestsResponse
 // @Failure      400  {object}  map[string]string
 // @Failure      401  {object}  map[string]string
 // @Failure      403  {object}  map[string]string
@@ -213,35 +436,52 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var req adminUserUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
 
-	user.ID = userID
-	updatedUser, err := h.userService.Update(&user)
+	user, err := h.userService.GetByID(userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedUser)
+	update := service.AdminUserUpdate{Email: req.Email, Role: req.Role, Password: req.Password}
+	requests, err := h.changeRequestService.RequestUserUpdate(adminID, userID, update, req.Reason)
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(requests) > 0 {
+		c.JSON(http.StatusAccepted, changeRequestsResponse{User: user, ChangeRequests: requests})
+		return
+	}
+	c.JSON(http.StatusOK, user)
 }
 
 // DeleteUser godoc
 // @Summary      Delete user
-// @Description  Deletes a specific user by ID (admin only)
+// @Description  Requests a specific user be closed and deleted (admin only). Their balances must be empty. The user is closed and deleted once a different admin approves the change request.
 // @Tags         admin
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        id   path      string  true  "User ID"
-// @Success      200  {object}  map[string]string
+// @Param        reason   query     string  true  "Why the user is deleted"
+// @Success      202  {object}  models.ChangeRequest
 // @Failure      400  {object}  map[string]string
 // @Failure      401  {object}  map[string]string
 // @Failure      403  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
 // @Router       /admin/users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	// Role check is handled by middleware, but we'll double-check here
@@ -258,11 +498,31 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
 		return
 	}
+	adminID, err := auth.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
 
-	if err := h.userService.Delete(userID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	request, err := h.changeRequestService.RequestUserDeletion(adminID, userID, c.Query("reason"))
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "user deleted successfully"})
+	c.JSON(http.StatusAccepted, request)
+}
+
+// lifecycleErrorStatus maps user lifecycle errors to HTTP status codes
+func lifecycleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrUserFrozen), errors.Is(err, models.ErrUserDormant):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrUserClosed), errors.Is(err, models.ErrUserNotFrozen), errors.Is(err, models.ErrUserNotEmpty):
+		return http.StatusConflict
+	default:
+		return transactionErrorStatus(err)
+	}
 }
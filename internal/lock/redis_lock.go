@@ -0,0 +1,76 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// releaseScript deletes the key only if it still holds our token, so a lock
+// that expired and was taken over by another holder is left alone
+var releaseScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+// extendScript resets the expiry of the key only if it still holds our token
+var extendScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// ErrLost is returned when extending a lock that expired and may be held by
+// someone else
+var ErrLost = errors.New("lock was lost")
+
+// Lock is a held distributed lock
+type Lock struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+// Acquire tries to take the lock on key for ttl. It returns nil without an
+// error when another holder has it.
+func Acquire(ctx context.Context, client *redis.Client, key string, ttl time.Duration) (*Lock, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+
+	ok, err := client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &Lock{client: client, key: key, token: token}, nil
+}
+
+// Release gives the lock up if it is still held
+func (l *Lock) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err()
+}
+
+// Extend keeps the lock for another ttl, so long runs do not outlive it. It
+// returns ErrLost when the lock already expired.
+func (l *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	extended, err := extendScript.Run(ctx, l.client, []string{l.key}, l.token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if extended == 0 {
+		return ErrLost
+	}
+	return nil
+}
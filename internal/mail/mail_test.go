@@ -0,0 +1,59 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageFormat(t *testing.T) {
+	date := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	message := Message{To: "jane@example.com", Subject: "Reset your password", Body: "Hello\nUse the link."}
+
+	data, err := message.Format("Bank <no-reply@example.com>", date)
+	require.NoError(t, err)
+	email := string(data)
+	assert.True(t, strings.HasPrefix(email, "From: Bank <no-reply@example.com>\r\nTo: jane@example.com\r\nSubject: Reset your password\r\n"))
+	assert.Contains(t, email, "Date: Sun, 01 Mar 2026 12:00:00 +0000\r\n")
+	assert.True(t, strings.HasSuffix(email, "\r\n\r\nHello\r\nUse the link."))
+
+	tests := []struct {
+		name    string
+		message Message
+	}{
+		{"recipient with line break", Message{To: "jane@example.com\r\nBcc: eve@example.com", Subject: "Hi"}},
+		{"subject with line break", Message{To: "jane@example.com", Subject: "Hi\nBcc: eve@example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.message.Format("no-reply@example.com", date)
+			assert.Equal(t, ErrInvalidHeader, err)
+		})
+	}
+}
+
+func TestEnvelopeAddress(t *testing.T) {
+	assert.Equal(t, "no-reply@example.com", envelopeAddress("Bank <no-reply@example.com>"))
+	assert.Equal(t, "no-reply@example.com", envelopeAddress("no-reply@example.com"))
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := NewFileMailer(dir, "no-reply@example.com")
+
+	require.NoError(t, mailer.Send(context.Background(), Message{To: "jane/../x@example.com", Subject: "Hi", Body: "Hello"}))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.True(t, strings.HasSuffix(files[0].Name(), "-jane_.._x@example.com.eml"))
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: jane/../x@example.com\r\n")
+}
@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer sends messages through an SMTP server, with STARTTLS when the
+// server offers it
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a mailer sending from the given address. Without a
+// username it does not authenticate.
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	mailer := &SMTPMailer{addr: net.JoinHostPort(host, port), from: from}
+	if username != "" {
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return mailer
+}
+
+// Send delivers the message. net/smtp has no context support, so ctx is only
+// checked before connecting.
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := message.Format(m.from, time.Now())
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, envelopeAddress(m.from), []string{message.To}, data)
+}
+
+// envelopeAddress strips the display name of a From address such as
+// "Bank <no-reply@example.com>"
+func envelopeAddress(from string) string {
+	if start, end := strings.LastIndexByte(from, '<'), strings.LastIndexByte(from, '>'); start >= 0 && end > start {
+		return from[start+1 : end]
+	}
+	return from
+}
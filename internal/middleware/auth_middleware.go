@@ -1,68 +1,61 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/takadao/banking/internal/models"
 )
 
-// AuthMiddleware handles JWT authentication
+// UserStore looks up the user a token was issued to
+type UserStore interface {
+	GetByID(id uuid.UUID) (*models.User, error)
+}
+
+// SessionStore looks up the session a token is bound to and saves its use
+type SessionStore interface {
+	GetByID(id uuid.UUID) (*models.Session, error)
+	Touch(session *models.Session) error
+}
+
+// ClientStore looks up the API keys of machine clients and saves their use
+type ClientStore interface {
+	GetKeyByPrefix(prefix string) (*models.APIKey, error)
+	GetKeyByID(id uuid.UUID) (*models.APIKey, error)
+	TouchKey(key *models.APIKey) error
+}
+
+// AuthMiddleware handles JWT authentication of users and API key and access
+// token authentication of machine clients
 type AuthMiddleware struct {
 	jwtSecret string
+	users     UserStore
+	sessions  SessionStore
+	clients   ClientStore
 }
 
 // NewAuthMiddleware creates a new AuthMiddleware instance
-func NewAuthMiddleware(jwtSecret string) *AuthMiddleware {
+func NewAuthMiddleware(jwtSecret string, users UserStore, sessions SessionStore, clients ClientStore) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtSecret: jwtSecret,
+		users:     users,
+		sessions:  sessions,
+		clients:   clients,
 	}
 }
 
 // RequireAuth middleware ensures the request has a valid JWT token
 func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
-			c.Abort()
-			return
-		}
-
-		// Check if the Authorization header has the correct format
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
-			c.Abort()
-			return
-		}
-
-		// Parse and validate the token
-		token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(m.jwtSecret), nil
-		})
-
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
-			return
-		}
-
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// Set user ID and role in the context
-			c.Set("user_id", claims["user_id"])
-			c.Set("role", claims["role"])
-			c.Next()
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			c.Abort()
+		if !m.authenticate(c) {
 			return
 		}
+		c.Next()
 	}
 }
 
@@ -70,8 +63,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// First check if the user is authenticated
-		m.RequireAuth()(c)
-		if c.IsAborted() {
+		if !m.authenticate(c) {
 			return
 		}
 
@@ -87,10 +79,215 @@ func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
 	}
 }
 
-// GenerateToken generates a JWT token for a user
-func (m *AuthMiddleware) GenerateToken(user *models.User) (string, error) {
+// RequireStreamAuth works like RequireAuth but also accepts the token in the
+// access_token query parameter, since browsers cannot set headers on
+// EventSource and WebSocket connections
+func (m *AuthMiddleware) RequireStreamAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		if !m.authenticate(c) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticate validates the bearer token and stores its claims in the context.
+// The token is a user's JWT, or an API key or access token of a machine
+// client. It aborts the request and returns false when the token is missing
+// or invalid.
+func (m *AuthMiddleware) authenticate(c *gin.Context) bool {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+		c.Abort()
+		return false
+	}
+
+	// Check if the Authorization header has the correct format
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
+		c.Abort()
+		return false
+	}
+
+	// API keys are sent as bearer tokens as they are
+	if strings.HasPrefix(parts[1], models.APIKeyPrefix) {
+		return m.authenticateKey(c, parts[1])
+	}
+
+	// Parse and validate the token
+	token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(m.jwtSecret), nil
+	})
+
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		c.Abort()
+		return false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		c.Abort()
+		return false
+	}
+
+	// Access tokens of machine clients name the API key they were issued for
+	if keyID, ok := claims["key_id"].(string); ok {
+		return m.authenticateClientToken(c, keyID, claims)
+	}
+
+	userID, _ := claims["user_id"].(string)
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		c.Abort()
+		return false
+	}
+	user, ok := m.loadUser(c, id)
+	if !ok {
+		return false
+	}
+
+	// Tokens are bound to the session they were issued for and end with it
+	sessionID, _ := claims["sid"].(string)
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": models.ErrSessionRevoked.Error()})
+		c.Abort()
+		return false
+	}
+	session, err := m.sessions.GetByID(sid)
+	now := time.Now()
+	if err != nil || session.UserID != user.ID || !session.Active(now) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": models.ErrSessionRevoked.Error()})
+		c.Abort()
+		return false
+	}
+	if session.Seen(now, c.ClientIP()) {
+		if err := m.sessions.Touch(session); err != nil {
+			log.Printf("Failed to save use of session %s: %v", session.ID, err)
+		}
+	}
+
+	// Set user ID, session ID and role in the context
+	c.Set("user_id", user.ID.String())
+	c.Set("session_id", session.ID.String())
+	c.Set("role", user.Role)
+	return true
+}
+
+// authenticateKey authenticates a machine client by its API key
+func (m *AuthMiddleware) authenticateKey(c *gin.Context, raw string) bool {
+	prefix, ok := models.ParseAPIKeyPrefix(raw)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": models.ErrAPIKeyInvalid.Error()})
+		c.Abort()
+		return false
+	}
+	key, err := m.clients.GetKeyByPrefix(prefix)
+	if err != nil || key.ServiceAccount == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": models.ErrAPIKeyInvalid.Error()})
+		c.Abort()
+		return false
+	}
+	now := time.Now()
+	if err := key.Verify(raw, now); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return false
+	}
+	return m.authorizeClient(c, key, key.Scopes, now)
+}
+
+// authenticateClientToken authenticates a machine client by an access token
+// of the client credentials grant, which ends with its API key
+func (m *AuthMiddleware) authenticateClientToken(c *gin.Context, keyID string, claims jwt.MapClaims) bool {
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		c.Abort()
+		return false
+	}
+	key, err := m.clients.GetKeyByID(id)
+	if err != nil || key.ServiceAccount == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": models.ErrAPIKeyInvalid.Error()})
+		c.Abort()
+		return false
+	}
+	now := time.Now()
+	if err := key.Usable(now); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return false
+	}
+	if userID, _ := claims["user_id"].(string); userID != key.ServiceAccount.UserID.String() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		c.Abort()
+		return false
+	}
+	scope, _ := claims["scope"].(string)
+	return m.authorizeClient(c, key, strings.Fields(scope), now)
+}
+
+// authorizeClient lets a machine client act as the user of its service
+// account on the routes its scopes grant
+func (m *AuthMiddleware) authorizeClient(c *gin.Context, key *models.APIKey, scopes []string, now time.Time) bool {
+	user, ok := m.loadUser(c, key.ServiceAccount.UserID)
+	if !ok {
+		return false
+	}
+	scope, ok := routeScope(c.Request.Method, c.FullPath())
+	if !ok || !models.HasScope(scopes, scope) {
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrInsufficientScope.Error()})
+		c.Abort()
+		return false
+	}
+	if key.Seen(now) {
+		if err := m.clients.TouchKey(key); err != nil {
+			log.Printf("Failed to save use of API key %s: %v", key.ID, err)
+		}
+	}
+
+	c.Set("user_id", user.ID.String())
+	c.Set("service_account_id", key.ServiceAccountID.String())
+	c.Set("role", user.Role)
+	return true
+}
+
+// loadUser retrieves the user a token acts as. Tokens of deleted and closed
+// users are no longer honoured, and the role is taken from the user as it
+// is now.
+func (m *AuthMiddleware) loadUser(c *gin.Context, id uuid.UUID) (*models.User, bool) {
+	user, err := m.users.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		c.Abort()
+		return nil, false
+	}
+	if err := user.CheckLogin(); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return nil, false
+	}
+	return user, true
+}
+
+// GenerateToken generates a JWT token for a user bound to their session
+func (m *AuthMiddleware) GenerateToken(user *models.User, session *models.Session) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": user.ID.String(),
+		"sid":     session.ID.String(),
 		"email":   user.Email,
 		"role":    user.Role,
 	}
@@ -98,3 +295,24 @@ func (m *AuthMiddleware) GenerateToken(user *models.User) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(m.jwtSecret))
 }
+
+// GenerateClientToken generates an access token for a machine client with
+// the scopes, valid for ttl and no longer than its API key
+func (m *AuthMiddleware) GenerateClientToken(key *models.APIKey, scopes []string, ttl time.Duration) (string, time.Duration, error) {
+	now := time.Now()
+	if remaining := key.ExpiresAt.Sub(now).Truncate(time.Second); remaining < ttl {
+		ttl = remaining
+	}
+	claims := jwt.MapClaims{
+		"user_id":   key.ServiceAccount.UserID.String(),
+		"client_id": key.ServiceAccountID.String(),
+		"key_id":    key.ID.String(),
+		"scope":     strings.Join(scopes, " "),
+		"iat":       now.Unix(),
+		"exp":       now.Add(ttl).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(m.jwtSecret))
+	return signed, ttl, err
+}
@@ -5,14 +5,82 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
 )
 
+// stubUsers is a UserStore holding the users of a test
+type stubUsers map[uuid.UUID]*models.User
+
+func (s stubUsers) GetByID(id uuid.UUID) (*models.User, error) {
+	user, ok := s[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+// stubSessions is a SessionStore holding the sessions of a test
+type stubSessions map[uuid.UUID]*models.Session
+
+func (s stubSessions) GetByID(id uuid.UUID) (*models.Session, error) {
+	session, ok := s[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return session, nil
+}
+
+func (s stubSessions) Touch(session *models.Session) error {
+	return nil
+}
+
+// start adds an active session of the user
+func (s stubSessions) start(user *models.User) *models.Session {
+	session := models.NewSession(user, "", "", "", time.Now())
+	s[session.ID] = session
+	return session
+}
+
+// stubClients is a ClientStore holding the API keys of a test
+type stubClients map[uuid.UUID]*models.APIKey
+
+func (s stubClients) GetKeyByPrefix(prefix string) (*models.APIKey, error) {
+	for _, key := range s {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s stubClients) GetKeyByID(id uuid.UUID) (*models.APIKey, error) {
+	key, ok := s[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return key, nil
+}
+
+func (s stubClients) TouchKey(key *models.APIKey) error {
+	return nil
+}
+
+// issue adds an API key with the scopes for a service account of the user
+func (s stubClients) issue(t *testing.T, user *models.User, scopes ...string) (*models.APIKey, string) {
+	account := &models.ServiceAccount{ID: uuid.New(), UserID: user.ID, Name: "integration"}
+	key, raw, err := models.NewAPIKey(account, scopes, models.DefaultAPIKeyTTL, time.Now())
+	assert.NoError(t, err)
+	s[key.ID] = key
+	return key, raw
+}
+
 func setupTestRouter(middleware *AuthMiddleware) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -36,18 +104,50 @@ func setupTestRouter(middleware *AuthMiddleware) *gin.Engine {
 }
 
 func TestRequireAuth(t *testing.T) {
-	middleware := NewAuthMiddleware("test-secret")
-	router := setupTestRouter(middleware)
-
-	// Create a test user
+	// Create test users
 	user := &models.User{
 		ID:    uuid.New(),
 		Email: "test@example.com",
 		Role:  "user",
 	}
+	closedUser := &models.User{
+		ID:     uuid.New(),
+		Email:  "closed@example.com",
+		Role:   "user",
+		Status: models.UserStatusClosed,
+	}
+	deletedUser := &models.User{
+		ID:    uuid.New(),
+		Email: "deleted@example.com",
+		Role:  "user",
+	}
+
+	sessions := stubSessions{}
+	middleware := NewAuthMiddleware("test-secret", stubUsers{user.ID: user, closedUser.ID: closedUser}, sessions, stubClients{})
+	router := setupTestRouter(middleware)
 
-	// Generate a valid token
-	token, err := middleware.GenerateToken(user)
+	// Generate tokens
+	token, err := middleware.GenerateToken(user, sessions.start(user))
+	assert.NoError(t, err)
+	closedToken, err := middleware.GenerateToken(closedUser, sessions.start(closedUser))
+	assert.NoError(t, err)
+	deletedToken, err := middleware.GenerateToken(deletedUser, sessions.start(deletedUser))
+	assert.NoError(t, err)
+
+	revoked := sessions.start(user)
+	revokedAt := time.Now()
+	revoked.RevokedAt = &revokedAt
+	revokedToken, err := middleware.GenerateToken(user, revoked)
+	assert.NoError(t, err)
+	idle := sessions.start(user)
+	idle.LastSeenAt = time.Now().Add(-models.SessionIdleTimeout)
+	idleToken, err := middleware.GenerateToken(user, idle)
+	assert.NoError(t, err)
+	otherUsersToken, err := middleware.GenerateToken(user, sessions.start(closedUser))
+	assert.NoError(t, err)
+	unknownSessionToken, err := middleware.GenerateToken(user, models.NewSession(user, "", "", "", time.Now()))
+	assert.NoError(t, err)
+	noSessionToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": user.ID.String()}).SignedString([]byte("test-secret"))
 	assert.NoError(t, err)
 
 	tests := []struct {
@@ -74,6 +174,48 @@ func TestRequireAuth(t *testing.T) {
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   map[string]interface{}{"error": "invalid token"},
 		},
+		{
+			name:           "Closed User Token",
+			authHeader:     "Bearer " + closedToken,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": "account is closed"},
+		},
+		{
+			name:           "Deleted User Token",
+			authHeader:     "Bearer " + deletedToken,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": "user not found"},
+		},
+		{
+			name:           "Revoked Session Token",
+			authHeader:     "Bearer " + revokedToken,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": models.ErrSessionRevoked.Error()},
+		},
+		{
+			name:           "Idle Session Token",
+			authHeader:     "Bearer " + idleToken,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": models.ErrSessionRevoked.Error()},
+		},
+		{
+			name:           "Other User's Session Token",
+			authHeader:     "Bearer " + otherUsersToken,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": models.ErrSessionRevoked.Error()},
+		},
+		{
+			name:           "Unknown Session Token",
+			authHeader:     "Bearer " + unknownSessionToken,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": models.ErrSessionRevoked.Error()},
+		},
+		{
+			name:           "Token Without Session",
+			authHeader:     "Bearer " + noSessionToken,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": models.ErrSessionRevoked.Error()},
+		},
 		{
 			name:           "Valid Token",
 			authHeader:     "Bearer " + token,
@@ -117,9 +259,6 @@ func TestRequireAuth(t *testing.T) {
 }
 
 func TestRequireAdmin(t *testing.T) {
-	middleware := NewAuthMiddleware("test-secret")
-	router := setupTestRouter(middleware)
-
 	// Create test users
 	adminUser := &models.User{
 		ID:    uuid.New(),
@@ -132,10 +271,14 @@ func TestRequireAdmin(t *testing.T) {
 		Role:  "user",
 	}
 
+	sessions := stubSessions{}
+	middleware := NewAuthMiddleware("test-secret", stubUsers{adminUser.ID: adminUser, regularUser.ID: regularUser}, sessions, stubClients{})
+	router := setupTestRouter(middleware)
+
 	// Generate tokens
-	adminToken, err := middleware.GenerateToken(adminUser)
+	adminToken, err := middleware.GenerateToken(adminUser, sessions.start(adminUser))
 	assert.NoError(t, err)
-	userToken, err := middleware.GenerateToken(regularUser)
+	userToken, err := middleware.GenerateToken(regularUser, sessions.start(regularUser))
 	assert.NoError(t, err)
 
 	tests := []struct {
@@ -191,8 +334,85 @@ func TestRequireAdmin(t *testing.T) {
 	}
 }
 
+func TestRequireAuthMachineClients(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Role: "user"}
+	closedUser := &models.User{ID: uuid.New(), Email: "closed@example.com", Role: "user", Status: models.UserStatusClosed}
+
+	clients := stubClients{}
+	middleware := NewAuthMiddleware("test-secret", stubUsers{user.ID: user, closedUser.ID: closedUser}, stubSessions{}, clients)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	ok := func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		c.JSON(http.StatusOK, gin.H{"user_id": userID})
+	}
+	router.GET("/api/v1/transactions/me", middleware.RequireAuth(), ok)
+	router.POST("/api/v1/transactions/transfer", middleware.RequireAuth(), ok)
+	router.GET("/api/v1/users/me", middleware.RequireAuth(), ok)
+
+	key, raw := clients.issue(t, user, models.ScopeTransactionsRead)
+	_, closedRaw := clients.issue(t, closedUser, models.ScopeTransactionsRead)
+	revoked, revokedRaw := clients.issue(t, user, models.ScopeTransactionsRead)
+	revokedAt := time.Now()
+	revoked.RevokedAt = &revokedAt
+	expired, expiredRaw := clients.issue(t, user, models.ScopeTransactionsRead)
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	deleted, deletedRaw := clients.issue(t, user, models.ScopeTransactionsRead)
+	deleted.ServiceAccount = nil
+
+	accessToken, _, err := middleware.GenerateClientToken(key, []string{models.ScopeTransactionsRead}, models.ClientTokenTTL)
+	assert.NoError(t, err)
+	noScopeToken, _, err := middleware.GenerateClientToken(key, nil, models.ClientTokenTTL)
+	assert.NoError(t, err)
+	revokedAccessToken, _, err := middleware.GenerateClientToken(revoked, []string{models.ScopeTransactionsRead}, models.ClientTokenTTL)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"API Key", "GET", "/api/v1/transactions/me", raw, http.StatusOK, ""},
+		{"API Key Without Write Scope", "POST", "/api/v1/transactions/transfer", raw, http.StatusForbidden, models.ErrInsufficientScope.Error()},
+		{"API Key On User Only Route", "GET", "/api/v1/users/me", raw, http.StatusForbidden, models.ErrInsufficientScope.Error()},
+		{"Tampered API Key", "GET", "/api/v1/transactions/me", raw + "x", http.StatusUnauthorized, models.ErrAPIKeyInvalid.Error()},
+		{"Malformed API Key", "GET", "/api/v1/transactions/me", "tk_nope", http.StatusUnauthorized, models.ErrAPIKeyInvalid.Error()},
+		{"Revoked API Key", "GET", "/api/v1/transactions/me", revokedRaw, http.StatusUnauthorized, models.ErrAPIKeyRevoked.Error()},
+		{"Expired API Key", "GET", "/api/v1/transactions/me", expiredRaw, http.StatusUnauthorized, models.ErrAPIKeyExpired.Error()},
+		{"Deleted Service Account", "GET", "/api/v1/transactions/me", deletedRaw, http.StatusUnauthorized, models.ErrAPIKeyInvalid.Error()},
+		{"Closed User's API Key", "GET", "/api/v1/transactions/me", closedRaw, http.StatusUnauthorized, "account is closed"},
+		{"Access Token", "GET", "/api/v1/transactions/me", accessToken, http.StatusOK, ""},
+		{"Access Token Without Scope", "GET", "/api/v1/transactions/me", noScopeToken, http.StatusForbidden, models.ErrInsufficientScope.Error()},
+		{"Access Token Of Revoked Key", "GET", "/api/v1/transactions/me", revokedAccessToken, http.StatusUnauthorized, models.ErrAPIKeyRevoked.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, user.ID.String(), response["user_id"])
+			} else {
+				assert.Equal(t, tt.expectedError, response["error"])
+			}
+		})
+	}
+}
+
 func TestGenerateToken(t *testing.T) {
-	middleware := NewAuthMiddleware("test-secret")
+	middleware := NewAuthMiddleware("test-secret", stubUsers{}, stubSessions{}, stubClients{})
 
 	tests := []struct {
 		name    string
@@ -221,7 +441,8 @@ func TestGenerateToken(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token, err := middleware.GenerateToken(tt.user)
+			session := models.NewSession(tt.user, "", "", "", time.Now())
+			token, err := middleware.GenerateToken(tt.user, session)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
@@ -237,6 +458,7 @@ func TestGenerateToken(t *testing.T) {
 			assert.NoError(t, err)
 			assert.True(t, parsedToken.Valid)
 			assert.Equal(t, tt.user.ID.String(), claims["user_id"])
+			assert.Equal(t, session.ID.String(), claims["sid"])
 			assert.Equal(t, tt.user.Email, claims["email"])
 			assert.Equal(t, tt.user.Role, claims["role"])
 		})
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/takadao/banking/internal/models"
+)
+
+// routeScopes maps routes to the scopes machine clients need for them. The
+// first rule whose prefix the route's path starts with applies; reads need
+// its read scope and other methods its write scope. Routes without a rule
+// or scope, such as managing the user's profile, sessions, API keys and
+// approval policies, are for users only.
+var routeScopes = []struct {
+	prefix string
+	read   string
+	write  string
+}{
+	{"/api/v1/transactions/", models.ScopeTransactionsRead, models.ScopeTransactionsWrite},
+	{"/api/v1/users/events", models.ScopeTransactionsRead, ""},
+	{"/api/v1/users/accounts/moves", "", models.ScopeTransactionsWrite},
+	{"/api/v1/users/pending-payments/", models.ScopeTransactionsRead, models.ScopeTransactionsWrite},
+	{"/api/v1/users/accounts/:id/pending-payments", models.ScopeTransactionsRead, ""},
+	{"/api/v1/users/accounts/:id/transactions", models.ScopeTransactionsRead, ""},
+	{"/api/v1/users/accounts/:id/approval-policies", "", ""},
+	{"/api/v1/users/accounts", models.ScopeAccountsRead, models.ScopeAccountsWrite},
+	{"/api/v1/users/account-invitations", models.ScopeAccountsRead, models.ScopeAccountsWrite},
+	{"/api/v1/users/balance", models.ScopeAccountsRead, ""},
+	{"/api/v1/users/holds", models.ScopeAccountsRead, ""},
+	{"/api/v1/users/interest", models.ScopeAccountsRead, ""},
+	{"/api/v1/users/standing-orders", models.ScopePaymentsRead, models.ScopePaymentsWrite},
+	{"/api/v1/users/payment-requests", models.ScopePaymentsRead, models.ScopePaymentsWrite},
+	{"/api/v1/users/beneficiaries", models.ScopeBeneficiariesRead, models.ScopeBeneficiariesWrite},
+	{"/api/v1/users/webhooks", models.ScopeWebhooks, models.ScopeWebhooks},
+	{"/api/v1/admin/", models.ScopeAdminRead, models.ScopeAdminWrite},
+}
+
+// routeScope returns the scope a machine client needs for the route with
+// the path pattern, or false if the route is for users only
+func routeScope(method, path string) (string, bool) {
+	for _, rule := range routeScopes {
+		if !strings.HasPrefix(path, rule.prefix) {
+			continue
+		}
+		scope := rule.write
+		if method == http.MethodGet || method == http.MethodHead {
+			scope = rule.read
+		}
+		return scope, scope != ""
+	}
+	return "", false
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/takadao/banking/internal/models"
+)
+
+func TestRouteScope(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{"GET", "/api/v1/transactions/me", models.ScopeTransactionsRead, true},
+		{"POST", "/api/v1/transactions/transfer", models.ScopeTransactionsWrite, true},
+		{"POST", "/api/v1/users/accounts/moves", models.ScopeTransactionsWrite, true},
+		{"GET", "/api/v1/users/accounts/:id/transactions", models.ScopeTransactionsRead, true},
+		{"GET", "/api/v1/users/accounts/:id", models.ScopeAccountsRead, true},
+		{"PUT", "/api/v1/users/accounts/:id", models.ScopeAccountsWrite, true},
+		{"GET", "/api/v1/users/accounts/:id/approval-policies", "", false},
+		{"POST", "/api/v1/users/accounts/:id/approval-policies", "", false},
+		{"DELETE", "/api/v1/users/accounts/:id/approval-policies/:policy_id", "", false},
+		{"GET", "/api/v1/users/balance", models.ScopeAccountsRead, true},
+		{"DELETE", "/api/v1/users/webhooks/:id", models.ScopeWebhooks, true},
+		{"GET", "/api/v1/admin/payouts", models.ScopeAdminRead, true},
+		{"POST", "/api/v1/admin/bank-files", models.ScopeAdminWrite, true},
+		{"GET", "/api/v1/users/me", "", false},
+		{"PUT", "/api/v1/users/me", "", false},
+		{"POST", "/api/v1/users/service-accounts/:id/keys", "", false},
+		{"POST", "/api/v1/auth/logout", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			scope, ok := routeScope(tt.method, tt.path)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, scope)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AccountType string
+
+const (
+	// AccountTypeMain is the account money arrives on when no account is given;
+	// every user has at most one per currency
+	AccountTypeMain    AccountType = "main"
+	AccountTypeSavings AccountType = "savings"
+	AccountTypeGoal    AccountType = "goal"
+)
+
+const (
+	// accountCountryCode is a user-assigned ISO 3166 code, so account numbers
+	// are IBAN-shaped without claiming to be real IBANs
+	accountCountryCode = "XT"
+	accountBankCode    = "TAKA"
+	accountDigits      = 10
+)
+
+// Account is a named wallet of a user in one currency, with its own number
+// and balance: the main account, savings pots and goals
+type Account struct {
+	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name         string         `gorm:"type:varchar(100);not null" json:"name"`
+	Type         AccountType    `gorm:"type:varchar(20);not null" json:"type"`
+	Currency     string         `gorm:"type:varchar(3);not null" json:"currency"`
+	Number       string         `gorm:"type:varchar(34);uniqueIndex;not null" json:"number"`
+	TargetAmount *float64       `gorm:"type:decimal(20,2)" json:"target_amount,omitempty"` // goals only
+	TargetDate   *time.Time     `gorm:"type:date" json:"target_date,omitempty"`            // goals only
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Role is the caller's role on the account, filled in when it is listed
+	Role AccountRole `gorm:"-" json:"role,omitempty"`
+
+	// Relationships
+	Balance *Balance `gorm:"foreignKey:AccountID" json:"balance,omitempty"`
+}
+
+// BeforeCreate will set a UUID and account number
+func (a *Account) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	if a.Number == "" {
+		number, err := NewAccountNumber()
+		if err != nil {
+			return err
+		}
+		a.Number = number
+	}
+	return nil
+}
+
+// Validate checks if the account is valid
+func (a *Account) Validate() error {
+	if strings.TrimSpace(a.Name) == "" || len(a.Name) > 100 {
+		return ErrInvalidAccountName
+	}
+	switch a.Type {
+	case AccountTypeMain, AccountTypeSavings:
+		if a.TargetAmount != nil || a.TargetDate != nil {
+			return ErrTargetOnlyForGoals
+		}
+	case AccountTypeGoal:
+		if a.TargetAmount == nil || *a.TargetAmount <= 0 {
+			return ErrInvalidGoalTarget
+		}
+	default:
+		return ErrInvalidAccountType
+	}
+	return nil
+}
+
+// NewAccountNumber generates an IBAN-shaped account number: country code,
+// two ISO 7064 mod 97-10 check digits, bank code and a random account part
+func NewAccountNumber() (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(accountDigits), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	bban := fmt.Sprintf("%s%0*d", accountBankCode, accountDigits, n)
+	return accountCountryCode + checkDigits(accountCountryCode, bban) + bban, nil
+}
+
+// ValidAccountNumber reports whether number has valid mod 97 check digits
+func ValidAccountNumber(number string) bool {
+	if len(number) < 5 {
+		return false
+	}
+	rearranged := number[4:] + number[:4]
+	return mod97(rearranged) == 1
+}
+
+func checkDigits(country, bban string) string {
+	return fmt.Sprintf("%02d", 98-mod97(bban+country+"00"))
+}
+
+// mod97 computes the remainder of the number formed by replacing letters
+// with 10..35, digit by digit so it never overflows
+func mod97(s string) int {
+	remainder := 0
+	for _, r := range strings.ToUpper(s) {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A'+10)) % 97
+		default:
+			return -1
+		}
+	}
+	return remainder
+}
+
+// Custom errors
+var (
+	ErrInvalidAccountName      = errors.New("account name is required and at most 100 characters")
+	ErrInvalidAccountType      = errors.New("account type must be savings or goal")
+	ErrInvalidGoalTarget       = errors.New("goal accounts need a positive target amount")
+	ErrTargetOnlyForGoals      = errors.New("only goal accounts can have a target")
+	ErrAccountNotFound         = errors.New("account not found")
+	ErrAccountCurrencyMismatch = errors.New("account currency does not match the transaction currency")
+	ErrAccountNotEmpty         = errors.New("account still has funds or holds")
+	ErrMainAccountRequired     = errors.New("the main account cannot be removed")
+	ErrSameAccount             = errors.New("source and destination accounts must differ")
+)
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AccountRole is what a user may do on an account. The account's user is its
+// owner; everyone else gets access through a membership.
+type AccountRole string
+
+const (
+	AccountRoleOwner   AccountRole = "owner"
+	AccountRoleCoOwner AccountRole = "co_owner"
+	AccountRoleSpender AccountRole = "spender"
+	AccountRoleViewer  AccountRole = "viewer"
+)
+
+// DefaultInvitationTTL is how long an invitation to an account can be accepted
+const DefaultInvitationTTL = 14 * 24 * time.Hour
+
+// CanSpend reports whether the role may move money out of the account
+func (r AccountRole) CanSpend() bool {
+	return r == AccountRoleOwner || r == AccountRoleCoOwner || r == AccountRoleSpender
+}
+
+// CanManage reports whether the role may invite and remove members
+func (r AccountRole) CanManage() bool {
+	return r == AccountRoleOwner || r == AccountRoleCoOwner
+}
+
+// Outranks reports whether the role may change or remove a member with the
+// other role: the owner manages everyone, co-owners manage spenders and viewers
+func (r AccountRole) Outranks(other AccountRole) bool {
+	switch r {
+	case AccountRoleOwner:
+		return other != AccountRoleOwner
+	case AccountRoleCoOwner:
+		return other == AccountRoleSpender || other == AccountRoleViewer
+	default:
+		return false
+	}
+}
+
+// validateMemberRole checks a role that can be granted to a member
+func validateMemberRole(role AccountRole, spendLimit *float64) error {
+	switch role {
+	case AccountRoleCoOwner, AccountRoleViewer:
+		if spendLimit != nil {
+			return ErrSpendLimitOnlyForSpenders
+		}
+	case AccountRoleSpender:
+		if spendLimit == nil || *spendLimit <= 0 {
+			return ErrInvalidSpendLimit
+		}
+	default:
+		return ErrInvalidMemberRole
+	}
+	return nil
+}
+
+// AccountMember gives a user other than the owner access to an account
+type AccountMember struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AccountID  uuid.UUID      `gorm:"type:uuid;not null;index" json:"account_id"`
+	UserID     uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	Role       AccountRole    `gorm:"type:varchar(20);not null" json:"role"`
+	SpendLimit *float64       `gorm:"type:decimal(20,2)" json:"spend_limit,omitempty"` // per calendar month (UTC), spenders only
+	AddedBy    uuid.UUID      `gorm:"type:uuid;not null" json:"added_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (m *AccountMember) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks if the membership is valid
+func (m *AccountMember) Validate() error {
+	return validateMemberRole(m.Role, m.SpendLimit)
+}
+
+// CheckSpend verifies a spender stays within their monthly limit, given what
+// they already spent from the account this month
+func (m *AccountMember) CheckSpend(amount, spentThisMonth float64) error {
+	if !m.Role.CanSpend() {
+		return ErrAccountAccessDenied
+	}
+	if m.Role == AccountRoleSpender && m.SpendLimit != nil && spentThisMonth+amount > *m.SpendLimit {
+		return ErrSpendLimitExceeded
+	}
+	return nil
+}
+
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationDeclined InvitationStatus = "declined"
+	InvitationRevoked  InvitationStatus = "revoked"
+)
+
+// AccountInvitation invites whoever holds an email address to join an
+// account; the user with that email accepts or declines it
+type AccountInvitation struct {
+	ID          uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AccountID   uuid.UUID        `gorm:"type:uuid;not null;index" json:"account_id"`
+	Email       string           `gorm:"type:varchar(255);not null;index" json:"email"`
+	Role        AccountRole      `gorm:"type:varchar(20);not null" json:"role"`
+	SpendLimit  *float64         `gorm:"type:decimal(20,2)" json:"spend_limit,omitempty"`
+	Status      InvitationStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	InvitedBy   uuid.UUID        `gorm:"type:uuid;not null" json:"invited_by"`
+	ExpiresAt   time.Time        `gorm:"not null" json:"expires_at"`
+	RespondedAt *time.Time       `json:"responded_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+
+	// Relationships
+	Account *Account `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+}
+
+// BeforeCreate will set a UUID and normalize the email
+func (i *AccountInvitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	i.Email = NormalizeEmail(i.Email)
+	return nil
+}
+
+// Validate checks if the invitation is valid
+func (i *AccountInvitation) Validate() error {
+	if !strings.Contains(i.Email, "@") {
+		return ErrInvalidInvitationEmail
+	}
+	return validateMemberRole(i.Role, i.SpendLimit)
+}
+
+// CheckResponse verifies the invitation can still be answered by a user with
+// the given email
+func (i *AccountInvitation) CheckResponse(email string, now time.Time) error {
+	if NormalizeEmail(email) != i.Email {
+		return ErrInvitationNotFound
+	}
+	if i.Status != InvitationPending {
+		return ErrInvitationNotPending
+	}
+	if !now.Before(i.ExpiresAt) {
+		return ErrInvitationExpired
+	}
+	return nil
+}
+
+// NormalizeEmail returns the form emails are compared in
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Custom errors
+var (
+	ErrInvalidMemberRole         = errors.New("role must be co_owner, spender or viewer")
+	ErrInvalidSpendLimit         = errors.New("spenders need a positive spend_limit")
+	ErrSpendLimitOnlyForSpenders = errors.New("only spenders can have a spend_limit")
+	ErrSpendLimitExceeded        = errors.New("monthly spend limit on this account exceeded")
+	ErrAccountAccessDenied       = errors.New("you are not allowed to do this on the account")
+	ErrAlreadyMember             = errors.New("user already has access to the account")
+	ErrInvalidInvitationEmail    = errors.New("invalid email")
+	ErrInvitationNotFound        = errors.New("invitation not found")
+	ErrInvitationNotPending      = errors.New("invitation was already answered or revoked")
+	ErrInvitationExpired         = errors.New("invitation has expired")
+)
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountRoleOutranks(t *testing.T) {
+	assert.True(t, AccountRoleOwner.Outranks(AccountRoleCoOwner))
+	assert.True(t, AccountRoleCoOwner.Outranks(AccountRoleSpender))
+	assert.False(t, AccountRoleCoOwner.Outranks(AccountRoleCoOwner))
+	assert.False(t, AccountRoleCoOwner.Outranks(AccountRoleOwner))
+	assert.False(t, AccountRoleSpender.Outranks(AccountRoleViewer))
+}
+
+func TestAccountMemberCheckSpend(t *testing.T) {
+	limit := 100.0
+	spender := AccountMember{Role: AccountRoleSpender, SpendLimit: &limit}
+	coOwner := AccountMember{Role: AccountRoleCoOwner}
+	viewer := AccountMember{Role: AccountRoleViewer}
+
+	tests := []struct {
+		name   string
+		member AccountMember
+		amount float64
+		spent  float64
+		err    error
+	}{
+		{"spender within limit", spender, 40, 60, nil},
+		{"spender over limit", spender, 40.01, 60, ErrSpendLimitExceeded},
+		{"co-owner unlimited", coOwner, 10000, 5000, nil},
+		{"viewer cannot spend", viewer, 1, 0, ErrAccountAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.err, tt.member.CheckSpend(tt.amount, tt.spent))
+		})
+	}
+}
+
+func TestAccountInvitationValidate(t *testing.T) {
+	limit := 50.0
+
+	assert.NoError(t, (&AccountInvitation{Email: "a@example.com", Role: AccountRoleSpender, SpendLimit: &limit}).Validate())
+	assert.NoError(t, (&AccountInvitation{Email: "a@example.com", Role: AccountRoleViewer}).Validate())
+	assert.Equal(t, ErrInvalidSpendLimit, (&AccountInvitation{Email: "a@example.com", Role: AccountRoleSpender}).Validate())
+	assert.Equal(t, ErrSpendLimitOnlyForSpenders, (&AccountInvitation{Email: "a@example.com", Role: AccountRoleCoOwner, SpendLimit: &limit}).Validate())
+	assert.Equal(t, ErrInvalidMemberRole, (&AccountInvitation{Email: "a@example.com", Role: AccountRoleOwner}).Validate())
+	assert.Equal(t, ErrInvalidInvitationEmail, (&AccountInvitation{Email: "nobody", Role: AccountRoleViewer}).Validate())
+}
+
+func TestAccountInvitationCheckResponse(t *testing.T) {
+	now := time.Now()
+	invitation := AccountInvitation{Email: "anna@example.com", Status: InvitationPending, ExpiresAt: now.Add(time.Hour)}
+
+	assert.NoError(t, invitation.CheckResponse(" Anna@Example.com", now))
+	assert.Equal(t, ErrInvitationNotFound, invitation.CheckResponse("bob@example.com", now))
+	assert.Equal(t, ErrInvitationExpired, invitation.CheckResponse("anna@example.com", now.Add(time.Hour)))
+
+	invitation.Status = InvitationRevoked
+	assert.Equal(t, ErrInvitationNotPending, invitation.CheckResponse("anna@example.com", now))
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAccountNumber(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		number, err := NewAccountNumber()
+		assert.NoError(t, err)
+		assert.Len(t, number, 18)
+		assert.True(t, strings.HasPrefix(number, "XT"))
+		assert.True(t, ValidAccountNumber(number), number)
+	}
+}
+
+func TestValidAccountNumber(t *testing.T) {
+	// Published example IBANs share the mod 97 check
+	assert.True(t, ValidAccountNumber("GB82WEST12345698765432"))
+	assert.True(t, ValidAccountNumber("DE89370400440532013000"))
+	assert.False(t, ValidAccountNumber("GB82WEST12345698765433"))
+	assert.False(t, ValidAccountNumber("XT00"))
+	assert.False(t, ValidAccountNumber("XT12TAKA-123"))
+}
+
+func TestAccountValidate(t *testing.T) {
+	target := 500.0
+
+	assert.NoError(t, (&Account{Name: "Holiday", Type: AccountTypeGoal, TargetAmount: &target}).Validate())
+	assert.NoError(t, (&Account{Name: "Rainy day", Type: AccountTypeSavings}).Validate())
+	assert.Equal(t, ErrInvalidGoalTarget, (&Account{Name: "Car", Type: AccountTypeGoal}).Validate())
+	assert.Equal(t, ErrTargetOnlyForGoals, (&Account{Name: "Pot", Type: AccountTypeSavings, TargetAmount: &target}).Validate())
+	assert.Equal(t, ErrInvalidAccountName, (&Account{Name: " ", Type: AccountTypeSavings}).Validate())
+	assert.Equal(t, ErrInvalidAccountType, (&Account{Name: "Pot", Type: "checking"}).Validate())
+}
+
+func TestMoveValidate(t *testing.T) {
+	pot, main := uuid.New(), uuid.New()
+
+	move := Transaction{Type: TransactionTypeMove, Amount: 10, SourceAccountID: &main, DestinationAccountID: &pot}
+	assert.NoError(t, move.Validate())
+	assert.True(t, move.Debits())
+	assert.Equal(t, &move.UserID, move.Beneficiary())
+
+	move.DestinationAccountID = &main
+	assert.Equal(t, ErrSameAccount, move.Validate())
+
+	move.DestinationAccountID = nil
+	assert.Equal(t, ErrMissingAccount, move.Validate())
+}
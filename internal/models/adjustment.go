@@ -0,0 +1,185 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxAdjustmentAttachments limits the documents referenced by an adjustment
+const MaxAdjustmentAttachments = 10
+
+type AdjustmentDirection string
+
+const (
+	AdjustmentCredit AdjustmentDirection = "credit"
+	AdjustmentDebit  AdjustmentDirection = "debit"
+)
+
+var reasonCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,39}$`)
+
+// AdjustmentReason is an entry of the catalog of reasons operations may give
+// for a manual balance adjustment
+type AdjustmentReason struct {
+	ID          uuid.UUID           `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Code        string              `gorm:"type:varchar(40);uniqueIndex;not null" json:"code"`
+	Description string              `gorm:"type:text;not null" json:"description"`
+	Direction   AdjustmentDirection `gorm:"type:varchar(10)" json:"direction,omitempty"` // either way when empty
+	Active      bool                `gorm:"not null;default:true" json:"active"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *AdjustmentReason) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks if the reason is valid
+func (r *AdjustmentReason) Validate() error {
+	if !reasonCodePattern.MatchString(r.Code) {
+		return ErrInvalidReasonCode
+	}
+	if strings.TrimSpace(r.Description) == "" {
+		return ErrReasonDescriptionRequired
+	}
+	if r.Direction != "" && r.Direction != AdjustmentCredit && r.Direction != AdjustmentDebit {
+		return ErrInvalidAdjustmentDirection
+	}
+	return nil
+}
+
+// Allows reports whether the reason may be given for an adjustment in direction
+func (r *AdjustmentReason) Allows(direction AdjustmentDirection) bool {
+	return r.Active && (r.Direction == "" || r.Direction == direction)
+}
+
+// Attachments references documents supporting an adjustment, such as ticket
+// numbers or document store URLs
+type Attachments []string
+
+// Value implements driver.Valuer
+func (a Attachments) Value() (driver.Value, error) {
+	if a == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (a *Attachments) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into Attachments", value)
+	}
+}
+
+// AdjustmentRequest describes a manual credit or debit of a user's balance
+// awaiting approval
+type AdjustmentRequest struct {
+	UserID      uuid.UUID           `json:"user_id"`
+	AccountID   *uuid.UUID          `json:"account_id,omitempty"` // the main account when empty
+	Direction   AdjustmentDirection `json:"direction"`
+	Amount      float64             `json:"amount"`
+	Currency    string              `json:"currency"`
+	ReasonCode  string              `json:"reason_code"`
+	Note        string              `json:"note"`
+	Attachments Attachments         `json:"attachments,omitempty"`
+}
+
+// Validate checks if the adjustment request is valid
+func (a *AdjustmentRequest) Validate() error {
+	if a.Direction != AdjustmentCredit && a.Direction != AdjustmentDebit {
+		return ErrInvalidAdjustmentDirection
+	}
+	if a.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if strings.TrimSpace(a.Note) == "" {
+		return ErrAdjustmentNoteRequired
+	}
+	if len(a.Attachments) > MaxAdjustmentAttachments {
+		return ErrTooManyAttachments
+	}
+	return nil
+}
+
+// Transaction builds the adjustment transaction. A credit names the user as
+// its recipient, a debit has none.
+func (a *AdjustmentRequest) Transaction(initiatedBy uuid.UUID) *Transaction {
+	tx := &Transaction{
+		UserID:      a.UserID,
+		Type:        TransactionTypeAdjustment,
+		Amount:      a.Amount,
+		Currency:    a.Currency,
+		Description: "Adjustment " + a.ReasonCode + ": " + a.Note,
+		InitiatedBy: &initiatedBy,
+	}
+	if a.Direction == AdjustmentCredit {
+		tx.RecipientID = &tx.UserID
+		tx.DestinationAccountID = a.AccountID
+	} else {
+		tx.SourceAccountID = a.AccountID
+	}
+	return tx
+}
+
+// Adjustment records a booked manual adjustment with its justification
+type Adjustment struct {
+	ID              uuid.UUID           `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TransactionID   uuid.UUID           `gorm:"type:uuid;uniqueIndex;not null" json:"transaction_id"`
+	ChangeRequestID uuid.UUID           `gorm:"type:uuid;not null" json:"change_request_id"`
+	UserID          uuid.UUID           `gorm:"type:uuid;not null;index" json:"user_id"`
+	Direction       AdjustmentDirection `gorm:"type:varchar(10);not null" json:"direction"`
+	Amount          float64             `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Currency        string              `gorm:"type:varchar(3);not null" json:"currency"`
+	ReasonCode      string              `gorm:"type:varchar(40);not null;index" json:"reason_code"`
+	Note            string              `gorm:"type:text;not null" json:"note"`
+	Attachments     Attachments         `gorm:"type:jsonb;not null;default:'[]'" json:"attachments"`
+	RequestedBy     uuid.UUID           `gorm:"type:uuid;not null" json:"requested_by"`
+	ApprovedBy      uuid.UUID           `gorm:"type:uuid;not null" json:"approved_by"`
+	CreatedAt       time.Time           `json:"created_at"`
+
+	// Relationships
+	Transaction *Transaction `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (a *Adjustment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// Custom errors
+var (
+	ErrInvalidReasonCode          = errors.New("reason code must be 2 to 40 upper case letters, digits or underscores")
+	ErrReasonDescriptionRequired  = errors.New("reason description is required")
+	ErrUnknownReasonCode          = errors.New("reason code is not in the catalog or not active")
+	ErrReasonDirectionMismatch    = errors.New("reason code cannot be used in this direction")
+	ErrInvalidAdjustmentDirection = errors.New("direction must be credit or debit")
+	ErrAdjustmentNoteRequired     = errors.New("a note is required")
+	ErrTooManyAttachments         = errors.New("too many attachments")
+)
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjustmentReasonAllows(t *testing.T) {
+	either := AdjustmentReason{Code: "BANK_ERROR", Description: "Booking error", Active: true}
+	credits := AdjustmentReason{Code: "GOODWILL", Description: "Goodwill", Direction: AdjustmentCredit, Active: true}
+	retired := AdjustmentReason{Code: "LEGACY", Description: "Legacy", Active: false}
+
+	assert.True(t, either.Allows(AdjustmentCredit))
+	assert.True(t, either.Allows(AdjustmentDebit))
+	assert.True(t, credits.Allows(AdjustmentCredit))
+	assert.False(t, credits.Allows(AdjustmentDebit))
+	assert.False(t, retired.Allows(AdjustmentCredit))
+}
+
+func TestAdjustmentReasonValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason AdjustmentReason
+		want   error
+	}{
+		{"valid", AdjustmentReason{Code: "FEE_REFUND", Description: "Fee refund"}, nil},
+		{"lower case code", AdjustmentReason{Code: "fee_refund", Description: "Fee refund"}, ErrInvalidReasonCode},
+		{"missing description", AdjustmentReason{Code: "FEE_REFUND", Description: " "}, ErrReasonDescriptionRequired},
+		{"unknown direction", AdjustmentReason{Code: "FEE_REFUND", Description: "Fee refund", Direction: "both"}, ErrInvalidAdjustmentDirection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.reason.Validate())
+		})
+	}
+}
+
+func TestAdjustmentRequestTransaction(t *testing.T) {
+	userID, accountID, adminID := uuid.New(), uuid.New(), uuid.New()
+	adjustment := AdjustmentRequest{
+		UserID:     userID,
+		AccountID:  &accountID,
+		Direction:  AdjustmentCredit,
+		Amount:     25,
+		Currency:   "EUR",
+		ReasonCode: "GOODWILL",
+		Note:       "Delayed transfer",
+	}
+	assert.NoError(t, adjustment.Validate())
+
+	credit := adjustment.Transaction(adminID)
+	assert.Equal(t, TransactionTypeAdjustment, credit.Type)
+	assert.False(t, credit.Debits())
+	assert.Equal(t, &userID, credit.Beneficiary())
+	assert.Equal(t, &accountID, credit.DestinationAccountID)
+	assert.Equal(t, &adminID, credit.InitiatedBy)
+	assert.NoError(t, credit.Validate())
+
+	adjustment.Direction = AdjustmentDebit
+	debit := adjustment.Transaction(adminID)
+	assert.True(t, debit.Debits())
+	assert.Nil(t, debit.Beneficiary())
+	assert.Equal(t, &accountID, debit.SourceAccountID)
+
+	adjustment.Note = ""
+	assert.Equal(t, ErrAdjustmentNoteRequired, adjustment.Validate())
+	adjustment.Note = "Duplicate"
+	adjustment.Attachments = make(Attachments, MaxAdjustmentAttachments+1)
+	assert.Equal(t, ErrTooManyAttachments, adjustment.Validate())
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultApprovalTTL is how long a payment waits for its approvals
+const DefaultApprovalTTL = 72 * time.Hour
+
+// ApprovalPolicy requires payments from an account within an amount band to
+// be approved by a number of the account's approvers (owner and co-owners)
+// other than the user who made the payment
+type ApprovalPolicy struct {
+	ID                uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AccountID         uuid.UUID      `gorm:"type:uuid;not null;index" json:"account_id"`
+	MinAmount         float64        `gorm:"type:decimal(20,2);not null;default:0" json:"min_amount"` // inclusive
+	MaxAmount         *float64       `gorm:"type:decimal(20,2)" json:"max_amount,omitempty"`          // exclusive, no upper bound when empty
+	RequiredApprovals int            `gorm:"not null" json:"required_approvals"`
+	CreatedBy         uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (p *ApprovalPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks if the policy is valid
+func (p *ApprovalPolicy) Validate() error {
+	if p.RequiredApprovals < 1 || p.MinAmount < 0 {
+		return ErrInvalidApprovalPolicy
+	}
+	if p.MaxAmount != nil && *p.MaxAmount <= p.MinAmount {
+		return ErrInvalidApprovalPolicy
+	}
+	return nil
+}
+
+// Covers reports whether amount falls in the policy's band
+func (p *ApprovalPolicy) Covers(amount float64) bool {
+	return amount >= p.MinAmount && (p.MaxAmount == nil || amount < *p.MaxAmount)
+}
+
+// PolicyFor returns the policy that applies to amount: the strictest of the
+// policies whose band covers it, or nil when the payment needs no approval
+func PolicyFor(policies []ApprovalPolicy, amount float64) *ApprovalPolicy {
+	var match *ApprovalPolicy
+	for i := range policies {
+		if policies[i].Covers(amount) && (match == nil || policies[i].RequiredApprovals > match.RequiredApprovals) {
+			match = &policies[i]
+		}
+	}
+	return match
+}
+
+type PendingPaymentStatus string
+
+const (
+	PendingPaymentPending   PendingPaymentStatus = "pending"
+	PendingPaymentApproved  PendingPaymentStatus = "approved" // policy satisfied, being executed
+	PendingPaymentExecuted  PendingPaymentStatus = "executed"
+	PendingPaymentFailed    PendingPaymentStatus = "failed"
+	PendingPaymentRejected  PendingPaymentStatus = "rejected"
+	PendingPaymentCancelled PendingPaymentStatus = "cancelled"
+	PendingPaymentExpired   PendingPaymentStatus = "expired"
+)
+
+// PendingPayment is a withdrawal or transfer held back until enough approvers
+// agreed to it. It is executed as its maker once the policy is satisfied.
+type PendingPayment struct {
+	ID                   uuid.UUID            `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AccountID            uuid.UUID            `gorm:"type:uuid;not null;index" json:"account_id"`
+	Type                 TransactionType      `gorm:"type:varchar(20);not null" json:"type"`
+	Amount               float64              `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Currency             string               `gorm:"type:varchar(3);not null" json:"currency"`
+	RecipientID          *uuid.UUID           `gorm:"type:uuid" json:"recipient_id,omitempty"`
+	DestinationAccountID *uuid.UUID           `gorm:"type:uuid" json:"destination_account_id,omitempty"`
+	Payout               *PayoutDestination   `gorm:"type:jsonb" json:"payout,omitempty"` // external account of a withdrawal
+	Description          string               `gorm:"type:text" json:"description"`
+	InitiatedBy          uuid.UUID            `gorm:"type:uuid;not null;index" json:"initiated_by"`
+	PolicyID             uuid.UUID            `gorm:"type:uuid;not null" json:"policy_id"`
+	RequiredApprovals    int                  `gorm:"not null" json:"required_approvals"`
+	Approvals            int                  `gorm:"not null;default:0" json:"approvals"`
+	Status               PendingPaymentStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ExpiresAt            time.Time            `gorm:"not null" json:"expires_at"`
+	TransactionID        *uuid.UUID           `gorm:"type:uuid" json:"transaction_id,omitempty"`
+	Error                string               `gorm:"type:text" json:"error,omitempty"`
+	ClosedAt             *time.Time           `json:"closed_at,omitempty"`
+	CreatedAt            time.Time            `json:"created_at"`
+	UpdatedAt            time.Time            `json:"updated_at"`
+
+	// Relationships
+	Decisions []PaymentApproval `gorm:"foreignKey:PendingPaymentID" json:"decisions,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (p *PendingPayment) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewPendingPayment holds back a transaction from an account under a policy
+func NewPendingPayment(tx *Transaction, accountID uuid.UUID, policy *ApprovalPolicy, expiresAt time.Time) *PendingPayment {
+	var destination *PayoutDestination
+	if tx.Payout != nil {
+		d := tx.Payout.Destination()
+		destination = &d
+	}
+	return &PendingPayment{
+		AccountID:            accountID,
+		Type:                 tx.Type,
+		Amount:               tx.Amount,
+		Currency:             tx.Currency,
+		RecipientID:          tx.RecipientID,
+		DestinationAccountID: tx.DestinationAccountID,
+		Payout:               destination,
+		Description:          tx.Description,
+		InitiatedBy:          *tx.InitiatedBy,
+		PolicyID:             policy.ID,
+		RequiredApprovals:    policy.RequiredApprovals,
+		Status:               PendingPaymentPending,
+		ExpiresAt:            expiresAt,
+	}
+}
+
+// Transaction builds the transaction executed once the payment is approved
+func (p *PendingPayment) Transaction() *Transaction {
+	accountID := p.AccountID
+	tx := &Transaction{
+		Type:                 p.Type,
+		Amount:               p.Amount,
+		Currency:             p.Currency,
+		RecipientID:          p.RecipientID,
+		Description:          p.Description,
+		SourceAccountID:      &accountID,
+		DestinationAccountID: p.DestinationAccountID,
+	}
+	if p.Payout != nil {
+		tx.Payout = NewPayout(*p.Payout)
+	}
+	return tx
+}
+
+// CheckDecision verifies approver can still approve or reject the payment
+func (p *PendingPayment) CheckDecision(approverID uuid.UUID, now time.Time) error {
+	if p.Status != PendingPaymentPending {
+		return ErrPaymentNotPending
+	}
+	if !now.Before(p.ExpiresAt) {
+		return ErrPaymentExpired
+	}
+	if approverID == p.InitiatedBy {
+		return ErrSelfApproval
+	}
+	return nil
+}
+
+type ApprovalDecision string
+
+const (
+	DecisionApprove ApprovalDecision = "approve"
+	DecisionReject  ApprovalDecision = "reject"
+)
+
+// PaymentApproval records one approver's decision on a pending payment
+type PaymentApproval struct {
+	ID               uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PendingPaymentID uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_payment_approver" json:"pending_payment_id"`
+	ApproverID       uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_payment_approver" json:"approver_id"`
+	Decision         ApprovalDecision `gorm:"type:varchar(10);not null" json:"decision"`
+	Comment          string           `gorm:"type:text" json:"comment,omitempty"`
+	CreatedAt        time.Time        `json:"created_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (a *PaymentApproval) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// Custom errors
+var (
+	ErrInvalidApprovalPolicy = errors.New("approval policy needs at least one approval and a valid amount band")
+	ErrPaymentNotPending     = errors.New("payment is no longer pending")
+	ErrPaymentExpired        = errors.New("payment approval has expired")
+	ErrSelfApproval          = errors.New("payments cannot be approved by the user who made them")
+	ErrAlreadyDecided        = errors.New("you already decided on this payment")
+	ErrApprovalRequired      = errors.New("an approval policy of the account requires this payment to be approved")
+)
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyFor(t *testing.T) {
+	tenThousand := 10000.0
+	policies := []ApprovalPolicy{
+		{MinAmount: 1000, MaxAmount: &tenThousand, RequiredApprovals: 1},
+		{MinAmount: 10000, RequiredApprovals: 2},
+		{MinAmount: 5000, MaxAmount: &tenThousand, RequiredApprovals: 2},
+	}
+
+	tests := []struct {
+		name     string
+		amount   float64
+		required int
+	}{
+		{"first band inclusive", 1000, 1},
+		{"overlap takes strictest", 5000, 2},
+		{"upper bound exclusive", 10000, 2},
+		{"unbounded top band", 250000, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := PolicyFor(policies, tt.amount)
+			assert.NotNil(t, policy)
+			assert.Equal(t, tt.required, policy.RequiredApprovals)
+		})
+	}
+
+	assert.Nil(t, PolicyFor(policies, 999.99))
+}
+
+func TestApprovalPolicyValidate(t *testing.T) {
+	low, high := 100.0, 500.0
+
+	assert.NoError(t, (&ApprovalPolicy{MinAmount: 100, MaxAmount: &high, RequiredApprovals: 2}).Validate())
+	assert.NoError(t, (&ApprovalPolicy{MinAmount: 100, RequiredApprovals: 1}).Validate())
+	assert.Equal(t, ErrInvalidApprovalPolicy, (&ApprovalPolicy{MinAmount: 100, RequiredApprovals: 0}).Validate())
+	assert.Equal(t, ErrInvalidApprovalPolicy, (&ApprovalPolicy{MinAmount: 100, MaxAmount: &low, RequiredApprovals: 1}).Validate())
+}
+
+func TestPendingPaymentCheckDecision(t *testing.T) {
+	now := time.Now()
+	maker, approver := uuid.New(), uuid.New()
+	payment := PendingPayment{InitiatedBy: maker, Status: PendingPaymentPending, ExpiresAt: now.Add(time.Hour)}
+
+	assert.NoError(t, payment.CheckDecision(approver, now))
+	assert.Equal(t, ErrSelfApproval, payment.CheckDecision(maker, now))
+	assert.Equal(t, ErrPaymentExpired, payment.CheckDecision(approver, now.Add(time.Hour)))
+
+	payment.Status = PendingPaymentExecuted
+	assert.Equal(t, ErrPaymentNotPending, payment.CheckDecision(approver, now))
+}
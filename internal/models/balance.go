@@ -11,9 +11,11 @@ import (
 
 type Balance struct {
 	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID    uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_user_currency" json:"user_id"`
-	Currency  string         `gorm:"type:varchar(3);not null;uniqueIndex:idx_user_currency" json:"currency"`
-	Amount    float64        `gorm:"type:decimal(20,2);not null;default:0" json:"amount"`
+	AccountID uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex" json:"account_id"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null;index:idx_user_currency" json:"user_id"`
+	Currency  string         `gorm:"type:varchar(3);not null;index:idx_user_currency" json:"currency"`
+	Amount    float64        `gorm:"type:decimal(20,2);not null;default:0" json:"amount"` // ledger balance
+	Held      float64        `gorm:"type:decimal(20,2);not null;default:0" json:"held"`   // reserved by active holds
 	UpdatedAt time.Time      `json:"updated_at"`
 	CreatedAt time.Time      `json:"created_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -35,15 +37,38 @@ func (b *Balance) Add(amount float64) {
 	b.Amount += amount
 }
 
-// Subtract subtracts amount from the balance
+// Available returns the ledger balance minus the funds reserved by holds
+func (b *Balance) Available() float64 {
+	return b.Amount - b.Held
+}
+
+// Subtract subtracts amount from the balance, which must be covered by the
+// available funds
 func (b *Balance) Subtract(amount float64) error {
-	if b.Amount < amount {
+	if b.Available() < amount {
 		return ErrInsufficientFunds
 	}
 	b.Amount -= amount
 	return nil
 }
 
+// Hold reserves amount of the available funds
+func (b *Balance) Hold(amount float64) error {
+	if b.Available() < amount {
+		return ErrInsufficientFunds
+	}
+	b.Held += amount
+	return nil
+}
+
+// ReleaseHold returns previously reserved funds to the available balance
+func (b *Balance) ReleaseHold(amount float64) {
+	b.Held -= amount
+	if b.Held < 0 {
+		b.Held = 0
+	}
+}
+
 // GetBalanceAtTime returns the balance at a specific point in time
 // This is used for historical balance queries
 type BalanceSnapshot struct {
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBalanceHolds(t *testing.T) {
+	balance := &Balance{Amount: 100}
+
+	assert.NoError(t, balance.Hold(60))
+	assert.Equal(t, 100.0, balance.Amount)
+	assert.Equal(t, 40.0, balance.Available())
+
+	// Debits and further holds are checked against available funds
+	assert.Equal(t, ErrInsufficientFunds, balance.Subtract(50))
+	assert.Equal(t, ErrInsufficientFunds, balance.Hold(50))
+	assert.NoError(t, balance.Subtract(40))
+	assert.Equal(t, 0.0, balance.Available())
+
+	balance.ReleaseHold(60)
+	assert.Equal(t, 60.0, balance.Available())
+	balance.ReleaseHold(10)
+	assert.Equal(t, 0.0, balance.Held)
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BankFileFormat string
+
+const (
+	BankFileCSV     BankFileFormat = "csv"
+	BankFileCamt054 BankFileFormat = "camt.054"
+)
+
+type BankEntryStatus string
+
+const (
+	// BankEntryBooked entries were matched to an account and deposited
+	BankEntryBooked BankEntryStatus = "booked"
+	// BankEntrySuspense entries wait for an admin to allocate them
+	BankEntrySuspense BankEntryStatus = "suspense"
+	// BankEntryAllocated entries were deposited to the account an admin chose
+	BankEntryAllocated BankEntryStatus = "allocated"
+)
+
+// accountNumberPattern finds our account numbers in remittance information
+var accountNumberPattern = regexp.MustCompile(fmt.Sprintf(`%s\d{2}%s\d{%d}`, accountCountryCode, accountBankCode, accountDigits))
+
+// BankFile is an imported bank notification file with the outcome per entry
+type BankFile struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Format     BankFileFormat `gorm:"type:varchar(20);not null" json:"format"`
+	Filename   string         `gorm:"type:varchar(255)" json:"filename"`
+	Checksum   string         `gorm:"type:varchar(64);not null;index" json:"checksum"` // SHA-256 of the file
+	ImportedBy uuid.UUID      `gorm:"type:uuid;not null" json:"imported_by"`
+	Entries    int            `gorm:"not null;default:0" json:"entries"`    // credit entries in the file
+	Booked     int            `gorm:"not null;default:0" json:"booked"`     // matched and deposited
+	Suspense   int            `gorm:"not null;default:0" json:"suspense"`   // left for manual allocation
+	Duplicates int            `gorm:"not null;default:0" json:"duplicates"` // imported before
+	CreatedAt  time.Time      `json:"created_at"`
+
+	// Relationships
+	BankEntries []BankEntry `gorm:"foreignKey:FileID" json:"bank_entries,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (f *BankFile) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
+
+// BankEntry is an incoming payment reported by the bank. EntryID is the
+// bank's unique reference, so importing the same entry twice books it once.
+type BankEntry struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FileID         uuid.UUID       `gorm:"type:uuid;not null;index" json:"file_id"`
+	EntryID        string          `gorm:"type:varchar(100);uniqueIndex;not null" json:"entry_id"`
+	BookingDate    time.Time       `gorm:"type:date;not null" json:"booking_date"`
+	Amount         float64         `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Currency       string          `gorm:"type:varchar(3);not null" json:"currency"`
+	Reference      string          `gorm:"type:text" json:"reference"` // remittance information
+	DebtorName     string          `gorm:"type:varchar(140)" json:"debtor_name,omitempty"`
+	DebtorAccount  string          `gorm:"type:varchar(34)" json:"debtor_account,omitempty"`
+	Status         BankEntryStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	SuspenseReason string          `gorm:"type:text" json:"suspense_reason,omitempty"`
+	AccountID      *uuid.UUID      `gorm:"type:uuid" json:"account_id,omitempty"`
+	UserID         *uuid.UUID      `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	TransactionID  *uuid.UUID      `gorm:"type:uuid" json:"transaction_id,omitempty"`
+	AllocatedBy    *uuid.UUID      `gorm:"type:uuid" json:"allocated_by,omitempty"`
+	AllocatedAt    *time.Time      `json:"allocated_at,omitempty"`
+	Note           string          `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (e *BankEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks if the entry is valid
+func (e *BankEntry) Validate() error {
+	if strings.TrimSpace(e.EntryID) == "" || len(e.EntryID) > 100 {
+		return ErrInvalidBankEntryID
+	}
+	if e.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if len(e.Currency) != 3 {
+		return ErrInvalidCurrency
+	}
+	return nil
+}
+
+// AccountNumber returns the first valid account number in the reference.
+// Payers often group the number in blocks, so spaces and hyphens are ignored.
+func (e *BankEntry) AccountNumber() string {
+	compact := strings.NewReplacer(" ", "", "-", "").Replace(strings.ToUpper(e.Reference))
+	for _, number := range accountNumberPattern.FindAllString(compact, -1) {
+		if ValidAccountNumber(number) {
+			return number
+		}
+	}
+	return ""
+}
+
+// Deposit builds the deposit of the entry into account
+func (e *BankEntry) Deposit(account *Account, initiatedBy uuid.UUID) *Transaction {
+	description := "Bank transfer"
+	if e.DebtorName != "" {
+		description += " from " + e.DebtorName
+	}
+	if e.Reference != "" {
+		description += ": " + e.Reference
+	}
+	return &Transaction{
+		UserID:               account.UserID,
+		Type:                 TransactionTypeDeposit,
+		Amount:               e.Amount,
+		Currency:             e.Currency,
+		Description:          description,
+		DestinationAccountID: &account.ID,
+		InitiatedBy:          &initiatedBy,
+	}
+}
+
+// Custom errors
+var (
+	ErrInvalidBankEntryID      = errors.New("bank entry ID is required and at most 100 characters")
+	ErrInvalidCurrency         = errors.New("currency must be a 3 letter code")
+	ErrUnsupportedBankFile     = errors.New("bank file format must be csv or camt.054")
+	ErrEmptyBankFile           = errors.New("bank file contains no entries")
+	ErrNoAccountReference      = errors.New("reference contains no account number")
+	ErrUnknownAccountReference = errors.New("account number in the reference does not exist")
+	ErrBankEntryNotInSuspense  = errors.New("bank entry is not in suspense")
+	ErrAllocationNoteRequired  = errors.New("a note is required to allocate a bank entry")
+)
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBankEntryAccountNumber(t *testing.T) {
+	number, err := NewAccountNumber()
+	assert.NoError(t, err)
+	grouped := strings.ToLower(number[:4] + " " + number[4:8] + " " + number[8:12] + " " + number[12:])
+	wrongCheck := number[:2] + "00" + number[4:]
+	if ValidAccountNumber(wrongCheck) {
+		wrongCheck = number[:2] + "01" + number[4:]
+	}
+
+	tests := []struct {
+		name      string
+		reference string
+		want      string
+	}{
+		{"plain", "Top up " + number, number},
+		{"grouped lower case", "top up " + grouped, number},
+		{"hyphenated", "Ref " + number[:8] + "-" + number[8:], number},
+		{"wrong check digits", "Top up " + wrongCheck, ""},
+		{"other bank", "DE89370400440532013000", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := BankEntry{Reference: tt.reference}
+			assert.Equal(t, tt.want, entry.AccountNumber())
+		})
+	}
+}
+
+func TestBankEntryDeposit(t *testing.T) {
+	account := &Account{ID: uuid.New(), UserID: uuid.New(), Currency: "EUR"}
+	adminID := uuid.New()
+	entry := BankEntry{EntryID: "E1", Amount: 25, Currency: "EUR", Reference: "Rent", DebtorName: "Jane Doe"}
+
+	deposit := entry.Deposit(account, adminID)
+	assert.Equal(t, TransactionTypeDeposit, deposit.Type)
+	assert.Equal(t, account.UserID, deposit.UserID)
+	assert.Equal(t, &account.ID, deposit.DestinationAccountID)
+	assert.Equal(t, &adminID, deposit.InitiatedBy)
+	assert.Equal(t, "Bank transfer from Jane Doe: Rent", deposit.Description)
+	assert.NoError(t, deposit.Validate())
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BeneficiaryType string
+
+const (
+	// BeneficiaryInternal is a customer of this bank, paid by transfer
+	BeneficiaryInternal BeneficiaryType = "internal"
+	// BeneficiaryExternal is an account at another bank, paid by payout
+	BeneficiaryExternal BeneficiaryType = "external"
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{6,15}$`)
+
+// Beneficiary is a payee saved in a user's beneficiary book. It is given by
+// exactly one of an IBAN, an email or a phone number; IBANs of this bank,
+// emails and phone numbers are resolved to the customer when it is saved.
+type Beneficiary struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID       `gorm:"type:uuid;not null;index" json:"user_id"`
+	Nickname    string          `gorm:"type:varchar(50);not null" json:"nickname"` // unique per user, ignoring case
+	Type        BeneficiaryType `gorm:"type:varchar(20);not null" json:"type"`
+	IBAN        string          `gorm:"column:iban;type:varchar(34)" json:"iban,omitempty"`
+	BIC         string          `gorm:"column:bic;type:varchar(11)" json:"bic,omitempty"`
+	Name        string          `gorm:"type:varchar(140)" json:"name,omitempty"` // account holder
+	Email       string          `gorm:"type:varchar(255)" json:"email,omitempty"`
+	Phone       string          `gorm:"type:varchar(32)" json:"phone,omitempty"`
+	RecipientID *uuid.UUID      `gorm:"type:uuid;index" json:"recipient_id,omitempty"` // internal beneficiaries
+	AccountID   *uuid.UUID      `gorm:"type:uuid" json:"account_id,omitempty"`         // credited account when given by IBAN
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (b *Beneficiary) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+// Normalize removes formatting from the identifiers
+func (b *Beneficiary) Normalize() {
+	b.Nickname = strings.TrimSpace(b.Nickname)
+	b.IBAN = NormalizeIBAN(b.IBAN)
+	b.BIC = NormalizeIBAN(b.BIC)
+	b.Name = strings.TrimSpace(b.Name)
+	b.Email = NormalizeEmail(b.Email)
+	b.Phone = NormalizePhone(b.Phone)
+}
+
+// Validate checks if the beneficiary is valid and sets its type
+func (b *Beneficiary) Validate() error {
+	if b.Nickname == "" || len(b.Nickname) > 50 {
+		return ErrInvalidNickname
+	}
+	given := 0
+	for _, identifier := range []string{b.IBAN, b.Email, b.Phone} {
+		if identifier != "" {
+			given++
+		}
+	}
+	if given != 1 {
+		return ErrBeneficiaryIdentifier
+	}
+
+	switch {
+	case b.IBAN != "" && !InternalIBAN(b.IBAN):
+		b.Type = BeneficiaryExternal
+		return b.Destination().Validate()
+	case b.IBAN != "" && !ValidIBAN(b.IBAN):
+		return ErrInvalidIBAN
+	case b.Phone != "" && !phonePattern.MatchString(b.Phone):
+		return ErrInvalidPhone
+	case b.Email != "" && !strings.Contains(b.Email, "@"):
+		return ErrInvalidEmail
+	}
+	if len(b.Name) > 140 {
+		return ErrInvalidCreditorName
+	}
+	b.Type = BeneficiaryInternal
+	return nil
+}
+
+// Destination returns the external account of the beneficiary
+func (b *Beneficiary) Destination() PayoutDestination {
+	return PayoutDestination{IBAN: b.IBAN, BIC: b.BIC, Name: b.Name}
+}
+
+// Transaction builds a payment to the beneficiary: a transfer to a customer of
+// this bank, or a withdrawal paid out to an external account
+func (b *Beneficiary) Transaction(amount float64, currency, description string) *Transaction {
+	if b.Type == BeneficiaryExternal {
+		return &Transaction{
+			Type:        TransactionTypeWithdraw,
+			Amount:      amount,
+			Currency:    currency,
+			Description: description,
+			Payout:      NewPayout(b.Destination()),
+		}
+	}
+	return &Transaction{
+		Type:                 TransactionTypeTransfer,
+		Amount:               amount,
+		Currency:             currency,
+		Description:          description,
+		RecipientID:          b.RecipientID,
+		DestinationAccountID: b.AccountID,
+	}
+}
+
+// NormalizePhone removes the separators phone numbers are often written with
+func NormalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '/':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+}
+
+// Custom errors
+var (
+	ErrInvalidNickname       = errors.New("nickname is required and at most 50 characters")
+	ErrBeneficiaryIdentifier = errors.New("give exactly one of iban, email or phone")
+	ErrInvalidPhone          = errors.New("invalid phone number")
+	ErrInvalidEmail          = errors.New("invalid email")
+	ErrDuplicateNickname     = errors.New("a beneficiary with this nickname already exists")
+	ErrBeneficiaryNotFound   = errors.New("no customer found for this IBAN, email or phone number")
+	ErrSelfBeneficiary       = errors.New("you cannot save yourself as beneficiary, move money between your accounts instead")
+)
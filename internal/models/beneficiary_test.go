@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeneficiaryValidate(t *testing.T) {
+	internal, err := NewAccountNumber()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		beneficiary Beneficiary
+		wantType    BeneficiaryType
+		wantErr     error
+	}{
+		{"external IBAN", Beneficiary{Nickname: "Landlord", IBAN: "de89 3704 0044 0532 0130 00", Name: "Jane Doe"}, BeneficiaryExternal, nil},
+		{"external IBAN without name", Beneficiary{Nickname: "Landlord", IBAN: "DE89370400440532013000"}, "", ErrInvalidCreditorName},
+		{"external IBAN with bad BIC", Beneficiary{Nickname: "Landlord", IBAN: "DE89370400440532013000", BIC: "COBA1", Name: "Jane Doe"}, "", ErrInvalidBIC},
+		{"IBAN with wrong length", Beneficiary{Nickname: "Landlord", IBAN: "DE8937040044053201300", Name: "Jane Doe"}, "", ErrInvalidIBAN},
+		{"own bank IBAN", Beneficiary{Nickname: "Sister", IBAN: internal}, BeneficiaryInternal, nil},
+		{"own bank IBAN with wrong check digits", Beneficiary{Nickname: "Sister", IBAN: "XT00" + internal[4:]}, "", ErrInvalidIBAN},
+		{"email", Beneficiary{Nickname: "Friend", Email: " Friend@Example.com "}, BeneficiaryInternal, nil},
+		{"phone", Beneficiary{Nickname: "Friend", Phone: "+49 (151) 234-56789"}, BeneficiaryInternal, nil},
+		{"bad phone", Beneficiary{Nickname: "Friend", Phone: "call me"}, "", ErrInvalidPhone},
+		{"two identifiers", Beneficiary{Nickname: "Friend", Email: "friend@example.com", Phone: "+4915123456789"}, "", ErrBeneficiaryIdentifier},
+		{"no identifier", Beneficiary{Nickname: "Friend"}, "", ErrBeneficiaryIdentifier},
+		{"missing nickname", Beneficiary{Nickname: " ", Email: "friend@example.com"}, "", ErrInvalidNickname},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.beneficiary
+			b.Normalize()
+			assert.Equal(t, tt.wantErr, b.Validate())
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.wantType, b.Type)
+			}
+		})
+	}
+}
+
+func TestBeneficiaryTransaction(t *testing.T) {
+	recipient := uuid.New()
+	account := uuid.New()
+	internal := Beneficiary{Type: BeneficiaryInternal, RecipientID: &recipient, AccountID: &account}
+	tx := internal.Transaction(20, "EUR", "Lunch")
+	assert.Equal(t, TransactionTypeTransfer, tx.Type)
+	assert.Equal(t, &recipient, tx.RecipientID)
+	assert.Equal(t, &account, tx.DestinationAccountID)
+	assert.Nil(t, tx.Payout)
+
+	external := Beneficiary{Type: BeneficiaryExternal, IBAN: "DE89370400440532013000", Name: "Jane Doe"}
+	tx = external.Transaction(700, "EUR", "Rent")
+	assert.Equal(t, TransactionTypeWithdraw, tx.Type)
+	assert.Nil(t, tx.RecipientID)
+	if assert.NotNil(t, tx.Payout) {
+		assert.Equal(t, external.Destination(), tx.Payout.Destination())
+	}
+}
@@ -0,0 +1,219 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultChangeRequestTTL is how long a change request waits for approval
+const DefaultChangeRequestTTL = 24 * time.Hour
+
+// ChangeAction is a sensitive admin operation that needs a second admin's
+// approval before it is applied
+type ChangeAction string
+
+const (
+	ChangeActionRoleChange     ChangeAction = "user.role_change"
+	ChangeActionPasswordChange ChangeAction = "user.password_change"
+	ChangeActionEmailChange    ChangeAction = "user.email_change"
+	ChangeActionUserDeletion   ChangeAction = "user.deletion"
+	ChangeActionUserErasure    ChangeAction = "user.erasure"
+	ChangeActionReversal       ChangeAction = "transaction.reversal"
+	ChangeActionAdjustment     ChangeAction = "user.adjustment"
+	ChangeActionHoldCapture    ChangeAction = "hold.capture"
+	ChangeActionAdminCreation  ChangeAction = "user.admin_creation"
+)
+
+// TargetType returns the kind of record the action applies to
+func (a ChangeAction) TargetType() string {
+	target, _, _ := strings.Cut(string(a), ".")
+	return target
+}
+
+// Valid reports whether the action is known
+func (a ChangeAction) Valid() bool {
+	switch a {
+	case ChangeActionRoleChange, ChangeActionPasswordChange, ChangeActionEmailChange, ChangeActionUserDeletion,
+		ChangeActionUserErasure, ChangeActionReversal, ChangeActionAdjustment, ChangeActionHoldCapture,
+		ChangeActionAdminCreation:
+		return true
+	default:
+		return false
+	}
+}
+
+type ChangeRequestStatus string
+
+const (
+	ChangeRequestPending   ChangeRequestStatus = "pending"
+	ChangeRequestApproved  ChangeRequestStatus = "approved" // approved, being applied
+	ChangeRequestExecuted  ChangeRequestStatus = "executed"
+	ChangeRequestFailed    ChangeRequestStatus = "failed"
+	ChangeRequestRejected  ChangeRequestStatus = "rejected"
+	ChangeRequestCancelled ChangeRequestStatus = "cancelled"
+	ChangeRequestExpired   ChangeRequestStatus = "expired"
+)
+
+// ChangeRequest holds back a sensitive admin action until a different admin
+// approved it
+type ChangeRequest struct {
+	ID              uuid.UUID           `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Action          ChangeAction        `gorm:"type:varchar(40);not null" json:"action"`
+	TargetID        uuid.UUID           `gorm:"type:uuid;not null;index" json:"target_id"`
+	Payload         string              `gorm:"type:jsonb;not null;default:'{}'" json:"payload"`
+	Secret          string              `gorm:"type:text" json:"-"` // password hash of a password change, cleared once closed
+	Reason          string              `gorm:"type:text;not null" json:"reason"`
+	Status          ChangeRequestStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	RequestedBy     uuid.UUID           `gorm:"type:uuid;not null" json:"requested_by"`
+	DecidedBy       *uuid.UUID          `gorm:"type:uuid" json:"decided_by,omitempty"`
+	DecisionComment string              `gorm:"type:text" json:"decision_comment,omitempty"`
+	DecidedAt       *time.Time          `json:"decided_at,omitempty"`
+	Error           string              `gorm:"type:text" json:"error,omitempty"`
+	ExpiresAt       time.Time           `gorm:"not null" json:"expires_at"`
+	ClosedAt        *time.Time          `json:"closed_at,omitempty"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+
+	// Relationships
+	AuditTrail []AuditLog `gorm:"foreignKey:ChangeRequestID" json:"audit_trail,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *ChangeRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// RoleChange is the payload of a role change
+type RoleChange struct {
+	Role string `json:"role"`
+}
+
+// EmailChange is the payload of an email change
+type EmailChange struct {
+	Email string `json:"email"`
+}
+
+// AdminCreation is the payload of the creation of an admin; the request's
+// target is the ID the admin gets
+type AdminCreation struct {
+	Email string `json:"email"`
+}
+
+// HoldCapture is the payload of a capture of a hold with a recipient
+type HoldCapture struct {
+	Amount float64 `json:"amount"`
+}
+
+// NewChangeRequest requests an action on a target with its JSON payload
+func NewChangeRequest(action ChangeAction, targetID uuid.UUID, payload interface{}, reason string, requestedBy uuid.UUID, expiresAt time.Time) (*ChangeRequest, error) {
+	data := []byte("{}")
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+	return &ChangeRequest{
+		Action:      action,
+		TargetID:    targetID,
+		Payload:     string(data),
+		Reason:      strings.TrimSpace(reason),
+		Status:      ChangeRequestPending,
+		RequestedBy: requestedBy,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// Validate checks if the change request is valid
+func (r *ChangeRequest) Validate() error {
+	if !r.Action.Valid() {
+		return ErrInvalidChangeAction
+	}
+	if r.Reason == "" {
+		return ErrChangeReasonRequired
+	}
+	return nil
+}
+
+// DecodePayload reads the request's payload into v
+func (r *ChangeRequest) DecodePayload(v interface{}) error {
+	return json.Unmarshal([]byte(r.Payload), v)
+}
+
+// CheckDecision verifies adminID can still approve or reject the request. The
+// admin who made it cannot, nor can the admin a user change applies to.
+func (r *ChangeRequest) CheckDecision(adminID uuid.UUID, now time.Time) error {
+	if r.Status != ChangeRequestPending {
+		return ErrChangeRequestNotPending
+	}
+	if !now.Before(r.ExpiresAt) {
+		return ErrChangeRequestExpired
+	}
+	if adminID == r.RequestedBy || (r.Action.TargetType() == AggregateUser && adminID == r.TargetID) {
+		return ErrChangeSelfApproval
+	}
+	return nil
+}
+
+// Audit trail actions
+const (
+	AuditChangeRequested = "change_request.requested"
+	AuditChangeApproved  = "change_request.approved"
+	AuditChangeRejected  = "change_request.rejected"
+	AuditChangeCancelled = "change_request.cancelled"
+	AuditChangeExpired   = "change_request.expired"
+	AuditChangeExecuted  = "change_request.executed"
+	AuditChangeFailed    = "change_request.failed"
+)
+
+// AuditLog is an append-only record of an admin action
+type AuditLog struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ActorID         *uuid.UUID `gorm:"type:uuid;index" json:"actor_id,omitempty"` // empty for the system
+	Action          string     `gorm:"type:varchar(50);not null" json:"action"`
+	ChangeRequestID *uuid.UUID `gorm:"type:uuid;index" json:"change_request_id,omitempty"`
+	TargetType      string     `gorm:"type:varchar(30);not null" json:"target_type"`
+	TargetID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"target_id"`
+	Comment         string     `gorm:"type:text" json:"comment,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (l *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewAuditLog records an action an actor took on a change request
+func (r *ChangeRequest) NewAuditLog(action string, actorID *uuid.UUID, comment string) *AuditLog {
+	return &AuditLog{
+		ActorID:         actorID,
+		Action:          action,
+		ChangeRequestID: &r.ID,
+		TargetType:      r.Action.TargetType(),
+		TargetID:        r.TargetID,
+		Comment:         comment,
+	}
+}
+
+// Custom errors
+var (
+	ErrInvalidChangeAction     = errors.New("invalid change action")
+	ErrChangeReasonRequired    = errors.New("a reason is required")
+	ErrChangeRequestNotPending = errors.New("change request is no longer pending")
+	ErrChangeRequestExpired    = errors.New("change request has expired")
+	ErrChangeSelfApproval      = errors.New("changes must be decided by an admin other than the requester and the user affected")
+	ErrChangeRequestNotOwned   = errors.New("only the admin who requested a change can cancel it")
+	ErrInvalidRole             = errors.New("role must be user or admin")
+	ErrEmailInUse              = errors.New("email address is already in use")
+)
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeRequestCheckDecision(t *testing.T) {
+	now := time.Now()
+	maker, checker, target := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		action  ChangeAction
+		status  ChangeRequestStatus
+		adminID uuid.UUID
+		at      time.Time
+		want    error
+	}{
+		{"other admin", ChangeActionRoleChange, ChangeRequestPending, checker, now, nil},
+		{"requester", ChangeActionRoleChange, ChangeRequestPending, maker, now, ErrChangeSelfApproval},
+		{"affected user", ChangeActionPasswordChange, ChangeRequestPending, target, now, ErrChangeSelfApproval},
+		{"transaction target is not a user", ChangeActionReversal, ChangeRequestPending, target, now, nil},
+		{"email change of the affected user", ChangeActionEmailChange, ChangeRequestPending, target, now, ErrChangeSelfApproval},
+		{"hold capture by requester", ChangeActionHoldCapture, ChangeRequestPending, maker, now, ErrChangeSelfApproval},
+		{"admin creation by requester", ChangeActionAdminCreation, ChangeRequestPending, maker, now, ErrChangeSelfApproval},
+		{"admin creation by other admin", ChangeActionAdminCreation, ChangeRequestPending, checker, now, nil},
+		{"expired", ChangeActionUserDeletion, ChangeRequestPending, checker, now.Add(time.Hour), ErrChangeRequestExpired},
+		{"already decided", ChangeActionUserDeletion, ChangeRequestRejected, checker, now, ErrChangeRequestNotPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := ChangeRequest{
+				Action:      tt.action,
+				TargetID:    target,
+				Status:      tt.status,
+				RequestedBy: maker,
+				ExpiresAt:   now.Add(time.Hour),
+			}
+			assert.Equal(t, tt.want, request.CheckDecision(tt.adminID, tt.at))
+		})
+	}
+}
+
+func TestNewChangeRequest(t *testing.T) {
+	request, err := NewChangeRequest(ChangeActionRoleChange, uuid.New(), RoleChange{Role: "admin"}, "  promoted to operations  ", uuid.New(), time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, request.Validate())
+	assert.Equal(t, "promoted to operations", request.Reason)
+	assert.Equal(t, "user", request.Action.TargetType())
+
+	var change RoleChange
+	assert.NoError(t, request.DecodePayload(&change))
+	assert.Equal(t, "admin", change.Role)
+
+	request, err = NewChangeRequest(ChangeActionUserDeletion, uuid.New(), nil, " ", uuid.New(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", request.Payload)
+	assert.Equal(t, ErrChangeReasonRequired, request.Validate())
+
+	request.Action = "user.promote"
+	assert.Equal(t, ErrInvalidChangeAction, request.Validate())
+}
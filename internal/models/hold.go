@@ -0,0 +1,117 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "active"
+	HoldCaptured HoldStatus = "captured"
+	HoldReleased HoldStatus = "released"
+	HoldExpired  HoldStatus = "expired"
+)
+
+const (
+	// DefaultHoldTTL is how long funds stay reserved when no expiry is given
+	DefaultHoldTTL = 7 * 24 * time.Hour
+	// MaxHoldTTL caps how long funds may be reserved
+	MaxHoldTTL = 30 * 24 * time.Hour
+)
+
+// Hold reserves funds on a balance before the final amount is known, like a
+// card authorization. It reduces the available balance but not the ledger
+// balance until it is captured, which creates the real transaction.
+type Hold struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID         uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	AccountID      *uuid.UUID     `gorm:"type:uuid" json:"account_id,omitempty"` // account the funds are reserved on, the main account when not given
+	Currency       string         `gorm:"type:varchar(3);not null" json:"currency"`
+	Amount         float64        `gorm:"type:decimal(20,2);not null" json:"amount"`
+	CapturedAmount float64        `gorm:"type:decimal(20,2);not null;default:0" json:"captured_amount"`
+	RecipientID    *uuid.UUID     `gorm:"type:uuid" json:"recipient_id,omitempty"` // captured as a transfer when set, otherwise as a withdrawal
+	Reference      string         `gorm:"type:varchar(100)" json:"reference"`
+	Description    string         `gorm:"type:text" json:"description"`
+	Status         HoldStatus     `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	ExpiresAt      time.Time      `gorm:"not null;index" json:"expires_at"`
+	TransactionID  *uuid.UUID     `gorm:"type:uuid" json:"transaction_id,omitempty"`
+	CreatedBy      uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	ClosedAt       *time.Time     `json:"closed_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (h *Hold) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks if the hold is valid
+func (h *Hold) Validate() error {
+	if h.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if h.RecipientID != nil && *h.RecipientID == h.UserID {
+		return ErrSelfTransfer
+	}
+	return nil
+}
+
+// TransactionType is the type of the transaction created on capture
+func (h *Hold) TransactionType() TransactionType {
+	if h.RecipientID != nil {
+		return TransactionTypeTransfer
+	}
+	return TransactionTypeWithdraw
+}
+
+// Transaction is the transaction capturing amount of the hold creates
+func (h *Hold) Transaction(amount float64) *Transaction {
+	description := h.Description
+	if description == "" {
+		description = "Capture of hold " + h.Reference
+	}
+	return &Transaction{
+		UserID:          h.UserID,
+		Type:            h.TransactionType(),
+		Amount:          amount,
+		Currency:        h.Currency,
+		RecipientID:     h.RecipientID,
+		Description:     description,
+		SourceAccountID: h.AccountID,
+	}
+}
+
+// CheckCapture verifies the hold can be captured for amount at the given time
+func (h *Hold) CheckCapture(amount float64, now time.Time) error {
+	if h.Status != HoldActive {
+		return ErrHoldNotActive
+	}
+	if !now.Before(h.ExpiresAt) {
+		return ErrHoldExpired
+	}
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if amount > h.Amount {
+		return ErrCaptureExceedsHold
+	}
+	return nil
+}
+
+// Custom errors
+var (
+	ErrHoldNotActive      = errors.New("hold is no longer active")
+	ErrHoldExpired        = errors.New("hold has expired")
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds the held amount")
+	ErrInvalidHoldTTL     = errors.New("expiry must be in the future and within 30 days")
+)
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoldCheckCapture(t *testing.T) {
+	now := time.Now()
+	active := Hold{Amount: 50, Status: HoldActive, ExpiresAt: now.Add(time.Hour)}
+	expired := Hold{Amount: 50, Status: HoldActive, ExpiresAt: now}
+	released := Hold{Amount: 50, Status: HoldReleased, ExpiresAt: now.Add(time.Hour)}
+
+	tests := []struct {
+		name   string
+		hold   Hold
+		amount float64
+		err    error
+	}{
+		{"full capture", active, 50, nil},
+		{"partial capture", active, 20.5, nil},
+		{"more than held", active, 50.01, ErrCaptureExceedsHold},
+		{"zero amount", active, 0, ErrInvalidAmount},
+		{"expired", expired, 10, ErrHoldExpired},
+		{"not active", released, 10, ErrHoldNotActive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.err, tt.hold.CheckCapture(tt.amount, now))
+		})
+	}
+}
+
+func TestHoldTransaction(t *testing.T) {
+	recipient := uuid.New()
+	hold := Hold{UserID: uuid.New(), Amount: 50, Currency: "EUR", RecipientID: &recipient, Reference: "AUTH-1"}
+
+	transaction := hold.Transaction(30)
+	assert.Equal(t, TransactionTypeTransfer, transaction.Type)
+	assert.Equal(t, hold.UserID, transaction.UserID)
+	assert.Equal(t, 30.0, transaction.Amount)
+	assert.Equal(t, &recipient, transaction.RecipientID)
+	assert.Equal(t, "Capture of hold AUTH-1", transaction.Description)
+
+	hold.RecipientID = nil
+	hold.Description = "Hotel"
+	transaction = hold.Transaction(50)
+	assert.Equal(t, TransactionTypeWithdraw, transaction.Type)
+	assert.Equal(t, "Hotel", transaction.Description)
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	bicPattern  = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+)
+
+// ibanLengths is the IBAN length of each country in the SWIFT IBAN registry,
+// and of our own account numbers
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BI": 27, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24,
+	"DE": 22, "DJ": 27, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18,
+	"FK": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27,
+	"GT": 28, "HN": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26,
+	"IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20,
+	"LU": 20, "LV": 21, "LY": 25, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MN": 20,
+	"MR": 27, "MT": 31, "MU": 30, "NI": 28, "NL": 18, "NO": 15, "OM": 23, "PK": 24,
+	"PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "RU": 33, "SA": 24,
+	"SC": 31, "SD": 18, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "SO": 23, "ST": 25,
+	"SV": 28, "TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+	"YE": 30,
+
+	// country code, check digits, bank code and account part
+	accountCountryCode: len(accountCountryCode) + 2 + len(accountBankCode) + accountDigits,
+}
+
+// NormalizeIBAN removes the spaces IBANs and BICs are often written with
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(iban), " ", ""))
+}
+
+// ValidIBAN checks the format, the length for the country and the check
+// digits of a normalized IBAN
+func ValidIBAN(iban string) bool {
+	if !ibanPattern.MatchString(iban) {
+		return false
+	}
+	if length, ok := ibanLengths[iban[:2]]; !ok || len(iban) != length {
+		return false
+	}
+	return ValidAccountNumber(iban)
+}
+
+// ValidBIC checks the format of a normalized BIC: bank code, country code,
+// location code and an optional branch code
+func ValidBIC(bic string) bool {
+	return bicPattern.MatchString(bic)
+}
+
+// InternalIBAN reports whether an IBAN is an account number of this bank
+func InternalIBAN(iban string) bool {
+	return strings.HasPrefix(iban, accountCountryCode)
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidIBAN(t *testing.T) {
+	internal, err := NewAccountNumber()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		iban string
+		want bool
+	}{
+		{"germany", "DE89370400440532013000", true},
+		{"united kingdom", "GB82WEST12345698765432", true},
+		{"norway", "NO9386011117947", true},
+		{"own account number", internal, true},
+		{"wrong check digits", "DE88370400440532013000", false},
+		{"too short for the country", "DE8937040044053201300", false},
+		{"too long for the country", "NO93860111179470", false},
+		{"unknown country", "ZZ89370400440532013000", false},
+		{"lower case", "de89370400440532013000", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ValidIBAN(tt.iban))
+		})
+	}
+}
+
+func TestValidBIC(t *testing.T) {
+	assert.True(t, ValidBIC("COBADEFF"))
+	assert.True(t, ValidBIC("COBADEFFXXX"))
+	assert.False(t, ValidBIC("COBADEF"))
+	assert.False(t, ValidBIC("COBADEFFXX"))
+	assert.False(t, ValidBIC("COB4DEFF"))
+	assert.Equal(t, "COBADEFFXXX", NormalizeIBAN(" coba deff xxx "))
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DayCount is the day-count convention turning an annual rate into a daily one
+type DayCount string
+
+const (
+	// DayCountActual365 divides by 365 every year (ACT/365 Fixed)
+	DayCountActual365 DayCount = "ACT/365"
+	// DayCountActual360 divides by 360, the money market convention
+	DayCountActual360 DayCount = "ACT/360"
+	// DayCountActualActual divides by the actual number of days in the year
+	DayCountActualActual DayCount = "ACT/ACT"
+)
+
+// DaysInYear returns the year basis for a day under the convention
+func (d DayCount) DaysInYear(day time.Time) float64 {
+	switch d {
+	case DayCountActual360:
+		return 360
+	case DayCountActualActual:
+		year := day.Year()
+		if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+			return 366
+		}
+		return 365
+	default:
+		return 365
+	}
+}
+
+// InterestRate sets the annual interest paid on balances in a currency from
+// EffectiveFrom on. Rates are never edited; a new rate with a later effective
+// date replaces the previous one, which keeps past accruals reproducible.
+type InterestRate struct {
+	ID            uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Currency      string        `gorm:"type:varchar(3);not null;uniqueIndex:idx_interest_rate_effective" json:"currency"`
+	EffectiveFrom time.Time     `gorm:"type:date;not null;uniqueIndex:idx_interest_rate_effective" json:"effective_from"`
+	Tiers         InterestTiers `gorm:"type:jsonb;not null" json:"tiers"`
+	DayCount      DayCount      `gorm:"type:varchar(10);not null" json:"day_count"`
+	CreatedBy     uuid.UUID     `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *InterestRate) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks if the interest rate is valid
+func (r *InterestRate) Validate() error {
+	switch r.DayCount {
+	case DayCountActual365, DayCountActual360, DayCountActualActual:
+	default:
+		return ErrInvalidDayCount
+	}
+	if len(r.Tiers) == 0 {
+		return ErrInvalidInterestTiers
+	}
+	for i, tier := range r.Tiers {
+		if tier.UpTo < 0 || tier.Rate < 0 || tier.Rate > 100 {
+			return ErrInvalidInterestTiers
+		}
+		if tier.UpTo == 0 && i != len(r.Tiers)-1 {
+			return ErrInvalidInterestTiers
+		}
+	}
+	return nil
+}
+
+// DailyInterest returns the unrounded interest earned by an end-of-day
+// balance on the given day
+func (r *InterestRate) DailyInterest(balance float64, day time.Time) float64 {
+	return r.Tiers.AnnualInterest(balance) / r.DayCount.DaysInYear(day)
+}
+
+// InterestTier pays Rate percent a year on the part of the balance up to
+// UpTo; the last tier may leave UpTo at 0 to cover the rest of the balance
+type InterestTier struct {
+	UpTo float64 `json:"up_to"`
+	Rate float64 `json:"rate"` // annual percentage, 2.5 means 2.5%
+}
+
+// InterestTiers is stored as a JSON array
+type InterestTiers []InterestTier
+
+// AnnualInterest returns a year's interest on balance, applying each tier's
+// rate to the band of the balance it covers. Balance above the last bounded
+// tier earns nothing unless an unbounded tier is configured.
+func (t InterestTiers) AnnualInterest(balance float64) float64 {
+	if balance <= 0 {
+		return 0
+	}
+	tiers := make(InterestTiers, len(t))
+	copy(tiers, t)
+	sort.SliceStable(tiers, func(i, j int) bool {
+		return tiers[j].UpTo == 0 || (tiers[i].UpTo != 0 && tiers[i].UpTo < tiers[j].UpTo)
+	})
+
+	var interest, lower float64
+	for _, tier := range tiers {
+		upper := tier.UpTo
+		if upper == 0 || upper > balance {
+			upper = balance
+		}
+		if upper > lower {
+			interest += (upper - lower) * tier.Rate / 100
+		}
+		if upper >= balance {
+			break
+		}
+		lower = upper
+	}
+	return interest
+}
+
+// Value implements driver.Valuer
+func (t InterestTiers) Value() (driver.Value, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (t *InterestTiers) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return fmt.Errorf("cannot scan %T into InterestTiers", value)
+	}
+}
+
+// InterestAccrual is the interest earned by a (user, currency) balance on one
+// day. Accruals are kept unrounded and posted as a deposit when the month is
+// capitalized; capitalized accruals are never recomputed.
+type InterestAccrual struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_interest_accrual_day" json:"user_id"`
+	Currency      string     `gorm:"type:varchar(3);not null;uniqueIndex:idx_interest_accrual_day" json:"currency"`
+	Date          time.Time  `gorm:"type:date;not null;uniqueIndex:idx_interest_accrual_day" json:"date"`
+	Balance       float64    `gorm:"type:decimal(20,2);not null" json:"balance"` // end-of-day ledger balance
+	Amount        float64    `gorm:"type:decimal(20,8);not null" json:"amount"`
+	RateID        uuid.UUID  `gorm:"type:uuid;not null" json:"rate_id"`
+	DayCount      DayCount   `gorm:"type:varchar(10);not null" json:"day_count"`
+	CapitalizedAt *time.Time `json:"capitalized_at,omitempty"`
+	TransactionID *uuid.UUID `gorm:"type:uuid" json:"transaction_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (a *InterestAccrual) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// InterestRun records that accrual completed for a day
+type InterestRun struct {
+	Date        time.Time `gorm:"type:date;primary_key" json:"date"`
+	Accruals    int       `gorm:"not null" json:"accruals"`
+	CompletedAt time.Time `gorm:"not null" json:"completed_at"`
+}
+
+// Custom errors
+var (
+	ErrInvalidDayCount      = errors.New("day_count must be one of ACT/365, ACT/360, ACT/ACT")
+	ErrInvalidInterestTiers = errors.New("interest tiers need rates between 0 and 100 and only the last tier may be unbounded")
+)
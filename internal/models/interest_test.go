@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterestTiersAnnualInterest(t *testing.T) {
+	tiers := InterestTiers{
+		{UpTo: 10000, Rate: 2},
+		{UpTo: 0, Rate: 1},
+	}
+
+	tests := []struct {
+		name     string
+		tiers    InterestTiers
+		balance  float64
+		expected float64
+	}{
+		{"within first tier", tiers, 5000, 100},
+		{"at tier bound", tiers, 10000, 200},
+		{"marginal above bound", tiers, 15000, 250},
+		{"negative balance", tiers, -100, 0},
+		{"capped without unbounded tier", InterestTiers{{UpTo: 1000, Rate: 3}}, 5000, 30},
+		{"zero rate band", InterestTiers{{UpTo: 1000, Rate: 0}, {UpTo: 0, Rate: 4}}, 2000, 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, tt.tiers.AnnualInterest(tt.balance), 1e-9)
+		})
+	}
+}
+
+func TestDayCountDaysInYear(t *testing.T) {
+	leap := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	regular := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	century := time.Date(1900, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 365.0, DayCountActual365.DaysInYear(leap))
+	assert.Equal(t, 360.0, DayCountActual360.DaysInYear(leap))
+	assert.Equal(t, 366.0, DayCountActualActual.DaysInYear(leap))
+	assert.Equal(t, 365.0, DayCountActualActual.DaysInYear(regular))
+	assert.Equal(t, 365.0, DayCountActualActual.DaysInYear(century))
+}
+
+func TestInterestRateDailyInterest(t *testing.T) {
+	rate := InterestRate{Tiers: InterestTiers{{Rate: 3.65}}, DayCount: DayCountActual365}
+	assert.InDelta(t, 1.0, rate.DailyInterest(10000, time.Now()), 1e-9)
+
+	rate.DayCount = DayCountActual360
+	assert.InDelta(t, 365.0/360.0, rate.DailyInterest(10000, time.Now()), 1e-9)
+}
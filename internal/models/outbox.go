@@ -0,0 +1,146 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Domain event types written to the outbox
+const (
+	EventTransactionCreated  = "transaction.created"
+	EventTransactionReversed = "transaction.reversed"
+	EventBalanceUpdated      = "balance.updated"
+	EventUserUpdated         = "user.updated"
+)
+
+// Aggregate types that domain events refer to
+const (
+	AggregateTransaction = "transaction"
+	AggregateBalance     = "balance"
+	AggregateUser        = "user"
+)
+
+// OutboxEvent is a domain event stored in the same database transaction as
+// the change it describes, and relayed to the event stream afterwards
+type OutboxEvent struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AggregateType string     `gorm:"type:varchar(50);not null" json:"aggregate_type"`
+	AggregateID   uuid.UUID  `gorm:"type:uuid;not null" json:"aggregate_id"`
+	EventType     string     `gorm:"type:varchar(50);not null" json:"event_type"`
+	Payload       string     `gorm:"type:jsonb;not null" json:"payload"`
+	CreatedAt     time.Time  `gorm:"index" json:"created_at"`
+	PublishedAt   *time.Time `json:"published_at,omitempty"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+}
+
+// TableName keeps the table name singular as in the outbox pattern
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (e *OutboxEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewOutboxEvent builds an outbox event with the given data as JSON payload
+func NewOutboxEvent(aggregateType string, aggregateID uuid.UUID, eventType string, data interface{}) (*OutboxEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       string(payload),
+	}, nil
+}
+
+// TransactionEventData is the payload of transaction events
+type TransactionEventData struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        TransactionType `json:"type"`
+	UserID      uuid.UUID       `json:"user_id"`
+	RecipientID *uuid.UUID      `json:"recipient_id,omitempty"`
+	Amount      float64         `json:"amount"`
+	Currency    string          `json:"currency"`
+	Description string          `json:"description"`
+	Status      string          `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+
+	SourceAccountID      *uuid.UUID `json:"source_account_id,omitempty"`
+	DestinationAccountID *uuid.UUID `json:"destination_account_id,omitempty"`
+	InitiatedBy          *uuid.UUID `json:"initiated_by,omitempty"`
+	ParentID             *uuid.UUID `json:"parent_id,omitempty"` // reversed transaction, or the one a fee was charged for
+}
+
+// NewTransactionEventData describes a transaction for event consumers
+func NewTransactionEventData(tx *Transaction) TransactionEventData {
+	return TransactionEventData{
+		ID:          tx.ID,
+		Type:        tx.Type,
+		UserID:      tx.UserID,
+		RecipientID: tx.RecipientID,
+		Amount:      tx.Amount,
+		Currency:    tx.Currency,
+		Description: tx.Description,
+		Status:      tx.Status,
+		CreatedAt:   tx.CreatedAt,
+
+		SourceAccountID:      tx.SourceAccountID,
+		DestinationAccountID: tx.DestinationAccountID,
+		InitiatedBy:          tx.InitiatedBy,
+		ParentID:             tx.ParentID,
+	}
+}
+
+// Parties returns the users involved in the transaction
+func (d TransactionEventData) Parties() []uuid.UUID {
+	parties := []uuid.UUID{d.UserID}
+	if d.RecipientID != nil && *d.RecipientID != d.UserID {
+		parties = append(parties, *d.RecipientID)
+	}
+	return parties
+}
+
+// BalanceEventData is the payload of balance.updated, carrying the balance
+// as committed by the transaction or hold that changed it
+type BalanceEventData struct {
+	AccountID     uuid.UUID  `json:"account_id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	Currency      string     `json:"currency"`
+	Amount        float64    `json:"amount"`
+	Held          float64    `json:"held"`
+	Available     float64    `json:"available"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	HoldID        *uuid.UUID `json:"hold_id,omitempty"`
+}
+
+// NewBalanceEventData describes a balance for event consumers
+func NewBalanceEventData(balance *Balance) BalanceEventData {
+	return BalanceEventData{
+		AccountID: balance.AccountID,
+		UserID:    balance.UserID,
+		Currency:  balance.Currency,
+		Amount:    balance.Amount,
+		Held:      balance.Held,
+		Available: balance.Available(),
+	}
+}
+
+// UserEventData is the payload of user events
+type UserEventData struct {
+	ID        uuid.UUID  `json:"id"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	Status    UserStatus `json:"status"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordPolicy is what a new password must satisfy
+type PasswordPolicy struct {
+	MinLength  int // in characters
+	MinClasses int // of lower case letters, upper case letters, digits and symbols
+	History    int // previous passwords that may not be reused, the current one included
+}
+
+// DefaultPasswordPolicy asks for 10 characters of three classes and forbids
+// reusing the last five passwords
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 10, MinClasses: 3, History: 5}
+
+// Validate checks the length and character classes of a password, and that
+// it does not contain the local part of the user's email
+func (p PasswordPolicy) Validate(password, email string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("%w: use at least %d characters", ErrWeakPassword, p.MinLength)
+	}
+	if classes := characterClasses(password); classes < p.MinClasses {
+		return fmt.Errorf("%w: use at least %d of lower case letters, upper case letters, digits and symbols", ErrWeakPassword, p.MinClasses)
+	}
+	local, _, _ := strings.Cut(email, "@")
+	if len([]rune(local)) >= 3 && strings.Contains(strings.ToLower(password), strings.ToLower(local)) {
+		return fmt.Errorf("%w: do not use your email address", ErrWeakPassword)
+	}
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	return classes
+}
+
+// PreviousPassword keeps the hash of a password a user had, so it is not
+// reused
+type PreviousPassword struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Hash      string    `gorm:"not null" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (p *PreviousPassword) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// Custom errors
+var (
+	ErrWeakPassword     = errors.New("password does not meet the policy")
+	ErrBreachedPassword = fmt.Errorf("%w: it appeared in a data breach, choose another one", ErrWeakPassword)
+	ErrReusedPassword   = fmt.Errorf("%w: it was used recently, choose another one", ErrWeakPassword)
+	ErrWrongPassword    = errors.New("current password is incorrect")
+)
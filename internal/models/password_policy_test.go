@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := DefaultPasswordPolicy
+
+	tests := []struct {
+		name     string
+		password string
+		email    string
+		wantErr  bool
+	}{
+		{"three classes", "correct-horse1", "jane@example.com", false},
+		{"four classes", "Correct-Horse1", "jane@example.com", false},
+		{"too short", "Sh0rt-pw", "jane@example.com", true},
+		{"length counts characters not bytes", "Pässwörd1ü", "jane@example.com", false},
+		{"two classes", "correcthorse1", "jane@example.com", true},
+		{"contains email", "Jane.Doe-2026", "jane.doe@example.com", true},
+		{"contains email in other case", "x-JANEDOE-2026", "janedoe@example.com", true},
+		{"short local part is ignored", "Correct-jd-1", "jd@example.com", false},
+		{"no email", "Correct-Horse1", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, tt.email)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrWeakPassword)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
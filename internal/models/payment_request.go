@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentRequestStatus string
+
+const (
+	PaymentRequestPending   PaymentRequestStatus = "pending"
+	PaymentRequestAccepted  PaymentRequestStatus = "accepted"
+	PaymentRequestDeclined  PaymentRequestStatus = "declined"
+	PaymentRequestCancelled PaymentRequestStatus = "cancelled"
+	PaymentRequestExpired   PaymentRequestStatus = "expired"
+)
+
+const (
+	// DefaultPaymentRequestTTL is how long a request stays payable when the
+	// requester does not choose an expiry
+	DefaultPaymentRequestTTL = 7 * 24 * time.Hour
+	// MaxPaymentRequestTTL caps the expiry a requester may choose
+	MaxPaymentRequestTTL = 90 * 24 * time.Hour
+)
+
+// PaymentRequest asks a payer to send money to the requester. Accepting it
+// executes a transfer from the payer to the requester.
+type PaymentRequest struct {
+	ID            uuid.UUID            `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RequesterID   uuid.UUID            `gorm:"type:uuid;not null;index" json:"requester_id"`
+	PayerID       uuid.UUID            `gorm:"type:uuid;not null;index" json:"payer_id"`
+	Amount        float64              `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Currency      string               `gorm:"type:varchar(3);not null" json:"currency"`
+	Memo          string               `gorm:"type:text" json:"memo"`
+	Status        PaymentRequestStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ExpiresAt     time.Time            `gorm:"not null" json:"expires_at"`
+	SplitID       *uuid.UUID           `gorm:"type:uuid;index" json:"split_id,omitempty"` // set when created by a split bill
+	TransactionID *uuid.UUID           `gorm:"type:uuid" json:"transaction_id,omitempty"`
+	RespondedAt   *time.Time           `json:"responded_at,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt       `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *PaymentRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks if the payment request is valid
+func (r *PaymentRequest) Validate() error {
+	if r.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if r.RequesterID == r.PayerID {
+		return ErrSelfRequest
+	}
+	return nil
+}
+
+// IsExpired reports whether a pending request can no longer be accepted
+func (r *PaymentRequest) IsExpired(now time.Time) bool {
+	return r.Status == PaymentRequestPending && !now.Before(r.ExpiresAt)
+}
+
+// SplitAmount divides total into n equal shares rounded to cents. The
+// remainder cents go to the first shares so the shares always add up to the
+// total.
+func SplitAmount(total float64, n int) ([]float64, error) {
+	if n <= 0 {
+		return nil, ErrInvalidSplit
+	}
+	cents := int64(math.Round(total * 100))
+	if cents < int64(n) {
+		return nil, ErrInvalidSplit
+	}
+
+	base := cents / int64(n)
+	remainder := cents % int64(n)
+	shares := make([]float64, n)
+	for i := range shares {
+		share := base
+		if int64(i) < remainder {
+			share++
+		}
+		shares[i] = float64(share) / 100
+	}
+	return shares, nil
+}
+
+// Custom errors
+var (
+	ErrSelfRequest        = errors.New("cannot request money from yourself")
+	ErrInvalidSplit       = errors.New("total is too small to split between participants")
+	ErrSplitMismatch      = errors.New("shares must add up to the total")
+	ErrRequestNotPending  = errors.New("payment request is no longer pending")
+	ErrRequestExpired     = errors.New("payment request has expired")
+	ErrInvalidRequestTTL  = errors.New("expiry must be in the future and within 90 days")
+	ErrDuplicateSplitUser = errors.New("each participant may only appear once in a split")
+)
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    float64
+		n        int
+		expected []float64
+		err      error
+	}{
+		{"even", 90, 3, []float64{30, 30, 30}, nil},
+		{"remainder to first shares", 100, 3, []float64{33.34, 33.33, 33.33}, nil},
+		{"two cents remainder", 10.01, 3, []float64{3.34, 3.34, 3.33}, nil},
+		{"single participant", 12.5, 1, []float64{12.5}, nil},
+		{"no participants", 10, 0, nil, ErrInvalidSplit},
+		{"less than a cent each", 0.02, 3, nil, ErrInvalidSplit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, err := SplitAmount(tt.total, tt.n)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.expected, shares)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PayoutStatus string
+
+const (
+	// PayoutPending payouts wait for the next batch file
+	PayoutPending PayoutStatus = "pending"
+	// PayoutBatched payouts were sent to the bank in a pain.001 file
+	PayoutBatched PayoutStatus = "batched"
+	// PayoutCompleted payouts were accepted by the bank
+	PayoutCompleted PayoutStatus = "completed"
+	// PayoutFailed payouts were rejected and their withdrawal refunded
+	PayoutFailed PayoutStatus = "failed"
+)
+
+// maxRemittanceInfo is the length of unstructured remittance information in
+// a credit transfer
+const maxRemittanceInfo = 140
+
+// PayoutDestination is the external account a withdrawal is paid out to
+type PayoutDestination struct {
+	IBAN string `json:"iban"`
+	BIC  string `json:"bic,omitempty"`
+	Name string `json:"name"` // account holder
+}
+
+// Normalize removes the spaces IBANs and BICs are often written with
+func (d *PayoutDestination) Normalize() {
+	d.IBAN = NormalizeIBAN(d.IBAN)
+	d.BIC = NormalizeIBAN(d.BIC)
+	d.Name = strings.TrimSpace(d.Name)
+}
+
+// Validate checks if the destination is valid
+func (d PayoutDestination) Validate() error {
+	if !ValidIBAN(d.IBAN) {
+		return ErrInvalidIBAN
+	}
+	if InternalIBAN(d.IBAN) {
+		return ErrInternalIBAN
+	}
+	if d.BIC != "" && !ValidBIC(d.BIC) {
+		return ErrInvalidBIC
+	}
+	if d.Name == "" || len(d.Name) > 140 {
+		return ErrInvalidCreditorName
+	}
+	return nil
+}
+
+// Value implements driver.Valuer
+func (d PayoutDestination) Value() (driver.Value, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (d *PayoutDestination) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return fmt.Errorf("cannot scan %T into PayoutDestination", value)
+	}
+}
+
+// Payout is the instruction to send a withdrawal to an external account. The
+// withdrawal is booked when the payout is created; a failed payout is
+// refunded by reversing it.
+type Payout struct {
+	ID                  uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TransactionID       uuid.UUID    `gorm:"type:uuid;uniqueIndex;not null" json:"transaction_id"`
+	UserID              uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Amount              float64      `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Currency            string       `gorm:"type:varchar(3);not null" json:"currency"`
+	IBAN                string       `gorm:"column:iban;type:varchar(34);not null" json:"iban"`
+	BIC                 string       `gorm:"column:bic;type:varchar(11)" json:"bic,omitempty"`
+	CreditorName        string       `gorm:"type:varchar(140);not null" json:"creditor_name"`
+	RemittanceInfo      string       `gorm:"type:varchar(140)" json:"remittance_info,omitempty"`
+	EndToEndID          string       `gorm:"type:varchar(35);uniqueIndex;not null" json:"end_to_end_id"`
+	Status              PayoutStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	BatchID             *uuid.UUID   `gorm:"type:uuid;index" json:"batch_id,omitempty"`
+	StatusReason        string       `gorm:"type:text" json:"status_reason,omitempty"`
+	RefundTransactionID *uuid.UUID   `gorm:"type:uuid" json:"refund_transaction_id,omitempty"`
+	ClosedAt            *time.Time   `json:"closed_at,omitempty"`
+	CreatedAt           time.Time    `json:"created_at"`
+	UpdatedAt           time.Time    `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (p *Payout) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewPayout creates a pending payout to destination; the amount is filled in
+// from its withdrawal by Prepare
+func NewPayout(destination PayoutDestination) *Payout {
+	return &Payout{
+		IBAN:         destination.IBAN,
+		BIC:          destination.BIC,
+		CreditorName: destination.Name,
+		Status:       PayoutPending,
+	}
+}
+
+// Destination returns the external account of the payout
+func (p *Payout) Destination() PayoutDestination {
+	return PayoutDestination{IBAN: p.IBAN, BIC: p.BIC, Name: p.CreditorName}
+}
+
+// Prepare fills in the payout from its booked withdrawal. The end-to-end ID
+// travels with the credit transfer and comes back in status reports.
+func (p *Payout) Prepare(withdrawal *Transaction) {
+	p.TransactionID = withdrawal.ID
+	p.UserID = withdrawal.UserID
+	p.Amount = withdrawal.Amount
+	p.Currency = withdrawal.Currency
+	p.RemittanceInfo = withdrawal.Description
+	if info := []rune(p.RemittanceInfo); len(info) > maxRemittanceInfo {
+		p.RemittanceInfo = string(info[:maxRemittanceInfo])
+	}
+	p.EndToEndID = strings.ReplaceAll(withdrawal.ID.String(), "-", "")
+}
+
+// PayoutBatch is a pain.001 credit transfer file of pending payouts
+type PayoutBatch struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	MessageID  string     `gorm:"type:varchar(35);uniqueIndex;not null" json:"message_id"`
+	Count      int        `gorm:"not null" json:"count"`
+	ControlSum float64    `gorm:"type:decimal(20,2);not null" json:"control_sum"` // sum of the amounts over all currencies
+	Document   string     `gorm:"type:text;not null" json:"-"`                    // the pain.001 XML
+	Completed  int        `gorm:"not null;default:0" json:"completed"`
+	Failed     int        `gorm:"not null;default:0" json:"failed"`
+	ReportedAt *time.Time `json:"reported_at,omitempty"` // last pain.002 status report
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// Relationships
+	Payouts []Payout `gorm:"foreignKey:BatchID" json:"payouts,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (b *PayoutBatch) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+// Custom errors
+var (
+	ErrInvalidIBAN              = errors.New("invalid IBAN")
+	ErrInternalIBAN             = errors.New("IBAN is an account at this bank, use a transfer")
+	ErrInvalidBIC               = errors.New("invalid BIC")
+	ErrInvalidCreditorName      = errors.New("account holder name is required and at most 140 characters")
+	ErrPayoutOnlyForWithdrawals = errors.New("only withdrawals can be paid out to an external account")
+	ErrPayoutNotReversible      = errors.New("withdrawals paid out to an external account are refunded when the payout fails")
+	ErrUnknownPayoutBatch       = errors.New("status report does not refer to a known payout batch")
+)
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayoutDestinationValidate(t *testing.T) {
+	internal, err := NewAccountNumber()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		destination PayoutDestination
+		want        error
+	}{
+		{"valid", PayoutDestination{IBAN: "de89 3704 0044 0532 0130 00", BIC: "cobadeffxxx", Name: " Jane Doe "}, nil},
+		{"without BIC", PayoutDestination{IBAN: "GB82WEST12345698765432", Name: "Jane Doe"}, nil},
+		{"wrong check digits", PayoutDestination{IBAN: "DE88370400440532013000", Name: "Jane Doe"}, ErrInvalidIBAN},
+		{"malformed IBAN", PayoutDestination{IBAN: "1234", Name: "Jane Doe"}, ErrInvalidIBAN},
+		{"own account", PayoutDestination{IBAN: internal, Name: "Jane Doe"}, ErrInternalIBAN},
+		{"malformed BIC", PayoutDestination{IBAN: "DE89370400440532013000", BIC: "COBA", Name: "Jane Doe"}, ErrInvalidBIC},
+		{"missing name", PayoutDestination{IBAN: "DE89370400440532013000", Name: "  "}, ErrInvalidCreditorName},
+		{"long name", PayoutDestination{IBAN: "DE89370400440532013000", Name: strings.Repeat("a", 141)}, ErrInvalidCreditorName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.destination.Normalize()
+			assert.Equal(t, tt.want, tt.destination.Validate())
+		})
+	}
+}
+
+func TestPayoutPrepare(t *testing.T) {
+	withdrawal := &Transaction{
+		ID:          uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+		UserID:      uuid.New(),
+		Type:        TransactionTypeWithdraw,
+		Amount:      250.5,
+		Currency:    "EUR",
+		Description: strings.Repeat("x", 200),
+	}
+	payout := NewPayout(PayoutDestination{IBAN: "DE89370400440532013000", Name: "Jane Doe"})
+	payout.Prepare(withdrawal)
+
+	assert.Equal(t, withdrawal.ID, payout.TransactionID)
+	assert.Equal(t, withdrawal.UserID, payout.UserID)
+	assert.Equal(t, 250.5, payout.Amount)
+	assert.Equal(t, "EUR", payout.Currency)
+	assert.Equal(t, "123e4567e89b12d3a456426614174000", payout.EndToEndID)
+	assert.Len(t, payout.RemittanceInfo, maxRemittanceInfo)
+	assert.Equal(t, PayoutPending, payout.Status)
+}
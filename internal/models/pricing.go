@@ -0,0 +1,237 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FeeRevenueAccountID is the system user whose balances collect the fees
+// charged to customers. It is created by the pricing migration.
+var FeeRevenueAccountID = uuid.MustParse("00000000-0000-0000-0000-000000000fee")
+
+// FeeKind is the kind of operation a fee rule prices
+type FeeKind string
+
+const (
+	FeeKindWithdraw FeeKind = "withdraw"
+	FeeKindTransfer FeeKind = "transfer"
+)
+
+// PricingPlan groups the fee rules applied to the users assigned to it.
+// Users without an assignment are priced by the default plan, if any.
+type PricingPlan struct {
+	ID                    uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name                  string         `gorm:"type:varchar(100);uniqueIndex;not null" json:"name"`
+	Description           string         `gorm:"type:text" json:"description"`
+	IsDefault             bool           `gorm:"not null;default:false" json:"is_default"`
+	FreeTransfersPerMonth int            `gorm:"not null;default:0" json:"free_transfers_per_month"`
+	Rules                 []FeeRule      `gorm:"foreignKey:PlanID" json:"rules"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (p *PricingPlan) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks if the plan and its rules are valid
+func (p *PricingPlan) Validate() error {
+	if p.Name == "" {
+		return ErrInvalidPricingPlan
+	}
+	if p.FreeTransfersPerMonth < 0 {
+		return ErrInvalidPricingPlan
+	}
+	seen := make(map[string]bool, len(p.Rules))
+	for i := range p.Rules {
+		if err := p.Rules[i].Validate(); err != nil {
+			return err
+		}
+		key := string(p.Rules[i].Kind) + "/" + p.Rules[i].Currency
+		if seen[key] {
+			return fmt.Errorf("%w: duplicate rule for %s", ErrInvalidFeeRule, key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// RuleFor returns the rule pricing kind in currency, preferring a
+// currency-specific rule over one that applies to any currency
+func (p *PricingPlan) RuleFor(kind FeeKind, currency string) *FeeRule {
+	var generic *FeeRule
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Kind != kind {
+			continue
+		}
+		if rule.Currency == currency {
+			return rule
+		}
+		if rule.Currency == "" {
+			generic = rule
+		}
+	}
+	return generic
+}
+
+// FeeRule prices one kind of operation. The fee is a flat part plus a
+// percentage of the amount, both taken from the matching tier when tiers are
+// configured, and finally clamped to the min/max caps.
+type FeeRule struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PlanID     uuid.UUID `gorm:"type:uuid;not null;index" json:"plan_id"`
+	Kind       FeeKind   `gorm:"type:varchar(20);not null" json:"kind"`
+	Currency   string    `gorm:"type:varchar(3)" json:"currency"` // empty applies to any currency
+	Flat       float64   `gorm:"type:decimal(20,2);not null;default:0" json:"flat"`
+	Percentage float64   `gorm:"type:decimal(8,4);not null;default:0" json:"percentage"` // 0.5 means 0.5%
+	Tiers      FeeTiers  `gorm:"type:jsonb" json:"tiers,omitempty"`
+	MinFee     float64   `gorm:"type:decimal(20,2);not null;default:0" json:"min_fee"`
+	MaxFee     float64   `gorm:"type:decimal(20,2);not null;default:0" json:"max_fee"` // 0 means no cap
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *FeeRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks if the fee rule is valid
+func (r *FeeRule) Validate() error {
+	switch r.Kind {
+	case FeeKindWithdraw, FeeKindTransfer:
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidFeeRule, r.Kind)
+	}
+	if r.Flat < 0 || r.Percentage < 0 || r.Percentage > 100 || r.MinFee < 0 || r.MaxFee < 0 {
+		return ErrInvalidFeeRule
+	}
+	if r.MaxFee > 0 && r.MinFee > r.MaxFee {
+		return fmt.Errorf("%w: min_fee exceeds max_fee", ErrInvalidFeeRule)
+	}
+	for i, tier := range r.Tiers {
+		if tier.UpTo < 0 || tier.Flat < 0 || tier.Percentage < 0 || tier.Percentage > 100 {
+			return ErrInvalidFeeRule
+		}
+		if tier.UpTo == 0 && i != len(r.Tiers)-1 {
+			return fmt.Errorf("%w: only the last tier may be unbounded", ErrInvalidFeeRule)
+		}
+	}
+	return nil
+}
+
+// Compute returns the fee for amount, rounded to cents
+func (r *FeeRule) Compute(amount float64) float64 {
+	flat, percentage := r.Flat, r.Percentage
+	if tier := r.Tiers.For(amount); tier != nil {
+		flat, percentage = tier.Flat, tier.Percentage
+	}
+
+	fee := flat + amount*percentage/100
+	if fee < r.MinFee {
+		fee = r.MinFee
+	}
+	if r.MaxFee > 0 && fee > r.MaxFee {
+		fee = r.MaxFee
+	}
+	return math.Round(fee*100) / 100
+}
+
+// FeeTier prices amounts up to UpTo (inclusive); the last tier may leave
+// UpTo at 0 to cover any larger amount
+type FeeTier struct {
+	UpTo       float64 `json:"up_to"`
+	Flat       float64 `json:"flat"`
+	Percentage float64 `json:"percentage"`
+}
+
+// FeeTiers is stored as a JSON array
+type FeeTiers []FeeTier
+
+// For returns the tier covering amount, or nil when there are no tiers or
+// the amount is above every bounded tier
+func (t FeeTiers) For(amount float64) *FeeTier {
+	tiers := make(FeeTiers, len(t))
+	copy(tiers, t)
+	sort.SliceStable(tiers, func(i, j int) bool {
+		return tiers[j].UpTo == 0 || (tiers[i].UpTo != 0 && tiers[i].UpTo < tiers[j].UpTo)
+	})
+	for i := range tiers {
+		if tiers[i].UpTo == 0 || amount <= tiers[i].UpTo {
+			return &tiers[i]
+		}
+	}
+	return nil
+}
+
+// Value implements driver.Valuer
+func (t FeeTiers) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (t *FeeTiers) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return fmt.Errorf("cannot scan %T into FeeTiers", value)
+	}
+}
+
+// UserPricingPlan assigns a pricing plan to a user
+type UserPricingPlan struct {
+	UserID     uuid.UUID `gorm:"type:uuid;primary_key" json:"user_id"`
+	PlanID     uuid.UUID `gorm:"type:uuid;not null;index" json:"plan_id"`
+	AssignedBy uuid.UUID `gorm:"type:uuid;not null" json:"assigned_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// FeeQuote is the fee a user would pay for an operation, computed before it
+// is executed
+type FeeQuote struct {
+	Type                   TransactionType `json:"type"`
+	Amount                 float64         `json:"amount"`
+	Currency               string          `json:"currency"`
+	Fee                    float64         `json:"fee"`
+	Total                  float64         `json:"total"`
+	PlanID                 *uuid.UUID      `json:"plan_id,omitempty"`
+	PlanName               string          `json:"plan_name,omitempty"`
+	FreeTransfersRemaining *int            `json:"free_transfers_remaining,omitempty"`
+}
+
+// Custom errors
+var (
+	ErrInvalidPricingPlan = errors.New("pricing plan needs a name and a non-negative free transfer allowance")
+	ErrInvalidFeeRule     = errors.New("invalid fee rule")
+)
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeRuleCompute(t *testing.T) {
+	tiered := FeeTiers{
+		{UpTo: 1000, Flat: 0, Percentage: 1},
+		{UpTo: 100, Flat: 1, Percentage: 0},
+		{UpTo: 0, Flat: 0, Percentage: 0.5},
+	}
+
+	tests := []struct {
+		name     string
+		rule     FeeRule
+		amount   float64
+		expected float64
+	}{
+		{"flat", FeeRule{Flat: 1.5}, 250, 1.5},
+		{"percentage rounded to cents", FeeRule{Percentage: 0.25}, 123.45, 0.31},
+		{"flat plus percentage", FeeRule{Flat: 0.3, Percentage: 1.4}, 50, 1},
+		{"minimum", FeeRule{Percentage: 1, MinFee: 2}, 50, 2},
+		{"maximum", FeeRule{Percentage: 1, MaxFee: 5}, 2000, 5},
+		{"first tier", FeeRule{Tiers: tiered}, 80, 1},
+		{"tier bound is inclusive", FeeRule{Tiers: tiered}, 100, 1},
+		{"middle tier", FeeRule{Tiers: tiered}, 500, 5},
+		{"unbounded tier", FeeRule{Tiers: tiered, MaxFee: 20}, 10000, 20},
+		{"no fee", FeeRule{}, 100, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.rule.Compute(tt.amount))
+		})
+	}
+}
+
+func TestPricingPlanRuleFor(t *testing.T) {
+	plan := PricingPlan{Rules: []FeeRule{
+		{Kind: FeeKindTransfer, Flat: 1},
+		{Kind: FeeKindTransfer, Currency: "USD", Flat: 2},
+		{Kind: FeeKindWithdraw, Currency: "EUR", Flat: 3},
+	}}
+
+	assert.Equal(t, 1.0, plan.RuleFor(FeeKindTransfer, "EUR").Flat)
+	assert.Equal(t, 2.0, plan.RuleFor(FeeKindTransfer, "USD").Flat)
+	assert.Equal(t, 3.0, plan.RuleFor(FeeKindWithdraw, "EUR").Flat)
+	assert.Nil(t, plan.RuleFor(FeeKindWithdraw, "USD"))
+}
+
+func TestFeeRuleValidate(t *testing.T) {
+	assert.NoError(t, (&FeeRule{Kind: FeeKindTransfer, Percentage: 1, MinFee: 1, MaxFee: 10}).Validate())
+	assert.ErrorIs(t, (&FeeRule{Kind: "deposit"}).Validate(), ErrInvalidFeeRule)
+	assert.ErrorIs(t, (&FeeRule{Kind: "exchange"}).Validate(), ErrInvalidFeeRule)
+	assert.ErrorIs(t, (&FeeRule{Kind: FeeKindTransfer, MinFee: 5, MaxFee: 1}).Validate(), ErrInvalidFeeRule)
+	assert.ErrorIs(t, (&FeeRule{Kind: FeeKindTransfer, Tiers: FeeTiers{{UpTo: 0}, {UpTo: 100}}}).Validate(), ErrInvalidFeeRule)
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DataCategory groups personal data that is kept for the same time
+type DataCategory string
+
+const (
+	// DataContact is how the user is reached and identified in the app: email,
+	// handle, password, beneficiaries, webhooks and account invitations
+	DataContact DataCategory = "contact"
+	// DataIdentity is the KYC profile: legal name, date of birth, address
+	DataIdentity DataCategory = "identity"
+	// DataFinancial is the free text and counterparty details of financial
+	// records: transaction descriptions, payout accounts, bank entry payers,
+	// payment request memos and standing order descriptions. Amounts and
+	// IDs are never purged, so the ledger still adds up.
+	DataFinancial DataCategory = "financial"
+)
+
+// RetentionRule says how long a category of data is kept after the customer
+// relationship ended, and why
+type RetentionRule struct {
+	Category DataCategory `json:"category"`
+	Years    int          `json:"years"`
+	Basis    string       `json:"basis"`
+}
+
+// RetentionPolicy holds the retention rule of each data category
+type RetentionPolicy []RetentionRule
+
+// DefaultRetentionPolicy follows the EU anti-money-laundering and commercial
+// bookkeeping record keeping periods
+var DefaultRetentionPolicy = RetentionPolicy{
+	{Category: DataContact, Years: 0, Basis: "no legal obligation to keep it once the relationship ended"},
+	{Category: DataIdentity, Years: 5, Basis: "anti-money-laundering customer due diligence records"},
+	{Category: DataFinancial, Years: 10, Basis: "commercial and tax bookkeeping records"},
+}
+
+// PurgeAt returns when data of a category must be purged for a relationship
+// that ended at end. Categories without a rule are purged at once.
+func (p RetentionPolicy) PurgeAt(category DataCategory, end time.Time) time.Time {
+	for _, rule := range p {
+		if rule.Category == category {
+			return end.AddDate(rule.Years, 0, 0)
+		}
+	}
+	return end
+}
+
+// Cutoff returns the latest end of a relationship whose data of a category is
+// due to be purged by now
+func (p RetentionPolicy) Cutoff(category DataCategory, now time.Time) time.Time {
+	for _, rule := range p {
+		if rule.Category == category {
+			return now.AddDate(-rule.Years, 0, 0)
+		}
+	}
+	return now
+}
+
+// Audit trail actions of data erasure
+const (
+	AuditUserErased = "user.erased"
+	AuditUserPurged = "user.purged"
+)
+
+// DataErasure records that a user's contact data was erased and when the
+// data kept for the retention period is due to be purged
+type DataErasure struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID            uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	RequestedBy       *uuid.UUID `gorm:"type:uuid" json:"requested_by,omitempty"` // empty when the retention job erased the user
+	ClosedAt          time.Time  `gorm:"not null" json:"closed_at"`               // end of the relationship, retention runs from here
+	ErasedAt          time.Time  `gorm:"not null" json:"erased_at"`
+	IdentityPurgeAt   time.Time  `gorm:"not null" json:"identity_purge_at"`
+	IdentityPurgedAt  *time.Time `json:"identity_purged_at,omitempty"`
+	FinancialPurgeAt  time.Time  `gorm:"not null" json:"financial_purge_at"`
+	FinancialPurgedAt *time.Time `json:"financial_purged_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (e *DataErasure) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewDataErasure schedules the purges of a user closed at closedAt under the
+// policy
+func NewDataErasure(userID uuid.UUID, requestedBy *uuid.UUID, closedAt, now time.Time, policy RetentionPolicy) *DataErasure {
+	return &DataErasure{
+		UserID:           userID,
+		RequestedBy:      requestedBy,
+		ClosedAt:         closedAt,
+		ErasedAt:         now,
+		IdentityPurgeAt:  policy.PurgeAt(DataIdentity, closedAt),
+		FinancialPurgeAt: policy.PurgeAt(DataFinancial, closedAt),
+	}
+}
+
+// Due returns the categories that are due to be purged and were not yet
+func (e *DataErasure) Due(now time.Time) []DataCategory {
+	var due []DataCategory
+	if e.IdentityPurgedAt == nil && !now.Before(e.IdentityPurgeAt) {
+		due = append(due, DataIdentity)
+	}
+	if e.FinancialPurgedAt == nil && !now.Before(e.FinancialPurgeAt) {
+		due = append(due, DataFinancial)
+	}
+	return due
+}
+
+// DataExport is the data held about a user, as exported to them
+type DataExport struct {
+	User            User
+	Profile         *UserProfile // nil when the user never submitted KYC
+	Accounts        []Account    // owned accounts
+	Balances        []Balance
+	Transactions    []Transaction
+	Beneficiaries   []Beneficiary
+	StandingOrders  []StandingOrder
+	PaymentRequests []PaymentRequest
+	Webhooks        []WebhookSubscription
+}
+
+// Pseudonymize replaces the user's contact data with values derived from
+// their ID, so financial records still point to a user who is no longer
+// identifiable. The password can never match again.
+func (u *User) Pseudonymize() {
+	u.Email = "erased-" + u.ID.String() + "@erased.invalid"
+	u.Handle = nil
+	u.Password = "!"
+	u.LastLoginAt = nil
+}
+
+// Custom errors
+var (
+	ErrAlreadyErased = errors.New("user data was already erased")
+)
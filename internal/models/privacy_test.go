@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	closedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	policy := RetentionPolicy{
+		{Category: DataIdentity, Years: 5},
+		{Category: DataFinancial, Years: 10},
+	}
+
+	assert.Equal(t, closedAt.AddDate(5, 0, 0), policy.PurgeAt(DataIdentity, closedAt))
+	assert.Equal(t, closedAt.AddDate(10, 0, 0), policy.PurgeAt(DataFinancial, closedAt))
+	assert.Equal(t, closedAt, policy.PurgeAt(DataContact, closedAt))
+
+	now := closedAt.AddDate(5, 0, 0)
+	assert.Equal(t, closedAt, policy.Cutoff(DataIdentity, now))
+	assert.Equal(t, now, policy.Cutoff(DataContact, now))
+}
+
+func TestDataErasureDue(t *testing.T) {
+	closedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	erasure := NewDataErasure(uuid.New(), nil, closedAt, closedAt, DefaultRetentionPolicy)
+	identityPurgeAt := closedAt.AddDate(5, 0, 0)
+	financialPurgeAt := closedAt.AddDate(10, 0, 0)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want []DataCategory
+	}{
+		{"at erasure", closedAt, nil},
+		{"before identity retention ended", identityPurgeAt.Add(-time.Second), nil},
+		{"identity retention ended", identityPurgeAt, []DataCategory{DataIdentity}},
+		{"both retention periods ended", financialPurgeAt, []DataCategory{DataIdentity, DataFinancial}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, erasure.Due(tt.now))
+		})
+	}
+
+	erasure.IdentityPurgedAt = &identityPurgeAt
+	assert.Equal(t, []DataCategory{DataFinancial}, erasure.Due(financialPurgeAt))
+}
+
+func TestUserPseudonymize(t *testing.T) {
+	handle := "jane"
+	lastLogin := time.Now()
+	user := &User{ID: uuid.New(), Email: "jane@example.com", Handle: &handle, Password: "$2a$10$hash", LastLoginAt: &lastLogin}
+
+	user.Pseudonymize()
+
+	assert.Equal(t, "erased-"+user.ID.String()+"@erased.invalid", user.Email)
+	assert.Nil(t, user.Handle)
+	assert.Nil(t, user.LastLoginAt)
+	assert.Error(t, user.CheckPassword(""))
+	assert.Error(t, user.CheckPassword("!"))
+}
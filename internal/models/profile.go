@@ -0,0 +1,135 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type KYCStatus string
+
+const (
+	KYCStatusUnverified KYCStatus = "unverified"
+	KYCStatusPending    KYCStatus = "pending"
+	KYCStatusVerified   KYCStatus = "verified"
+	KYCStatusRejected   KYCStatus = "rejected"
+)
+
+// KYCLevel is the verification tier granted by an admin on approval
+type KYCLevel int
+
+const (
+	KYCLevelNone  KYCLevel = 0
+	KYCLevelBasic KYCLevel = 1
+	KYCLevelFull  KYCLevel = 2
+)
+
+// KYCLimits describes what a user may do at a given KYC level
+type KYCLimits struct {
+	CanDeposit  bool    `json:"can_deposit"`
+	CanWithdraw bool    `json:"can_withdraw"`
+	CanTransfer bool    `json:"can_transfer"`
+	MaxAmount   float64 `json:"max_amount"` // per transaction, 0 means unlimited
+}
+
+var kycLevelLimits = map[KYCLevel]KYCLimits{
+	KYCLevelNone:  {CanDeposit: true, MaxAmount: 1000},
+	KYCLevelBasic: {CanDeposit: true, CanWithdraw: true, CanTransfer: true, MaxAmount: 10000},
+	KYCLevelFull:  {CanDeposit: true, CanWithdraw: true, CanTransfer: true},
+}
+
+// LimitsForLevel returns the capabilities granted at the given level
+func LimitsForLevel(level KYCLevel) KYCLimits {
+	if limits, ok := kycLevelLimits[level]; ok {
+		return limits
+	}
+	return kycLevelLimits[KYCLevelNone]
+}
+
+// Allows checks whether a transaction of the given type and amount is permitted
+func (l KYCLimits) Allows(txType TransactionType, amount float64) error {
+	switch txType {
+	case TransactionTypeDeposit:
+		if !l.CanDeposit {
+			return ErrKYCNotAllowed
+		}
+	case TransactionTypeWithdraw:
+		if !l.CanWithdraw {
+			return ErrKYCNotAllowed
+		}
+	case TransactionTypeTransfer:
+		if !l.CanTransfer {
+			return ErrKYCNotAllowed
+		}
+	}
+	if l.MaxAmount > 0 && amount > l.MaxAmount {
+		return ErrKYCLimitExceeded
+	}
+	return nil
+}
+
+// UserProfile holds the customer's identity data and KYC state
+type UserProfile struct {
+	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID          uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	LegalName       string         `gorm:"type:varchar(255);not null" json:"legal_name"`
+	DateOfBirth     *time.Time     `gorm:"type:date" json:"date_of_birth,omitempty"`
+	Phone           string         `gorm:"type:varchar(32)" json:"phone,omitempty"`
+	AddressLine1    string         `gorm:"type:varchar(255)" json:"address_line1"`
+	AddressLine2    string         `gorm:"type:varchar(255)" json:"address_line2,omitempty"`
+	City            string         `gorm:"type:varchar(100)" json:"city"`
+	PostalCode      string         `gorm:"type:varchar(20)" json:"postal_code"`
+	Country         string         `gorm:"type:varchar(2)" json:"country"`
+	Nationality     string         `gorm:"type:varchar(2)" json:"nationality"`
+	KYCStatus       KYCStatus      `gorm:"column:kyc_status;type:varchar(20);not null;default:'unverified'" json:"kyc_status"`
+	KYCLevel        KYCLevel       `gorm:"column:kyc_level;not null;default:0" json:"kyc_level"`
+	RejectionReason string         `gorm:"type:text" json:"rejection_reason,omitempty"`
+	SubmittedAt     *time.Time     `json:"submitted_at,omitempty"`
+	ReviewedAt      *time.Time     `json:"reviewed_at,omitempty"`
+	ReviewedBy      *uuid.UUID     `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (p *UserProfile) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// EffectiveLevel returns the KYC level currently in force. Only verified
+// profiles benefit from the level granted on approval.
+func (p *UserProfile) EffectiveLevel() KYCLevel {
+	if p == nil || p.KYCStatus != KYCStatusVerified {
+		return KYCLevelNone
+	}
+	return p.KYCLevel
+}
+
+// Validate checks that the profile carries the data required for review
+func (p *UserProfile) Validate() error {
+	if p.LegalName == "" || p.DateOfBirth == nil || p.AddressLine1 == "" ||
+		p.City == "" || p.PostalCode == "" || p.Country == "" || p.Nationality == "" {
+		return ErrIncompleteProfile
+	}
+	adult := p.DateOfBirth.AddDate(18, 0, 0)
+	if adult.After(time.Now()) {
+		return ErrUnderage
+	}
+	return nil
+}
+
+// Custom errors
+var (
+	ErrKYCNotAllowed     = errors.New("operation not allowed for current KYC level")
+	ErrKYCLimitExceeded  = errors.New("amount exceeds the limit for current KYC level")
+	ErrIncompleteProfile = errors.New("legal name, date of birth, address, country and nationality are required")
+	ErrUnderage          = errors.New("customer must be at least 18 years old")
+	ErrInvalidKYCLevel   = errors.New("invalid KYC level")
+	ErrKYCNotPending     = errors.New("profile is not pending review")
+)
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKYCLimitsAllows(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   KYCLevel
+		txType  TransactionType
+		amount  float64
+		wantErr error
+	}{
+		{"Unverified Deposit", KYCLevelNone, TransactionTypeDeposit, 100, nil},
+		{"Unverified Transfer", KYCLevelNone, TransactionTypeTransfer, 10, ErrKYCNotAllowed},
+		{"Unverified Withdraw", KYCLevelNone, TransactionTypeWithdraw, 10, ErrKYCNotAllowed},
+		{"Unverified Deposit Over Limit", KYCLevelNone, TransactionTypeDeposit, 5000, ErrKYCLimitExceeded},
+		{"Basic Transfer", KYCLevelBasic, TransactionTypeTransfer, 500, nil},
+		{"Basic Transfer Over Limit", KYCLevelBasic, TransactionTypeTransfer, 20000, ErrKYCLimitExceeded},
+		{"Full Transfer Unlimited", KYCLevelFull, TransactionTypeTransfer, 1000000, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := LimitsForLevel(tt.level).Allows(tt.txType, tt.amount)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestUserProfileEffectiveLevel(t *testing.T) {
+	var missing *UserProfile
+	assert.Equal(t, KYCLevelNone, missing.EffectiveLevel())
+
+	pending := &UserProfile{KYCStatus: KYCStatusPending, KYCLevel: KYCLevelFull}
+	assert.Equal(t, KYCLevelNone, pending.EffectiveLevel())
+
+	verified := &UserProfile{KYCStatus: KYCStatusVerified, KYCLevel: KYCLevelFull}
+	assert.Equal(t, KYCLevelFull, verified.EffectiveLevel())
+}
+
+func TestUserProfileValidate(t *testing.T) {
+	adult := time.Now().AddDate(-30, 0, 0)
+	minor := time.Now().AddDate(-10, 0, 0)
+	profile := UserProfile{
+		LegalName:    "Jane Doe",
+		DateOfBirth:  &adult,
+		AddressLine1: "Damrak 1",
+		City:         "Amsterdam",
+		PostalCode:   "1012 LG",
+		Country:      "NL",
+		Nationality:  "NL",
+	}
+	assert.NoError(t, profile.Validate())
+
+	profile.DateOfBirth = &minor
+	assert.Equal(t, ErrUnderage, profile.Validate())
+
+	profile.LegalName = ""
+	assert.Equal(t, ErrIncompleteProfile, profile.Validate())
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// NormalizeHandle lower-cases a handle and removes the @ it is often
+// written with
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// ValidHandle checks a normalized handle: 3 to 30 letters, digits or
+// underscores
+func ValidHandle(handle string) bool {
+	return handlePattern.MatchString(handle)
+}
+
+// CheckRecipient verifies a user may receive transfers. Closed users are not
+// found; staff accounts, users frozen for all transactions and customers
+// whose KYC was rejected are blocked. profile may be nil.
+func CheckRecipient(user *User, profile *UserProfile) error {
+	if user.Status == UserStatusClosed {
+		return ErrRecipientNotFound
+	}
+	if user.IsAdmin() || !user.CanReceive() {
+		return ErrRecipientBlocked
+	}
+	if profile != nil && profile.KYCStatus == KYCStatusRejected {
+		return ErrRecipientBlocked
+	}
+	return nil
+}
+
+// PayeePreview lets a payer confirm who they are about to pay without
+// revealing the recipient's full name or email
+type PayeePreview struct {
+	RecipientID uuid.UUID `json:"recipient_id"`
+	DisplayName string    `json:"display_name"`     // masked legal name, or masked email when unverified
+	Handle      string    `json:"handle,omitempty"` // when the recipient set one
+	Verified    bool      `json:"verified"`         // display name comes from a KYC verified profile
+}
+
+// NewPayeePreview builds the preview of a recipient; profile may be nil
+func NewPayeePreview(user *User, profile *UserProfile) *PayeePreview {
+	preview := &PayeePreview{RecipientID: user.ID}
+	if user.Handle != nil {
+		preview.Handle = *user.Handle
+	}
+	if profile != nil && profile.KYCStatus == KYCStatusVerified && strings.TrimSpace(profile.LegalName) != "" {
+		preview.DisplayName = MaskName(profile.LegalName)
+		preview.Verified = true
+	} else {
+		preview.DisplayName = MaskEmail(user.Email)
+	}
+	return preview
+}
+
+// MaskName keeps the first letter of each part of a name: "Jane Doe" becomes
+// "J*** D**"
+func MaskName(name string) string {
+	parts := strings.Fields(name)
+	for i, part := range parts {
+		runes := []rune(part)
+		parts[i] = string(runes[0]) + strings.Repeat("*", len(runes)-1)
+	}
+	return strings.Join(parts, " ")
+}
+
+// MaskEmail keeps the first letter of the local part and the domain:
+// "jane@example.com" becomes "j***@example.com"
+func MaskEmail(email string) string {
+	local, domain, found := strings.Cut(email, "@")
+	if !found || local == "" {
+		return "***"
+	}
+	return string([]rune(local)[0]) + "***@" + domain
+}
+
+// Custom errors
+var (
+	ErrRecipientNotFound = errors.New("recipient not found")
+	ErrRecipientBlocked  = errors.New("recipient cannot receive transfers")
+	ErrRecipientQuery    = errors.New("give exactly one of recipient_id, email or handle")
+	ErrInvalidHandle     = errors.New("handle must be 3 to 30 letters, digits or underscores")
+	ErrHandleTaken       = errors.New("handle is already taken")
+)
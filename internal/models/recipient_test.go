@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandle(t *testing.T) {
+	assert.Equal(t, "jane_doe", NormalizeHandle(" @Jane_Doe "))
+	assert.True(t, ValidHandle("jane_doe"))
+	assert.True(t, ValidHandle("j42"))
+	assert.False(t, ValidHandle("jd"))
+	assert.False(t, ValidHandle("jane.doe"))
+	assert.False(t, ValidHandle("jane_doe_with_a_handle_too_long"))
+}
+
+func TestNewPayeePreview(t *testing.T) {
+	handle := "jane_doe"
+	user := &User{ID: uuid.New(), Email: "jane@example.com", Handle: &handle}
+
+	tests := []struct {
+		name         string
+		profile      *UserProfile
+		wantName     string
+		wantVerified bool
+	}{
+		{"without profile", nil, "j***@example.com", false},
+		{"pending profile", &UserProfile{LegalName: "Jane Doe", KYCStatus: KYCStatusPending}, "j***@example.com", false},
+		{"verified profile", &UserProfile{LegalName: "Jane  Élise Doe", KYCStatus: KYCStatusVerified}, "J*** É**** D**", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview := NewPayeePreview(user, tt.profile)
+			assert.Equal(t, user.ID, preview.RecipientID)
+			assert.Equal(t, tt.wantName, preview.DisplayName)
+			assert.Equal(t, tt.wantVerified, preview.Verified)
+			assert.Equal(t, "jane_doe", preview.Handle)
+		})
+	}
+}
+
+func TestCheckRecipient(t *testing.T) {
+	customer := &User{Role: "user"}
+	assert.NoError(t, CheckRecipient(customer, nil))
+	assert.NoError(t, CheckRecipient(customer, &UserProfile{KYCStatus: KYCStatusPending}))
+	assert.Equal(t, ErrRecipientBlocked, CheckRecipient(customer, &UserProfile{KYCStatus: KYCStatusRejected}))
+	assert.Equal(t, ErrRecipientBlocked, CheckRecipient(&User{Role: "admin"}, nil))
+	assert.NoError(t, CheckRecipient(&User{Role: "user", Status: UserStatusFrozen, FreezeScope: FreezeOutgoing}, nil))
+	assert.NoError(t, CheckRecipient(&User{Role: "user", Status: UserStatusDormant}, nil))
+	assert.Equal(t, ErrRecipientBlocked, CheckRecipient(&User{Role: "user", Status: UserStatusFrozen, FreezeScope: FreezeAll}, nil))
+	assert.Equal(t, ErrRecipientNotFound, CheckRecipient(&User{Role: "user", Status: UserStatusClosed}, nil))
+}
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReconciliationStatus string
+
+const (
+	ReconciliationRunning       ReconciliationStatus = "running"
+	ReconciliationBalanced      ReconciliationStatus = "balanced"
+	ReconciliationDiscrepancies ReconciliationStatus = "discrepancies"
+	ReconciliationFailed        ReconciliationStatus = "failed"
+)
+
+// LedgerBalance is a user's balance in a currency, either as stored or as
+// recomputed from the transaction history
+type LedgerBalance struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Currency string    `json:"currency"`
+	Amount   float64   `json:"amount"`
+}
+
+// CurrencyTotal compares the sum of all stored balances in a currency with the
+// sum recomputed from the transaction history
+type CurrencyTotal struct {
+	Currency string  `json:"currency"`
+	Stored   float64 `json:"stored"`
+	Computed float64 `json:"computed"`
+}
+
+// CurrencyTotals is stored as JSON
+type CurrencyTotals []CurrencyTotal
+
+// Value implements driver.Valuer
+func (t CurrencyTotals) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (t *CurrencyTotals) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return fmt.Errorf("cannot scan %T into CurrencyTotals", value)
+	}
+}
+
+// ReconciliationRun is one verification of the stored balances against the
+// transaction history
+type ReconciliationRun struct {
+	ID               uuid.UUID            `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Status           ReconciliationStatus `gorm:"type:varchar(20);not null;default:'running'" json:"status"`
+	BalancesChecked  int                  `gorm:"not null;default:0" json:"balances_checked"`
+	DiscrepancyCount int                  `gorm:"not null;default:0" json:"discrepancy_count"`
+	Totals           CurrencyTotals       `gorm:"type:jsonb;not null;default:'[]'" json:"totals"`
+	Error            string               `gorm:"type:text" json:"error,omitempty"`
+	StartedAt        time.Time            `gorm:"not null;index" json:"started_at"`
+	FinishedAt       *time.Time           `json:"finished_at,omitempty"`
+
+	// Relationships
+	Discrepancies []ReconciliationDiscrepancy `gorm:"foreignKey:RunID" json:"discrepancies,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (r *ReconciliationRun) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// ReconciliationDiscrepancy is a balance whose stored amount differs from the
+// transaction history, or a currency whose stored total does when UserID is
+// empty
+type ReconciliationDiscrepancy struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RunID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"run_id"`
+	UserID     *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Currency   string     `gorm:"type:varchar(3);not null" json:"currency"`
+	Stored     float64    `gorm:"type:decimal(20,2);not null" json:"stored"`
+	Computed   float64    `gorm:"type:decimal(20,2);not null" json:"computed"`
+	Difference float64    `gorm:"type:decimal(20,2);not null" json:"difference"` // stored minus computed
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (d *ReconciliationDiscrepancy) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// Complete compares the stored balances with those recomputed from the
+// transaction history, per user and currency and in total per currency, and
+// records the differences of a cent or more
+func (r *ReconciliationRun) Complete(stored, computed []LedgerBalance, now time.Time) {
+	type key struct {
+		userID   uuid.UUID
+		currency string
+	}
+	pairs := map[key]*ReconciliationDiscrepancy{}
+	totals := map[string]*CurrencyTotal{}
+	entry := func(b LedgerBalance) (*ReconciliationDiscrepancy, *CurrencyTotal) {
+		k := key{b.UserID, b.Currency}
+		if pairs[k] == nil {
+			userID := b.UserID
+			pairs[k] = &ReconciliationDiscrepancy{RunID: r.ID, UserID: &userID, Currency: b.Currency}
+		}
+		if totals[b.Currency] == nil {
+			totals[b.Currency] = &CurrencyTotal{Currency: b.Currency}
+		}
+		return pairs[k], totals[b.Currency]
+	}
+	for _, b := range stored {
+		pair, total := entry(b)
+		pair.Stored += b.Amount
+		total.Stored += b.Amount
+	}
+	for _, b := range computed {
+		pair, total := entry(b)
+		pair.Computed += b.Amount
+		total.Computed += b.Amount
+	}
+
+	r.Discrepancies = nil
+	for _, pair := range pairs {
+		if differs(pair.Stored, pair.Computed) {
+			pair.Difference = roundCents(pair.Stored - pair.Computed)
+			r.Discrepancies = append(r.Discrepancies, *pair)
+		}
+	}
+	r.Totals = make(CurrencyTotals, 0, len(totals))
+	for _, total := range totals {
+		total.Stored, total.Computed = roundCents(total.Stored), roundCents(total.Computed)
+		r.Totals = append(r.Totals, *total)
+		if differs(total.Stored, total.Computed) {
+			r.Discrepancies = append(r.Discrepancies, ReconciliationDiscrepancy{
+				RunID:      r.ID,
+				Currency:   total.Currency,
+				Stored:     total.Stored,
+				Computed:   total.Computed,
+				Difference: roundCents(total.Stored - total.Computed),
+			})
+		}
+	}
+	sort.Slice(r.Totals, func(i, j int) bool { return r.Totals[i].Currency < r.Totals[j].Currency })
+	sort.SliceStable(r.Discrepancies, func(i, j int) bool {
+		return math.Abs(r.Discrepancies[i].Difference) > math.Abs(r.Discrepancies[j].Difference)
+	})
+
+	r.BalancesChecked = len(pairs)
+	r.DiscrepancyCount = len(r.Discrepancies)
+	r.Status = ReconciliationBalanced
+	if r.DiscrepancyCount > 0 {
+		r.Status = ReconciliationDiscrepancies
+	}
+	r.FinishedAt = &now
+}
+
+// Fail records why the run could not complete
+func (r *ReconciliationRun) Fail(err error, now time.Time) {
+	r.Status = ReconciliationFailed
+	r.Error = err.Error()
+	r.FinishedAt = &now
+}
+
+// differs reports whether two amounts differ by a cent or more
+func differs(a, b float64) bool {
+	return math.Abs(a-b) >= 0.005
+}
+
+// roundCents rounds an amount to cents
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
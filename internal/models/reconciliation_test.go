@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconciliationRunComplete(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		stored        []LedgerBalance
+		computed      []LedgerBalance
+		status        ReconciliationStatus
+		discrepancies []ReconciliationDiscrepancy
+	}{
+		{
+			name: "balanced across accounts",
+			stored: []LedgerBalance{
+				{UserID: alice, Currency: "EUR", Amount: 60},
+				{UserID: alice, Currency: "EUR", Amount: 40}, // savings pot
+				{UserID: bob, Currency: "USD", Amount: 10.1},
+			},
+			computed: []LedgerBalance{
+				{UserID: alice, Currency: "EUR", Amount: 100},
+				{UserID: bob, Currency: "USD", Amount: 10.100000001},
+			},
+			status: ReconciliationBalanced,
+		},
+		{
+			name:     "balance without history",
+			stored:   []LedgerBalance{{UserID: alice, Currency: "EUR", Amount: 5}},
+			computed: nil,
+			status:   ReconciliationDiscrepancies,
+			discrepancies: []ReconciliationDiscrepancy{
+				{UserID: &alice, Currency: "EUR", Stored: 5, Computed: 0, Difference: 5},
+				{Currency: "EUR", Stored: 5, Computed: 0, Difference: 5},
+			},
+		},
+		{
+			name: "offsetting errors leave the total balanced",
+			stored: []LedgerBalance{
+				{UserID: alice, Currency: "EUR", Amount: 90},
+				{UserID: bob, Currency: "EUR", Amount: 10},
+			},
+			computed: []LedgerBalance{
+				{UserID: alice, Currency: "EUR", Amount: 100},
+				{UserID: bob, Currency: "EUR", Amount: 0},
+			},
+			status: ReconciliationDiscrepancies,
+			discrepancies: []ReconciliationDiscrepancy{
+				{UserID: &alice, Currency: "EUR", Stored: 90, Computed: 100, Difference: -10},
+				{UserID: &bob, Currency: "EUR", Stored: 10, Computed: 0, Difference: 10},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := ReconciliationRun{Status: ReconciliationRunning, StartedAt: now}
+			run.Complete(tt.stored, tt.computed, now)
+
+			assert.Equal(t, tt.status, run.Status)
+			assert.Equal(t, len(tt.discrepancies), run.DiscrepancyCount)
+			assert.ElementsMatch(t, tt.discrepancies, run.Discrepancies)
+			assert.Equal(t, &now, run.FinishedAt)
+		})
+	}
+}
+
+func TestReconciliationRunTotals(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	run := ReconciliationRun{}
+	run.Complete(
+		[]LedgerBalance{
+			{UserID: alice, Currency: "USD", Amount: 1},
+			{UserID: alice, Currency: "EUR", Amount: 2.5},
+			{UserID: bob, Currency: "EUR", Amount: 0.25},
+		},
+		[]LedgerBalance{
+			{UserID: alice, Currency: "USD", Amount: 1},
+			{UserID: alice, Currency: "EUR", Amount: 2.5},
+			{UserID: bob, Currency: "EUR", Amount: 0.25},
+		},
+		time.Now(),
+	)
+
+	assert.Equal(t, 3, run.BalancesChecked)
+	assert.Equal(t, CurrencyTotals{
+		{Currency: "EUR", Stored: 2.75, Computed: 2.75},
+		{Currency: "USD", Stored: 1, Computed: 1},
+	}, run.Totals)
+}
+
+func TestReconciliationRunFail(t *testing.T) {
+	run := ReconciliationRun{Status: ReconciliationRunning}
+	run.Fail(errors.New("connection reset"), time.Now())
+
+	assert.Equal(t, ReconciliationFailed, run.Status)
+	assert.Equal(t, "connection reset", run.Error)
+	assert.NotNil(t, run.FinishedAt)
+}
@@ -0,0 +1,252 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// Scopes an API key grants a machine client. Admin scopes are for keys of
+// admins only.
+const (
+	ScopeAccountsRead       = "accounts:read"
+	ScopeAccountsWrite      = "accounts:write"
+	ScopeTransactionsRead   = "transactions:read"
+	ScopeTransactionsWrite  = "transactions:write"
+	ScopePaymentsRead       = "payments:read"
+	ScopePaymentsWrite      = "payments:write"
+	ScopeBeneficiariesRead  = "beneficiaries:read"
+	ScopeBeneficiariesWrite = "beneficiaries:write"
+	ScopeWebhooks           = "webhooks"
+	ScopeAdminRead          = "admin:read"
+	ScopeAdminWrite         = "admin:write"
+)
+
+// ClientScopes lists every scope an API key may have
+var ClientScopes = []string{
+	ScopeAccountsRead,
+	ScopeAccountsWrite,
+	ScopeTransactionsRead,
+	ScopeTransactionsWrite,
+	ScopePaymentsRead,
+	ScopePaymentsWrite,
+	ScopeBeneficiariesRead,
+	ScopeBeneficiariesWrite,
+	ScopeWebhooks,
+	ScopeAdminRead,
+	ScopeAdminWrite,
+}
+
+const (
+	// APIKeyPrefix starts every API key, so they are told apart from JWTs
+	APIKeyPrefix = "tk_"
+	// DefaultAPIKeyTTL and MaxAPIKeyTTL bound how long API keys are valid
+	DefaultAPIKeyTTL = 90 * 24 * time.Hour
+	MaxAPIKeyTTL     = 365 * 24 * time.Hour
+	// APIKeyRotationGrace is how long a rotated key keeps working, so
+	// clients can switch to its replacement
+	APIKeyRotationGrace = 24 * time.Hour
+	// ClientTokenTTL is how long access tokens of the client credentials
+	// grant are valid
+	ClientTokenTTL = time.Hour
+	// apiKeyTouchInterval is how often the last use of a key is saved
+	apiKeyTouchInterval = time.Minute
+)
+
+// ServiceAccount is a machine client acting for the user who created it,
+// such as a backend integration. It is the client of the OAuth2 client
+// credentials grant, with its API keys as client secrets.
+type ServiceAccount struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"` // the OAuth2 client ID
+	UserID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`                   // the client acts as
+	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (a *ServiceAccount) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// APIKey is a credential of a service account. Only a SHA-256 hash of the
+// key is stored; its prefix, which is part of the key, finds it.
+type APIKey struct {
+	ID               uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ServiceAccountID uuid.UUID       `gorm:"type:uuid;not null;index" json:"service_account_id"`
+	Prefix           string          `gorm:"type:varchar(16);not null;uniqueIndex" json:"prefix"`
+	Hash             string          `gorm:"type:varchar(64);not null" json:"-"`
+	Scopes           pq.StringArray  `gorm:"type:text[];not null" json:"scopes" swaggertype:"array,string"`
+	ExpiresAt        time.Time       `gorm:"not null" json:"expires_at"`
+	RevokedAt        *time.Time      `json:"revoked_at,omitempty"`
+	LastUsedAt       *time.Time      `json:"last_used_at,omitempty"`
+	RotatedFromID    *uuid.UUID      `gorm:"type:uuid" json:"rotated_from_id,omitempty"` // the key this one replaced
+	CreatedAt        time.Time       `json:"created_at"`
+	ServiceAccount   *ServiceAccount `gorm:"foreignKey:ServiceAccountID" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewAPIKey creates a key of the service account with the scopes, valid for
+// ttl. It returns the key to hand to the client, which is not kept.
+func NewAPIKey(account *ServiceAccount, scopes []string, ttl time.Duration, now time.Time) (*APIKey, string, error) {
+	prefix := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	key := &APIKey{
+		ID:               uuid.New(),
+		ServiceAccountID: account.ID,
+		Prefix:           hex.EncodeToString(prefix),
+		Scopes:           scopes,
+		ExpiresAt:        now.Add(ttl),
+		CreatedAt:        now,
+		ServiceAccount:   account,
+	}
+	raw := APIKeyPrefix + key.Prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	key.Hash = hashAPIKey(raw)
+	return key, raw, nil
+}
+
+// ParseAPIKeyPrefix returns the prefix that finds the key, or false if raw
+// is not shaped like an API key
+func ParseAPIKeyPrefix(raw string) (string, bool) {
+	rest, found := strings.CutPrefix(raw, APIKeyPrefix)
+	if !found {
+		return "", false
+	}
+	prefix, secret, found := strings.Cut(rest, "_")
+	if !found || len(prefix) != 12 || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// Verify checks raw is this key and that it can still be used
+func (k *APIKey) Verify(raw string, now time.Time) error {
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(raw)), []byte(k.Hash)) != 1 {
+		return ErrAPIKeyInvalid
+	}
+	return k.Usable(now)
+}
+
+// Usable reports why the key can no longer be used, if so
+func (k *APIKey) Usable(now time.Time) error {
+	if k.RevokedAt != nil {
+		return ErrAPIKeyRevoked
+	}
+	if !now.Before(k.ExpiresAt) {
+		return ErrAPIKeyExpired
+	}
+	return nil
+}
+
+// Rotate creates the key replacing this one, with its scopes and lifetime.
+// This key keeps working for the rotation grace period, or until it expires
+// if that is sooner.
+func (k *APIKey) Rotate(now time.Time) (*APIKey, string, error) {
+	if err := k.Usable(now); err != nil {
+		return nil, "", err
+	}
+	replacement, raw, err := NewAPIKey(k.ServiceAccount, k.Scopes, k.ExpiresAt.Sub(k.CreatedAt), now)
+	if err != nil {
+		return nil, "", err
+	}
+	replacement.RotatedFromID = &k.ID
+	if graceEnd := now.Add(APIKeyRotationGrace); graceEnd.Before(k.ExpiresAt) {
+		k.ExpiresAt = graceEnd
+	}
+	return replacement, raw, nil
+}
+
+// Seen records a use of the key, reporting whether it should be saved; uses
+// within a minute of the last saved one are not
+func (k *APIKey) Seen(now time.Time) bool {
+	if k.LastUsedAt != nil && now.Sub(*k.LastUsedAt) < apiKeyTouchInterval {
+		return false
+	}
+	k.LastUsedAt = &now
+	return true
+}
+
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateScopes checks the scopes are known and that only admins get admin
+// scopes, returning them sorted without duplicates
+func ValidateScopes(scopes []string, role string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	var valid []string
+	for _, scope := range scopes {
+		if seen[scope] {
+			continue
+		}
+		if !HasScope(ClientScopes, scope) {
+			return nil, ErrInvalidScope
+		}
+		if strings.HasPrefix(scope, "admin:") && role != "admin" {
+			return nil, ErrScopeNotAllowed
+		}
+		seen[scope] = true
+		valid = append(valid, scope)
+	}
+	if len(valid) == 0 {
+		return nil, ErrInvalidScope
+	}
+	sort.Strings(valid)
+	return valid, nil
+}
+
+// HasScope reports whether scopes include scope
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Audit trail actions of API keys
+const (
+	AuditAPIKeyCreated = "user.api_key_created"
+	AuditAPIKeyRotated = "user.api_key_rotated"
+	AuditAPIKeyRevoked = "user.api_key_revoked"
+)
+
+// Custom errors
+var (
+	ErrAPIKeyInvalid     = errors.New("invalid API key")
+	ErrAPIKeyExpired     = errors.New("API key has expired")
+	ErrAPIKeyRevoked     = errors.New("API key has been revoked")
+	ErrInvalidScope      = errors.New("unknown or missing scope")
+	ErrScopeNotAllowed   = errors.New("admin scopes are for admins only")
+	ErrInsufficientScope = errors.New("the API key lacks the scope for this request")
+	ErrAPIKeyTTL         = errors.New("API keys are valid for 1 to 365 days")
+)
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyVerify(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	account := &ServiceAccount{ID: uuid.New(), UserID: uuid.New()}
+	key, raw, err := NewAPIKey(account, []string{ScopeAccountsRead}, DefaultAPIKeyTTL, now)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(raw, APIKeyPrefix+key.Prefix+"_"))
+	assert.NotContains(t, key.Hash, raw)
+
+	prefix, ok := ParseAPIKeyPrefix(raw)
+	assert.True(t, ok)
+	assert.Equal(t, key.Prefix, prefix)
+
+	tests := []struct {
+		name    string
+		raw     string
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", raw, now, nil},
+		{"just before expiry", raw, now.Add(DefaultAPIKeyTTL - time.Second), nil},
+		{"expired", raw, now.Add(DefaultAPIKeyTTL), ErrAPIKeyExpired},
+		{"other secret", raw[:len(raw)-1] + "x", now, ErrAPIKeyInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, key.Verify(tt.raw, tt.now))
+		})
+	}
+
+	revokedAt := now
+	key.RevokedAt = &revokedAt
+	assert.Equal(t, ErrAPIKeyRevoked, key.Verify(raw, now))
+}
+
+func TestParseAPIKeyPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		wantOK bool
+	}{
+		{"api key", "tk_0123456789ab_c2VjcmV0", true},
+		{"jwt", "eyJhbGciOiJIUzI1NiJ9.e30.sig", false},
+		{"short prefix", "tk_0123_c2VjcmV0", false},
+		{"no secret", "tk_0123456789ab_", false},
+		{"no separator", "tk_0123456789ab", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := ParseAPIKeyPrefix(tt.raw)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestAPIKeyRotate(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	account := &ServiceAccount{ID: uuid.New(), UserID: uuid.New()}
+	key, _, err := NewAPIKey(account, []string{ScopeAccountsRead, ScopeTransactionsRead}, 30*24*time.Hour, created)
+	assert.NoError(t, err)
+
+	now := created.Add(10 * 24 * time.Hour)
+	replacement, raw, err := key.Rotate(now)
+	assert.NoError(t, err)
+	assert.NoError(t, replacement.Verify(raw, now))
+	assert.Equal(t, key.Scopes, replacement.Scopes)
+	assert.Equal(t, now.Add(30*24*time.Hour), replacement.ExpiresAt)
+	assert.Equal(t, &key.ID, replacement.RotatedFromID)
+	assert.Equal(t, now.Add(APIKeyRotationGrace), key.ExpiresAt, "the old key works for the grace period")
+
+	// A key about to expire keeps its expiry
+	key, _, err = NewAPIKey(account, []string{ScopeAccountsRead}, time.Hour, created)
+	assert.NoError(t, err)
+	_, _, err = key.Rotate(created)
+	assert.NoError(t, err)
+	assert.Equal(t, created.Add(time.Hour), key.ExpiresAt)
+
+	_, _, err = key.Rotate(created.Add(time.Hour))
+	assert.Equal(t, ErrAPIKeyExpired, err)
+}
+
+func TestValidateScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		role    string
+		want    []string
+		wantErr error
+	}{
+		{"sorted without duplicates", []string{ScopeTransactionsRead, ScopeAccountsRead, ScopeTransactionsRead}, "user", []string{ScopeAccountsRead, ScopeTransactionsRead}, nil},
+		{"unknown scope", []string{"accounts:delete"}, "user", nil, ErrInvalidScope},
+		{"no scopes", nil, "user", nil, ErrInvalidScope},
+		{"admin scope for user", []string{ScopeAdminRead}, "user", nil, ErrScopeNotAllowed},
+		{"admin scope for admin", []string{ScopeAdminRead}, "admin", []string{ScopeAdminRead}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scopes, err := ValidateScopes(tt.scopes, tt.role)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, scopes)
+		})
+	}
+}
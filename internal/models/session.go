@@ -0,0 +1,129 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// SessionIdleTimeout ends sessions that were not used for this long
+	SessionIdleTimeout = 30 * 24 * time.Hour
+	// sessionTouchInterval is how often the last use of a session is saved
+	sessionTouchInterval = time.Minute
+)
+
+// Session is a login of a user on a device. The tokens issued at login are
+// bound to it, so they stop working once it is revoked or idle too long.
+type Session struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	DeviceName string     `gorm:"type:varchar(100);not null" json:"device_name"`
+	UserAgent  string     `gorm:"type:varchar(500)" json:"user_agent"`
+	IP         string     `gorm:"column:ip;type:varchar(45)" json:"ip"` // of the last use
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Current    bool       `gorm:"-" json:"current"` // the session of the request
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewSession starts a session of the user. Without a device name one is
+// derived from the user agent.
+func NewSession(user *User, deviceName, userAgent, ip string, now time.Time) *Session {
+	deviceName = strings.TrimSpace(deviceName)
+	if deviceName == "" {
+		deviceName = DeviceName(userAgent)
+	}
+	return &Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		DeviceName: truncate(deviceName, 100),
+		UserAgent:  truncate(userAgent, 500),
+		IP:         ip,
+		LastSeenAt: now,
+	}
+}
+
+// Active reports whether the session's tokens are still honoured
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Sub(s.LastSeenAt) < SessionIdleTimeout
+}
+
+// Seen records a use of the session from the IP, reporting whether it
+// changed enough to be saved; uses within a minute from the same IP are not
+func (s *Session) Seen(now time.Time, ip string) bool {
+	if ip == s.IP && now.Sub(s.LastSeenAt) < sessionTouchInterval {
+		return false
+	}
+	s.LastSeenAt = now
+	s.IP = ip
+	return true
+}
+
+// DeviceName describes the browser and operating system of a user agent,
+// such as "Firefox on Windows"
+func DeviceName(userAgent string) string {
+	var browser, system string
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	}
+	switch {
+	case strings.Contains(userAgent, "iPhone"):
+		system = "iPhone"
+	case strings.Contains(userAgent, "iPad"):
+		system = "iPad"
+	case strings.Contains(userAgent, "Android"):
+		system = "Android"
+	case strings.Contains(userAgent, "Windows"):
+		system = "Windows"
+	case strings.Contains(userAgent, "Mac OS X"):
+		system = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		system = "Linux"
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	case userAgent != "":
+		// API clients, such as curl/8.5.0, by their product name
+		product, _, _ := strings.Cut(userAgent, "/")
+		return truncate(product, 100)
+	default:
+		return "Unknown device"
+	}
+}
+
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+// Custom errors
+var (
+	ErrSessionRevoked = errors.New("session has ended, please log in again")
+)
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceName(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{"chrome on macos", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", "Chrome on macOS"},
+		{"edge on windows", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0", "Edge on Windows"},
+		{"firefox on linux", "Mozilla/5.0 (X11; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0", "Firefox on Linux"},
+		{"safari on iphone", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", "Safari on iPhone"},
+		{"chrome on android", "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"api client", "curl/8.5.0", "curl"},
+		{"empty", "", "Unknown device"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DeviceName(tt.userAgent))
+		})
+	}
+}
+
+func TestSessionActiveAndSeen(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	user := &User{ID: uuid.New()}
+
+	session := NewSession(user, "  Jane's laptop ", "curl/8.5.0", "192.0.2.1", now)
+	assert.Equal(t, "Jane's laptop", session.DeviceName)
+	assert.True(t, session.Active(now))
+	assert.True(t, session.Active(now.Add(SessionIdleTimeout-time.Second)))
+	assert.False(t, session.Active(now.Add(SessionIdleTimeout)))
+
+	assert.False(t, session.Seen(now.Add(30*time.Second), "192.0.2.1"), "recent use from the same IP is not saved")
+	assert.True(t, session.Seen(now.Add(30*time.Second), "198.51.100.7"), "use from another IP is saved")
+	assert.Equal(t, "198.51.100.7", session.IP)
+	assert.True(t, session.Seen(now.Add(2*time.Minute), "198.51.100.7"))
+	assert.Equal(t, now.Add(2*time.Minute), session.LastSeenAt)
+
+	revokedAt := now.Add(time.Hour)
+	session.RevokedAt = &revokedAt
+	assert.False(t, session.Active(now.Add(time.Hour)))
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StandingOrderFrequency string
+
+const (
+	FrequencyOnce    StandingOrderFrequency = "once"
+	FrequencyDaily   StandingOrderFrequency = "daily"
+	FrequencyWeekly  StandingOrderFrequency = "weekly"
+	FrequencyMonthly StandingOrderFrequency = "monthly"
+)
+
+type StandingOrderStatus string
+
+const (
+	StandingOrderActive    StandingOrderStatus = "active"
+	StandingOrderPaused    StandingOrderStatus = "paused"
+	StandingOrderCompleted StandingOrderStatus = "completed"
+	StandingOrderCancelled StandingOrderStatus = "cancelled"
+	StandingOrderFailed    StandingOrderStatus = "failed"
+)
+
+// InsufficientFundsPolicy decides what happens to an occurrence that cannot
+// be paid
+type InsufficientFundsPolicy string
+
+const (
+	InsufficientFundsRetry InsufficientFundsPolicy = "retry"
+	InsufficientFundsSkip  InsufficientFundsPolicy = "skip"
+)
+
+type ExecutionStatus string
+
+const (
+	ExecutionPending    ExecutionStatus = "pending"
+	ExecutionProcessing ExecutionStatus = "processing"
+	ExecutionSucceeded  ExecutionStatus = "succeeded"
+	ExecutionSkipped    ExecutionStatus = "skipped"
+	ExecutionFailed     ExecutionStatus = "failed"
+)
+
+type StandingOrder struct {
+	ID                  uuid.UUID               `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID              uuid.UUID               `gorm:"type:uuid;not null;index" json:"user_id"`
+	RecipientID         uuid.UUID               `gorm:"type:uuid;not null" json:"recipient_id"`
+	Amount              float64                 `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Currency            string                  `gorm:"type:varchar(3);not null" json:"currency"`
+	Description         string                  `gorm:"type:text" json:"description"`
+	Frequency           StandingOrderFrequency  `gorm:"type:varchar(20);not null" json:"frequency"`
+	StartDate           time.Time               `gorm:"not null" json:"start_date"`
+	EndDate             *time.Time              `json:"end_date,omitempty"`
+	MaxOccurrences      int                     `gorm:"not null;default:0" json:"max_occurrences"` // 0 means no limit
+	Occurrence          int                     `gorm:"not null;default:0" json:"occurrence"`      // occurrences processed so far
+	NextRunAt           time.Time               `gorm:"not null" json:"next_run_at"`
+	NextAttemptAt       time.Time               `gorm:"not null;index" json:"next_attempt_at"`
+	OnInsufficientFunds InsufficientFundsPolicy `gorm:"type:varchar(20);not null;default:'retry'" json:"on_insufficient_funds"`
+	MaxRetries          int                     `gorm:"not null;default:3" json:"max_retries"`
+	Status              StandingOrderStatus     `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	LastError           string                  `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt           time.Time               `json:"created_at"`
+	UpdatedAt           time.Time               `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt          `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (o *StandingOrder) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate checks if the standing order is valid
+func (o *StandingOrder) Validate() error {
+	if o.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if o.UserID == o.RecipientID {
+		return ErrSelfTransfer
+	}
+	switch o.Frequency {
+	case FrequencyOnce, FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+	default:
+		return ErrInvalidFrequency
+	}
+	switch o.OnInsufficientFunds {
+	case InsufficientFundsRetry, InsufficientFundsSkip:
+	default:
+		return ErrInvalidFundsPolicy
+	}
+	if o.EndDate != nil && o.EndDate.Before(o.StartDate) {
+		return ErrInvalidSchedule
+	}
+	if o.MaxOccurrences < 0 || o.MaxRetries < 0 {
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+// OccurrenceDate returns the date of the n-th occurrence (starting at 0).
+// Monthly dates are computed from the start date so a schedule starting on
+// the 31st pays on the last day of shorter months without drifting.
+func (o *StandingOrder) OccurrenceDate(n int) time.Time {
+	switch o.Frequency {
+	case FrequencyDaily:
+		return o.StartDate.AddDate(0, 0, n)
+	case FrequencyWeekly:
+		return o.StartDate.AddDate(0, 0, 7*n)
+	case FrequencyMonthly:
+		return addMonthsClamped(o.StartDate, n)
+	default:
+		return o.StartDate
+	}
+}
+
+// Advance moves the order past the current occurrence, completing it when
+// the schedule is exhausted
+func (o *StandingOrder) Advance() {
+	o.Occurrence++
+	o.LastError = ""
+
+	if o.Frequency == FrequencyOnce || (o.MaxOccurrences > 0 && o.Occurrence >= o.MaxOccurrences) {
+		o.Status = StandingOrderCompleted
+		return
+	}
+	next := o.OccurrenceDate(o.Occurrence)
+	if o.EndDate != nil && next.After(*o.EndDate) {
+		o.Status = StandingOrderCompleted
+		return
+	}
+	o.NextRunAt = next
+	o.NextAttemptAt = next
+}
+
+// Resume reactivates a paused order. Occurrences missed while it was paused
+// are skipped rather than paid all at once, so it next runs today or at the
+// first occurrence after.
+func (o *StandingOrder) Resume(now time.Time) {
+	o.Status = StandingOrderActive
+	today := now.UTC().Truncate(24 * time.Hour)
+	for o.Status == StandingOrderActive && o.NextRunAt.Before(today) {
+		o.Advance()
+	}
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	firstOfTarget := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfTarget.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfTarget.AddDate(0, 0, day-1)
+}
+
+// StandingOrderExecution records the outcome of one occurrence. The unique
+// (standing_order_id, scheduled_for) pair guarantees an occurrence is only
+// ever executed once.
+type StandingOrderExecution struct {
+	ID              uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	StandingOrderID uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_standing_order_occurrence" json:"standing_order_id"`
+	ScheduledFor    time.Time       `gorm:"not null;uniqueIndex:idx_standing_order_occurrence" json:"scheduled_for"`
+	Status          ExecutionStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Attempts        int             `gorm:"not null;default:0" json:"attempts"`
+	TransactionID   *uuid.UUID      `gorm:"type:uuid" json:"transaction_id,omitempty"`
+	Error           string          `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (e *StandingOrderExecution) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// Custom errors
+var (
+	ErrSelfTransfer       = errors.New("cannot transfer to the same account")
+	ErrInvalidFrequency   = errors.New("frequency must be one of once, daily, weekly, monthly")
+	ErrInvalidFundsPolicy = errors.New("on_insufficient_funds must be retry or skip")
+	ErrInvalidSchedule    = errors.New("invalid schedule")
+	ErrOrderNotEditable   = errors.New("standing order can no longer be changed")
+)
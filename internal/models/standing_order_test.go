@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestStandingOrderOccurrenceDate(t *testing.T) {
+	tests := []struct {
+		name      string
+		frequency StandingOrderFrequency
+		start     time.Time
+		n         int
+		expected  time.Time
+	}{
+		{"once", FrequencyOnce, date(2024, 1, 31), 3, date(2024, 1, 31)},
+		{"daily", FrequencyDaily, date(2024, 2, 28), 2, date(2024, 3, 1)},
+		{"weekly", FrequencyWeekly, date(2024, 1, 1), 2, date(2024, 1, 15)},
+		{"monthly leap february", FrequencyMonthly, date(2024, 1, 31), 1, date(2024, 2, 29)},
+		{"monthly february", FrequencyMonthly, date(2023, 1, 31), 1, date(2023, 2, 28)},
+		{"monthly does not drift", FrequencyMonthly, date(2024, 1, 31), 2, date(2024, 3, 31)},
+		{"monthly across year", FrequencyMonthly, date(2024, 11, 30), 3, date(2025, 2, 28)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &StandingOrder{Frequency: tt.frequency, StartDate: tt.start}
+			assert.Equal(t, tt.expected, order.OccurrenceDate(tt.n))
+		})
+	}
+}
+
+func TestStandingOrderAdvance(t *testing.T) {
+	order := &StandingOrder{Frequency: FrequencyOnce, StartDate: date(2024, 1, 1), Status: StandingOrderActive}
+	order.Advance()
+	assert.Equal(t, StandingOrderCompleted, order.Status)
+
+	order = &StandingOrder{Frequency: FrequencyMonthly, StartDate: date(2024, 1, 31), MaxOccurrences: 2, Status: StandingOrderActive}
+	order.Advance()
+	assert.Equal(t, StandingOrderActive, order.Status)
+	assert.Equal(t, date(2024, 2, 29), order.NextRunAt)
+	order.Advance()
+	assert.Equal(t, StandingOrderCompleted, order.Status)
+
+	endDate := date(2024, 1, 10)
+	order = &StandingOrder{Frequency: FrequencyWeekly, StartDate: date(2024, 1, 1), EndDate: &endDate, Status: StandingOrderActive}
+	order.Advance()
+	assert.Equal(t, date(2024, 1, 8), order.NextAttemptAt)
+	order.Advance()
+	assert.Equal(t, StandingOrderCompleted, order.Status)
+}
+
+func TestStandingOrderResume(t *testing.T) {
+	now := time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	order := &StandingOrder{Frequency: FrequencyWeekly, StartDate: date(2024, 1, 1), NextRunAt: date(2024, 1, 1), Status: StandingOrderPaused}
+	order.Resume(now)
+	assert.Equal(t, StandingOrderActive, order.Status)
+	assert.Equal(t, date(2024, 3, 25), order.NextRunAt)
+	assert.Equal(t, 12, order.Occurrence)
+
+	order = &StandingOrder{Frequency: FrequencyDaily, StartDate: date(2024, 3, 1), NextRunAt: date(2024, 3, 1), Status: StandingOrderPaused}
+	order.Resume(now)
+	assert.Equal(t, date(2024, 3, 20), order.NextRunAt)
+
+	order = &StandingOrder{Frequency: FrequencyMonthly, StartDate: date(2024, 1, 1), NextRunAt: date(2024, 1, 1), MaxOccurrences: 2, Status: StandingOrderPaused}
+	order.Resume(now)
+	assert.Equal(t, StandingOrderCompleted, order.Status)
+}
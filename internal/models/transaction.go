@@ -15,24 +15,47 @@ const (
 	TransactionTypeDeposit  TransactionType = "deposit"
 	TransactionTypeWithdraw TransactionType = "withdraw"
 	TransactionTypeTransfer TransactionType = "transfer"
+	TransactionTypeFee      TransactionType = "fee"
+	// TransactionTypeMove moves money between two accounts of the same user
+	TransactionTypeMove TransactionType = "move"
+	// TransactionTypeReversal undoes another transaction, its parent
+	TransactionTypeReversal TransactionType = "reversal"
+	// TransactionTypeAdjustment is a manual correction by operations; it
+	// credits the user when they are the recipient and debits them otherwise
+	TransactionTypeAdjustment TransactionType = "adjustment"
+)
+
+const (
+	TransactionStatusCompleted = "completed"
+	TransactionStatusReversed  = "reversed"
 )
 
 type Transaction struct {
-	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID      uuid.UUID       `gorm:"type:uuid;not null;index" json:"user_id"`
-	Type        TransactionType `gorm:"type:varchar(20);not null" json:"type"`
-	Amount      float64         `gorm:"type:decimal(20,2);not null" json:"amount"`
-	Currency    string          `gorm:"type:varchar(3);not null" json:"currency"`
-	RecipientID *uuid.UUID      `gorm:"type:uuid;index" json:"recipient_id,omitempty"`
-	Description string          `gorm:"type:text" json:"description"`
-	Status      string          `gorm:"type:varchar(20);not null;default:'completed'" json:"status"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt  `gorm:"index" json:"-"`
+	ID                   uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID               uuid.UUID       `gorm:"type:uuid;not null;index" json:"user_id"`
+	Type                 TransactionType `gorm:"type:varchar(20);not null" json:"type"`
+	Amount               float64         `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Currency             string          `gorm:"type:varchar(3);not null" json:"currency"`
+	RecipientID          *uuid.UUID      `gorm:"type:uuid;index" json:"recipient_id,omitempty"`
+	Description          string          `gorm:"type:text" json:"description"`
+	Status               string          `gorm:"type:varchar(20);not null;default:'completed'" json:"status"`
+	Fee                  float64         `gorm:"type:decimal(20,2);not null;default:0" json:"fee"`        // fee charged on top of the amount
+	ParentID             *uuid.UUID      `gorm:"type:uuid;index" json:"parent_id,omitempty"`              // transaction a fee was charged for or a reversal undoes
+	SourceAccountID      *uuid.UUID      `gorm:"type:uuid;index" json:"source_account_id,omitempty"`      // debited account, the main account when not given
+	DestinationAccountID *uuid.UUID      `gorm:"type:uuid;index" json:"destination_account_id,omitempty"` // credited account, the main account when not given
+	InitiatedBy          *uuid.UUID      `gorm:"type:uuid;index" json:"initiated_by,omitempty"`           // member who made the transaction on the user's account
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt  `gorm:"index" json:"-"`
+
+	// FreeTransfers is the monthly allowance of free transfers of the user's
+	// pricing plan. The fee is waived when the transfer is booked within it.
+	FreeTransfers int `gorm:"-" json:"-"`
 
 	// Relationships
-	User      User  `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Recipient *User `gorm:"foreignKey:RecipientID" json:"recipient,omitempty"`
+	User      User    `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Recipient *User   `gorm:"foreignKey:RecipientID" json:"recipient,omitempty"`
+	Payout    *Payout `gorm:"foreignKey:TransactionID" json:"payout,omitempty"` // withdrawals to an external account
 }
 
 // BeforeCreate will set a UUID rather than numeric ID
@@ -49,15 +72,126 @@ func (t *Transaction) Validate() error {
 		return ErrInvalidAmount
 	}
 
-	if t.Type == TransactionTypeTransfer && t.RecipientID == nil {
+	if (t.Type == TransactionTypeTransfer || t.Type == TransactionTypeFee) && t.RecipientID == nil {
 		return ErrMissingRecipient
 	}
 
+	if t.Type == TransactionTypeTransfer && *t.RecipientID == t.UserID {
+		return ErrSelfTransfer
+	}
+
+	if t.Payout != nil {
+		if t.Type != TransactionTypeWithdraw {
+			return ErrPayoutOnlyForWithdrawals
+		}
+		if err := t.Payout.Destination().Validate(); err != nil {
+			return err
+		}
+	}
+
+	if t.Type == TransactionTypeMove {
+		if t.SourceAccountID == nil || t.DestinationAccountID == nil {
+			return ErrMissingAccount
+		}
+		if *t.SourceAccountID == *t.DestinationAccountID {
+			return ErrSameAccount
+		}
+	}
+
 	return nil
 }
 
+// Debits reports whether the transaction takes money from the user's account
+func (t *Transaction) Debits() bool {
+	switch t.Type {
+	case TransactionTypeWithdraw, TransactionTypeTransfer, TransactionTypeFee, TransactionTypeMove:
+		return true
+	case TransactionTypeAdjustment:
+		return t.RecipientID == nil
+	default:
+		return false
+	}
+}
+
+// Beneficiary returns the user whose account the transaction credits, if any
+func (t *Transaction) Beneficiary() *uuid.UUID {
+	switch t.Type {
+	case TransactionTypeDeposit, TransactionTypeMove:
+		return &t.UserID
+	case TransactionTypeTransfer, TransactionTypeFee, TransactionTypeAdjustment:
+		return t.RecipientID
+	default:
+		return nil
+	}
+}
+
+// FeeKindFor returns the fee rule kind that prices a transaction type, if any
+func FeeKindFor(txType TransactionType) (FeeKind, bool) {
+	switch txType {
+	case TransactionTypeWithdraw:
+		return FeeKindWithdraw, true
+	case TransactionTypeTransfer:
+		return FeeKindTransfer, true
+	default:
+		return "", false
+	}
+}
+
+// NewFeeTransaction books the fee of a transaction to the fee revenue account
+func (t *Transaction) NewFeeTransaction() *Transaction {
+	return &Transaction{
+		UserID:          t.UserID,
+		Type:            TransactionTypeFee,
+		Amount:          t.Fee,
+		Currency:        t.Currency,
+		RecipientID:     &FeeRevenueAccountID,
+		ParentID:        &t.ID,
+		Description:     "Fee for " + string(t.Type) + " " + t.ID.String(),
+		SourceAccountID: t.SourceAccountID, // charged to the account that paid
+		InitiatedBy:     t.InitiatedBy,
+	}
+}
+
+// CheckReversible verifies the transaction can be reversed. Fees are reversed
+// together with the transaction they were charged for. A withdrawal paid out
+// to an external account is checked only when its payout is loaded.
+func (t *Transaction) CheckReversible() error {
+	switch t.Type {
+	case TransactionTypeDeposit, TransactionTypeWithdraw, TransactionTypeTransfer, TransactionTypeMove, TransactionTypeAdjustment:
+	default:
+		return ErrNotReversible
+	}
+	if t.Status == TransactionStatusReversed {
+		return ErrAlreadyReversed
+	}
+	if t.Payout != nil {
+		return ErrPayoutNotReversible
+	}
+	return nil
+}
+
+// NewReversal books the money of the transaction back: the account it
+// credited is debited and the account it debited is credited
+func (t *Transaction) NewReversal(description string, initiatedBy uuid.UUID) *Transaction {
+	return &Transaction{
+		UserID:               t.UserID,
+		Type:                 TransactionTypeReversal,
+		Amount:               t.Amount,
+		Currency:             t.Currency,
+		RecipientID:          t.RecipientID,
+		ParentID:             &t.ID,
+		Description:          description,
+		SourceAccountID:      t.DestinationAccountID,
+		DestinationAccountID: t.SourceAccountID,
+		InitiatedBy:          &initiatedBy,
+	}
+}
+
 // Custom errors
 var (
 	ErrInvalidAmount    = errors.New("invalid amount")
 	ErrMissingRecipient = errors.New("recipient is required for transfer")
+	ErrMissingAccount   = errors.New("source and destination accounts are required for a move")
+	ErrNotReversible    = errors.New("only deposits, withdrawals, transfers, moves and adjustments can be reversed")
+	ErrAlreadyReversed  = errors.New("transaction was already reversed")
 )
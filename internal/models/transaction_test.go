@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionReversal(t *testing.T) {
+	source, destination, admin := uuid.New(), uuid.New(), uuid.New()
+	transfer := Transaction{
+		ID:                   uuid.New(),
+		Type:                 TransactionTypeTransfer,
+		Amount:               40,
+		Currency:             "EUR",
+		Status:               TransactionStatusCompleted,
+		SourceAccountID:      &source,
+		DestinationAccountID: &destination,
+	}
+	assert.NoError(t, transfer.CheckReversible())
+
+	reversal := transfer.NewReversal("duplicate payment", admin)
+	assert.Equal(t, TransactionTypeReversal, reversal.Type)
+	assert.Equal(t, &transfer.ID, reversal.ParentID)
+	assert.Equal(t, &destination, reversal.SourceAccountID)
+	assert.Equal(t, &source, reversal.DestinationAccountID)
+	assert.Equal(t, ErrNotReversible, reversal.CheckReversible())
+
+	transfer.Status = TransactionStatusReversed
+	assert.Equal(t, ErrAlreadyReversed, transfer.CheckReversible())
+
+	fee := Transaction{Type: TransactionTypeFee, Status: TransactionStatusCompleted}
+	assert.Equal(t, ErrNotReversible, fee.CheckReversible())
+}
@@ -9,13 +9,20 @@ import (
 )
 
 type User struct {
-	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Email     string         `gorm:"uniqueIndex;not null" json:"email"`
-	Password  string         `gorm:"not null" json:"-"`
-	Role      string         `gorm:"not null;default:'user'" json:"role"` // user or admin
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Email           string         `gorm:"uniqueIndex;not null" json:"email"`
+	Handle          *string        `gorm:"type:varchar(30)" json:"handle,omitempty"` // unique, others pay the user by it
+	Password        string         `gorm:"not null" json:"-"`
+	Role            string         `gorm:"not null;default:'user'" json:"role"` // user or admin
+	Status          UserStatus     `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	FreezeScope     FreezeScope    `gorm:"type:varchar(20)" json:"freeze_scope,omitempty"` // frozen users only
+	StatusReason    string         `gorm:"type:text" json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time     `json:"status_changed_at,omitempty"`
+	LastLoginAt     *time.Time     `json:"last_login_at,omitempty"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // BeforeCreate will set a UUID rather than numeric ID
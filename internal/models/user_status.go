@@ -0,0 +1,176 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserStatus is where a user is in their lifecycle
+type UserStatus string
+
+const (
+	UserStatusActive UserStatus = "active"
+	// UserStatusFrozen is set by an admin; the freeze scope says what it blocks
+	UserStatusFrozen UserStatus = "frozen"
+	// UserStatusDormant is set when a customer was inactive for the dormancy
+	// period; their next login reactivates them
+	UserStatusDormant UserStatus = "dormant"
+	// UserStatusClosed is final: the user can no longer log in, pay or be paid
+	UserStatusClosed UserStatus = "closed"
+)
+
+// FreezeScope is what a freeze blocks
+type FreezeScope string
+
+const (
+	// FreezeOutgoing blocks money leaving the user; they can still be paid
+	FreezeOutgoing FreezeScope = "outgoing"
+	// FreezeAll blocks every transaction of the user, incoming ones too
+	FreezeAll FreezeScope = "all"
+)
+
+// DormancyPeriod is how long a customer may go without logging in or making
+// a transaction before they are marked dormant
+const DormancyPeriod = 365 * 24 * time.Hour
+
+// Audit trail actions of the user lifecycle
+const (
+	AuditUserFrozen      = "user.frozen"
+	AuditUserUnfrozen    = "user.unfrozen"
+	AuditUserDormant     = "user.dormant"
+	AuditUserReactivated = "user.reactivated"
+	AuditUserClosed      = "user.closed"
+	AuditUserReopened    = "user.reopened"
+)
+
+// Valid reports whether the freeze scope is known
+func (s FreezeScope) Valid() bool {
+	return s == FreezeOutgoing || s == FreezeAll
+}
+
+// CheckLogin verifies the user may authenticate
+func (u *User) CheckLogin() error {
+	if u.Status == UserStatusClosed {
+		return ErrUserClosed
+	}
+	return nil
+}
+
+// CheckTransaction verifies the user may make a transaction. Closed users
+// make none; frozen users none that the freeze covers; dormant users can
+// still receive money but must log in again before spending.
+func (u *User) CheckTransaction(transaction *Transaction) error {
+	switch u.Status {
+	case UserStatusClosed:
+		return ErrUserClosed
+	case UserStatusFrozen:
+		if u.FreezeScope == FreezeAll || transaction.Debits() {
+			return ErrUserFrozen
+		}
+	case UserStatusDormant:
+		if transaction.Debits() {
+			return ErrUserDormant
+		}
+	}
+	return nil
+}
+
+// CanReceive reports whether money may be paid to the user
+func (u *User) CanReceive() bool {
+	switch u.Status {
+	case UserStatusClosed:
+		return false
+	case UserStatusFrozen:
+		return u.FreezeScope != FreezeAll
+	default:
+		return true
+	}
+}
+
+// Freeze blocks the user's outgoing or all transactions for a reason. A
+// frozen user can be frozen again to change the scope.
+func (u *User) Freeze(scope FreezeScope, reason string, now time.Time) error {
+	if u.Status == UserStatusClosed {
+		return ErrUserClosed
+	}
+	if !scope.Valid() {
+		return ErrInvalidFreezeScope
+	}
+	return u.setStatus(UserStatusFrozen, scope, reason, now)
+}
+
+// Unfreeze lifts a freeze
+func (u *User) Unfreeze(reason string, now time.Time) error {
+	if u.Status != UserStatusFrozen {
+		return ErrUserNotFrozen
+	}
+	return u.setStatus(UserStatusActive, "", reason, now)
+}
+
+// Close marks the user closed; the caller checks their balances are empty
+func (u *User) Close(reason string, now time.Time) error {
+	if u.Status == UserStatusClosed {
+		return ErrUserClosed
+	}
+	if u.Status == UserStatusFrozen {
+		return ErrUserFrozen
+	}
+	return u.setStatus(UserStatusClosed, "", reason, now)
+}
+
+// Reopen freezes a closed user entirely, when money comes back to them
+// after they closed. An admin unfreezes them to pay it out again.
+func (u *User) Reopen(reason string, now time.Time) error {
+	if u.Status != UserStatusClosed {
+		return ErrUserNotClosed
+	}
+	return u.setStatus(UserStatusFrozen, FreezeAll, reason, now)
+}
+
+// Reactivate makes a dormant user active again, reporting whether they were
+// dormant
+func (u *User) Reactivate(now time.Time) bool {
+	if u.Status != UserStatusDormant {
+		return false
+	}
+	u.setStatus(UserStatusActive, "", "Logged in", now)
+	return true
+}
+
+func (u *User) setStatus(status UserStatus, scope FreezeScope, reason string, now time.Time) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrChangeReasonRequired
+	}
+	u.Status = status
+	u.FreezeScope = scope
+	u.StatusReason = reason
+	u.StatusChangedAt = &now
+	return nil
+}
+
+// NewAuditLog records a lifecycle action an actor took on the user; actorID
+// is nil for the system
+func (u *User) NewAuditLog(action string, actorID *uuid.UUID, comment string) *AuditLog {
+	return &AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: AggregateUser,
+		TargetID:   u.ID,
+		Comment:    comment,
+	}
+}
+
+// Custom errors
+var (
+	ErrUserFrozen         = errors.New("account is frozen")
+	ErrUserDormant        = errors.New("account is dormant, log in again to reactivate it")
+	ErrUserClosed         = errors.New("account is closed")
+	ErrUserNotFrozen      = errors.New("account is not frozen")
+	ErrUserNotClosed      = errors.New("account is not closed")
+	ErrUserNotEmpty       = errors.New("balances must be zero and unheld to close the account, or give a payout account for the remainder")
+	ErrInvalidFreezeScope = errors.New("freeze scope must be outgoing or all")
+)
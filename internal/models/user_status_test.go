@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserCheckTransaction(t *testing.T) {
+	recipient := uuid.New()
+	deposit := &Transaction{Type: TransactionTypeDeposit}
+	withdrawal := &Transaction{Type: TransactionTypeWithdraw}
+	transfer := &Transaction{Type: TransactionTypeTransfer, RecipientID: &recipient}
+
+	tests := []struct {
+		name         string
+		user         User
+		wantDeposit  error
+		wantWithdraw error
+		wantTransfer error
+	}{
+		{"active", User{Status: UserStatusActive}, nil, nil, nil},
+		{"frozen outgoing", User{Status: UserStatusFrozen, FreezeScope: FreezeOutgoing}, nil, ErrUserFrozen, ErrUserFrozen},
+		{"frozen all", User{Status: UserStatusFrozen, FreezeScope: FreezeAll}, ErrUserFrozen, ErrUserFrozen, ErrUserFrozen},
+		{"dormant", User{Status: UserStatusDormant}, nil, ErrUserDormant, ErrUserDormant},
+		{"closed", User{Status: UserStatusClosed}, ErrUserClosed, ErrUserClosed, ErrUserClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDeposit, tt.user.CheckTransaction(deposit))
+			assert.Equal(t, tt.wantWithdraw, tt.user.CheckTransaction(withdrawal))
+			assert.Equal(t, tt.wantTransfer, tt.user.CheckTransaction(transfer))
+		})
+	}
+}
+
+func TestUserLifecycle(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	user := &User{Status: UserStatusActive}
+
+	assert.Equal(t, ErrInvalidFreezeScope, user.Freeze("incoming", "Fraud report", now))
+	assert.Equal(t, ErrChangeReasonRequired, user.Freeze(FreezeAll, " ", now))
+	assert.Equal(t, ErrUserNotFrozen, user.Unfreeze("Cleared", now))
+
+	assert.NoError(t, user.Freeze(FreezeOutgoing, "Fraud report", now))
+	assert.Equal(t, UserStatusFrozen, user.Status)
+	assert.Equal(t, FreezeOutgoing, user.FreezeScope)
+	assert.Equal(t, &now, user.StatusChangedAt)
+	assert.NoError(t, user.Freeze(FreezeAll, "Confirmed fraud", now))
+	assert.Equal(t, FreezeAll, user.FreezeScope)
+	assert.Equal(t, ErrUserFrozen, user.Close("Leaving", now))
+
+	assert.NoError(t, user.Unfreeze("Cleared", now))
+	assert.Equal(t, UserStatusActive, user.Status)
+	assert.Empty(t, user.FreezeScope)
+	assert.False(t, user.Reactivate(now))
+
+	user.Status = UserStatusDormant
+	assert.NoError(t, user.CheckLogin())
+	assert.True(t, user.Reactivate(now))
+	assert.Equal(t, UserStatusActive, user.Status)
+
+	assert.NoError(t, user.Close("Leaving", now))
+	assert.Equal(t, UserStatusClosed, user.Status)
+	assert.Equal(t, ErrUserClosed, user.CheckLogin())
+	assert.Equal(t, ErrUserClosed, user.Close("Again", now))
+	assert.Equal(t, ErrUserClosed, user.Freeze(FreezeAll, "Fraud report", now))
+
+	assert.NoError(t, user.Reopen("Closure payout rejected", now))
+	assert.Equal(t, UserStatusFrozen, user.Status)
+	assert.Equal(t, FreezeAll, user.FreezeScope)
+	assert.Equal(t, ErrUserNotClosed, user.Reopen("Again", now))
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TokenPurpose is what a user token may be redeemed for
+type TokenPurpose string
+
+const (
+	TokenPasswordReset     TokenPurpose = "password_reset"
+	TokenEmailVerification TokenPurpose = "email_verification"
+)
+
+// How long user tokens can be redeemed
+const (
+	PasswordResetTTL     = time.Hour
+	EmailVerificationTTL = 48 * time.Hour
+)
+
+// TTL returns how long a token of the purpose can be redeemed
+func (p TokenPurpose) TTL() time.Duration {
+	if p == TokenPasswordReset {
+		return PasswordResetTTL
+	}
+	return EmailVerificationTTL
+}
+
+// UserToken is a single-use token mailed to a user to reset their password
+// or verify their email. Only its ID and expiry are in the token the user
+// gets, signed so they cannot be forged; redeeming it sets UsedAt.
+type UserToken struct {
+	ID        uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Purpose   TokenPurpose `gorm:"type:varchar(30);not null" json:"purpose"`
+	Email     string       `gorm:"type:varchar(255);not null" json:"email"` // the token was mailed to
+	ExpiresAt time.Time    `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at,omitempty"` // redeemed, or superseded by a newer token
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (t *UserToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewUserToken creates a token of the purpose for the user's current email
+func NewUserToken(user *User, purpose TokenPurpose, now time.Time) *UserToken {
+	return &UserToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		ExpiresAt: now.Add(purpose.TTL()).Truncate(time.Second),
+	}
+}
+
+// Sign returns the token to mail to the user: its ID and expiry followed by
+// an HMAC-SHA256 of them and the purpose, base64url encoded
+func (t *UserToken) Sign(key []byte) string {
+	payload := make([]byte, 24)
+	copy(payload, t.ID[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(t.ExpiresAt.Unix()))
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(tokenMAC(key, t.Purpose, payload))
+}
+
+// ParseUserToken verifies the signature of a token of the purpose and that
+// it has not expired, returning the ID of the token to redeem
+func ParseUserToken(key []byte, purpose TokenPurpose, token string, now time.Time) (uuid.UUID, error) {
+	encodedPayload, encodedMAC, found := strings.Cut(strings.TrimSpace(token), ".")
+	if !found {
+		return uuid.Nil, ErrTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) != 24 {
+		return uuid.Nil, ErrTokenInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, tokenMAC(key, purpose, payload)) {
+		return uuid.Nil, ErrTokenInvalid
+	}
+
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0)
+	if !now.Before(expiresAt) {
+		return uuid.Nil, ErrTokenExpired
+	}
+	id, err := uuid.FromBytes(payload[:16])
+	if err != nil {
+		return uuid.Nil, ErrTokenInvalid
+	}
+	return id, nil
+}
+
+// Check verifies the stored token can still be redeemed by the user. A token
+// mailed to an address the user no longer has is invalid.
+func (t *UserToken) Check(user *User, now time.Time) error {
+	if t.UsedAt != nil || t.UserID != user.ID || !strings.EqualFold(t.Email, user.Email) {
+		return ErrTokenInvalid
+	}
+	if !now.Before(t.ExpiresAt) {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+func tokenMAC(key []byte, purpose TokenPurpose, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// EmailVerified reports whether the user verified their current email
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// Audit trail actions of account recovery
+const (
+	AuditPasswordReset = "user.password_reset"
+	AuditEmailVerified = "user.email_verified"
+)
+
+// Custom errors
+var (
+	ErrTokenInvalid         = errors.New("invalid or already used token")
+	ErrTokenExpired         = errors.New("token has expired")
+	ErrEmailNotVerified     = errors.New("email address is not verified")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+)
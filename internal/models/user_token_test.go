@@ -0,0 +1,61 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserTokenSignAndParse(t *testing.T) {
+	key := []byte("secret")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	token := NewUserToken(&User{ID: uuid.New(), Email: "jane@example.com"}, TokenPasswordReset, now)
+	signed := token.Sign(key)
+	payload, _, _ := strings.Cut(signed, ".")
+
+	tests := []struct {
+		name    string
+		key     []byte
+		purpose TokenPurpose
+		token   string
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", key, TokenPasswordReset, signed, now, nil},
+		{"just before expiry", key, TokenPasswordReset, signed, now.Add(PasswordResetTTL - time.Second), nil},
+		{"expired", key, TokenPasswordReset, signed, now.Add(PasswordResetTTL), ErrTokenExpired},
+		{"other purpose", key, TokenEmailVerification, signed, now, ErrTokenInvalid},
+		{"other key", []byte("other"), TokenPasswordReset, signed, now, ErrTokenInvalid},
+		{"tampered signature", key, TokenPasswordReset, payload + ".AAAA", now, ErrTokenInvalid},
+		{"no signature", key, TokenPasswordReset, payload, now, ErrTokenInvalid},
+		{"garbage", key, TokenPasswordReset, "not-a-token", now, ErrTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := ParseUserToken(tt.key, tt.purpose, tt.token, tt.now)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, token.ID, id)
+			}
+		})
+	}
+}
+
+func TestUserTokenCheck(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	user := &User{ID: uuid.New(), Email: "jane@example.com"}
+	token := NewUserToken(user, TokenEmailVerification, now)
+
+	assert.NoError(t, token.Check(user, now))
+	assert.NoError(t, token.Check(&User{ID: user.ID, Email: "Jane@Example.com"}, now))
+	assert.Equal(t, ErrTokenExpired, token.Check(user, now.Add(EmailVerificationTTL)))
+	assert.Equal(t, ErrTokenInvalid, token.Check(&User{ID: user.ID, Email: "jane@new.example.com"}, now))
+	assert.Equal(t, ErrTokenInvalid, token.Check(&User{ID: uuid.New(), Email: user.Email}, now))
+
+	token.UsedAt = &now
+	assert.Equal(t, ErrTokenInvalid, token.Check(user, now))
+}
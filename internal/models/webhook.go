@@ -0,0 +1,145 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// EventBalanceLow is derived from balance.updated when a balance drops
+// below a subscription's threshold
+const EventBalanceLow = "balance.low"
+
+// WebhookEventTypes lists every event a subscription may subscribe to
+var WebhookEventTypes = []string{
+	EventTransactionCreated,
+	EventTransactionReversed,
+	EventBalanceLow,
+	EventUserUpdated,
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"
+)
+
+const (
+	// WebhookMaxAttempts is the number of attempts before a delivery is dead-lettered
+	WebhookMaxAttempts = 8
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+)
+
+type WebhookSubscription struct {
+	ID                  uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID              uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	URL                 string         `gorm:"type:text;not null" json:"url"`
+	Secret              string         `gorm:"type:varchar(100);not null" json:"-"`
+	Events              pq.StringArray `gorm:"type:text[];not null" json:"events" swaggertype:"array,string"`
+	LowBalanceThreshold float64        `gorm:"type:decimal(20,2);not null;default:0" json:"low_balance_threshold"`
+	Description         string         `gorm:"type:text" json:"description"`
+	Active              bool           `gorm:"not null;default:true" json:"active"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (s *WebhookSubscription) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Matches reports whether the subscription wants the given event
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	for _, e := range s.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID             `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SubscriptionID uuid.UUID             `gorm:"type:uuid;not null;index" json:"subscription_id"`
+	ReplayOf       *uuid.UUID            `gorm:"type:uuid" json:"replay_of,omitempty"`
+	EventID        uuid.UUID             `gorm:"type:uuid;not null;index" json:"event_id"`
+	EventType      string                `gorm:"type:varchar(50);not null" json:"event_type"`
+	Payload        string                `gorm:"type:jsonb;not null" json:"payload"`
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	Attempts       int                   `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time             `gorm:"not null;index" json:"next_attempt_at"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// RecordFailure registers a failed attempt and schedules the next one with
+// exponential backoff, dead-lettering the delivery once attempts run out
+func (d *WebhookDelivery) RecordFailure(now time.Time, statusCode int, reason string) {
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.LastStatusCode = statusCode
+	d.LastError = reason
+	if d.Attempts >= WebhookMaxAttempts {
+		d.Status = WebhookDeliveryDead
+		return
+	}
+	d.NextAttemptAt = now.Add(WebhookBackoff(d.Attempts))
+}
+
+// MarkDead dead-letters the delivery without further retries
+func (d *WebhookDelivery) MarkDead(now time.Time, reason string) {
+	d.LastAttemptAt = &now
+	d.LastError = reason
+	d.Status = WebhookDeliveryDead
+}
+
+// RecordSuccess marks the delivery as delivered
+func (d *WebhookDelivery) RecordSuccess(now time.Time, statusCode int) {
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.LastStatusCode = statusCode
+	d.LastError = ""
+	d.Status = WebhookDeliverySucceeded
+	d.DeliveredAt = &now
+}
+
+// WebhookBackoff returns the delay before the retry following the given attempt
+func WebhookBackoff(attempt int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// Custom errors
+var (
+	ErrInvalidWebhookURL     = errors.New("webhook url must be an absolute http or https url")
+	ErrInvalidWebhookEvent   = errors.New("unknown webhook event type")
+	ErrWebhookAddressBlocked = errors.New("webhook url must point to a public address")
+)
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, WebhookBackoff(1))
+	assert.Equal(t, time.Minute, WebhookBackoff(2))
+	assert.Equal(t, 4*time.Minute, WebhookBackoff(4))
+	assert.Equal(t, 6*time.Hour, WebhookBackoff(20))
+}
+
+func TestWebhookDeliveryRecordFailure(t *testing.T) {
+	now := time.Now()
+	delivery := &WebhookDelivery{Status: WebhookDeliveryPending}
+
+	delivery.RecordFailure(now, 500, "unexpected status 500")
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, now.Add(30*time.Second), delivery.NextAttemptAt)
+
+	for delivery.Attempts < WebhookMaxAttempts {
+		delivery.RecordFailure(now, 500, "unexpected status 500")
+	}
+	assert.Equal(t, WebhookDeliveryDead, delivery.Status)
+
+	delivery = &WebhookDelivery{Status: WebhookDeliveryPending}
+	delivery.RecordSuccess(now, 204)
+	assert.Equal(t, WebhookDeliverySucceeded, delivery.Status)
+	assert.NotNil(t, delivery.DeliveredAt)
+}
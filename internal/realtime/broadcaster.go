@@ -0,0 +1,53 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Broadcaster records notifications for users and announces them to all API
+// instances
+type Broadcaster struct {
+	client *redis.Client
+}
+
+// NewBroadcaster creates a broadcaster on the given Redis client
+func NewBroadcaster(client *redis.Client) *Broadcaster {
+	return &Broadcaster{client: client}
+}
+
+// Notify appends the notification to the user's stream, so reconnecting
+// clients can resume from it, and publishes it to connected clients
+func (b *Broadcaster) Notify(ctx context.Context, userID uuid.UUID, notificationType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	id, err := b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: userStream(userID),
+		MaxLen: userStreamLength,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type": notificationType,
+			"data": string(payload),
+		},
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	message, err := json.Marshal(announcement{
+		ID:     id,
+		UserID: userID,
+		Type:   notificationType,
+		Data:   payload,
+	})
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, notifyChannel, message).Err()
+}
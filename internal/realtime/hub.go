@@ -0,0 +1,181 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// subscriberBuffer is how many notifications may queue for a slow client
+// before it is disconnected
+const subscriberBuffer = 64
+
+// Hub receives announced notifications on one pub/sub connection per API
+// instance and fans them out to the local subscribers of each user
+type Hub struct {
+	client *redis.Client
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*subscription]struct{}
+}
+
+type subscription struct {
+	ch     chan Notification
+	closed bool
+}
+
+// NewHub creates a hub on the given Redis client
+func NewHub(client *redis.Client) *Hub {
+	return &Hub{
+		client:      client,
+		subscribers: make(map[uuid.UUID]map[*subscription]struct{}),
+	}
+}
+
+// Run dispatches announcements until the context is cancelled
+func (h *Hub) Run(ctx context.Context) {
+	pubsub := h.client.Subscribe(ctx, notifyChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var a announcement
+			if err := json.Unmarshal([]byte(msg.Payload), &a); err != nil {
+				log.Printf("realtime: dropping malformed announcement: %v", err)
+				continue
+			}
+			h.dispatch(Notification{ID: a.ID, UserID: a.UserID, Type: a.Type, Data: a.Data})
+		}
+	}
+}
+
+// Subscribe streams the user's notifications until the context is cancelled.
+// When lastEventID is set, notifications recorded after it are replayed first.
+// The channel is closed when the context ends or the client falls too far
+// behind.
+func (h *Hub) Subscribe(ctx context.Context, userID uuid.UUID, lastEventID string) (<-chan Notification, error) {
+	// Register before reading the backlog so nothing published in between is missed
+	sub := &subscription{ch: make(chan Notification, subscriberBuffer)}
+	h.register(userID, sub)
+
+	var backlog []Notification
+	if lastEventID != "" {
+		var err error
+		backlog, err = h.backlog(ctx, userID, lastEventID)
+		if err != nil {
+			h.unregister(userID, sub)
+			return nil, err
+		}
+	}
+
+	out := make(chan Notification)
+	go func() {
+		defer close(out)
+		defer h.unregister(userID, sub)
+
+		lastSent := lastEventID
+		send := func(n Notification) bool {
+			if !streamIDAfter(n.ID, lastSent) {
+				return true
+			}
+			select {
+			case out <- n:
+				lastSent = n.ID
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, n := range backlog {
+			if !send(n) {
+				return
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n, ok := <-sub.ch:
+				if !ok || !send(n) {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (h *Hub) backlog(ctx context.Context, userID uuid.UUID, lastEventID string) ([]Notification, error) {
+	messages, err := h.client.XRange(ctx, userStream(userID), "("+lastEventID, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]Notification, 0, len(messages))
+	for _, m := range messages {
+		notificationType, _ := m.Values["type"].(string)
+		data, _ := m.Values["data"].(string)
+		notifications = append(notifications, Notification{
+			ID:     m.ID,
+			UserID: userID,
+			Type:   notificationType,
+			Data:   json.RawMessage(data),
+		})
+	}
+	return notifications, nil
+}
+
+func (h *Hub) dispatch(n Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[n.UserID] {
+		select {
+		case sub.ch <- n:
+		default:
+			// The client is not keeping up; drop it so it reconnects and
+			// resumes from its last event
+			h.closeLocked(n.UserID, sub)
+		}
+	}
+}
+
+func (h *Hub) register(userID uuid.UUID, sub *subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*subscription]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+}
+
+func (h *Hub) unregister(userID uuid.UUID, sub *subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closeLocked(userID, sub)
+}
+
+func (h *Hub) closeLocked(userID uuid.UUID, sub *subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.ch)
+	delete(h.subscribers[userID], sub)
+	if len(h.subscribers[userID]) == 0 {
+		delete(h.subscribers, userID)
+	}
+}
@@ -0,0 +1,85 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Notification types pushed to connected clients
+const (
+	NotificationTransaction = "transaction"
+	NotificationBalance     = "balance"
+)
+
+const (
+	// notifyChannel is the pub/sub channel announcing new notifications to
+	// every API instance
+	notifyChannel = "banking:user-notify"
+	// userStreamPrefix prefixes the capped per-user stream kept for resuming
+	userStreamPrefix = "banking:user-events:"
+	// userStreamLength is how many notifications a client can catch up on
+	userStreamLength = 500
+)
+
+// Notification is a message for one user. ID is its position in the user's
+// stream and is what clients send back as Last-Event-ID.
+type Notification struct {
+	ID     string          `json:"id"`
+	UserID uuid.UUID       `json:"-"`
+	Type   string          `json:"type"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// announcement is the pub/sub payload
+type announcement struct {
+	ID     string          `json:"id"`
+	UserID uuid.UUID       `json:"user_id"`
+	Type   string          `json:"type"`
+	Data   json.RawMessage `json:"data"`
+}
+
+func userStream(userID uuid.UUID) string {
+	return userStreamPrefix + userID.String()
+}
+
+// streamIDAfter reports whether stream ID a is after b. Empty IDs sort first.
+func streamIDAfter(a, b string) bool {
+	ams, aseq, aerr := parseStreamID(a)
+	bms, bseq, berr := parseStreamID(b)
+	if aerr != nil {
+		return false
+	}
+	if berr != nil {
+		return true
+	}
+	if ams != bms {
+		return ams > bms
+	}
+	return aseq > bseq
+}
+
+func parseStreamID(id string) (uint64, uint64, error) {
+	parts := strings.SplitN(id, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid stream id %q", id)
+	}
+	ms, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return ms, seq, nil
+}
+
+// ValidStreamID reports whether id can be used as a resume position
+func ValidStreamID(id string) bool {
+	_, _, err := parseStreamID(id)
+	return err == nil
+}
@@ -0,0 +1,35 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamIDAfter(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"Later Millisecond", "1700000000001-0", "1700000000000-5", true},
+		{"Later Sequence", "1700000000000-2", "1700000000000-1", true},
+		{"Same ID", "1700000000000-1", "1700000000000-1", false},
+		{"Earlier", "1699999999999-9", "1700000000000-0", false},
+		{"Nothing Sent Yet", "1700000000000-0", "", true},
+		{"Malformed Candidate", "garbage", "1700000000000-0", false},
+		{"Numeric Not Lexical", "1700000000000-10", "1700000000000-9", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, streamIDAfter(tt.a, tt.b))
+		})
+	}
+}
+
+func TestValidStreamID(t *testing.T) {
+	assert.True(t, ValidStreamID("1700000000000-0"))
+	assert.False(t, ValidStreamID("1700000000000"))
+	assert.False(t, ValidStreamID("abc-def"))
+}
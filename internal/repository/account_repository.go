@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mainAccountName is the name given to main accounts opened on first credit
+const mainAccountName = "Main"
+
+type AccountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+// Create opens an account together with its empty balance
+func (r *AccountRepository) Create(account *models.Account) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		if err := db.Create(account).Error; err != nil {
+			return err
+		}
+		balance := models.Balance{AccountID: account.ID, UserID: account.UserID, Currency: account.Currency}
+		if err := db.Create(&balance).Error; err != nil {
+			return err
+		}
+		account.Balance = &balance
+		return nil
+	})
+}
+
+// Update saves an account's name and goal target
+func (r *AccountRepository) Update(account *models.Account) error {
+	return r.db.Model(account).
+		Select("name", "target_amount", "target_date").
+		Updates(account).Error
+}
+
+// Close deletes an account whose balance is empty and unheld. The balance row
+// is locked so no transaction can credit it while it is being closed.
+func (r *AccountRepository) Close(account *models.Account) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		balance, err := lockAccountBalance(db, account)
+		if err != nil {
+			return err
+		}
+		if balance.Amount != 0 || balance.Held != 0 {
+			return models.ErrAccountNotEmpty
+		}
+		if err := db.Delete(balance).Error; err != nil {
+			return err
+		}
+		return db.Delete(account).Error
+	})
+}
+
+// GetByID retrieves an account with its balance
+func (r *AccountRepository) GetByID(id uuid.UUID) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Preload("Balance").First(&account, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetByIDAndUserID retrieves an account owned by a user, with its balance
+func (r *AccountRepository) GetByIDAndUserID(id, userID uuid.UUID) (*models.Account, error) {
+	var account models.Account
+	err := r.db.Preload("Balance").Where("id = ? AND user_id = ?", id, userID).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetMain retrieves a user's main account in a currency
+func (r *AccountRepository) GetMain(userID uuid.UUID, currency string) (*models.Account, error) {
+	var account models.Account
+	err := r.db.Where("user_id = ? AND currency = ? AND type = ?", userID, currency, models.AccountTypeMain).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetByNumber retrieves an open account by its account number
+func (r *AccountRepository) GetByNumber(number string) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Where("number = ?", number).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListByUserID retrieves the accounts a user owns or is a member of with
+// their balances, main accounts first
+func (r *AccountRepository) ListByUserID(userID uuid.UUID) ([]models.Account, error) {
+	var accounts []models.Account
+	err := r.db.Preload("Balance").
+		Where("user_id = ? OR id IN (?)", userID,
+			r.db.Model(&models.AccountMember{}).Select("account_id").Where("user_id = ?", userID)).
+		Order("currency, type = 'main' DESC, created_at").
+		Find(&accounts).Error
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// ListTransactions retrieves the transactions debiting or crediting an
+// account, optionally only those a member initiated
+func (r *AccountRepository) ListTransactions(accountID uuid.UUID, initiatedBy *uuid.UUID, page, pageSize int) ([]models.Transaction, int64, error) {
+	query := r.db.Model(&models.Transaction{}).
+		Where("(source_account_id = ? OR destination_account_id = ?)", accountID, accountID)
+	if initiatedBy != nil {
+		query = query.Where("initiated_by = ?", *initiatedBy)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var transactions []models.Transaction
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&transactions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return transactions, total, nil
+}
+
+// checkSpendLimit verifies a member debiting the account keeps to their role
+// and monthly limit. It runs once the balance of the account is locked, so
+// concurrent debits of the member see each other; debits of the owner, of
+// users who are not members such as admins, and fees are not limited.
+func checkSpendLimit(db *gorm.DB, account *models.Account, tx *models.Transaction) error {
+	if tx.InitiatedBy == nil || *tx.InitiatedBy == account.UserID || tx.Type == models.TransactionTypeFee {
+		return nil
+	}
+	var member models.AccountMember
+	if err := db.Where("account_id = ? AND user_id = ?", account.ID, *tx.InitiatedBy).First(&member).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	var spent float64
+	if member.Role == models.AccountRoleSpender {
+		now := time.Now().UTC()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		err := db.Model(&models.Transaction{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("source_account_id = ? AND initiated_by = ? AND type <> ? AND created_at >= ? AND id <> ?",
+				account.ID, member.UserID, models.TransactionTypeFee, monthStart, tx.ID).
+			Scan(&spent).Error
+		if err != nil {
+			return err
+		}
+	}
+	return member.CheckSpend(tx.Amount, spent)
+}
+
+// GetMember retrieves a user's membership of an account
+func (r *AccountRepository) GetMember(accountID, userID uuid.UUID) (*models.AccountMember, error) {
+	var member models.AccountMember
+	if err := r.db.Where("account_id = ? AND user_id = ?", accountID, userID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListMemberships retrieves all memberships of a user
+func (r *AccountRepository) ListMemberships(userID uuid.UUID) ([]models.AccountMember, error) {
+	var members []models.AccountMember
+	if err := r.db.Where("user_id = ?", userID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ListMembers retrieves the members of an account
+func (r *AccountRepository) ListMembers(accountID uuid.UUID) ([]models.AccountMember, error) {
+	var members []models.AccountMember
+	err := r.db.Preload("User").Where("account_id = ?", accountID).Order("created_at").Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// UpdateMember saves a member's role and spend limit
+func (r *AccountRepository) UpdateMember(member *models.AccountMember) error {
+	return r.db.Model(member).Select("role", "spend_limit").Updates(member).Error
+}
+
+// RemoveMember revokes a membership
+func (r *AccountRepository) RemoveMember(member *models.AccountMember) error {
+	return r.db.Delete(member).Error
+}
+
+// CreateInvitation creates an invitation to an account
+func (r *AccountRepository) CreateInvitation(invitation *models.AccountInvitation) error {
+	return r.db.Create(invitation).Error
+}
+
+// GetInvitation retrieves an invitation with its account
+func (r *AccountRepository) GetInvitation(id uuid.UUID) (*models.AccountInvitation, error) {
+	var invitation models.AccountInvitation
+	if err := r.db.Preload("Account").First(&invitation, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// ListPendingInvitations retrieves the open invitations of an account
+func (r *AccountRepository) ListPendingInvitations(accountID uuid.UUID, now time.Time) ([]models.AccountInvitation, error) {
+	var invitations []models.AccountInvitation
+	err := r.db.Where("account_id = ? AND status = ? AND expires_at > ?", accountID, models.InvitationPending, now).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// ListInvitationsByEmail retrieves the open invitations sent to an email
+func (r *AccountRepository) ListInvitationsByEmail(email string, now time.Time) ([]models.AccountInvitation, error) {
+	var invitations []models.AccountInvitation
+	err := r.db.Preload("Account").
+		Where("email = ? AND status = ? AND expires_at > ?", models.NormalizeEmail(email), models.InvitationPending, now).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// CloseInvitation moves a pending invitation to status. It reports false when
+// the invitation was answered or revoked concurrently.
+func (r *AccountRepository) CloseInvitation(id uuid.UUID, status models.InvitationStatus, now time.Time) (bool, error) {
+	result := r.db.Model(&models.AccountInvitation{}).
+		Where("id = ? AND status = ?", id, models.InvitationPending).
+		Updates(map[string]interface{}{"status": status, "responded_at": now})
+	return result.RowsAffected == 1, result.Error
+}
+
+// AcceptInvitation closes a pending invitation and creates the membership it
+// grants in one database transaction
+func (r *AccountRepository) AcceptInvitation(invitation *models.AccountInvitation, member *models.AccountMember, now time.Time) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		result := db.Model(&models.AccountInvitation{}).
+			Where("id = ? AND status = ?", invitation.ID, models.InvitationPending).
+			Updates(map[string]interface{}{"status": models.InvitationAccepted, "responded_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return models.ErrInvitationNotPending
+		}
+		return db.Create(member).Error
+	})
+}
+
+// resolveAccount returns the account a transaction debits or credits. An
+// explicit account must belong to the user and be in the currency; without
+// one the user's main account is used, which is opened on first credit.
+// Debiting a missing main account fails with insufficient funds.
+func resolveAccount(db *gorm.DB, userID uuid.UUID, currency string, accountID *uuid.UUID, credit bool) (*models.Account, error) {
+	var account models.Account
+	if accountID != nil {
+		if err := db.Where("id = ? AND user_id = ?", *accountID, userID).First(&account).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, models.ErrAccountNotFound
+			}
+			return nil, err
+		}
+		if account.Currency != currency {
+			return nil, models.ErrAccountCurrencyMismatch
+		}
+		return &account, nil
+	}
+
+	if credit {
+		main := models.Account{UserID: userID, Name: mainAccountName, Type: models.AccountTypeMain, Currency: currency}
+		// A concurrent first credit may open it at the same time
+		err := db.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "user_id"}, {Name: "currency"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "type = 'main' AND deleted_at IS NULL"}}},
+			DoNothing:   true,
+		}).Create(&main).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := db.Where("user_id = ? AND currency = ? AND type = ?", userID, currency, models.AccountTypeMain).First(&account).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, models.ErrInsufficientFunds
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// lockAccountBalances locks the balances of the given accounts in ascending
+// account ID order, so two transfers between the same accounts in opposite
+// directions cannot deadlock. Nil accounts are skipped.
+func lockAccountBalances(db *gorm.DB, accounts ...*models.Account) (map[uuid.UUID]*models.Balance, error) {
+	ordered := make([]*models.Account, 0, len(accounts))
+	for _, account := range accounts {
+		if account != nil {
+			ordered = append(ordered, account)
+		}
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return bytes.Compare(ordered[i].ID[:], ordered[j].ID[:]) < 0
+	})
+
+	balances := make(map[uuid.UUID]*models.Balance, len(ordered))
+	for _, account := range ordered {
+		if _, ok := balances[account.ID]; ok {
+			continue
+		}
+		balance, err := lockAccountBalance(db, account)
+		if err != nil {
+			return nil, err
+		}
+		balances[account.ID] = balance
+	}
+	return balances, nil
+}
+
+// lockAccountBalance reads an account's balance for update, creating it empty
+// if the account has none yet
+func lockAccountBalance(db *gorm.DB, account *models.Account) (*models.Balance, error) {
+	balance := models.Balance{AccountID: account.ID, UserID: account.UserID, Currency: account.Currency}
+	err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "account_id"}}, DoNothing: true}).Create(&balance).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var locked models.Balance
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("account_id = ?", account.ID).First(&locked).Error; err != nil {
+		return nil, err
+	}
+	return &locked, nil
+}
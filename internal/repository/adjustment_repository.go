@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+)
+
+type AdjustmentRepository struct {
+	db           *gorm.DB
+	transactions *TransactionRepository
+}
+
+func NewAdjustmentRepository(db *gorm.DB, transactions *TransactionRepository) *AdjustmentRepository {
+	return &AdjustmentRepository{db: db, transactions: transactions}
+}
+
+// CreateReason adds a reason code to the catalog
+func (r *AdjustmentRepository) CreateReason(reason *models.AdjustmentReason) error {
+	return r.db.Create(reason).Error
+}
+
+// UpdateReason saves a catalog entry
+func (r *AdjustmentRepository) UpdateReason(reason *models.AdjustmentReason) error {
+	return r.db.Save(reason).Error
+}
+
+// GetReason retrieves a catalog entry by ID
+func (r *AdjustmentRepository) GetReason(id uuid.UUID) (*models.AdjustmentReason, error) {
+	var reason models.AdjustmentReason
+	if err := r.db.First(&reason, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &reason, nil
+}
+
+// GetReasonByCode retrieves a catalog entry by its code
+func (r *AdjustmentRepository) GetReasonByCode(code string) (*models.AdjustmentReason, error) {
+	var reason models.AdjustmentReason
+	if err := r.db.Where("code = ?", code).First(&reason).Error; err != nil {
+		return nil, err
+	}
+	return &reason, nil
+}
+
+// ListReasons retrieves the catalog by code, optionally only active entries
+func (r *AdjustmentRepository) ListReasons(activeOnly bool) ([]models.AdjustmentReason, error) {
+	query := r.db.Order("code ASC")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	var reasons []models.AdjustmentReason
+	if err := query.Find(&reasons).Error; err != nil {
+		return nil, err
+	}
+	return reasons, nil
+}
+
+// Create books the adjustment transaction and records the adjustment with it
+func (r *AdjustmentRepository) Create(adjustment *models.Adjustment, transaction *models.Transaction) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		if err := r.transactions.createInTx(db, transaction); err != nil {
+			return err
+		}
+		adjustment.TransactionID = transaction.ID
+		return db.Create(adjustment).Error
+	})
+}
+
+// List retrieves adjustments with their transactions, newest first, optionally
+// filtered by user and reason code
+func (r *AdjustmentRepository) List(userID *uuid.UUID, reasonCode string, page, pageSize int) ([]models.Adjustment, int64, error) {
+	query := r.db.Model(&models.Adjustment{})
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if reasonCode != "" {
+		query = query.Where("reason_code = ?", reasonCode)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var adjustments []models.Adjustment
+	offset := (page - 1) * pageSize
+	err := query.Preload("Transaction").
+		Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&adjustments).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return adjustments, total, nil
+}
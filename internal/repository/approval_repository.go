@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ApprovalRepository struct {
+	db           *gorm.DB
+	transactions *TransactionRepository
+}
+
+func NewApprovalRepository(db *gorm.DB, transactions *TransactionRepository) *ApprovalRepository {
+	return &ApprovalRepository{db: db, transactions: transactions}
+}
+
+// CreatePolicy creates an approval policy
+func (r *ApprovalRepository) CreatePolicy(policy *models.ApprovalPolicy) error {
+	return r.db.Create(policy).Error
+}
+
+// DeletePolicy removes an account's approval policy
+func (r *ApprovalRepository) DeletePolicy(accountID, policyID uuid.UUID) error {
+	result := r.db.Where("id = ? AND account_id = ?", policyID, accountID).Delete(&models.ApprovalPolicy{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListPolicies retrieves an account's approval policies by amount band
+func (r *ApprovalRepository) ListPolicies(accountID uuid.UUID) ([]models.ApprovalPolicy, error) {
+	var policies []models.ApprovalPolicy
+	if err := r.db.Where("account_id = ?", accountID).Order("min_amount ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// CreatePayment creates a pending payment
+func (r *ApprovalRepository) CreatePayment(payment *models.PendingPayment) error {
+	return r.db.Create(payment).Error
+}
+
+// GetPayment retrieves a pending payment with the decisions taken on it
+func (r *ApprovalRepository) GetPayment(id uuid.UUID) (*models.PendingPayment, error) {
+	var payment models.PendingPayment
+	err := r.db.Preload("Decisions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).First(&payment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// ListPayments retrieves an account's pending payments, optionally filtered
+// by status
+func (r *ApprovalRepository) ListPayments(accountID uuid.UUID, status models.PendingPaymentStatus) ([]models.PendingPayment, error) {
+	query := r.db.Where("account_id = ?", accountID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var payments []models.PendingPayment
+	if err := query.Order("created_at DESC").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// Decide records an approver's decision on a pending payment. An approval
+// counts towards the policy and moves the payment to approved once enough
+// were given; a rejection closes it. The payment row is locked so concurrent
+// decisions are counted one at a time.
+func (r *ApprovalRepository) Decide(id uuid.UUID, approval *models.PaymentApproval, now time.Time) (*models.PendingPayment, error) {
+	var payment models.PendingPayment
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := payment.CheckDecision(approval.ApproverID, now); err != nil {
+			return err
+		}
+
+		var decided int64
+		err := db.Model(&models.PaymentApproval{}).
+			Where("pending_payment_id = ? AND approver_id = ?", id, approval.ApproverID).
+			Count(&decided).Error
+		if err != nil {
+			return err
+		}
+		if decided > 0 {
+			return models.ErrAlreadyDecided
+		}
+
+		approval.PendingPaymentID = id
+		if err := db.Create(approval).Error; err != nil {
+			return err
+		}
+
+		if approval.Decision == models.DecisionReject {
+			payment.Status = models.PendingPaymentRejected
+			payment.ClosedAt = &now
+		} else {
+			payment.Approvals++
+			if payment.Approvals >= payment.RequiredApprovals {
+				payment.Status = models.PendingPaymentApproved
+			}
+		}
+		return db.Save(&payment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// Transition moves a payment from one status to another and applies the given
+// column updates. It reports false when the payment was no longer in the
+// expected status.
+func (r *ApprovalRepository) Transition(id uuid.UUID, from, to models.PendingPaymentStatus, updates map[string]interface{}) (bool, error) {
+	values := map[string]interface{}{"status": to, "updated_at": time.Now()}
+	for column, value := range updates {
+		values[column] = value
+	}
+	result := r.db.Model(&models.PendingPayment{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(values)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Execute books the transaction of an approved payment and marks it executed
+// in one database transaction, so a payment is never executed without its
+// transaction nor booked twice. A payment no longer approved is returned as
+// it is, without booking.
+func (r *ApprovalRepository) Execute(id uuid.UUID, transaction *models.Transaction, now time.Time) (*models.PendingPayment, error) {
+	var payment models.PendingPayment
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if payment.Status != models.PendingPaymentApproved {
+			return nil
+		}
+		if err := r.transactions.createInTx(db, transaction); err != nil {
+			return err
+		}
+
+		payment.Status = models.PendingPaymentExecuted
+		payment.TransactionID = &transaction.ID
+		payment.ClosedAt = &now
+		return db.Save(&payment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// ListApprovedIDs retrieves up to limit payments approved before the given
+// time that were not executed yet
+func (r *ApprovalRepository) ListApprovedIDs(before time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.PendingPayment{}).
+		Where("status = ? AND updated_at <= ?", models.PendingPaymentApproved, before).
+		Order("updated_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ExpireDue marks up to limit pending payments past their expiry as expired
+func (r *ApprovalRepository) ExpireDue(now time.Time, limit int) (int, error) {
+	due := r.db.Model(&models.PendingPayment{}).
+		Select("id").
+		Where("status = ? AND expires_at <= ?", models.PendingPaymentPending, now).
+		Limit(limit)
+	result := r.db.Model(&models.PendingPayment{}).
+		Where("id IN (?) AND status = ?", due, models.PendingPaymentPending).
+		Updates(map[string]interface{}{"status": models.PendingPaymentExpired, "closed_at": now, "updated_at": now})
+	return int(result.RowsAffected), result.Error
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BankImportRepository struct {
+	db           *gorm.DB
+	transactions *TransactionRepository
+}
+
+func NewBankImportRepository(db *gorm.DB, transactions *TransactionRepository) *BankImportRepository {
+	return &BankImportRepository{db: db, transactions: transactions}
+}
+
+// CreateFile records an imported file
+func (r *BankImportRepository) CreateFile(file *models.BankFile) error {
+	return r.db.Create(file).Error
+}
+
+// UpdateFileCounts saves the outcome of an import
+func (r *BankImportRepository) UpdateFileCounts(file *models.BankFile) error {
+	return r.db.Model(file).
+		Select("entries", "booked", "suspense", "duplicates").
+		Updates(file).Error
+}
+
+// Book records a matched entry and books its deposit. It returns false,
+// booking nothing, when an entry with the same bank entry ID exists.
+func (r *BankImportRepository) Book(entry *models.BankEntry, deposit *models.Transaction) (bool, error) {
+	inserted := false
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		ok, err := insertBankEntry(db, entry)
+		if err != nil || !ok {
+			return err
+		}
+		if err := r.transactions.createInTx(db, deposit); err != nil {
+			return err
+		}
+		entry.TransactionID = &deposit.ID
+		inserted = true
+		return db.Model(entry).Update("transaction_id", deposit.ID).Error
+	})
+	return inserted, err
+}
+
+// Suspend records an unmatched entry for manual allocation. It returns false
+// when an entry with the same bank entry ID exists.
+func (r *BankImportRepository) Suspend(entry *models.BankEntry) (bool, error) {
+	return insertBankEntry(r.db, entry)
+}
+
+// Allocate books the deposit of an entry in suspense into the account an
+// admin chose
+func (r *BankImportRepository) Allocate(id uuid.UUID, account *models.Account, adminID uuid.UUID, note string, now time.Time) (*models.BankEntry, error) {
+	var entry models.BankEntry
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if entry.Status != models.BankEntrySuspense {
+			return models.ErrBankEntryNotInSuspense
+		}
+
+		deposit := entry.Deposit(account, adminID)
+		if err := r.transactions.createInTx(db, deposit); err != nil {
+			return err
+		}
+		entry.Status = models.BankEntryAllocated
+		entry.AccountID = &account.ID
+		entry.UserID = &account.UserID
+		entry.TransactionID = &deposit.ID
+		entry.AllocatedBy = &adminID
+		entry.AllocatedAt = &now
+		entry.Note = note
+		return db.Save(&entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetFile retrieves an imported file with its entries
+func (r *BankImportRepository) GetFile(id uuid.UUID) (*models.BankFile, error) {
+	var file models.BankFile
+	err := r.db.Preload("BankEntries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("booking_date ASC, entry_id ASC")
+	}).First(&file, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// ListFiles retrieves imported files, newest first
+func (r *BankImportRepository) ListFiles(page, pageSize int) ([]models.BankFile, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.BankFile{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var files []models.BankFile
+	offset := (page - 1) * pageSize
+	err := r.db.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&files).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return files, total, nil
+}
+
+// GetEntry retrieves a bank entry
+func (r *BankImportRepository) GetEntry(id uuid.UUID) (*models.BankEntry, error) {
+	var entry models.BankEntry
+	if err := r.db.First(&entry, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListEntries retrieves bank entries, optionally only those with a status,
+// oldest booking first so the suspense queue reads in order of arrival
+func (r *BankImportRepository) ListEntries(status models.BankEntryStatus, page, pageSize int) ([]models.BankEntry, int64, error) {
+	query := r.db.Model(&models.BankEntry{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.BankEntry
+	offset := (page - 1) * pageSize
+	err := query.Order("booking_date ASC, created_at ASC").
+		Offset(offset).Limit(pageSize).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// insertBankEntry records an entry unless its bank entry ID is known
+func insertBankEntry(db *gorm.DB, entry *models.BankEntry) (bool, error) {
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entry_id"}},
+		DoNothing: true,
+	}).Create(entry)
+	return result.RowsAffected == 1, result.Error
+}
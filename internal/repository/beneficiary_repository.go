@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+)
+
+type BeneficiaryRepository struct {
+	db *gorm.DB
+}
+
+func NewBeneficiaryRepository(db *gorm.DB) *BeneficiaryRepository {
+	return &BeneficiaryRepository{db: db}
+}
+
+// Create saves a beneficiary
+func (r *BeneficiaryRepository) Create(beneficiary *models.Beneficiary) error {
+	return r.db.Create(beneficiary).Error
+}
+
+// Update saves changes to a beneficiary
+func (r *BeneficiaryRepository) Update(beneficiary *models.Beneficiary) error {
+	return r.db.Save(beneficiary).Error
+}
+
+// Delete removes a beneficiary of a user, reporting whether it existed
+func (r *BeneficiaryRepository) Delete(id, userID uuid.UUID) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Beneficiary{})
+	return result.RowsAffected == 1, result.Error
+}
+
+// GetByIDAndUserID retrieves a beneficiary of a user
+func (r *BeneficiaryRepository) GetByIDAndUserID(id, userID uuid.UUID) (*models.Beneficiary, error) {
+	var beneficiary models.Beneficiary
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&beneficiary).Error; err != nil {
+		return nil, err
+	}
+	return &beneficiary, nil
+}
+
+// ListByUserID retrieves a user's beneficiaries by nickname
+func (r *BeneficiaryRepository) ListByUserID(userID uuid.UUID) ([]models.Beneficiary, error) {
+	var beneficiaries []models.Beneficiary
+	if err := r.db.Where("user_id = ?", userID).Order("nickname ASC").Find(&beneficiaries).Error; err != nil {
+		return nil, err
+	}
+	return beneficiaries, nil
+}
+
+// NicknameTaken reports whether another beneficiary of the user has the
+// nickname
+func (r *BeneficiaryRepository) NicknameTaken(userID uuid.UUID, nickname string, except uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Beneficiary{}).
+		Where("user_id = ? AND LOWER(nickname) = LOWER(?) AND id <> ?", userID, nickname, except).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ChangeRequestRepository struct {
+	db *gorm.DB
+}
+
+func NewChangeRequestRepository(db *gorm.DB) *ChangeRequestRepository {
+	return &ChangeRequestRepository{db: db}
+}
+
+// AuditFilter narrows down audit log entries; empty fields match all
+type AuditFilter struct {
+	ActorID         *uuid.UUID
+	ChangeRequestID *uuid.UUID
+	TargetID        *uuid.UUID
+}
+
+// Create creates a change request and audits it as requested
+func (r *ChangeRequestRepository) Create(requests ...*models.ChangeRequest) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		for _, request := range requests {
+			if err := db.Create(request).Error; err != nil {
+				return err
+			}
+			if err := db.Create(request.NewAuditLog(models.AuditChangeRequested, &request.RequestedBy, request.Reason)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetByID retrieves a change request with its audit trail
+func (r *ChangeRequestRepository) GetByID(id uuid.UUID) (*models.ChangeRequest, error) {
+	var request models.ChangeRequest
+	err := r.db.Preload("AuditTrail", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).First(&request, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// List retrieves change requests with pagination, newest first, optionally
+// filtered by status
+func (r *ChangeRequestRepository) List(status models.ChangeRequestStatus, page, pageSize int) ([]models.ChangeRequest, int64, error) {
+	query := r.db.Model(&models.ChangeRequest{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var requests []models.ChangeRequest
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&requests).Error; err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+// Decide records an admin's approval or rejection of a pending change request
+// and audits it. A rejection closes the request; an approved request is left
+// to be applied.
+func (r *ChangeRequestRepository) Decide(id, adminID uuid.UUID, approve bool, comment string, now time.Time) (*models.ChangeRequest, error) {
+	var request models.ChangeRequest
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := request.CheckDecision(adminID, now); err != nil {
+			return err
+		}
+
+		action := models.AuditChangeApproved
+		request.Status = models.ChangeRequestApproved
+		if !approve {
+			action = models.AuditChangeRejected
+			request.Status = models.ChangeRequestRejected
+			request.Secret = ""
+			request.ClosedAt = &now
+		}
+		request.DecidedBy = &adminID
+		request.DecisionComment = comment
+		request.DecidedAt = &now
+		if err := db.Save(&request).Error; err != nil {
+			return err
+		}
+		return db.Create(request.NewAuditLog(action, &adminID, comment)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Close moves a change request from one status to a closing one, applies the
+// given column updates and appends the audit entry. It reports false, without
+// auditing, when the request was no longer in the expected status.
+func (r *ChangeRequestRepository) Close(request *models.ChangeRequest, from, to models.ChangeRequestStatus, updates map[string]interface{}, entry *models.AuditLog) (bool, error) {
+	closed := false
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		now := time.Now()
+		values := map[string]interface{}{"status": to, "secret": "", "closed_at": now, "updated_at": now}
+		for column, value := range updates {
+			values[column] = value
+		}
+		result := db.Model(&models.ChangeRequest{}).
+			Where("id = ? AND status = ?", request.ID, from).
+			Updates(values)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		closed = true
+		return db.Create(entry).Error
+	})
+	return closed, err
+}
+
+// ExpireDue marks up to limit pending change requests past their expiry as
+// expired and audits each as done by the system
+func (r *ChangeRequestRepository) ExpireDue(now time.Time, limit int) (int, error) {
+	var expired []models.ChangeRequest
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		err := db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND expires_at <= ?", models.ChangeRequestPending, now).
+			Limit(limit).
+			Find(&expired).Error
+		if err != nil || len(expired) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(expired))
+		entries := make([]*models.AuditLog, len(expired))
+		for i := range expired {
+			ids[i] = expired[i].ID
+			entries[i] = expired[i].NewAuditLog(models.AuditChangeExpired, nil, "")
+		}
+		err = db.Model(&models.ChangeRequest{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": models.ChangeRequestExpired, "secret": "", "closed_at": now, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+		return db.Create(&entries).Error
+	})
+	return len(expired), err
+}
+
+// ListAudit retrieves audit log entries with pagination, newest first
+func (r *ChangeRequestRepository) ListAudit(filter AuditFilter, page, pageSize int) ([]models.AuditLog, int64, error) {
+	query := r.db.Model(&models.AuditLog{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.ChangeRequestID != nil {
+		query = query.Where("change_request_id = ?", *filter.ChangeRequestID)
+	}
+	if filter.TargetID != nil {
+		query = query.Where("target_id = ?", *filter.TargetID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditLog
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HoldRepository struct {
+	db           *gorm.DB
+	transactions *TransactionRepository
+}
+
+func NewHoldRepository(db *gorm.DB, transactions *TransactionRepository) *HoldRepository {
+	return &HoldRepository{db: db, transactions: transactions}
+}
+
+// Create reserves the hold amount on the user's balance and records the hold
+func (r *HoldRepository) Create(hold *models.Hold) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		account, err := resolveAccount(db, hold.UserID, hold.Currency, hold.AccountID, false)
+		if err != nil {
+			return err
+		}
+		hold.AccountID = &account.ID
+		balance, err := lockAccountBalance(db, account)
+		if err != nil {
+			return err
+		}
+		if err := balance.Hold(hold.Amount); err != nil {
+			return err
+		}
+		if err := db.Save(balance).Error; err != nil {
+			return err
+		}
+		if err := db.Create(hold).Error; err != nil {
+			return err
+		}
+		return appendHoldBalanceEvent(db, hold, balance)
+	})
+}
+
+// Capture settles an active hold with the transaction capturing it. The whole
+// hold is lifted from the balance and the transaction is booked as a
+// withdrawal or transfer, so a partial capture returns the rest to the
+// available balance.
+func (r *HoldRepository) Capture(id uuid.UUID, transaction *models.Transaction, now time.Time) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&hold, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := hold.CheckCapture(transaction.Amount, now); err != nil {
+			return err
+		}
+
+		balance, err := lockHoldBalance(db, &hold)
+		if err != nil {
+			return err
+		}
+		balance.ReleaseHold(hold.Amount)
+		if err := db.Save(balance).Error; err != nil {
+			return err
+		}
+
+		// Publishes the transaction and the resulting balances, hold lifted
+		if err := r.transactions.createInTx(db, transaction); err != nil {
+			return err
+		}
+
+		hold.Status = models.HoldCaptured
+		hold.CapturedAmount = transaction.Amount
+		hold.TransactionID = &transaction.ID
+		hold.ClosedAt = &now
+		return db.Save(&hold).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// Release lifts an active hold without booking anything, closing it with the
+// given status (released or expired)
+func (r *HoldRepository) Release(id uuid.UUID, status models.HoldStatus, now time.Time) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&hold, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if hold.Status != models.HoldActive {
+			return models.ErrHoldNotActive
+		}
+
+		balance, err := lockHoldBalance(db, &hold)
+		if err != nil {
+			return err
+		}
+		balance.ReleaseHold(hold.Amount)
+		if err := db.Save(balance).Error; err != nil {
+			return err
+		}
+
+		hold.Status = status
+		hold.ClosedAt = &now
+		if err := db.Save(&hold).Error; err != nil {
+			return err
+		}
+		return appendHoldBalanceEvent(db, &hold, balance)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// GetByID retrieves a hold by ID
+func (r *HoldRepository) GetByID(id uuid.UUID) (*models.Hold, error) {
+	var hold models.Hold
+	if err := r.db.First(&hold, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// List retrieves holds, optionally filtered by user and status
+func (r *HoldRepository) List(userID *uuid.UUID, status models.HoldStatus, page, pageSize int) ([]models.Hold, int64, error) {
+	filter := func(db *gorm.DB) *gorm.DB {
+		if userID != nil {
+			db = db.Where("user_id = ?", *userID)
+		}
+		if status != "" {
+			db = db.Where("status = ?", status)
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.Model(&models.Hold{}).Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var holds []models.Hold
+	offset := (page - 1) * pageSize
+	err := r.db.Scopes(filter).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&holds).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return holds, total, nil
+}
+
+// ListExpiredIDs retrieves up to limit active holds past their expiry
+func (r *HoldRepository) ListExpiredIDs(now time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Model(&models.Hold{}).
+		Where("status = ? AND expires_at <= ?", models.HoldActive, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// lockHoldBalance reads the balance a hold reserves funds on for update
+func lockHoldBalance(db *gorm.DB, hold *models.Hold) (*models.Balance, error) {
+	account, err := resolveAccount(db, hold.UserID, hold.Currency, hold.AccountID, false)
+	if err != nil {
+		return nil, err
+	}
+	return lockAccountBalance(db, account)
+}
+
+// appendHoldBalanceEvent publishes the balance changed by placing or lifting a hold
+func appendHoldBalanceEvent(db *gorm.DB, hold *models.Hold, balance *models.Balance) error {
+	data := models.NewBalanceEventData(balance)
+	data.HoldID = &hold.ID
+	event, err := models.NewOutboxEvent(models.AggregateBalance, balance.ID, models.EventBalanceUpdated, data)
+	if err != nil {
+		return err
+	}
+	return appendOutboxEvents(db, event)
+}
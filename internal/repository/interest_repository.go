@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InterestRepository struct {
+	db           *gorm.DB
+	transactions *TransactionRepository
+}
+
+func NewInterestRepository(db *gorm.DB, transactions *TransactionRepository) *InterestRepository {
+	return &InterestRepository{db: db, transactions: transactions}
+}
+
+// InterestBalance identifies a balance that may earn interest
+type InterestBalance struct {
+	UserID   uuid.UUID
+	Currency string
+}
+
+// CapitalizationGroup is the uncapitalized interest of a balance for a month
+type CapitalizationGroup struct {
+	UserID   uuid.UUID
+	Currency string
+	Month    time.Time
+}
+
+// CreateRate creates an interest rate
+func (r *InterestRepository) CreateRate(rate *models.InterestRate) error {
+	return r.db.Create(rate).Error
+}
+
+// ListRates retrieves all interest rates, latest effective first
+func (r *InterestRepository) ListRates() ([]models.InterestRate, error) {
+	var rates []models.InterestRate
+	if err := r.db.Order("currency ASC, effective_from DESC").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// ListBalances retrieves the currencies customers held balances in before the
+// given time; interest is paid on the total over all of a user's accounts.
+// Closed users earn none.
+func (r *InterestRepository) ListBalances(currencies []string, before time.Time) ([]InterestBalance, error) {
+	var balances []InterestBalance
+	err := r.db.Table("balances").
+		Distinct("balances.user_id, balances.currency").
+		Joins("JOIN users ON users.id = balances.user_id AND users.role = ? AND users.status <> ? AND users.deleted_at IS NULL", "user", models.UserStatusClosed).
+		Where("balances.currency IN ? AND balances.created_at < ? AND balances.deleted_at IS NULL", currencies, before).
+		Order("balances.user_id, balances.currency").
+		Scan(&balances).Error
+	return balances, err
+}
+
+// UpsertAccrual stores the accrual of a day, replacing a previous computation
+// unless it was already capitalized
+func (r *InterestRepository) UpsertAccrual(accrual *models.InterestAccrual) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "currency"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"balance", "amount", "rate_id", "day_count", "updated_at"}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "interest_accruals.capitalized_at IS NULL"}}},
+	}).Create(accrual).Error
+}
+
+// DeleteAccrual removes the accrual of a day that no longer earns interest,
+// unless it was already capitalized
+func (r *InterestRepository) DeleteAccrual(userID uuid.UUID, currency string, day time.Time) error {
+	return r.db.Where("user_id = ? AND currency = ? AND date = ? AND capitalized_at IS NULL", userID, currency, day).
+		Delete(&models.InterestAccrual{}).Error
+}
+
+// LastRunDate returns the latest day accrual completed for, or nil
+func (r *InterestRepository) LastRunDate() (*time.Time, error) {
+	var run models.InterestRun
+	err := r.db.Order("date DESC").First(&run).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run.Date, nil
+}
+
+// SaveRun records that accrual completed for a day
+func (r *InterestRepository) SaveRun(run *models.InterestRun) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(run).Error
+}
+
+// ListCapitalizable retrieves up to limit (balance, month) groups with
+// uncapitalized accruals dated before the given day, leaving out closed users
+func (r *InterestRepository) ListCapitalizable(before time.Time, limit int) ([]CapitalizationGroup, error) {
+	var groups []CapitalizationGroup
+	err := r.db.Model(&models.InterestAccrual{}).
+		Select("interest_accruals.user_id, interest_accruals.currency, date_trunc('month', interest_accruals.date) AS month").
+		Joins("JOIN users ON users.id = interest_accruals.user_id AND users.status <> ?", models.UserStatusClosed).
+		Where("interest_accruals.capitalized_at IS NULL AND interest_accruals.date < ?", before).
+		Group("interest_accruals.user_id, interest_accruals.currency, date_trunc('month', interest_accruals.date)").
+		Order("month ASC").
+		Limit(limit).
+		Scan(&groups).Error
+	return groups, err
+}
+
+// Capitalize posts the month's accrued interest of a balance as a deposit and
+// marks the accruals capitalized, in one database transaction. The posted
+// amount is the sum of the daily accruals rounded to cents; nothing is posted
+// when it rounds to zero.
+func (r *InterestRepository) Capitalize(group CapitalizationGroup, now time.Time) (*models.Transaction, error) {
+	var transaction *models.Transaction
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		var accruals []models.InterestAccrual
+		err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND currency = ? AND date >= ? AND date < ? AND capitalized_at IS NULL",
+				group.UserID, group.Currency, group.Month, group.Month.AddDate(0, 1, 0)).
+			Find(&accruals).Error
+		if err != nil || len(accruals) == 0 {
+			return err
+		}
+
+		var total float64
+		ids := make([]uuid.UUID, len(accruals))
+		for i, accrual := range accruals {
+			total += accrual.Amount
+			ids[i] = accrual.ID
+		}
+
+		updates := map[string]interface{}{"capitalized_at": now, "updated_at": now}
+		if amount := math.Round(total*100) / 100; amount > 0 {
+			transaction = &models.Transaction{
+				UserID:      group.UserID,
+				Type:        models.TransactionTypeDeposit,
+				Amount:      amount,
+				Currency:    group.Currency,
+				Description: "Interest " + group.Month.Format("2006-01"),
+			}
+			if err := r.transactions.createInTx(db, transaction); err != nil {
+				return err
+			}
+			updates["transaction_id"] = transaction.ID
+		}
+		return db.Model(&models.InterestAccrual{}).Where("id IN ?", ids).Updates(updates).Error
+	})
+	return transaction, err
+}
+
+// SumUncapitalized returns the interest accrued but not yet posted on a balance
+func (r *InterestRepository) SumUncapitalized(userID uuid.UUID, currency string) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.InterestAccrual{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("user_id = ? AND currency = ? AND capitalized_at IS NULL", userID, currency).
+		Scan(&total).Error
+	return total, err
+}
+
+// ListAccruals retrieves a balance's most recent accruals
+func (r *InterestRepository) ListAccruals(userID uuid.UUID, currency string, limit int) ([]models.InterestAccrual, error) {
+	var accruals []models.InterestAccrual
+	err := r.db.Where("user_id = ? AND currency = ?", userID, currency).
+		Order("date DESC").
+		Limit(limit).
+		Find(&accruals).Error
+	return accruals, err
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// appendOutboxEvents writes domain events using the caller's database
+// transaction so they commit or roll back together with the change
+func appendOutboxEvents(db *gorm.DB, events ...*models.OutboxEvent) error {
+	for _, event := range events {
+		if err := db.Create(event).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishBatch locks up to limit unpublished events in creation order and
+// hands them to publish one by one. Published events are marked in the same
+// database transaction, so a crash before commit leads to the batch being
+// published again rather than lost. The batch stops at the first failure to
+// keep events in order.
+func (r *OutboxRepository) PublishBatch(limit int, publish func(event *models.OutboxEvent) error) (int, error) {
+	published := 0
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		var events []models.OutboxEvent
+		err := db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL").
+			Order("created_at ASC").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range events {
+			event := &events[i]
+			if err := publish(event); err != nil {
+				return db.Model(event).Updates(map[string]interface{}{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": err.Error(),
+				}).Error
+			}
+			if err := db.Model(event).Update("published_at", time.Now()).Error; err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	return published, err
+}
+
+// DeletePublishedBefore removes events that were relayed before the cutoff
+func (r *OutboxRepository) DeletePublishedBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("published_at IS NOT NULL AND published_at < ?", cutoff).Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
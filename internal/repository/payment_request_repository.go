@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+)
+
+type PaymentRequestRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRequestRepository(db *gorm.DB) *PaymentRequestRepository {
+	return &PaymentRequestRepository{db: db}
+}
+
+// CreateBatch creates payment requests atomically, so a split bill is either
+// sent to every participant or to none
+func (r *PaymentRequestRepository) CreateBatch(requests []models.PaymentRequest) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		return db.Create(&requests).Error
+	})
+}
+
+// GetByID retrieves a payment request by ID
+func (r *PaymentRequestRepository) GetByID(id uuid.UUID) (*models.PaymentRequest, error) {
+	var request models.PaymentRequest
+	if err := r.db.First(&request, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// ListByUserID retrieves payment requests a user sent (outgoing), received
+// (incoming) or both, optionally filtered by status
+func (r *PaymentRequestRepository) ListByUserID(userID uuid.UUID, direction string, status models.PaymentRequestStatus) ([]models.PaymentRequest, error) {
+	query := r.db.Model(&models.PaymentRequest{})
+	switch direction {
+	case "incoming":
+		query = query.Where("payer_id = ?", userID)
+	case "outgoing":
+		query = query.Where("requester_id = ?", userID)
+	default:
+		query = query.Where("payer_id = ? OR requester_id = ?", userID, userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var requests []models.PaymentRequest
+	if err := query.Order("created_at DESC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// Transition moves a payment request from one status to another and applies
+// the given column updates. It reports false when the request was no longer
+// in the expected status, which makes concurrent accept/decline/cancel safe.
+func (r *PaymentRequestRepository) Transition(id uuid.UUID, from, to models.PaymentRequestStatus, updates map[string]interface{}) (bool, error) {
+	values := map[string]interface{}{"status": to, "updated_at": time.Now()}
+	for column, value := range updates {
+		values[column] = value
+	}
+	result := r.db.Model(&models.PaymentRequest{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(values)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ExpireDue marks up to limit pending requests past their expiry as expired
+func (r *PaymentRequestRepository) ExpireDue(now time.Time, limit int) (int, error) {
+	due := r.db.Model(&models.PaymentRequest{}).
+		Select("id").
+		Where("status = ? AND expires_at <= ?", models.PaymentRequestPending, now).
+		Limit(limit)
+	result := r.db.Model(&models.PaymentRequest{}).
+		Where("id IN (?) AND status = ?", due, models.PaymentRequestPending).
+		Updates(map[string]interface{}{"status": models.PaymentRequestExpired, "updated_at": now})
+	return int(result.RowsAffected), result.Error
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PayoutRepository struct {
+	db *gorm.DB
+}
+
+func NewPayoutRepository(db *gorm.DB) *PayoutRepository {
+	return &PayoutRepository{db: db}
+}
+
+// BatchPending locks up to limit pending payouts in creation order and, when
+// the oldest was created before dueBefore, hands them to build to write the
+// batch document. The batch is saved and its payouts marked batched in the
+// same database transaction. It returns nil when nothing is due.
+func (r *PayoutRepository) BatchPending(limit int, dueBefore time.Time, build func(batch *models.PayoutBatch, payouts []models.Payout) error) (*models.PayoutBatch, error) {
+	var batch *models.PayoutBatch
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		var payouts []models.Payout
+		err := db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", models.PayoutPending).
+			Order("created_at ASC").
+			Limit(limit).
+			Find(&payouts).Error
+		if err != nil || len(payouts) == 0 || payouts[0].CreatedAt.After(dueBefore) {
+			return err
+		}
+
+		id := uuid.New()
+		batch = &models.PayoutBatch{
+			ID:        id,
+			MessageID: strings.ReplaceAll(id.String(), "-", ""),
+			Count:     len(payouts),
+		}
+		ids := make([]uuid.UUID, len(payouts))
+		for i := range payouts {
+			ids[i] = payouts[i].ID
+			batch.ControlSum += payouts[i].Amount
+		}
+		if err := build(batch, payouts); err != nil {
+			return err
+		}
+		if err := db.Create(batch).Error; err != nil {
+			return err
+		}
+		return db.Model(&models.Payout{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": models.PayoutBatched, "batch_id": batch.ID, "updated_at": time.Now()}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// ApplyStatusReport settles the batched payouts of the batch with the given
+// message ID. outcome returns the status the bank reported for a payout with
+// its reason; completed and failed payouts are closed, and failed ones have
+// their withdrawal and its fees reversed on behalf of initiatedBy. Payouts
+// already settled by an earlier report are left alone. It returns the batch
+// and how many payouts it settled.
+func (r *PayoutRepository) ApplyStatusReport(messageID string, initiatedBy uuid.UUID, now time.Time, outcome func(payout *models.Payout) (models.PayoutStatus, string)) (*models.PayoutBatch, int, error) {
+	var batch models.PayoutBatch
+	settled := 0
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, "message_id = ?", messageID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrUnknownPayoutBatch
+		}
+		if err != nil {
+			return err
+		}
+
+		var payouts []models.Payout
+		err = db.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("batch_id = ? AND status = ?", batch.ID, models.PayoutBatched).
+			Find(&payouts).Error
+		if err != nil {
+			return err
+		}
+		for i := range payouts {
+			payout := &payouts[i]
+			status, reason := outcome(payout)
+			switch status {
+			case models.PayoutCompleted:
+				batch.Completed++
+			case models.PayoutFailed:
+				refund, err := refundPayoutInTx(db, payout, reason, initiatedBy)
+				if err != nil {
+					return err
+				}
+				payout.RefundTransactionID = &refund.ID
+				batch.Failed++
+			default:
+				continue
+			}
+			payout.Status = status
+			payout.StatusReason = reason
+			payout.ClosedAt = &now
+			if err := db.Save(payout).Error; err != nil {
+				return err
+			}
+			settled++
+		}
+
+		batch.ReportedAt = &now
+		return db.Model(&batch).
+			Select("completed", "failed", "reported_at").
+			Updates(&batch).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return &batch, settled, nil
+}
+
+// refundPayoutInTx reverses the withdrawal of a failed payout and its fees
+func refundPayoutInTx(db *gorm.DB, payout *models.Payout, reason string, initiatedBy uuid.UUID) (*models.Transaction, error) {
+	var withdrawal models.Transaction
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&withdrawal, "id = ?", payout.TransactionID).Error; err != nil {
+		return nil, err
+	}
+	description := "Refund of failed payout " + payout.EndToEndID
+	if reason != "" {
+		description += ": " + reason
+	}
+	refund, err := reverseWithFeesInTx(db, &withdrawal, description, initiatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := reopenClosedUserInTx(db, withdrawal.UserID, description, initiatedBy); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// reopenClosedUserInTx freezes a closed user again when money was refunded
+// to them, such as a closure payout the bank rejected, so admins find it
+// among frozen users rather than stranded on a closed one
+func reopenClosedUserInTx(db *gorm.DB, userID uuid.UUID, reason string, initiatedBy uuid.UUID) error {
+	var user models.User
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // erased
+	}
+	if err != nil || user.Status != models.UserStatusClosed {
+		return err
+	}
+	if err := user.Reopen(reason, time.Now()); err != nil {
+		return err
+	}
+	return setStatusInTx(db, &user, user.NewAuditLog(models.AuditUserReopened, &initiatedBy, reason))
+}
+
+// Get retrieves a payout
+func (r *PayoutRepository) Get(id uuid.UUID) (*models.Payout, error) {
+	var payout models.Payout
+	if err := r.db.First(&payout, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &payout, nil
+}
+
+// List retrieves payouts, optionally only those with a status, newest first
+func (r *PayoutRepository) List(status models.PayoutStatus, page, pageSize int) ([]models.Payout, int64, error) {
+	query := r.db.Model(&models.Payout{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var payouts []models.Payout
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&payouts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return payouts, total, nil
+}
+
+// GetBatch retrieves a batch with its payouts
+func (r *PayoutRepository) GetBatch(id uuid.UUID) (*models.PayoutBatch, error) {
+	var batch models.PayoutBatch
+	err := r.db.Preload("Payouts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).First(&batch, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ListBatches retrieves batches, newest first
+func (r *PayoutRepository) ListBatches(page, pageSize int) ([]models.PayoutBatch, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.PayoutBatch{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var batches []models.PayoutBatch
+	offset := (page - 1) * pageSize
+	err := r.db.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&batches).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return batches, total, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PricingRepository struct {
+	db *gorm.DB
+}
+
+func NewPricingRepository(db *gorm.DB) *PricingRepository {
+	return &PricingRepository{db: db}
+}
+
+// CreatePlan creates a pricing plan with its rules
+func (r *PricingRepository) CreatePlan(plan *models.PricingPlan) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		if plan.IsDefault {
+			if err := clearDefaultPlan(db, uuid.Nil); err != nil {
+				return err
+			}
+		}
+		return db.Create(plan).Error
+	})
+}
+
+// UpdatePlan saves a pricing plan and replaces its rules
+func (r *PricingRepository) UpdatePlan(plan *models.PricingPlan) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		if plan.IsDefault {
+			if err := clearDefaultPlan(db, plan.ID); err != nil {
+				return err
+			}
+		}
+		if err := db.Omit("Rules").Save(plan).Error; err != nil {
+			return err
+		}
+		if err := db.Where("plan_id = ?", plan.ID).Delete(&models.FeeRule{}).Error; err != nil {
+			return err
+		}
+		for i := range plan.Rules {
+			plan.Rules[i].ID = uuid.Nil
+			plan.Rules[i].PlanID = plan.ID
+		}
+		if len(plan.Rules) == 0 {
+			return nil
+		}
+		return db.Create(&plan.Rules).Error
+	})
+}
+
+// clearDefaultPlan unsets the default flag on every plan except keep
+func clearDefaultPlan(db *gorm.DB, keep uuid.UUID) error {
+	return db.Model(&models.PricingPlan{}).
+		Where("is_default AND id <> ?", keep).
+		Update("is_default", false).Error
+}
+
+// GetPlan retrieves a pricing plan with its rules
+func (r *PricingRepository) GetPlan(id uuid.UUID) (*models.PricingPlan, error) {
+	var plan models.PricingPlan
+	if err := r.db.Preload("Rules").First(&plan, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ListPlans retrieves all pricing plans with their rules
+func (r *PricingRepository) ListPlans() ([]models.PricingPlan, error) {
+	var plans []models.PricingPlan
+	if err := r.db.Preload("Rules").Order("name ASC").Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// GetPlanForUser retrieves the plan assigned to a user, falling back to the
+// default plan. It returns gorm.ErrRecordNotFound when neither exists.
+func (r *PricingRepository) GetPlanForUser(userID uuid.UUID) (*models.PricingPlan, error) {
+	var plan models.PricingPlan
+	err := r.db.Preload("Rules").
+		Joins("JOIN user_pricing_plans ON user_pricing_plans.plan_id = pricing_plans.id").
+		Where("user_pricing_plans.user_id = ?", userID).
+		First(&plan).Error
+	if err == nil {
+		return &plan, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	if err := r.db.Preload("Rules").Where("is_default").First(&plan).Error; err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// AssignPlan assigns a pricing plan to a user, replacing any previous plan
+func (r *PricingRepository) AssignPlan(assignment *models.UserPricingPlan) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"plan_id", "assigned_by", "updated_at"}),
+	}).Create(assignment).Error
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PrivacyRepository gathers a user's data for export and erases and purges
+// it under the retention policy
+type PrivacyRepository struct {
+	db *gorm.DB
+}
+
+func NewPrivacyRepository(db *gorm.DB) *PrivacyRepository {
+	return &PrivacyRepository{db: db}
+}
+
+// Export loads the personal and financial data held about a user
+func (r *PrivacyRepository) Export(userID uuid.UUID) (*models.DataExport, error) {
+	export := &models.DataExport{}
+	if err := r.db.First(&export.User, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	var profiles []models.UserProfile
+	if err := r.db.Where("user_id = ?", userID).Limit(1).Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	if len(profiles) > 0 {
+		export.Profile = &profiles[0]
+	}
+
+	queries := []struct {
+		dest  interface{}
+		query *gorm.DB
+	}{
+		{&export.Accounts, r.db.Where("user_id = ?", userID).Order("created_at")},
+		{&export.Balances, r.db.Where("user_id = ?", userID).Order("currency")},
+		{&export.Transactions, r.db.Where("user_id = ? OR recipient_id = ?", userID, userID).Order("created_at")},
+		{&export.Beneficiaries, r.db.Where("user_id = ?", userID).Order("created_at")},
+		{&export.StandingOrders, r.db.Where("user_id = ?", userID).Order("created_at")},
+		{&export.PaymentRequests, r.db.Where("requester_id = ? OR payer_id = ?", userID, userID).Order("created_at")},
+		{&export.Webhooks, r.db.Where("user_id = ?", userID).Order("created_at")},
+	}
+	for _, q := range queries {
+		if err := q.query.Find(q.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return export, nil
+}
+
+// GetErasure retrieves the erasure of a user
+func (r *PrivacyRepository) GetErasure(userID uuid.UUID) (*models.DataErasure, error) {
+	var erasure models.DataErasure
+	if err := r.db.First(&erasure, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &erasure, nil
+}
+
+// ListErasures retrieves erasures, newest first, with pagination
+func (r *PrivacyRepository) ListErasures(page, pageSize int) ([]models.DataErasure, int64, error) {
+	var erasures []models.DataErasure
+	var total int64
+
+	if err := r.db.Model(&models.DataErasure{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := r.db.Order("erased_at DESC").Offset(offset).Limit(pageSize).Find(&erasures).Error; err != nil {
+		return nil, 0, err
+	}
+	return erasures, total, nil
+}
+
+// Erase saves a pseudonymized user with the erasure and its audit log entry,
+// closing the user first when closure is given. The user's beneficiaries,
+// webhooks, previous passwords, sessions, email verification and password
+// reset tokens, service accounts with their API keys and the pending account
+// invitations sent to their old email are deleted, as nothing needs to be kept
+// of them.
+func (r *PrivacyRepository) Erase(user *models.User, erasure *models.DataErasure, closure, entry *models.AuditLog) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		var locked models.User
+		if err := db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, "id = ?", user.ID).Error; err != nil {
+			return err
+		}
+		var erased int64
+		if err := db.Model(&models.DataErasure{}).Where("user_id = ?", user.ID).Count(&erased).Error; err != nil {
+			return err
+		}
+		if erased > 0 {
+			return models.ErrAlreadyErased
+		}
+		if closure != nil {
+			if err := closeUserInTx(db, user, closure); err != nil {
+				return err
+			}
+		}
+
+		if err := db.Where("email = ? AND status = ?", locked.Email, models.InvitationPending).Delete(&models.AccountInvitation{}).Error; err != nil {
+			return err
+		}
+		if err := db.Where("user_id = ?", user.ID).Delete(&models.Beneficiary{}).Error; err != nil {
+			return err
+		}
+		if err := db.Where("user_id = ?", user.ID).Delete(&models.PreviousPassword{}).Error; err != nil {
+			return err
+		}
+		if err := db.Where("user_id = ?", user.ID).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		if err := db.Where("user_id = ?", user.ID).Delete(&models.UserToken{}).Error; err != nil {
+			return err
+		}
+		serviceAccounts := db.Unscoped().Model(&models.ServiceAccount{}).Select("id").Where("user_id = ?", user.ID)
+		if err := db.Where("service_account_id IN (?)", serviceAccounts).Delete(&models.APIKey{}).Error; err != nil {
+			return err
+		}
+		if err := db.Unscoped().Where("user_id = ?", user.ID).Delete(&models.ServiceAccount{}).Error; err != nil {
+			return err
+		}
+		subscriptions := db.Unscoped().Model(&models.WebhookSubscription{}).Select("id").Where("user_id = ?", user.ID)
+		if err := db.Where("subscription_id IN (?)", subscriptions).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := db.Unscoped().Where("user_id = ?", user.ID).Delete(&models.WebhookSubscription{}).Error; err != nil {
+			return err
+		}
+
+		err := db.Unscoped().Model(user).
+			Select("email", "handle", "password", "last_login_at").
+			Updates(user).Error
+		if err != nil {
+			return err
+		}
+		if err := db.Create(erasure).Error; err != nil {
+			return err
+		}
+		if err := db.Create(entry).Error; err != nil {
+			return err
+		}
+		return appendUserUpdatedEvent(db, user)
+	})
+}
+
+// ListUnerased retrieves up to limit users closed before cutoff whose data
+// was not yet erased
+func (r *PrivacyRepository) ListUnerased(cutoff time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Unscoped().
+		Where("status = ? AND status_changed_at <= ?", models.UserStatusClosed, cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM data_erasures e WHERE e.user_id = users.id)").
+		Order("status_changed_at").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// PurgeDue purges the data of up to limit erasures whose retention period
+// ended, returning how many erasures it purged. The KYC profile is deleted
+// with the identity data; the free text and counterparty details of
+// financial records are blanked while their amounts are kept.
+func (r *PrivacyRepository) PurgeDue(now time.Time, limit int) (int, error) {
+	purged := 0
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		var erasures []models.DataErasure
+		err := db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(identity_purged_at IS NULL AND identity_purge_at <= ?) OR (financial_purged_at IS NULL AND financial_purge_at <= ?)", now, now).
+			Order("identity_purge_at").
+			Limit(limit).
+			Find(&erasures).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range erasures {
+			erasure := &erasures[i]
+			due := erasure.Due(now)
+			categories := make([]string, 0, len(due))
+			for _, category := range due {
+				if err := purge(db, erasure.UserID, category); err != nil {
+					return err
+				}
+				switch category {
+				case models.DataIdentity:
+					erasure.IdentityPurgedAt = &now
+				case models.DataFinancial:
+					erasure.FinancialPurgedAt = &now
+				}
+				categories = append(categories, string(category))
+			}
+			if err := db.Model(erasure).Select("identity_purged_at", "financial_purged_at").Updates(erasure).Error; err != nil {
+				return err
+			}
+			entry := &models.AuditLog{
+				Action:     models.AuditUserPurged,
+				TargetType: models.AggregateUser,
+				TargetID:   erasure.UserID,
+				Comment:    strings.Join(categories, ", "),
+			}
+			if err := db.Create(entry).Error; err != nil {
+				return err
+			}
+		}
+		purged = len(erasures)
+		return nil
+	})
+	return purged, err
+}
+
+func purge(db *gorm.DB, userID uuid.UUID, category models.DataCategory) error {
+	switch category {
+	case models.DataIdentity:
+		return db.Unscoped().Where("user_id = ?", userID).Delete(&models.UserProfile{}).Error
+	case models.DataFinancial:
+		updates := []struct {
+			model   interface{}
+			where   string
+			columns map[string]interface{}
+		}{
+			{&models.Transaction{}, "user_id = ?", map[string]interface{}{"description": ""}},
+			{&models.Payout{}, "user_id = ?", map[string]interface{}{"iban": "", "bic": "", "creditor_name": "", "remittance_info": ""}},
+			{&models.BankEntry{}, "user_id = ?", map[string]interface{}{"reference": "", "debtor_name": "", "debtor_account": ""}},
+			{&models.PaymentRequest{}, "requester_id = ?", map[string]interface{}{"memo": ""}},
+			{&models.StandingOrder{}, "user_id = ?", map[string]interface{}{"description": ""}},
+		}
+		for _, u := range updates {
+			if err := db.Unscoped().Model(u.model).Where(u.where, userID).UpdateColumns(u.columns).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
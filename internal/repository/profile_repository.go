@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+)
+
+type ProfileRepository struct {
+	db *gorm.DB
+}
+
+func NewProfileRepository(db *gorm.DB) *ProfileRepository {
+	return &ProfileRepository{db: db}
+}
+
+// GetByUserID retrieves the profile of a user
+func (r *ProfileRepository) GetByUserID(userID uuid.UUID) (*models.UserProfile, error) {
+	var profile models.UserProfile
+	if err := r.db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// ListVerifiedByPhone retrieves the KYC verified profiles with a phone number,
+// ignoring the separators it was written with
+func (r *ProfileRepository) ListVerifiedByPhone(phone string) ([]models.UserProfile, error) {
+	var profiles []models.UserProfile
+	err := r.db.Where("regexp_replace(phone, '[ .()/-]', '', 'g') = ? AND kyc_status = ?", phone, models.KYCStatusVerified).
+		Limit(2).
+		Find(&profiles).Error
+	if err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// Save creates or updates a profile
+func (r *ProfileRepository) Save(profile *models.UserProfile) error {
+	return r.db.Save(profile).Error
+}
+
+// ListByStatus retrieves profiles in a KYC status with pagination
+func (r *ProfileRepository) ListByStatus(status models.KYCStatus, page, pageSize int) ([]models.UserProfile, int64, error) {
+	var profiles []models.UserProfile
+	var total int64
+
+	query := r.db.Model(&models.UserProfile{}).Where("kyc_status = ?", status)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := r.db.Where("kyc_status = ?", status).
+		Order("submitted_at ASC").
+		Offset(offset).Limit(pageSize).
+		Find(&profiles).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return profiles, total, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+)
+
+type ReconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{db: db}
+}
+
+// ledgerSQL sums the net amount of every transaction per party and currency
+const ledgerSQL = `SELECT party AS user_id, currency, SUM(amount) AS amount FROM (
+	SELECT transactions.user_id AS party, transactions.currency, %s AS amount
+	FROM transactions %s
+	UNION ALL
+	SELECT transactions.recipient_id AS party, transactions.currency, %s AS amount
+	FROM transactions %s
+	WHERE transactions.recipient_id IS NOT NULL AND transactions.recipient_id <> transactions.user_id
+) AS legs GROUP BY party, currency`
+
+// LedgerSnapshot retrieves the stored balances per user and currency together
+// with those recomputed from the transaction history. Both are read from the
+// same snapshot, so transactions booked meanwhile cannot show as discrepancies.
+func (r *ReconciliationRepository) LedgerSnapshot() (stored, computed []models.LedgerBalance, err error) {
+	err = r.db.Transaction(func(db *gorm.DB) error {
+		if err := db.Table("balances").
+			Select("user_id, currency, SUM(amount) AS amount").
+			Where("deleted_at IS NULL").
+			Group("user_id, currency").
+			Scan(&stored).Error; err != nil {
+			return err
+		}
+
+		// Every transaction counts for its user and, if another party, for
+		// its recipient
+		return db.Raw(fmt.Sprintf(ledgerSQL,
+			netAmount("transactions.user_id"), reversalJoin,
+			netAmount("transactions.recipient_id"), reversalJoin)).
+			Scan(&computed).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	return stored, computed, err
+}
+
+// CreateRun records the start of a run
+func (r *ReconciliationRepository) CreateRun(run *models.ReconciliationRun) error {
+	return r.db.Create(run).Error
+}
+
+// FinishRun saves the outcome of a run with its discrepancies
+func (r *ReconciliationRepository) FinishRun(run *models.ReconciliationRun) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		if len(run.Discrepancies) > 0 {
+			if err := db.CreateInBatches(run.Discrepancies, 500).Error; err != nil {
+				return err
+			}
+		}
+		return db.Model(run).
+			Select("status", "balances_checked", "discrepancy_count", "totals", "error", "finished_at").
+			Updates(run).Error
+	})
+}
+
+// GetRun retrieves a run with its discrepancies, largest first
+func (r *ReconciliationRepository) GetRun(id uuid.UUID) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	err := r.db.Preload("Discrepancies", func(db *gorm.DB) *gorm.DB {
+		return db.Order("ABS(difference) DESC")
+	}).First(&run, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRuns retrieves runs without their discrepancies, newest first
+func (r *ReconciliationRepository) ListRuns(page, pageSize int) ([]models.ReconciliationRun, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.ReconciliationRun{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []models.ReconciliationRun
+	offset := (page - 1) * pageSize
+	err := r.db.Order("started_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&runs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
+
+// LastRun retrieves the most recently started run
+func (r *ReconciliationRepository) LastRun() (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	if err := r.db.Order("started_at DESC").First(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+)
+
+// ServiceAccountRepository stores service accounts and their API keys
+type ServiceAccountRepository struct {
+	db *gorm.DB
+}
+
+func NewServiceAccountRepository(db *gorm.DB) *ServiceAccountRepository {
+	return &ServiceAccountRepository{db: db}
+}
+
+// Create saves a service account
+func (r *ServiceAccountRepository) Create(account *models.ServiceAccount) error {
+	return r.db.Create(account).Error
+}
+
+// GetByIDAndUserID retrieves a service account of a user
+func (r *ServiceAccountRepository) GetByIDAndUserID(id, userID uuid.UUID) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListByUserID retrieves a user's service accounts by name
+func (r *ServiceAccountRepository) ListByUserID(userID uuid.UUID) ([]models.ServiceAccount, error) {
+	var accounts []models.ServiceAccount
+	if err := r.db.Where("user_id = ?", userID).Order("name ASC").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// Delete deletes a service account and revokes its keys
+func (r *ServiceAccountRepository) Delete(account *models.ServiceAccount, now time.Time) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		err := db.Model(&models.APIKey{}).
+			Where("service_account_id = ? AND revoked_at IS NULL", account.ID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return db.Delete(account).Error
+	})
+}
+
+// CreateKey saves an API key with the audit log entry of its creation
+func (r *ServiceAccountRepository) CreateKey(key *models.APIKey, entry *models.AuditLog) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		if err := db.Omit("ServiceAccount").Create(key).Error; err != nil {
+			return err
+		}
+		return db.Create(entry).Error
+	})
+}
+
+// RotateKey saves the shortened expiry of a rotated key with the key
+// replacing it and the audit log entry
+func (r *ServiceAccountRepository) RotateKey(old, replacement *models.APIKey, entry *models.AuditLog) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		result := db.Model(&models.APIKey{}).
+			Where("id = ? AND revoked_at IS NULL", old.ID).
+			Update("expires_at", old.ExpiresAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrAPIKeyRevoked
+		}
+		if err := db.Omit("ServiceAccount").Create(replacement).Error; err != nil {
+			return err
+		}
+		return db.Create(entry).Error
+	})
+}
+
+// RevokeKey revokes an API key with the audit log entry, reporting whether
+// it was not revoked yet
+func (r *ServiceAccountRepository) RevokeKey(key *models.APIKey, entry *models.AuditLog) (bool, error) {
+	revoked := false
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		result := db.Model(&models.APIKey{}).
+			Where("id = ? AND revoked_at IS NULL", key.ID).
+			Update("revoked_at", key.RevokedAt)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		revoked = true
+		return db.Create(entry).Error
+	})
+	return revoked, err
+}
+
+// GetKey retrieves an API key of a service account
+func (r *ServiceAccountRepository) GetKey(id, accountID uuid.UUID) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("id = ? AND service_account_id = ?", id, accountID).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListKeys retrieves the API keys of a service account, newest first
+func (r *ServiceAccountRepository) ListKeys(accountID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := r.db.Where("service_account_id = ?", accountID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetKeyByPrefix retrieves an API key by its prefix with its service
+// account, which is nil once deleted
+func (r *ServiceAccountRepository) GetKeyByPrefix(prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Preload("ServiceAccount").Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetKeyByID retrieves an API key by ID with its service account, which is
+// nil once deleted
+func (r *ServiceAccountRepository) GetKeyByID(id uuid.UUID) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Preload("ServiceAccount").First(&key, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// TouchKey saves when an API key was last used
+func (r *ServiceAccountRepository) TouchKey(key *models.APIKey) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", key.ID).Update("last_used_at", key.LastUsedAt).Error
+}
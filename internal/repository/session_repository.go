@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+)
+
+// SessionRepository stores the login sessions of users
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create saves a session
+func (r *SessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+// GetByID retrieves a session by ID
+func (r *SessionRepository) GetByID(id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.First(&session, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Touch saves when and from where a session was last used
+func (r *SessionRepository) Touch(session *models.Session) error {
+	return r.db.Model(session).Updates(map[string]interface{}{
+		"last_seen_at": session.LastSeenAt,
+		"ip":           session.IP,
+	}).Error
+}
+
+// ListActive retrieves the user's sessions that are not revoked and were
+// used since the cutoff, most recently used first
+func (r *SessionRepository) ListActive(userID uuid.UUID, cutoff time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, cutoff).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Revoke ends a session of the user, reporting whether it was active
+func (r *SessionRepository) Revoke(id, userID uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// RevokeAll ends all sessions of the user, returning how many were active
+func (r *SessionRepository) RevokeAll(userID uuid.UUID, now time.Time) (int, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now)
+	return int(result.RowsAffected), result.Error
+}
+
+// RevokeOthers ends all sessions of the user but the kept one, returning how
+// many were active
+func (r *SessionRepository) RevokeOthers(userID, keepID uuid.UUID, now time.Time) (int, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		Update("revoked_at", now)
+	return int(result.RowsAffected), result.Error
+}
+
+// DeleteStale deletes up to limit sessions revoked before revokedBefore or
+// last used before seenBefore, returning how many were deleted
+func (r *SessionRepository) DeleteStale(revokedBefore, seenBefore time.Time, limit int) (int, error) {
+	stale := r.db.Model(&models.Session{}).Select("id").
+		Where("revoked_at < ? OR last_seen_at < ?", revokedBefore, seenBefore).
+		Limit(limit)
+	result := r.db.Where("id IN (?)", stale).Delete(&models.Session{})
+	return int(result.RowsAffected), result.Error
+}
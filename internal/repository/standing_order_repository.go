@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StandingOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewStandingOrderRepository(db *gorm.DB) *StandingOrderRepository {
+	return &StandingOrderRepository{db: db}
+}
+
+// Create creates a new standing order
+func (r *StandingOrderRepository) Create(order *models.StandingOrder) error {
+	return r.db.Create(order).Error
+}
+
+// scheduleColumns are the columns the scheduler moves an order along with
+var scheduleColumns = []string{"occurrence", "next_run_at", "next_attempt_at", "last_error", "status", "updated_at"}
+
+// settingsColumns are the columns a user may change on an order
+var settingsColumns = []string{"amount", "description", "end_date", "max_occurrences", "on_insufficient_funds", "max_retries", "status", "updated_at"}
+
+// UpdateSchedule saves where the scheduler left an order. An order the user
+// paused or cancelled meanwhile, or that was cancelled when its owner was
+// closed, is left as it is.
+func (r *StandingOrderRepository) UpdateSchedule(order *models.StandingOrder) error {
+	return r.db.Model(order).Where("status = ?", models.StandingOrderActive).
+		Select(scheduleColumns).Updates(order).Error
+}
+
+// UpdateSettings saves a user's change to an order that is still in the
+// given status; resuming also moves the schedule past missed occurrences. It
+// returns gorm.ErrRecordNotFound if the status changed meanwhile.
+func (r *StandingOrderRepository) UpdateSettings(order *models.StandingOrder, status models.StandingOrderStatus, reschedule bool) error {
+	columns := settingsColumns
+	if reschedule {
+		columns = append(append([]string{}, settingsColumns...), scheduleColumns...)
+	}
+	result := r.db.Model(order).Where("status = ?", status).Select(columns).Updates(order)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetByIDAndUserID retrieves a standing order owned by a user
+func (r *StandingOrderRepository) GetByIDAndUserID(id, userID uuid.UUID) (*models.StandingOrder, error) {
+	var order models.StandingOrder
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// ListByUserID retrieves a user's standing orders
+func (r *StandingOrderRepository) ListByUserID(userID uuid.UUID) ([]models.StandingOrder, error) {
+	var orders []models.StandingOrder
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// ListDue retrieves active standing orders whose next attempt is due
+func (r *StandingOrderRepository) ListDue(now time.Time, limit int) ([]models.StandingOrder, error) {
+	var orders []models.StandingOrder
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", models.StandingOrderActive, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetOrCreateExecution returns the execution record of an occurrence,
+// creating it in pending state the first time the occurrence is seen
+func (r *StandingOrderRepository) GetOrCreateExecution(orderID uuid.UUID, scheduledFor time.Time) (*models.StandingOrderExecution, error) {
+	execution := models.StandingOrderExecution{
+		StandingOrderID: orderID,
+		ScheduledFor:    scheduledFor,
+		Status:          models.ExecutionPending,
+	}
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&execution).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var existing models.StandingOrderExecution
+	err = r.db.Where("standing_order_id = ? AND scheduled_for = ?", orderID, scheduledFor).First(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// UpdateExecution saves an execution record
+func (r *StandingOrderRepository) UpdateExecution(execution *models.StandingOrderExecution) error {
+	return r.db.Save(execution).Error
+}
+
+// ListExecutions retrieves the executions of a standing order, newest first
+func (r *StandingOrderRepository) ListExecutions(orderID uuid.UUID) ([]models.StandingOrderExecution, error) {
+	var executions []models.StandingOrderExecution
+	err := r.db.Where("standing_order_id = ?", orderID).Order("scheduled_for DESC").Find(&executions).Error
+	if err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
@@ -1,11 +1,13 @@
 package repository
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/takadao/banking/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TransactionRepository struct {
@@ -19,82 +21,285 @@ func NewTransactionRepository(db *gorm.DB) *TransactionRepository {
 // Create creates a new transaction
 func (r *TransactionRepository) Create(tx *models.Transaction) error {
 	return r.db.Transaction(func(db *gorm.DB) error {
-		// Create the transaction record
-		if err := db.Create(tx).Error; err != nil {
+		return r.createInTx(db, tx)
+	})
+}
+
+// createInTx records the transaction, applies it to the balances involved and
+// appends the resulting domain events, all within the given database transaction
+func (r *TransactionRepository) createInTx(db *gorm.DB, tx *models.Transaction) error {
+	// Resolve the accounts first so the transaction records them
+	var source, destination *models.Account
+	if tx.Debits() {
+		account, err := resolveAccount(db, tx.UserID, tx.Currency, tx.SourceAccountID, false)
+		if err != nil {
+			return err
+		}
+		source = account
+		tx.SourceAccountID = &account.ID
+	}
+	if beneficiary := tx.Beneficiary(); beneficiary != nil {
+		account, err := resolveAccount(db, *beneficiary, tx.Currency, tx.DestinationAccountID, true)
+		if err != nil {
 			return err
 		}
+		destination = account
+		tx.DestinationAccountID = &account.ID
+	}
 
-		// Update sender's balance
-		if tx.Type == models.TransactionTypeWithdraw || tx.Type == models.TransactionTypeTransfer {
-			var senderBalance models.Balance
-			if err := db.Where("user_id = ? AND currency = ?", tx.UserID, tx.Currency).First(&senderBalance).Error; err != nil {
-				if err == gorm.ErrRecordNotFound {
-					return models.ErrInsufficientFunds
-				}
-				return err
-			}
+	// Waive the fee of a transfer within the monthly allowance
+	if tx.FreeTransfers > 0 && tx.Fee > 0 {
+		free, err := withinFreeTransfers(db, tx)
+		if err != nil {
+			return err
+		}
+		if free {
+			tx.Fee = 0
+		}
+	}
 
-			if err := senderBalance.Subtract(tx.Amount); err != nil {
-				return err
-			}
+	// Create the transaction record
+	if err := db.Omit("Payout").Create(tx).Error; err != nil {
+		return err
+	}
 
-			if err := db.Save(&senderBalance).Error; err != nil {
-				return err
-			}
+	// Record the payout instruction of a withdrawal to an external account
+	if tx.Payout != nil {
+		tx.Payout.Prepare(tx)
+		if err := db.Create(tx.Payout).Error; err != nil {
+			return err
 		}
+	}
 
-		// Update recipient's balance for transfers
-		if tx.Type == models.TransactionTypeTransfer && tx.RecipientID != nil {
-			var recipientBalance models.Balance
-			err := db.Where("user_id = ? AND currency = ?", tx.RecipientID, tx.Currency).First(&recipientBalance).Error
-			if err != nil {
-				if err == gorm.ErrRecordNotFound {
-					recipientBalance = models.Balance{
-						UserID:   *tx.RecipientID,
-						Currency: tx.Currency,
-						Amount:   0,
-					}
-				} else {
-					return err
-				}
-			}
+	// Lock both rows up front so concurrent debits and holds see each other's
+	// changes
+	balances, err := lockAccountBalances(db, source, destination)
+	if err != nil {
+		return err
+	}
+	var updated []models.Balance
 
-			recipientBalance.Add(tx.Amount)
-			if err := db.Save(&recipientBalance).Error; err != nil {
-				return err
-			}
+	// Debit the source account
+	if source != nil {
+		balance := balances[source.ID]
+		if err := checkSpendLimit(db, source, tx); err != nil {
+			return err
+		}
+		if err := balance.Subtract(tx.Amount); err != nil {
+			return err
+		}
+		if err := db.Save(balance).Error; err != nil {
+			return err
 		}
+		updated = append(updated, *balance)
+	}
 
-		// Update balance for deposits
-		if tx.Type == models.TransactionTypeDeposit {
-			var balance models.Balance
-			err := db.Where("user_id = ? AND currency = ?", tx.UserID, tx.Currency).First(&balance).Error
-			if err != nil {
-				if err == gorm.ErrRecordNotFound {
-					balance = models.Balance{
-						UserID:   tx.UserID,
-						Currency: tx.Currency,
-						Amount:   0,
-					}
-				} else {
-					return err
-				}
-			}
+	// Credit the destination account
+	if destination != nil {
+		balance := balances[destination.ID]
+		balance.Add(tx.Amount)
+		if err := db.Save(balance).Error; err != nil {
+			return err
+		}
+		updated = append(updated, *balance)
+	}
+
+	if err := appendTransactionEvents(db, models.EventTransactionCreated, tx, updated); err != nil {
+		return err
+	}
 
-			balance.Add(tx.Amount)
-			if err := db.Save(&balance).Error; err != nil {
+	// Book the fee as a separate linked transaction, so the amount and its fee
+	// either both succeed or both roll back
+	if tx.Fee > 0 {
+		return r.createInTx(db, tx.NewFeeTransaction())
+	}
+	return nil
+}
+
+// PayOutAndClose books the payouts of what remains on a closing user's
+// accounts and closes the user in one database transaction, so the user is
+// either closed with nothing left or not closed at all
+func (r *TransactionRepository) PayOutAndClose(payouts []*models.Transaction, user *models.User, entry *models.AuditLog) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		for _, payout := range payouts {
+			if err := r.createInTx(db, payout); err != nil {
 				return err
 			}
 		}
+		return closeUserInTx(db, user, entry)
+	})
+}
 
-		return nil
+// withinFreeTransfers reports whether the user made fewer transfers this
+// month than their allowance. The user's row is locked first, so concurrent
+// transfers of the user are counted one after the other.
+func withinFreeTransfers(db *gorm.DB, tx *models.Transaction) (bool, error) {
+	var user models.User
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, "id = ?", tx.UserID).Error; err != nil {
+		return false, err
+	}
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var used int64
+	err := db.Model(&models.Transaction{}).
+		Where("user_id = ? AND type = ? AND created_at >= ?", tx.UserID, models.TransactionTypeTransfer, monthStart).
+		Count(&used).Error
+	return used < int64(tx.FreeTransfers), err
+}
+
+// Reverse books the reversal of a transaction and of the fees charged for it,
+// restoring the balances of the accounts involved, and marks them reversed.
+// It returns the reversal of the transaction itself. Withdrawals paid out to
+// an external account are refunded when their payout fails instead.
+func (r *TransactionRepository) Reverse(id uuid.UUID, description string, initiatedBy uuid.UUID) (*models.Transaction, error) {
+	var reversal *models.Transaction
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		var original models.Transaction
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&original, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := original.CheckReversible(); err != nil {
+			return err
+		}
+		var payouts int64
+		if err := db.Model(&models.Payout{}).Where("transaction_id = ?", id).Count(&payouts).Error; err != nil {
+			return err
+		}
+		if payouts > 0 {
+			return models.ErrPayoutNotReversible
+		}
+
+		var err error
+		reversal, err = reverseWithFeesInTx(db, &original, description, initiatedBy)
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+	return reversal, nil
+}
+
+// reverseWithFeesInTx reverses a locked transaction and the fees charged for
+// it, returning the reversal of the transaction itself
+func reverseWithFeesInTx(db *gorm.DB, original *models.Transaction, description string, initiatedBy uuid.UUID) (*models.Transaction, error) {
+	reversal, err := reverseInTx(db, original, description, initiatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	var fees []models.Transaction
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("parent_id = ? AND type = ? AND status = ?", original.ID, models.TransactionTypeFee, models.TransactionStatusCompleted).
+		Find(&fees).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range fees {
+		if _, err := reverseInTx(db, &fees[i], description, initiatedBy); err != nil {
+			return nil, err
+		}
+	}
+	return reversal, nil
+}
+
+// reverseInTx records the reversal of a transaction, moves its money back and
+// appends the transaction.reversed event. Transactions booked before accounts
+// existed fall back to the main accounts.
+func reverseInTx(db *gorm.DB, original *models.Transaction, description string, initiatedBy uuid.UUID) (*models.Transaction, error) {
+	var debited, credited *models.Account
+	if beneficiary := original.Beneficiary(); beneficiary != nil {
+		account, err := resolveAccount(db, *beneficiary, original.Currency, original.DestinationAccountID, false)
+		if err != nil {
+			return nil, err
+		}
+		debited = account
+	}
+	if original.Debits() {
+		account, err := resolveAccount(db, original.UserID, original.Currency, original.SourceAccountID, true)
+		if err != nil {
+			return nil, err
+		}
+		credited = account
+	}
+
+	reversal := original.NewReversal(description, initiatedBy)
+	if debited != nil {
+		reversal.SourceAccountID = &debited.ID
+	}
+	if credited != nil {
+		reversal.DestinationAccountID = &credited.ID
+	}
+	if err := db.Create(reversal).Error; err != nil {
+		return nil, err
+	}
+
+	var updated []models.Balance
+
+	// Take the money back from the account it went to
+	if debited != nil {
+		balance, err := lockAccountBalance(db, debited)
+		if err != nil {
+			return nil, err
+		}
+		if err := balance.Subtract(original.Amount); err != nil {
+			return nil, err
+		}
+		if err := db.Save(balance).Error; err != nil {
+			return nil, err
+		}
+		updated = append(updated, *balance)
+	}
+
+	// And return it to the account it came from
+	if credited != nil {
+		balance, err := lockAccountBalance(db, credited)
+		if err != nil {
+			return nil, err
+		}
+		balance.Add(original.Amount)
+		if err := db.Save(balance).Error; err != nil {
+			return nil, err
+		}
+		updated = append(updated, *balance)
+	}
+
+	if err := db.Model(original).Update("status", models.TransactionStatusReversed).Error; err != nil {
+		return nil, err
+	}
+	if err := appendTransactionEvents(db, models.EventTransactionReversed, reversal, updated); err != nil {
+		return nil, err
+	}
+	return reversal, nil
+}
+
+// appendTransactionEvents writes the transaction event and one balance.updated
+// event per balance the transaction changed
+func appendTransactionEvents(db *gorm.DB, eventType string, tx *models.Transaction, balances []models.Balance) error {
+	events := make([]*models.OutboxEvent, 0, len(balances)+1)
+
+	event, err := models.NewOutboxEvent(models.AggregateTransaction, tx.ID, eventType, models.NewTransactionEventData(tx))
+	if err != nil {
+		return err
+	}
+	events = append(events, event)
+
+	for i := range balances {
+		data := models.NewBalanceEventData(&balances[i])
+		data.TransactionID = &tx.ID
+		event, err := models.NewOutboxEvent(models.AggregateBalance, balances[i].ID, models.EventBalanceUpdated, data)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+
+	return appendOutboxEvents(db, events...)
 }
 
 // GetByID retrieves a transaction by ID
 func (r *TransactionRepository) GetByID(id uuid.UUID) (*models.Transaction, error) {
 	var tx models.Transaction
-	err := r.db.Preload("User").Preload("Recipient").First(&tx, "id = ?", id).Error
+	err := r.db.Preload("User").Preload("Recipient").Preload("Payout").First(&tx, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -146,14 +351,31 @@ func (r *TransactionRepository) GetAll(page, pageSize int) ([]models.Transaction
 	return transactions, total, nil
 }
 
+// signedAmount is the SQL for what a transaction, in the table aliased by the
+// first argument, adds to the balance of the party given by the second. Moves
+// between the user's own accounts leave the total unchanged.
+const signedAmount = "CASE WHEN %[1]s.type = 'move' THEN 0 WHEN %[1]s.type = 'deposit' OR (%[1]s.type IN ('transfer', 'fee', 'adjustment') AND %[1]s.recipient_id = %[2]s) THEN %[1]s.amount ELSE -%[1]s.amount END"
+
+// reversalJoin joins the transaction a reversal undoes as parents
+const reversalJoin = "LEFT JOIN transactions AS parents ON parents.id = transactions.parent_id AND transactions.type = 'reversal'"
+
+// netAmount is the SQL for what a transaction adds to the balance of party, a
+// reversal counting as the opposite of the transaction it undoes. It needs
+// reversalJoin.
+func netAmount(party string) string {
+	return fmt.Sprintf("CASE WHEN transactions.type = 'reversal' THEN -(%s) ELSE %s END",
+		fmt.Sprintf(signedAmount, "parents", party), fmt.Sprintf(signedAmount, "transactions", party))
+}
+
 // GetBalanceAtTime retrieves a user's balance at a specific point in time
 func (r *TransactionRepository) GetBalanceAtTime(userID uuid.UUID, currency string, atTime time.Time) (float64, error) {
 	var balance float64
 
 	// Calculate balance by summing all transactions up to the specified time
 	err := r.db.Model(&models.Transaction{}).
-		Select("COALESCE(SUM(CASE WHEN type = 'deposit' OR (type = 'transfer' AND recipient_id = ?) THEN amount ELSE -amount END), 0)", userID).
-		Where("(user_id = ? OR recipient_id = ?) AND currency = ? AND created_at <= ?", userID, userID, currency, atTime).
+		Joins(reversalJoin).
+		Select("COALESCE(SUM("+netAmount("?")+"), 0)", userID, userID).
+		Where("(transactions.user_id = ? OR transactions.recipient_id = ?) AND transactions.currency = ? AND transactions.created_at <= ?", userID, userID, currency, atTime).
 		Scan(&balance).Error
 
 	if err != nil {
@@ -163,10 +385,31 @@ func (r *TransactionRepository) GetBalanceAtTime(userID uuid.UUID, currency stri
 	return balance, nil
 }
 
+// CountSince counts a user's transactions of a type created since the given time
+func (r *TransactionRepository) CountSince(userID uuid.UUID, txType models.TransactionType, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Transaction{}).
+		Where("user_id = ? AND type = ? AND created_at >= ?", userID, txType, since).
+		Count(&count).Error
+	return count, err
+}
+
+// GetBalance retrieves the balance of a user's main account in a currency
+func (r *TransactionRepository) GetBalance(userID uuid.UUID, currency string) (*models.Balance, error) {
+	var balance models.Balance
+	err := r.db.Joins("JOIN accounts ON accounts.id = balances.account_id AND accounts.type = ?", models.AccountTypeMain).
+		Where("balances.user_id = ? AND balances.currency = ?", userID, currency).
+		First(&balance).Error
+	if err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
 // GetByIDAndUserID retrieves a transaction by ID and user ID
 func (r *TransactionRepository) GetByIDAndUserID(transactionID, userID uuid.UUID) (*models.Transaction, error) {
 	var transaction models.Transaction
-	if err := r.db.Where("id = ? AND user_id = ?", transactionID, userID).First(&transaction).Error; err != nil {
+	if err := r.db.Preload("Payout").Where("id = ? AND user_id = ?", transactionID, userID).First(&transaction).Error; err != nil {
 		return nil, err
 	}
 	return &transaction, nil
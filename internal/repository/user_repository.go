@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/takadao/banking/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -14,9 +17,14 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-// Create creates a new user
+// Create creates a new user and remembers their password
 func (r *UserRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+	return r.db.Transaction(func(db *gorm.DB) error {
+		if err := db.Create(user).Error; err != nil {
+			return err
+		}
+		return rememberPassword(db, user)
+	})
 }
 
 // GetByID retrieves a user by ID
@@ -29,6 +37,15 @@ func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+// GetByIDUnscoped retrieves a user by ID, including deleted users
+func (r *UserRepository) GetByIDUnscoped(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.db.Unscoped().First(&user, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 // GetByEmail retrieves a user by email
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	var user models.User
@@ -39,9 +56,211 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
-// Update updates a user
+// GetByHandle retrieves a user by handle
+func (r *UserRepository) GetByHandle(handle string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("handle = ?", handle).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetHandle sets or, when nil, clears the handle of a user
+func (r *UserRepository) SetHandle(id uuid.UUID, handle *string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("handle", handle).Error
+}
+
+// Update saves a user's email, password, role and email verification,
+// remembering a changed password, and records a user.updated event. Other
+// columns are left alone, so a concurrent freeze or closure is kept; the user
+// is read back so the event carries them.
 func (r *UserRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+	return r.db.Transaction(func(db *gorm.DB) error {
+		err := db.Model(user).
+			Select("email", "password", "role", "email_verified_at", "updated_at").
+			Updates(user).Error
+		if err != nil {
+			return err
+		}
+		if err := db.First(user, "id = ?", user.ID).Error; err != nil {
+			return err
+		}
+		if err := rememberPassword(db, user); err != nil {
+			return err
+		}
+		return appendUserUpdatedEvent(db, user)
+	})
+}
+
+// ListPreviousPasswords retrieves the hashes of the user's last limit
+// passwords, newest first
+func (r *UserRepository) ListPreviousPasswords(userID uuid.UUID, limit int) ([]string, error) {
+	var hashes []string
+	err := r.db.Model(&models.PreviousPassword{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("hash", &hashes).Error
+	return hashes, err
+}
+
+// SetStatus saves a change of the user's lifecycle status with its audit
+// log entry and records a user.updated event
+func (r *UserRepository) SetStatus(user *models.User, entry *models.AuditLog) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		return setStatusInTx(db, user, entry)
+	})
+}
+
+// Close saves a user as closed once no balance of theirs holds money. The
+// user row is locked so the balances are checked against concurrent
+// closures, and the user's handle is released, their standing orders are
+// cancelled, their unposted interest forfeited and their memberships of other
+// users' accounts removed.
+func (r *UserRepository) Close(user *models.User, entry *models.AuditLog) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		return closeUserInTx(db, user, entry)
+	})
+}
+
+func closeUserInTx(db *gorm.DB, user *models.User, entry *models.AuditLog) error {
+	var locked models.User
+	if err := db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, "id = ?", user.ID).Error; err != nil {
+		return err
+	}
+	if locked.Status == models.UserStatusClosed {
+		return models.ErrUserClosed
+	}
+
+	funded, err := hasFunds(db, user.ID)
+	if err != nil {
+		return err
+	}
+	if funded {
+		return models.ErrUserNotEmpty
+	}
+
+	// Interest accrued but not yet posted is forfeited: the accruals are
+	// settled without a deposit, as when they round to zero
+	now := time.Now()
+	if err := db.Model(&models.InterestAccrual{}).
+		Where("user_id = ? AND capitalized_at IS NULL", user.ID).
+		Updates(map[string]interface{}{"capitalized_at": now, "updated_at": now}).Error; err != nil {
+		return err
+	}
+
+	user.Handle = nil
+	if err := db.Model(&models.StandingOrder{}).
+		Where("user_id = ? AND status IN ?", user.ID, []models.StandingOrderStatus{models.StandingOrderActive, models.StandingOrderPaused}).
+		Update("status", models.StandingOrderCancelled).Error; err != nil {
+		return err
+	}
+	if err := db.Where("user_id = ?", user.ID).Delete(&models.AccountMember{}).Error; err != nil {
+		return err
+	}
+	return setStatusInTx(db, user, entry)
+}
+
+// RecordLogin stores when the user last logged in, and their reactivation
+// with its audit log entry when they were dormant
+func (r *UserRepository) RecordLogin(user *models.User, reactivation *models.AuditLog) error {
+	if reactivation == nil {
+		return r.db.Model(user).UpdateColumn("last_login_at", user.LastLoginAt).Error
+	}
+	return r.db.Transaction(func(db *gorm.DB) error {
+		return setStatusInTx(db, user, reactivation)
+	})
+}
+
+// MarkDormant marks up to limit active customers dormant who neither logged
+// in nor made a transaction since cutoff, returning how many it marked
+func (r *UserRepository) MarkDormant(cutoff, now time.Time, limit int) (int, error) {
+	marked := 0
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		var users []models.User
+		err := db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND role = ?", models.UserStatusActive, "user").
+			Where("COALESCE(last_login_at, created_at) < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM transactions t WHERE t.user_id = users.id AND t.created_at >= ?)", cutoff).
+			Order("created_at").
+			Limit(limit).
+			Find(&users).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range users {
+			user := &users[i]
+			user.Status = models.UserStatusDormant
+			user.StatusReason = "No activity since " + cutoff.Format("2006-01-02")
+			user.StatusChangedAt = &now
+			if err := setStatusInTx(db, user, user.NewAuditLog(models.AuditUserDormant, nil, user.StatusReason)); err != nil {
+				return err
+			}
+		}
+		marked = len(users)
+		return nil
+	})
+	return marked, err
+}
+
+// HasFunds reports whether any balance of the user holds or reserves money
+func (r *UserRepository) HasFunds(id uuid.UUID) (bool, error) {
+	return hasFunds(r.db, id)
+}
+
+func hasFunds(db *gorm.DB, userID uuid.UUID) (bool, error) {
+	var funded int64
+	err := db.Model(&models.Balance{}).
+		Where("user_id = ? AND (amount <> 0 OR held <> 0)", userID).
+		Count(&funded).Error
+	return funded > 0, err
+}
+
+func setStatusInTx(db *gorm.DB, user *models.User, entry *models.AuditLog) error {
+	err := db.Model(user).
+		Select("handle", "status", "freeze_scope", "status_reason", "status_changed_at", "last_login_at").
+		Updates(user).Error
+	if err != nil {
+		return err
+	}
+	if err := db.Create(entry).Error; err != nil {
+		return err
+	}
+	return appendUserUpdatedEvent(db, user)
+}
+
+// rememberPassword adds the user's password hash to their previous passwords
+// unless it is the latest one there already
+func rememberPassword(db *gorm.DB, user *models.User) error {
+	var latest []string
+	err := db.Model(&models.PreviousPassword{}).
+		Where("user_id = ?", user.ID).
+		Order("created_at DESC").
+		Limit(1).
+		Pluck("hash", &latest).Error
+	if err != nil {
+		return err
+	}
+	if len(latest) > 0 && latest[0] == user.Password {
+		return nil
+	}
+	return db.Create(&models.PreviousPassword{UserID: user.ID, Hash: user.Password}).Error
+}
+
+func appendUserUpdatedEvent(db *gorm.DB, user *models.User) error {
+	event, err := models.NewOutboxEvent(models.AggregateUser, user.ID, models.EventUserUpdated, models.UserEventData{
+		ID:        user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		Status:    user.Status,
+		UpdatedAt: user.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	return appendOutboxEvents(db, event)
 }
 
 // Delete deletes a user
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+)
+
+// UserTokenRepository stores the password reset and email verification
+// tokens mailed to users
+type UserTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+// Issue saves a token, superseding the unused tokens of the same purpose the
+// user was sent before, so only the latest mail works
+func (r *UserTokenRepository) Issue(token *models.UserToken) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		err := db.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return db.Create(token).Error
+	})
+}
+
+// GetByID retrieves a token by ID
+func (r *UserTokenRepository) GetByID(id uuid.UUID) (*models.UserToken, error) {
+	var token models.UserToken
+	if err := r.db.First(&token, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Redeem marks the token used and saves the given columns of the user it was
+// redeemed for, with the audit log entry and a user.updated event. A token
+// redeemed concurrently fails with ErrTokenInvalid.
+func (r *UserTokenRepository) Redeem(token *models.UserToken, user *models.User, entry *models.AuditLog, columns ...string) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		result := db.Model(&models.UserToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", token.UsedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrTokenInvalid
+		}
+
+		if err := db.Model(user).Select(columns).Updates(user).Error; err != nil {
+			return err
+		}
+		if err := rememberPassword(db, user); err != nil {
+			return err
+		}
+		if err := db.Create(entry).Error; err != nil {
+			return err
+		}
+		return appendUserUpdatedEvent(db, user)
+	})
+}
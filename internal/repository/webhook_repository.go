@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// CreateSubscription creates a new webhook subscription
+func (r *WebhookRepository) CreateSubscription(sub *models.WebhookSubscription) error {
+	return r.db.Create(sub).Error
+}
+
+// GetSubscription retrieves a subscription owned by a user
+func (r *WebhookRepository) GetSubscription(id, userID uuid.UUID) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&sub).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ListSubscriptions retrieves all subscriptions owned by a user
+func (r *WebhookRepository) ListSubscriptions(userID uuid.UUID) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// ListActiveSubscriptions retrieves a user's active subscriptions for an event
+func (r *WebhookRepository) ListActiveSubscriptions(userID uuid.UUID, eventType string) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := r.db.Where("user_id = ? AND active = ? AND ? = ANY(events)", userID, true, eventType).Find(&subs).Error
+	if err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// DeleteSubscription deletes a subscription owned by a user
+func (r *WebhookRepository) DeleteSubscription(id, userID uuid.UUID) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.WebhookSubscription{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateDeliveries queues deliveries for sending. Deliveries of an event a
+// subscription already has are skipped.
+func (r *WebhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// ClaimDueDeliveries locks up to limit pending deliveries that are due and
+// leases them for the given duration so concurrent workers skip them
+func (r *WebhookRepository) ClaimDueDeliveries(now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Transaction(func(db *gorm.DB) error {
+		err := db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return db.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery saves the outcome of a delivery attempt
+func (r *WebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+// GetDelivery retrieves a delivery belonging to one of the user's subscriptions
+func (r *WebhookRepository) GetDelivery(id, userID uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.Joins("JOIN webhook_subscriptions ON webhook_subscriptions.id = webhook_deliveries.subscription_id").
+		Where("webhook_deliveries.id = ? AND webhook_subscriptions.user_id = ?", id, userID).
+		First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries retrieves deliveries of a subscription with pagination,
+// optionally filtered by status
+func (r *WebhookRepository) ListDeliveries(subscriptionID uuid.UUID, status string, page, pageSize int) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("subscription_id = ?", subscriptionID)
+		if status != "" {
+			db = db.Where("status = ?", status)
+		}
+		return db
+	}
+
+	if err := r.db.Model(&models.WebhookDelivery{}).Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := r.db.Scopes(filter).Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// GetSubscriptionByID retrieves a subscription regardless of owner
+func (r *WebhookRepository) GetSubscriptionByID(id uuid.UUID) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := r.db.First(&sub, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
@@ -11,6 +11,24 @@ func SetupRouter(
 	authHandler *handlers.AuthHandler,
 	userHandler *handlers.UserHandler,
 	transactionHandler *handlers.TransactionHandler,
+	kycHandler *handlers.KYCHandler,
+	webhookHandler *handlers.WebhookHandler,
+	eventHandler *handlers.EventHandler,
+	standingOrderHandler *handlers.StandingOrderHandler,
+	paymentRequestHandler *handlers.PaymentRequestHandler,
+	holdHandler *handlers.HoldHandler,
+	pricingHandler *handlers.PricingHandler,
+	interestHandler *handlers.InterestHandler,
+	accountHandler *handlers.AccountHandler,
+	approvalHandler *handlers.ApprovalHandler,
+	changeRequestHandler *handlers.ChangeRequestHandler,
+	adjustmentHandler *handlers.AdjustmentHandler,
+	reconciliationHandler *handlers.ReconciliationHandler,
+	bankImportHandler *handlers.BankImportHandler,
+	payoutHandler *handlers.PayoutHandler,
+	beneficiaryHandler *handlers.BeneficiaryHandler,
+	privacyHandler *handlers.PrivacyHandler,
+	serviceAccountHandler *handlers.ServiceAccountHandler,
 	authMiddleware *middleware.AuthMiddleware,
 ) *gin.Engine {
 	router := gin.Default()
@@ -34,6 +52,23 @@ func SetupRouter(
 				adminAuth.POST("/login", authHandler.AdminLogin)
 				adminAuth.POST("/register", authMiddleware.RequireAuth(), authHandler.RegisterAdmin)
 			}
+
+			// Account recovery with tokens mailed to the user
+			auth.POST("/password/forgot", authHandler.ForgotPassword)
+			auth.POST("/password/reset", authHandler.ResetPassword)
+			auth.POST("/email/verify", authHandler.VerifyEmail)
+			auth.POST("/logout", authMiddleware.RequireAuth(), authHandler.Logout)
+		}
+
+		// OAuth2 client credentials grant for machine clients
+		api.POST("/oauth/token", serviceAccountHandler.Token)
+
+		// Real-time notification streams accept the token as query parameter
+		events := api.Group("/users/events")
+		events.Use(authMiddleware.RequireStreamAuth())
+		{
+			events.GET("", eventHandler.StreamEvents)
+			events.GET("/ws", eventHandler.StreamEventsWebSocket)
 		}
 
 		// Protected routes
@@ -45,7 +80,71 @@ func SetupRouter(
 			{
 				user.GET("/me", userHandler.GetMe)
 				user.PUT("/me", userHandler.UpdateMe)
+				user.PUT("/me/handle", userHandler.SetMyHandle)
+				user.POST("/me/email/verification", authHandler.ResendEmailVerification)
+				user.GET("/me/sessions", authHandler.ListSessions)
+				user.DELETE("/me/sessions", authHandler.RevokeAllSessions)
+				user.DELETE("/me/sessions/:id", authHandler.RevokeSession)
+				user.POST("/service-accounts", serviceAccountHandler.CreateServiceAccount)
+				user.GET("/service-accounts", serviceAccountHandler.ListServiceAccounts)
+				user.DELETE("/service-accounts/:id", serviceAccountHandler.DeleteServiceAccount)
+				user.POST("/service-accounts/:id/keys", serviceAccountHandler.CreateAPIKey)
+				user.GET("/service-accounts/:id/keys", serviceAccountHandler.ListAPIKeys)
+				user.POST("/service-accounts/:id/keys/:key_id/rotate", serviceAccountHandler.RotateAPIKey)
+				user.DELETE("/service-accounts/:id/keys/:key_id", serviceAccountHandler.RevokeAPIKey)
+				user.POST("/me/close", userHandler.CloseMe)
+				user.GET("/me/export", privacyHandler.ExportMe)
+				user.POST("/me/erasure", privacyHandler.EraseMe)
 				user.GET("/balance", userHandler.GetBalances)
+				user.GET("/me/profile", kycHandler.GetMyProfile)
+				user.PUT("/me/profile", kycHandler.SubmitMyProfile)
+				user.POST("/webhooks", webhookHandler.CreateWebhook)
+				user.GET("/webhooks", webhookHandler.ListWebhooks)
+				user.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+				user.GET("/webhooks/:id/deliveries", webhookHandler.ListWebhookDeliveries)
+				user.POST("/webhooks/deliveries/:delivery_id/replay", webhookHandler.ReplayWebhookDelivery)
+				user.POST("/standing-orders", standingOrderHandler.CreateStandingOrder)
+				user.GET("/standing-orders", standingOrderHandler.ListStandingOrders)
+				user.GET("/standing-orders/:id", standingOrderHandler.GetStandingOrder)
+				user.PUT("/standing-orders/:id", standingOrderHandler.UpdateStandingOrder)
+				user.DELETE("/standing-orders/:id", standingOrderHandler.CancelStandingOrder)
+				user.POST("/payment-requests", paymentRequestHandler.CreatePaymentRequest)
+				user.POST("/payment-requests/split", paymentRequestHandler.SplitBill)
+				user.GET("/payment-requests", paymentRequestHandler.ListPaymentRequests)
+				user.GET("/payment-requests/:id", paymentRequestHandler.GetPaymentRequest)
+				user.POST("/payment-requests/:id/accept", paymentRequestHandler.AcceptPaymentRequest)
+				user.POST("/payment-requests/:id/decline", paymentRequestHandler.DeclinePaymentRequest)
+				user.POST("/payment-requests/:id/cancel", paymentRequestHandler.CancelPaymentRequest)
+				user.GET("/holds", holdHandler.ListMyHolds)
+				user.GET("/interest", interestHandler.GetMyInterest)
+				user.POST("/accounts", accountHandler.CreateAccount)
+				user.GET("/accounts", accountHandler.ListAccounts)
+				user.POST("/accounts/moves", accountHandler.MoveMoney)
+				user.GET("/accounts/:id", accountHandler.GetAccount)
+				user.PUT("/accounts/:id", accountHandler.UpdateAccount)
+				user.DELETE("/accounts/:id", accountHandler.CloseAccount)
+				user.GET("/accounts/:id/transactions", accountHandler.ListAccountTransactions)
+				user.GET("/accounts/:id/members", accountHandler.ListAccountMembers)
+				user.PUT("/accounts/:id/members/:user_id", accountHandler.UpdateAccountMember)
+				user.DELETE("/accounts/:id/members/:user_id", accountHandler.RemoveAccountMember)
+				user.POST("/accounts/:id/invitations", accountHandler.InviteAccountMember)
+				user.DELETE("/accounts/:id/invitations/:invitation_id", accountHandler.RevokeAccountInvitation)
+				user.GET("/account-invitations", accountHandler.ListMyAccountInvitations)
+				user.POST("/account-invitations/:id/accept", accountHandler.AcceptAccountInvitation)
+				user.POST("/account-invitations/:id/decline", accountHandler.DeclineAccountInvitation)
+				user.POST("/accounts/:id/approval-policies", approvalHandler.CreateApprovalPolicy)
+				user.GET("/accounts/:id/approval-policies", approvalHandler.ListApprovalPolicies)
+				user.DELETE("/accounts/:id/approval-policies/:policy_id", approvalHandler.DeleteApprovalPolicy)
+				user.GET("/accounts/:id/pending-payments", approvalHandler.ListPendingPayments)
+				user.GET("/pending-payments/:id", approvalHandler.GetPendingPayment)
+				user.POST("/pending-payments/:id/approve", approvalHandler.ApprovePendingPayment)
+				user.POST("/pending-payments/:id/reject", approvalHandler.RejectPendingPayment)
+				user.POST("/pending-payments/:id/cancel", approvalHandler.CancelPendingPayment)
+				user.POST("/beneficiaries", beneficiaryHandler.CreateBeneficiary)
+				user.GET("/beneficiaries", beneficiaryHandler.ListBeneficiaries)
+				user.GET("/beneficiaries/:id", beneficiaryHandler.GetBeneficiary)
+				user.PUT("/beneficiaries/:id", beneficiaryHandler.UpdateBeneficiary)
+				user.DELETE("/beneficiaries/:id", beneficiaryHandler.DeleteBeneficiary)
 			}
 
 			// Admin routes
@@ -56,8 +155,55 @@ func SetupRouter(
 				admin.GET("/users/:id", userHandler.GetUser)
 				admin.PUT("/users/:id", userHandler.UpdateUser)
 				admin.DELETE("/users/:id", userHandler.DeleteUser)
+				admin.POST("/users/:id/freeze", userHandler.FreezeUser)
+				admin.POST("/users/:id/unfreeze", userHandler.UnfreezeUser)
+				admin.POST("/users/:id/erasure", privacyHandler.RequestErasure)
+				admin.GET("/data-erasures", privacyHandler.ListErasures)
 				admin.GET("/transactions", transactionHandler.ListTransactions)
 				admin.GET("/transactions/:id", transactionHandler.GetTransaction)
+				admin.POST("/transactions/:id/reverse", changeRequestHandler.RequestReversal)
+				admin.GET("/change-requests", changeRequestHandler.ListChangeRequests)
+				admin.GET("/change-requests/:id", changeRequestHandler.GetChangeRequest)
+				admin.POST("/change-requests/:id/approve", changeRequestHandler.ApproveChangeRequest)
+				admin.POST("/change-requests/:id/reject", changeRequestHandler.RejectChangeRequest)
+				admin.POST("/change-requests/:id/cancel", changeRequestHandler.CancelChangeRequest)
+				admin.GET("/audit-log", changeRequestHandler.ListAuditLog)
+				admin.POST("/adjustments", adjustmentHandler.CreateAdjustment)
+				admin.GET("/adjustments", adjustmentHandler.ListAdjustments)
+				admin.POST("/adjustment-reasons", adjustmentHandler.CreateAdjustmentReason)
+				admin.GET("/adjustment-reasons", adjustmentHandler.ListAdjustmentReasons)
+				admin.PUT("/adjustment-reasons/:id", adjustmentHandler.UpdateAdjustmentReason)
+				admin.GET("/kyc", kycHandler.ListKYCReviews)
+				admin.GET("/kyc/:user_id", kycHandler.GetKYCProfile)
+				admin.POST("/kyc/:user_id/approve", kycHandler.ApproveKYC)
+				admin.POST("/kyc/:user_id/reject", kycHandler.RejectKYC)
+				admin.POST("/holds", holdHandler.PlaceHold)
+				admin.GET("/holds", holdHandler.ListHolds)
+				admin.GET("/holds/:id", holdHandler.GetHold)
+				admin.POST("/holds/:id/capture", holdHandler.CaptureHold)
+				admin.POST("/holds/:id/release", holdHandler.ReleaseHold)
+				admin.POST("/pricing-plans", pricingHandler.CreatePricingPlan)
+				admin.GET("/pricing-plans", pricingHandler.ListPricingPlans)
+				admin.GET("/pricing-plans/:id", pricingHandler.GetPricingPlan)
+				admin.PUT("/pricing-plans/:id", pricingHandler.UpdatePricingPlan)
+				admin.PUT("/users/:id/pricing-plan", pricingHandler.AssignPricingPlan)
+				admin.POST("/interest-rates", interestHandler.CreateInterestRate)
+				admin.GET("/interest-rates", interestHandler.ListInterestRates)
+				admin.POST("/reconciliation/runs", reconciliationHandler.RunReconciliation)
+				admin.GET("/reconciliation/runs", reconciliationHandler.ListReconciliationRuns)
+				admin.GET("/reconciliation/runs/:id", reconciliationHandler.GetReconciliationRun)
+				admin.POST("/bank-files", bankImportHandler.ImportBankFile)
+				admin.GET("/bank-files", bankImportHandler.ListBankFiles)
+				admin.GET("/bank-files/:id", bankImportHandler.GetBankFile)
+				admin.GET("/bank-entries", bankImportHandler.ListBankEntries)
+				admin.GET("/bank-entries/:id", bankImportHandler.GetBankEntry)
+				admin.POST("/bank-entries/:id/allocate", bankImportHandler.AllocateBankEntry)
+				admin.GET("/payouts", payoutHandler.ListPayouts)
+				admin.GET("/payouts/:id", payoutHandler.GetPayout)
+				admin.GET("/payout-batches", payoutHandler.ListPayoutBatches)
+				admin.GET("/payout-batches/:id", payoutHandler.GetPayoutBatch)
+				admin.GET("/payout-batches/:id/document", payoutHandler.DownloadPayoutBatch)
+				admin.POST("/payout-batches/status-reports", payoutHandler.ImportPayoutStatusReport)
 			}
 
 			// Transaction routes (for both users and admins)
@@ -68,6 +214,8 @@ func SetupRouter(
 				transactions.POST("/deposit", transactionHandler.Deposit)
 				transactions.POST("/withdraw", transactionHandler.Withdraw)
 				transactions.POST("/transfer", transactionHandler.Transfer)
+				transactions.POST("/quote", transactionHandler.QuoteTransaction)
+				transactions.GET("/recipients/preview", transactionHandler.PreviewRecipient)
 			}
 		}
 	}
@@ -0,0 +1,437 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+type AccountService struct {
+	repo            *repository.AccountRepository
+	transactionRepo *repository.TransactionRepository
+	userRepo        *repository.UserRepository
+}
+
+func NewAccountService(repo *repository.AccountRepository, transactionRepo *repository.TransactionRepository, userRepo *repository.UserRepository) *AccountService {
+	return &AccountService{
+		repo:            repo,
+		transactionRepo: transactionRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// AccountUpdate holds the fields of an account a user may change; nil fields
+// are left untouched
+type AccountUpdate struct {
+	Name         *string
+	TargetAmount *float64
+	TargetDate   *time.Time
+}
+
+// AccountMembers lists who has access to an account: its owner, the members
+// and the invitations still open
+type AccountMembers struct {
+	OwnerID     uuid.UUID                  `json:"owner_id"`
+	Members     []models.AccountMember     `json:"members"`
+	Invitations []models.AccountInvitation `json:"invitations"`
+}
+
+// Open creates a savings pot or goal. Main accounts are opened automatically
+// by the first credit in a currency.
+func (s *AccountService) Open(account *models.Account) error {
+	if account.Type == models.AccountTypeMain {
+		return models.ErrInvalidAccountType
+	}
+	if err := account.Validate(); err != nil {
+		return err
+	}
+	if err := s.repo.Create(account); err != nil {
+		return err
+	}
+	account.Role = models.AccountRoleOwner
+	return nil
+}
+
+// List retrieves the accounts the user owns or is a member of, with their
+// balances and the user's role on each
+func (s *AccountService) List(userID uuid.UUID) ([]models.Account, error) {
+	accounts, err := s.repo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.repo.ListMemberships(userID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[uuid.UUID]models.AccountRole, len(memberships))
+	for _, m := range memberships {
+		roles[m.AccountID] = m.Role
+	}
+	for i := range accounts {
+		if accounts[i].UserID == userID {
+			accounts[i].Role = models.AccountRoleOwner
+		} else {
+			accounts[i].Role = roles[accounts[i].ID]
+		}
+	}
+	return accounts, nil
+}
+
+// Get retrieves an account the user has access to, with its balance
+func (s *AccountService) Get(id, userID uuid.UUID) (*models.Account, error) {
+	account, _, err := s.access(id, userID)
+	return account, err
+}
+
+// MainAccount retrieves the user's main account in a currency
+func (s *AccountService) MainAccount(userID uuid.UUID, currency string) (*models.Account, error) {
+	account, err := s.repo.GetMain(userID, currency)
+	if err != nil {
+		return nil, err
+	}
+	account.Role = models.AccountRoleOwner
+	return account, nil
+}
+
+// Update renames an account or changes a goal's target
+func (s *AccountService) Update(id, userID uuid.UUID, update AccountUpdate) (*models.Account, error) {
+	account, _, err := s.access(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !account.Role.CanManage() {
+		return nil, models.ErrAccountAccessDenied
+	}
+
+	if update.Name != nil {
+		account.Name = *update.Name
+	}
+	if update.TargetAmount != nil {
+		account.TargetAmount = update.TargetAmount
+	}
+	if update.TargetDate != nil {
+		account.TargetDate = update.TargetDate
+	}
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Close deletes an empty savings pot or goal; only its owner may close it
+func (s *AccountService) Close(id, userID uuid.UUID) error {
+	account, err := s.repo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return err
+	}
+	if account.Type == models.AccountTypeMain {
+		return models.ErrMainAccountRequired
+	}
+	return s.repo.Close(account)
+}
+
+// ListTransactions retrieves the transactions of an account the user has
+// access to, optionally only those one member initiated
+func (s *AccountService) ListTransactions(id, userID uuid.UUID, initiatedBy *uuid.UUID, page, pageSize int) ([]models.Transaction, int64, error) {
+	if _, _, err := s.access(id, userID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListTransactions(id, initiatedBy, page, pageSize)
+}
+
+// AuthorizeDebit checks the user may take money out of the account and
+// returns the account. The monthly limit of spenders is checked when the
+// debit is booked, under the lock of the account's balance.
+func (s *AccountService) AuthorizeDebit(id, userID uuid.UUID) (*models.Account, error) {
+	account, member, err := s.access(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil && !member.Role.CanSpend() {
+		return nil, models.ErrAccountAccessDenied
+	}
+	return account, nil
+}
+
+// AuthorizeCredit checks the user may pay into the account, which viewers
+// may not, and returns the account
+func (s *AccountService) AuthorizeCredit(id, userID uuid.UUID) (*models.Account, error) {
+	account, _, err := s.access(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Role == models.AccountRoleViewer {
+		return nil, models.ErrAccountAccessDenied
+	}
+	return account, nil
+}
+
+// Move transfers money between two accounts of the same owner in the same
+// currency, on behalf of the owner or a member of both accounts. Moves change
+// no totals, so they are neither KYC gated nor priced.
+func (s *AccountService) Move(userID, sourceID, destinationID uuid.UUID, amount float64, description string) (*models.Transaction, error) {
+	source, err := s.AuthorizeDebit(sourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := s.AuthorizeCredit(destinationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if source.UserID != destination.UserID {
+		return nil, models.ErrAccountAccessDenied
+	}
+	if description == "" {
+		description = "Move to another account"
+	}
+
+	transaction := &models.Transaction{
+		UserID:               source.UserID,
+		Type:                 models.TransactionTypeMove,
+		Amount:               amount,
+		Currency:             source.Currency,
+		Description:          description,
+		SourceAccountID:      &sourceID,
+		DestinationAccountID: &destinationID,
+		InitiatedBy:          &userID,
+	}
+	if err := transaction.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.transactionRepo.Create(transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// ListMembers returns who has access to an account the user has access to;
+// open invitations are only shown to those who manage members
+func (s *AccountService) ListMembers(id, userID uuid.UUID) (*AccountMembers, error) {
+	account, _, err := s.access(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repo.ListMembers(id)
+	if err != nil {
+		return nil, err
+	}
+	result := &AccountMembers{OwnerID: account.UserID, Members: members, Invitations: []models.AccountInvitation{}}
+	if account.Role.CanManage() {
+		if result.Invitations, err = s.repo.ListPendingInvitations(id, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Invite invites the holder of an email to the account with a role the
+// inviting user outranks
+func (s *AccountService) Invite(id, userID uuid.UUID, invitation *models.AccountInvitation) error {
+	account, _, err := s.access(id, userID)
+	if err != nil {
+		return err
+	}
+	if !account.Role.Outranks(invitation.Role) {
+		return models.ErrAccountAccessDenied
+	}
+	invitation.Email = models.NormalizeEmail(invitation.Email)
+	if err := invitation.Validate(); err != nil {
+		return err
+	}
+
+	// Existing users who already have access cannot be invited again
+	invitee, err := s.userRepo.GetByEmail(invitation.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if invitee != nil {
+		if invitee.ID == account.UserID {
+			return models.ErrAlreadyMember
+		}
+		if _, err := s.repo.GetMember(id, invitee.ID); err == nil {
+			return models.ErrAlreadyMember
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	invitation.AccountID = id
+	invitation.InvitedBy = userID
+	invitation.Status = models.InvitationPending
+	invitation.ExpiresAt = time.Now().Add(models.DefaultInvitationTTL)
+	return s.repo.CreateInvitation(invitation)
+}
+
+// RevokeInvitation withdraws an open invitation to the account
+func (s *AccountService) RevokeInvitation(id, userID, invitationID uuid.UUID) error {
+	account, _, err := s.access(id, userID)
+	if err != nil {
+		return err
+	}
+	invitation, err := s.repo.GetInvitation(invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation.AccountID != id {
+		return models.ErrInvitationNotFound
+	}
+	if !account.Role.Outranks(invitation.Role) {
+		return models.ErrAccountAccessDenied
+	}
+	ok, err := s.repo.CloseInvitation(invitationID, models.InvitationRevoked, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.ErrInvitationNotPending
+	}
+	return nil
+}
+
+// UpdateMember changes a member's role or spend limit. The user must outrank
+// both the member's current and new role.
+func (s *AccountService) UpdateMember(id, userID, memberID uuid.UUID, role models.AccountRole, spendLimit *float64) (*models.AccountMember, error) {
+	account, _, err := s.access(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.repo.GetMember(id, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if !account.Role.Outranks(member.Role) || !account.Role.Outranks(role) {
+		return nil, models.ErrAccountAccessDenied
+	}
+
+	member.Role = role
+	member.SpendLimit = spendLimit
+	if err := member.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateMember(member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember revokes a member's access. Members may always leave; removing
+// someone else requires outranking them.
+func (s *AccountService) RemoveMember(id, userID, memberID uuid.UUID) error {
+	account, _, err := s.access(id, userID)
+	if err != nil {
+		return err
+	}
+	member, err := s.repo.GetMember(id, memberID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && !account.Role.Outranks(member.Role) {
+		return models.ErrAccountAccessDenied
+	}
+	return s.repo.RemoveMember(member)
+}
+
+// ListInvitations retrieves the open invitations sent to the user's email
+func (s *AccountService) ListInvitations(userID uuid.UUID) ([]models.AccountInvitation, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListInvitationsByEmail(user.Email, time.Now())
+}
+
+// AcceptInvitation makes the user a member of the account they were invited to
+func (s *AccountService) AcceptInvitation(invitationID, userID uuid.UUID) (*models.AccountMember, error) {
+	invitation, err := s.invitationFor(invitationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Account == nil || invitation.Account.UserID == userID {
+		return nil, models.ErrAlreadyMember
+	}
+	if _, err := s.repo.GetMember(invitation.AccountID, userID); err == nil {
+		return nil, models.ErrAlreadyMember
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	member := &models.AccountMember{
+		AccountID:  invitation.AccountID,
+		UserID:     userID,
+		Role:       invitation.Role,
+		SpendLimit: invitation.SpendLimit,
+		AddedBy:    invitation.InvitedBy,
+	}
+	if err := s.repo.AcceptInvitation(invitation, member, time.Now()); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// DeclineInvitation turns down an invitation sent to the user
+func (s *AccountService) DeclineInvitation(invitationID, userID uuid.UUID) error {
+	if _, err := s.invitationFor(invitationID, userID); err != nil {
+		return err
+	}
+	ok, err := s.repo.CloseInvitation(invitationID, models.InvitationDeclined, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.ErrInvitationNotPending
+	}
+	return nil
+}
+
+// invitationFor retrieves an invitation the user can still answer
+func (s *AccountService) invitationFor(invitationID, userID uuid.UUID) (*models.AccountInvitation, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := s.repo.GetInvitation(invitationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	if err := invitation.CheckResponse(user.Email, time.Now()); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// access loads an account with the user's role on it, and their membership
+// unless they own it. Accounts the user has no access to are reported as not
+// found so their existence is not revealed.
+func (s *AccountService) access(id, userID uuid.UUID) (*models.Account, *models.AccountMember, error) {
+	account, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrAccountNotFound
+		}
+		return nil, nil, err
+	}
+	if account.UserID == userID {
+		account.Role = models.AccountRoleOwner
+		return account, nil, nil
+	}
+
+	member, err := s.repo.GetMember(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.ErrAccountNotFound
+		}
+		return nil, nil, err
+	}
+	account.Role = member.Role
+	return account, member, nil
+}
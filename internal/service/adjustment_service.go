@@ -0,0 +1,132 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+// AdjustmentService manages the reason code catalog and books manual balance
+// adjustments once their change request was approved
+type AdjustmentService struct {
+	repo     *repository.AdjustmentRepository
+	userRepo *repository.UserRepository
+}
+
+func NewAdjustmentService(repo *repository.AdjustmentRepository, userRepo *repository.UserRepository) *AdjustmentService {
+	return &AdjustmentService{repo: repo, userRepo: userRepo}
+}
+
+// AdjustmentReasonUpdate holds the changes to a catalog entry; nil fields are
+// left unchanged. Codes cannot change as booked adjustments refer to them.
+type AdjustmentReasonUpdate struct {
+	Description *string
+	Direction   *models.AdjustmentDirection
+	Active      *bool
+}
+
+// CreateReason adds a reason code to the catalog
+func (s *AdjustmentService) CreateReason(reason *models.AdjustmentReason) error {
+	reason.Code = strings.ToUpper(strings.TrimSpace(reason.Code))
+	reason.Active = true
+	if err := reason.Validate(); err != nil {
+		return err
+	}
+	return s.repo.CreateReason(reason)
+}
+
+// UpdateReason changes a catalog entry; deactivated codes cannot be used for
+// new adjustments
+func (s *AdjustmentService) UpdateReason(id uuid.UUID, update AdjustmentReasonUpdate) (*models.AdjustmentReason, error) {
+	reason, err := s.repo.GetReason(id)
+	if err != nil {
+		return nil, err
+	}
+	if update.Description != nil {
+		reason.Description = *update.Description
+	}
+	if update.Direction != nil {
+		reason.Direction = *update.Direction
+	}
+	if update.Active != nil {
+		reason.Active = *update.Active
+	}
+	if err := reason.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateReason(reason); err != nil {
+		return nil, err
+	}
+	return reason, nil
+}
+
+// ListReasons retrieves the catalog, optionally only the active codes
+func (s *AdjustmentService) ListReasons(activeOnly bool) ([]models.AdjustmentReason, error) {
+	return s.repo.ListReasons(activeOnly)
+}
+
+// List retrieves booked adjustments
+func (s *AdjustmentService) List(userID *uuid.UUID, reasonCode string, page, pageSize int) ([]models.Adjustment, int64, error) {
+	return s.repo.List(userID, reasonCode, page, pageSize)
+}
+
+// Check validates an adjustment against the catalog before it is requested
+func (s *AdjustmentService) Check(adjustment *models.AdjustmentRequest) error {
+	adjustment.ReasonCode = strings.ToUpper(strings.TrimSpace(adjustment.ReasonCode))
+	adjustment.Note = strings.TrimSpace(adjustment.Note)
+	if err := adjustment.Validate(); err != nil {
+		return err
+	}
+
+	reason, err := s.repo.GetReasonByCode(adjustment.ReasonCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrUnknownReasonCode
+		}
+		return err
+	}
+	if !reason.Active {
+		return models.ErrUnknownReasonCode
+	}
+	if !reason.Allows(adjustment.Direction) {
+		return models.ErrReasonDirectionMismatch
+	}
+
+	_, err = s.userRepo.GetByID(adjustment.UserID)
+	return err
+}
+
+// Book books an approved adjustment request through the ledger. Adjustments
+// are neither priced nor KYC gated.
+func (s *AdjustmentService) Book(request *models.ChangeRequest) (*models.Adjustment, error) {
+	var adjustment models.AdjustmentRequest
+	if err := request.DecodePayload(&adjustment); err != nil {
+		return nil, err
+	}
+
+	transaction := adjustment.Transaction(request.RequestedBy)
+	if err := transaction.Validate(); err != nil {
+		return nil, err
+	}
+	booked := &models.Adjustment{
+		ChangeRequestID: request.ID,
+		UserID:          adjustment.UserID,
+		Direction:       adjustment.Direction,
+		Amount:          adjustment.Amount,
+		Currency:        adjustment.Currency,
+		ReasonCode:      adjustment.ReasonCode,
+		Note:            adjustment.Note,
+		Attachments:     adjustment.Attachments,
+		RequestedBy:     request.RequestedBy,
+		ApprovedBy:      *request.DecidedBy,
+	}
+	if err := s.repo.Create(booked, transaction); err != nil {
+		return nil, err
+	}
+	booked.Transaction = transaction
+	return booked, nil
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+// approvalRetryDelay is how long a payment stays approved before it is
+// executed again, so payments being executed are left alone
+const approvalRetryDelay = time.Minute
+
+type ApprovalService struct {
+	repo               *repository.ApprovalRepository
+	accountService     *AccountService
+	transactionService *TransactionService
+}
+
+func NewApprovalService(repo *repository.ApprovalRepository, accountService *AccountService, transactionService *TransactionService) *ApprovalService {
+	return &ApprovalService{
+		repo:               repo,
+		accountService:     accountService,
+		transactionService: transactionService,
+	}
+}
+
+// CreatePolicy adds an approval policy to an account; only its owner may
+func (s *ApprovalService) CreatePolicy(accountID, userID uuid.UUID, policy *models.ApprovalPolicy) error {
+	account, err := s.accountService.Get(accountID, userID)
+	if err != nil {
+		return err
+	}
+	if account.Role != models.AccountRoleOwner {
+		return models.ErrAccountAccessDenied
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	policy.AccountID = accountID
+	policy.CreatedBy = userID
+	return s.repo.CreatePolicy(policy)
+}
+
+// ListPolicies retrieves the approval policies of an account the user has
+// access to
+func (s *ApprovalService) ListPolicies(accountID, userID uuid.UUID) ([]models.ApprovalPolicy, error) {
+	if _, err := s.accountService.Get(accountID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPolicies(accountID)
+}
+
+// DeletePolicy removes an approval policy; payments already pending keep the
+// approvals they were created with
+func (s *ApprovalService) DeletePolicy(accountID, userID, policyID uuid.UUID) error {
+	account, err := s.accountService.Get(accountID, userID)
+	if err != nil {
+		return err
+	}
+	if account.Role != models.AccountRoleOwner {
+		return models.ErrAccountAccessDenied
+	}
+	return s.repo.DeletePolicy(accountID, policyID)
+}
+
+// Submit makes a withdrawal or transfer on behalf of the initiating user.
+// When a policy of the paying account covers the amount, the payment is held
+// back for approval and returned; otherwise it is executed straight away and
+// no pending payment is returned.
+func (s *ApprovalService) Submit(initiatorID uuid.UUID, transaction *models.Transaction) (*models.PendingPayment, error) {
+	account, policy, err := s.policyFor(initiatorID, transaction)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, s.transactionService.Initiate(initiatorID, transaction)
+	}
+
+	if account.Currency != transaction.Currency {
+		return nil, models.ErrAccountCurrencyMismatch
+	}
+	// Only what could be booked now is held back, so approvers are not asked
+	// for a payment the user's status, KYC level or recipient already refuses
+	if err := s.transactionService.prepare(initiatorID, transaction); err != nil {
+		return nil, err
+	}
+
+	payment := models.NewPendingPayment(transaction, account.ID, policy, time.Now().Add(models.DefaultApprovalTTL))
+	if err := s.repo.CreatePayment(payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// Transfer makes a transfer that cannot wait for approval, such as a standing
+// order or the payment of a payment request, from the payer's main account.
+// It is refused when a policy of the account covers the amount.
+func (s *ApprovalService) Transfer(fromUserID, toUserID uuid.UUID, amount float64, currency, description string) (*models.Transaction, error) {
+	if fromUserID == toUserID {
+		return nil, models.ErrSelfTransfer
+	}
+	transaction := &models.Transaction{
+		Type:        models.TransactionTypeTransfer,
+		Amount:      amount,
+		Currency:    currency,
+		RecipientID: &toUserID,
+		Description: description,
+	}
+	_, policy, err := s.policyFor(fromUserID, transaction)
+	if err != nil {
+		return nil, err
+	}
+	if policy != nil {
+		return nil, models.ErrApprovalRequired
+	}
+	if err := s.transactionService.Initiate(fromUserID, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// Get retrieves a pending payment of an account the user has access to
+func (s *ApprovalService) Get(id, userID uuid.UUID) (*models.PendingPayment, error) {
+	payment, err := s.repo.GetPayment(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.accountService.Get(payment.AccountID, userID); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// List retrieves the pending payments of an account the user has access to
+func (s *ApprovalService) List(accountID, userID uuid.UUID, status models.PendingPaymentStatus) ([]models.PendingPayment, error) {
+	if _, err := s.accountService.Get(accountID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPayments(accountID, status)
+}
+
+// Approve records the user's approval and executes the payment once its
+// policy is satisfied. A payment that then fails, for example for lack of
+// funds, is returned with status failed.
+func (s *ApprovalService) Approve(id, userID uuid.UUID, comment string) (*models.PendingPayment, error) {
+	payment, err := s.decide(id, userID, models.DecisionApprove, comment)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status == models.PendingPaymentApproved {
+		return s.execute(payment)
+	}
+	return payment, nil
+}
+
+// Reject turns the payment down; one rejection is enough
+func (s *ApprovalService) Reject(id, userID uuid.UUID, comment string) (*models.PendingPayment, error) {
+	return s.decide(id, userID, models.DecisionReject, comment)
+}
+
+// Cancel withdraws a pending payment; only the user who made it may
+func (s *ApprovalService) Cancel(id, userID uuid.UUID) error {
+	payment, err := s.Get(id, userID)
+	if err != nil {
+		return err
+	}
+	if payment.InitiatedBy != userID {
+		return models.ErrAccountAccessDenied
+	}
+	ok, err := s.repo.Transition(id, models.PendingPaymentPending, models.PendingPaymentCancelled, map[string]interface{}{"closed_at": time.Now()})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.ErrPaymentNotPending
+	}
+	return nil
+}
+
+// ExecuteApproved executes up to limit payments that stayed approved for a
+// minute, because the database failed or the process stopped while they were
+// executed. It matches the worker's poll job signature.
+func (s *ApprovalService) ExecuteApproved(ctx context.Context, limit int) (int, error) {
+	ids, err := s.repo.ListApprovedIDs(time.Now().Add(-approvalRetryDelay), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	executed := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		payment, err := s.repo.GetPayment(id)
+		if err != nil {
+			return executed, err
+		}
+		if _, err := s.execute(payment); err != nil {
+			log.Printf("executing pending payment %s: %v", id, err)
+			continue
+		}
+		executed++
+	}
+	return executed, nil
+}
+
+// ExpireDue expires up to limit pending payments that were not approved in time
+func (s *ApprovalService) ExpireDue(ctx context.Context, limit int) (int, error) {
+	return s.repo.ExpireDue(time.Now(), limit)
+}
+
+// decide records a decision by a user who may approve payments of the account:
+// its owner or a co-owner
+func (s *ApprovalService) decide(id, userID uuid.UUID, decision models.ApprovalDecision, comment string) (*models.PendingPayment, error) {
+	payment, err := s.Get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.accountService.Get(payment.AccountID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !account.Role.CanManage() {
+		return nil, models.ErrAccountAccessDenied
+	}
+
+	approval := &models.PaymentApproval{ApproverID: userID, Decision: decision, Comment: comment}
+	return s.repo.Decide(id, approval, time.Now())
+}
+
+// policyFor returns the account a withdrawal or transfer pays from and the
+// policy that applies to it, if any. Other transactions and payments without
+// an account to pay from need no approval.
+func (s *ApprovalService) policyFor(initiatorID uuid.UUID, transaction *models.Transaction) (*models.Account, *models.ApprovalPolicy, error) {
+	if transaction.Type != models.TransactionTypeWithdraw && transaction.Type != models.TransactionTypeTransfer {
+		return nil, nil, nil
+	}
+
+	var account *models.Account
+	var err error
+	if transaction.SourceAccountID != nil {
+		account, err = s.accountService.AuthorizeDebit(*transaction.SourceAccountID, initiatorID)
+	} else {
+		account, err = s.accountService.MainAccount(initiatorID, transaction.Currency)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Nothing to pay from, let the transaction report it
+			return nil, nil, nil
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	policies, err := s.repo.ListPolicies(account.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return account, models.PolicyFor(policies, transaction.Amount), nil
+}
+
+// execute books an approved payment as its maker. The maker's right to spend
+// from the account is checked again, as it may have changed while waiting. A
+// payment that cannot be booked fails, while one hit by a failure of the
+// database stays approved for ExecuteApproved to retry.
+func (s *ApprovalService) execute(payment *models.PendingPayment) (*models.PendingPayment, error) {
+	transaction := payment.Transaction()
+	err := s.transactionService.prepare(payment.InitiatedBy, transaction)
+	if err == nil {
+		var executed *models.PendingPayment
+		if executed, err = s.repo.Execute(payment.ID, transaction, time.Now()); err == nil {
+			return executed, nil
+		}
+	}
+	if repository.IsTransient(err) {
+		return nil, err
+	}
+
+	now := time.Now()
+	failed, updateErr := s.repo.Transition(payment.ID, models.PendingPaymentApproved, models.PendingPaymentFailed,
+		map[string]interface{}{"error": err.Error(), "closed_at": now})
+	if updateErr != nil {
+		return nil, updateErr
+	}
+	if !failed {
+		// Executed meanwhile by another run
+		return s.repo.GetPayment(payment.ID)
+	}
+	payment.Status = models.PendingPaymentFailed
+	payment.Error = err.Error()
+	payment.ClosedAt = &now
+	return payment, nil
+}
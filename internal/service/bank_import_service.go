@@ -0,0 +1,196 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/bankfile"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+// BankImportService imports bank notification files, depositing the incoming
+// payments it can match to an account and keeping the rest in suspense
+type BankImportService struct {
+	repo        *repository.BankImportRepository
+	accountRepo *repository.AccountRepository
+	userRepo    *repository.UserRepository
+	kycService  *KYCService
+}
+
+func NewBankImportService(repo *repository.BankImportRepository, accountRepo *repository.AccountRepository, userRepo *repository.UserRepository, kycService *KYCService) *BankImportService {
+	return &BankImportService{
+		repo:        repo,
+		accountRepo: accountRepo,
+		userRepo:    userRepo,
+		kycService:  kycService,
+	}
+}
+
+// Import parses a bank file and processes each credit entry. An entry whose
+// reference names one of our account numbers is deposited into that account;
+// the others, deposits the owner's KYC level does not allow and those to
+// frozen, closed or deleted users go to the suspense queue. Entries imported
+// before are skipped, so a file that failed halfway can simply be imported
+// again.
+func (s *BankImportService) Import(format models.BankFileFormat, filename string, data []byte, adminID uuid.UUID) (*models.BankFile, error) {
+	format, entries, err := bankfile.Parse(format, data)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(data)
+	file := &models.BankFile{
+		Format:     format,
+		Filename:   filename,
+		Checksum:   hex.EncodeToString(checksum[:]),
+		ImportedBy: adminID,
+		Entries:    len(entries),
+	}
+	if err := s.repo.CreateFile(file); err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		entry.FileID = file.ID
+
+		account, err := s.match(entry)
+		var inserted bool
+		switch {
+		case err == nil:
+			entry.Status = models.BankEntryBooked
+			entry.AccountID = &account.ID
+			entry.UserID = &account.UserID
+			inserted, err = s.repo.Book(entry, entry.Deposit(account, adminID))
+			if inserted {
+				file.Booked++
+			}
+		case isSuspenseReason(err):
+			entry.Status = models.BankEntrySuspense
+			entry.SuspenseReason = err.Error()
+			if account != nil {
+				entry.UserID = &account.UserID
+			}
+			inserted, err = s.repo.Suspend(entry)
+			if inserted {
+				file.Suspense++
+			}
+		}
+		if err != nil {
+			// Record what was done so far
+			if updateErr := s.repo.UpdateFileCounts(file); updateErr != nil {
+				return nil, updateErr
+			}
+			return nil, err
+		}
+		if !inserted {
+			file.Duplicates++
+		}
+	}
+
+	if err := s.repo.UpdateFileCounts(file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Allocate deposits an entry in suspense into the account with the given
+// number. The admin's note documents why; the owner's KYC level and a
+// freeze are not checked again, but closed users cannot be paid.
+func (s *BankImportService) Allocate(entryID uuid.UUID, accountNumber, note string, adminID uuid.UUID) (*models.BankEntry, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, models.ErrAllocationNoteRequired
+	}
+	account, err := s.accountRepo.GetByNumber(strings.ToUpper(strings.ReplaceAll(accountNumber, " ", "")))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrAccountNotFound
+		}
+		return nil, err
+	}
+	owner, err := s.userRepo.GetByID(account.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrUserClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	if owner.Status == models.UserStatusClosed {
+		return nil, models.ErrUserClosed
+	}
+	return s.repo.Allocate(entryID, account, adminID, note, time.Now())
+}
+
+// GetFile retrieves an imported file with its entries
+func (s *BankImportService) GetFile(id uuid.UUID) (*models.BankFile, error) {
+	return s.repo.GetFile(id)
+}
+
+// ListFiles retrieves imported files
+func (s *BankImportService) ListFiles(page, pageSize int) ([]models.BankFile, int64, error) {
+	return s.repo.ListFiles(page, pageSize)
+}
+
+// GetEntry retrieves a bank entry
+func (s *BankImportService) GetEntry(id uuid.UUID) (*models.BankEntry, error) {
+	return s.repo.GetEntry(id)
+}
+
+// ListEntries retrieves bank entries, optionally only those with a status
+func (s *BankImportService) ListEntries(status models.BankEntryStatus, page, pageSize int) ([]models.BankEntry, int64, error) {
+	return s.repo.ListEntries(status, page, pageSize)
+}
+
+// match finds the account an entry pays into. Errors for which
+// isSuspenseReason holds leave the entry for manual allocation; the account
+// is returned with them when it was found.
+func (s *BankImportService) match(entry *models.BankEntry) (*models.Account, error) {
+	number := entry.AccountNumber()
+	if number == "" {
+		return nil, models.ErrNoAccountReference
+	}
+	account, err := s.accountRepo.GetByNumber(number)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrUnknownAccountReference
+		}
+		return nil, err
+	}
+	if account.Currency != entry.Currency {
+		return account, models.ErrAccountCurrencyMismatch
+	}
+	owner, err := s.userRepo.GetByID(account.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Users are closed before they are deleted
+		return account, models.ErrUserClosed
+	}
+	if err != nil {
+		return account, err
+	}
+	if err := owner.CheckTransaction(&models.Transaction{Type: models.TransactionTypeDeposit}); err != nil {
+		return account, err
+	}
+	if err := s.kycService.CheckTransaction(account.UserID, models.TransactionTypeDeposit, entry.Amount); err != nil {
+		return account, err
+	}
+	return account, nil
+}
+
+// isSuspenseReason reports whether a matching error sends an entry to the
+// suspense queue rather than failing the import
+func isSuspenseReason(err error) bool {
+	return errors.Is(err, models.ErrNoAccountReference) ||
+		errors.Is(err, models.ErrUnknownAccountReference) ||
+		errors.Is(err, models.ErrAccountCurrencyMismatch) ||
+		errors.Is(err, models.ErrUserFrozen) ||
+		errors.Is(err, models.ErrUserClosed) ||
+		errors.Is(err, models.ErrKYCNotAllowed) ||
+		errors.Is(err, models.ErrKYCLimitExceeded)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+// BeneficiaryService manages the beneficiary books of users
+type BeneficiaryService struct {
+	repo        *repository.BeneficiaryRepository
+	userRepo    *repository.UserRepository
+	accountRepo *repository.AccountRepository
+	profileRepo *repository.ProfileRepository
+}
+
+func NewBeneficiaryService(repo *repository.BeneficiaryRepository, userRepo *repository.UserRepository, accountRepo *repository.AccountRepository, profileRepo *repository.ProfileRepository) *BeneficiaryService {
+	return &BeneficiaryService{
+		repo:        repo,
+		userRepo:    userRepo,
+		accountRepo: accountRepo,
+		profileRepo: profileRepo,
+	}
+}
+
+// BeneficiaryUpdate holds the fields of a beneficiary a user may change; nil
+// fields are left untouched. The IBAN, email or phone number is fixed.
+type BeneficiaryUpdate struct {
+	Nickname *string
+	Name     *string
+}
+
+// Create validates a beneficiary and saves it to the user's book. IBANs of
+// this bank, emails and phone numbers must belong to another customer; phone
+// numbers match KYC verified profiles only.
+func (s *BeneficiaryService) Create(beneficiary *models.Beneficiary) error {
+	beneficiary.Normalize()
+	if err := beneficiary.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkNickname(beneficiary); err != nil {
+		return err
+	}
+	if beneficiary.Type == models.BeneficiaryInternal {
+		if err := s.resolve(beneficiary); err != nil {
+			return err
+		}
+	}
+	return s.repo.Create(beneficiary)
+}
+
+// Get retrieves a beneficiary of the user
+func (s *BeneficiaryService) Get(id, userID uuid.UUID) (*models.Beneficiary, error) {
+	return s.repo.GetByIDAndUserID(id, userID)
+}
+
+// List retrieves the user's beneficiaries by nickname
+func (s *BeneficiaryService) List(userID uuid.UUID) ([]models.Beneficiary, error) {
+	return s.repo.ListByUserID(userID)
+}
+
+// Update renames a beneficiary or changes the account holder name
+func (s *BeneficiaryService) Update(id, userID uuid.UUID, update BeneficiaryUpdate) (*models.Beneficiary, error) {
+	beneficiary, err := s.repo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if update.Nickname != nil {
+		beneficiary.Nickname = *update.Nickname
+	}
+	if update.Name != nil {
+		beneficiary.Name = *update.Name
+	}
+	beneficiary.Normalize()
+	if err := beneficiary.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkNickname(beneficiary); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(beneficiary); err != nil {
+		return nil, err
+	}
+	return beneficiary, nil
+}
+
+// Delete removes a beneficiary from the user's book
+func (s *BeneficiaryService) Delete(id, userID uuid.UUID) error {
+	deleted, err := s.repo.Delete(id, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Transaction builds a payment of the user to one of their beneficiaries
+func (s *BeneficiaryService) Transaction(id, userID uuid.UUID, amount float64, currency, description string) (*models.Transaction, error) {
+	beneficiary, err := s.repo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return beneficiary.Transaction(amount, currency, description), nil
+}
+
+func (s *BeneficiaryService) checkNickname(beneficiary *models.Beneficiary) error {
+	taken, err := s.repo.NicknameTaken(beneficiary.UserID, beneficiary.Nickname, beneficiary.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return models.ErrDuplicateNickname
+	}
+	return nil
+}
+
+// resolve finds the customer an internal beneficiary refers to
+func (s *BeneficiaryService) resolve(beneficiary *models.Beneficiary) error {
+	var recipientID uuid.UUID
+	switch {
+	case beneficiary.IBAN != "":
+		account, err := s.accountRepo.GetByNumber(beneficiary.IBAN)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrBeneficiaryNotFound
+		}
+		if err != nil {
+			return err
+		}
+		recipientID = account.UserID
+		beneficiary.AccountID = &account.ID
+	case beneficiary.Email != "":
+		user, err := s.userRepo.GetByEmail(beneficiary.Email)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrBeneficiaryNotFound
+		}
+		if err != nil {
+			return err
+		}
+		recipientID = user.ID
+	default:
+		profiles, err := s.profileRepo.ListVerifiedByPhone(beneficiary.Phone)
+		if err != nil {
+			return err
+		}
+		// A number shared by several customers identifies none of them
+		if len(profiles) != 1 {
+			return models.ErrBeneficiaryNotFound
+		}
+		recipientID = profiles[0].UserID
+	}
+
+	if recipientID == beneficiary.UserID {
+		return models.ErrSelfBeneficiary
+	}
+	beneficiary.RecipientID = &recipientID
+	return nil
+}
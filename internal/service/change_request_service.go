@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ChangeRequestService applies sensitive admin actions only once a second
+// admin approved them, keeping an audit trail of every step
+type ChangeRequestService struct {
+	repo               *repository.ChangeRequestRepository
+	userRepo           *repository.UserRepository
+	transactionService *TransactionService
+	adjustmentService  *AdjustmentService
+	privacyService     *PrivacyService
+	passwordService    *PasswordService
+	holdService        *HoldService
+	userService        *UserService
+	sessionService     *SessionService
+}
+
+func NewChangeRequestService(repo *repository.ChangeRequestRepository, userRepo *repository.UserRepository, transactionService *TransactionService, adjustmentService *AdjustmentService, privacyService *PrivacyService, passwordService *PasswordService, holdService *HoldService, userService *UserService, sessionService *SessionService) *ChangeRequestService {
+	return &ChangeRequestService{
+		repo:               repo,
+		userRepo:           userRepo,
+		transactionService: transactionService,
+		adjustmentService:  adjustmentService,
+		privacyService:     privacyService,
+		passwordService:    passwordService,
+		holdService:        holdService,
+		userService:        userService,
+		sessionService:     sessionService,
+	}
+}
+
+// AdminUserUpdate holds the changes an admin asks for on a user; empty
+// fields and those equal to the user's are left alone
+type AdminUserUpdate struct {
+	Email    string
+	Role     string
+	Password string
+}
+
+// RequestUserUpdate requests a user's email, role and password be changed,
+// one change request each. Every change is checked before any is stored,
+// and they are stored together, so one that is refused leaves none pending.
+// Password resets are mailed to the email, so it is not changed by one admin
+// alone either. Only the new password's hash is kept on its request, and
+// only until the request is closed.
+func (s *ChangeRequestService) RequestUserUpdate(adminID, userID uuid.UUID, update AdminUserUpdate, reason string) ([]*models.ChangeRequest, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []*models.ChangeRequest
+	add := func(action models.ChangeAction, payload interface{}, secret string) error {
+		request, err := s.newRequest(adminID, action, userID, payload, secret, reason)
+		if err != nil {
+			return err
+		}
+		requests = append(requests, request)
+		return nil
+	}
+	if update.Email != "" && update.Email != user.Email {
+		if _, err := s.userRepo.GetByEmail(update.Email); err == nil {
+			return nil, models.ErrEmailInUse
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err := add(models.ChangeActionEmailChange, models.EmailChange{Email: update.Email}, ""); err != nil {
+			return nil, err
+		}
+	}
+	if update.Role != "" && update.Role != user.Role {
+		if update.Role != "user" && update.Role != "admin" {
+			return nil, models.ErrInvalidRole
+		}
+		if err := add(models.ChangeActionRoleChange, models.RoleChange{Role: update.Role}, ""); err != nil {
+			return nil, err
+		}
+	}
+	if update.Password != "" {
+		if err := s.passwordService.Check(user, update.Password); err != nil {
+			return nil, err
+		}
+		hashed := models.User{Password: update.Password}
+		if err := hashed.HashPassword(); err != nil {
+			return nil, err
+		}
+		if err := add(models.ChangeActionPasswordChange, nil, hashed.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	if err := s.repo.Create(requests...); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// RequestAdminCreation requests an admin account be created. The new admin
+// could decide on the requester's other changes, so it needs a second admin
+// too. The admin's ID is chosen now, so the request has a target, and only
+// the password's hash is kept on the request.
+func (s *ChangeRequestService) RequestAdminCreation(adminID uuid.UUID, email, password, reason string) (*models.ChangeRequest, error) {
+	if _, err := s.userRepo.GetByEmail(email); err == nil {
+		return nil, models.ErrEmailInUse
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := s.passwordService.Check(&models.User{Email: email}, password); err != nil {
+		return nil, err
+	}
+	hashed := models.User{Password: password}
+	if err := hashed.HashPassword(); err != nil {
+		return nil, err
+	}
+	return s.request(adminID, models.ChangeActionAdminCreation, uuid.New(), models.AdminCreation{Email: email}, hashed.Password, reason)
+}
+
+// RequestUserDeletion requests a user be closed and deleted; their balances
+// must be empty
+func (s *ChangeRequestService) RequestUserDeletion(adminID, userID uuid.UUID, reason string) (*models.ChangeRequest, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, err
+	}
+	funded, err := s.userRepo.HasFunds(userID)
+	if err != nil {
+		return nil, err
+	}
+	if funded {
+		return nil, models.ErrUserNotEmpty
+	}
+	return s.request(adminID, models.ChangeActionUserDeletion, userID, nil, "", reason)
+}
+
+// RequestUserErasure requests a user's personal data be erased. Deleted users
+// may be erased too; users who are not closed yet must have empty balances.
+func (s *ChangeRequestService) RequestUserErasure(adminID, userID uuid.UUID, reason string) (*models.ChangeRequest, error) {
+	if _, err := s.userRepo.GetByIDUnscoped(userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.privacyService.GetErasure(userID); err == nil {
+		return nil, models.ErrAlreadyErased
+	}
+	funded, err := s.userRepo.HasFunds(userID)
+	if err != nil {
+		return nil, err
+	}
+	if funded {
+		return nil, models.ErrUserNotEmpty
+	}
+	return s.request(adminID, models.ChangeActionUserErasure, userID, nil, "", reason)
+}
+
+// RequestReversal requests a transaction and its fees be reversed
+func (s *ChangeRequestService) RequestReversal(adminID, transactionID uuid.UUID, reason string) (*models.ChangeRequest, error) {
+	transaction, err := s.transactionService.GetByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := transaction.CheckReversible(); err != nil {
+		return nil, err
+	}
+	return s.request(adminID, models.ChangeActionReversal, transactionID, nil, "", reason)
+}
+
+// RequestAdjustment requests a manual credit or debit of a user's balance with
+// a reason code from the catalog
+func (s *ChangeRequestService) RequestAdjustment(adminID uuid.UUID, adjustment *models.AdjustmentRequest) (*models.ChangeRequest, error) {
+	if err := s.adjustmentService.Check(adjustment); err != nil {
+		return nil, err
+	}
+	reason := adjustment.ReasonCode + ": " + adjustment.Note
+	return s.request(adminID, models.ChangeActionAdjustment, adjustment.UserID, adjustment, "", reason)
+}
+
+// RequestHoldCapture requests a hold with a recipient be captured for amount,
+// or in full when zero, since that moves money to another user
+func (s *ChangeRequestService) RequestHoldCapture(adminID, holdID uuid.UUID, amount float64, reason string) (*models.ChangeRequest, error) {
+	hold, err := s.holdService.Get(holdID)
+	if err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	if err := hold.CheckCapture(amount, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.request(adminID, models.ChangeActionHoldCapture, holdID, models.HoldCapture{Amount: amount}, "", reason)
+}
+
+// Get retrieves a change request with its audit trail
+func (s *ChangeRequestService) Get(id uuid.UUID) (*models.ChangeRequest, error) {
+	return s.repo.GetByID(id)
+}
+
+// List retrieves change requests, optionally filtered by status
+func (s *ChangeRequestService) List(status models.ChangeRequestStatus, page, pageSize int) ([]models.ChangeRequest, int64, error) {
+	return s.repo.List(status, page, pageSize)
+}
+
+// ListAudit retrieves audit log entries
+func (s *ChangeRequestService) ListAudit(filter repository.AuditFilter, page, pageSize int) ([]models.AuditLog, int64, error) {
+	return s.repo.ListAudit(filter, page, pageSize)
+}
+
+// Approve records a second admin's approval and applies the change. A change
+// that cannot be applied is returned with status failed and the error.
+func (s *ChangeRequestService) Approve(id, adminID uuid.UUID, comment string) (*models.ChangeRequest, error) {
+	request, err := s.repo.Decide(id, adminID, true, comment, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.apply(request); err != nil {
+		if _, closeErr := s.repo.Close(request, models.ChangeRequestApproved, models.ChangeRequestFailed,
+			map[string]interface{}{"error": err.Error()}, request.NewAuditLog(models.AuditChangeFailed, &adminID, err.Error())); closeErr != nil {
+			return nil, closeErr
+		}
+	} else {
+		if _, err := s.repo.Close(request, models.ChangeRequestApproved, models.ChangeRequestExecuted,
+			nil, request.NewAuditLog(models.AuditChangeExecuted, &adminID, "")); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetByID(id)
+}
+
+// Reject turns a change request down
+func (s *ChangeRequestService) Reject(id, adminID uuid.UUID, comment string) (*models.ChangeRequest, error) {
+	if _, err := s.repo.Decide(id, adminID, false, comment, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// Cancel withdraws a pending change request; only the admin who made it may
+func (s *ChangeRequestService) Cancel(id, adminID uuid.UUID) error {
+	request, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if request.RequestedBy != adminID {
+		return models.ErrChangeRequestNotOwned
+	}
+	closed, err := s.repo.Close(request, models.ChangeRequestPending, models.ChangeRequestCancelled,
+		nil, request.NewAuditLog(models.AuditChangeCancelled, &adminID, ""))
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return models.ErrChangeRequestNotPending
+	}
+	return nil
+}
+
+// request stores a pending change request, audited as requested by adminID
+func (s *ChangeRequestService) request(adminID uuid.UUID, action models.ChangeAction, targetID uuid.UUID, payload interface{}, secret, reason string) (*models.ChangeRequest, error) {
+	request, err := s.newRequest(adminID, action, targetID, payload, secret, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// newRequest creates a valid pending change request without storing it
+func (s *ChangeRequestService) newRequest(adminID uuid.UUID, action models.ChangeAction, targetID uuid.UUID, payload interface{}, secret, reason string) (*models.ChangeRequest, error) {
+	request, err := models.NewChangeRequest(action, targetID, payload, reason, adminID, time.Now().Add(models.DefaultChangeRequestTTL))
+	if err != nil {
+		return nil, err
+	}
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	request.Secret = secret
+	return request, nil
+}
+
+// apply carries out an approved change; reversals and adjustments are booked
+// as initiated by the admin who requested them
+func (s *ChangeRequestService) apply(request *models.ChangeRequest) error {
+	switch request.Action {
+	case models.ChangeActionRoleChange:
+		var change models.RoleChange
+		if err := request.DecodePayload(&change); err != nil {
+			return err
+		}
+		user, err := s.userRepo.GetByID(request.TargetID)
+		if err != nil {
+			return err
+		}
+		user.Role = change.Role
+		return s.userRepo.Update(user)
+	case models.ChangeActionPasswordChange:
+		user, err := s.userRepo.GetByID(request.TargetID)
+		if err != nil {
+			return err
+		}
+		user.Password = request.Secret
+		if err := s.userRepo.Update(user); err != nil {
+			return err
+		}
+		// As with a password reset, whoever knew the old password is logged out
+		_, err = s.sessionService.RevokeAll(user.ID)
+		return err
+	case models.ChangeActionEmailChange:
+		var change models.EmailChange
+		if err := request.DecodePayload(&change); err != nil {
+			return err
+		}
+		// Updating through the user service has the new email verified again
+		_, err := s.userService.Update(&models.User{ID: request.TargetID, Email: change.Email})
+		return err
+	case models.ChangeActionAdminCreation:
+		var creation models.AdminCreation
+		if err := request.DecodePayload(&creation); err != nil {
+			return err
+		}
+		_, err := s.userService.CreateAdmin(request.TargetID, creation.Email, request.Secret)
+		return err
+	case models.ChangeActionUserDeletion:
+		return deleteUser(s.userRepo, request.TargetID, request.RequestedBy, request.Reason)
+	case models.ChangeActionUserErasure:
+		_, err := s.privacyService.Erase(request.TargetID, &request.RequestedBy, request.Reason)
+		return err
+	case models.ChangeActionReversal:
+		_, err := s.transactionService.Reverse(request.TargetID, request.Reason, request.RequestedBy)
+		return err
+	case models.ChangeActionAdjustment:
+		_, err := s.adjustmentService.Book(request)
+		return err
+	case models.ChangeActionHoldCapture:
+		var capture models.HoldCapture
+		if err := request.DecodePayload(&capture); err != nil {
+			return err
+		}
+		_, _, err := s.holdService.Capture(request.TargetID, capture.Amount)
+		return err
+	default:
+		return models.ErrInvalidChangeAction
+	}
+}
+
+// ChangeRequestExpiry expires the change requests nobody decided in time. It
+// applies no change, so the worker runs it without the services that do.
+type ChangeRequestExpiry struct {
+	repo *repository.ChangeRequestRepository
+}
+
+func NewChangeRequestExpiry(repo *repository.ChangeRequestRepository) *ChangeRequestExpiry {
+	return &ChangeRequestExpiry{repo: repo}
+}
+
+// ExpireDue expires up to limit change requests that were not decided in time
+func (e *ChangeRequestExpiry) ExpireDue(ctx context.Context, limit int) (int, error) {
+	return e.repo.ExpireDue(time.Now(), limit)
+}
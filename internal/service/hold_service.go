@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+type HoldService struct {
+	repo               *repository.HoldRepository
+	transactionService *TransactionService
+}
+
+func NewHoldService(repo *repository.HoldRepository, transactionService *TransactionService) *HoldService {
+	return &HoldService{
+		repo:               repo,
+		transactionService: transactionService,
+	}
+}
+
+// Place reserves funds on the user's balance. A zero ttl uses the default
+// expiry. The hold is checked like the withdrawal or transfer it will
+// become: the user's status and KYC level, and the recipient.
+func (s *HoldService) Place(hold *models.Hold, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = models.DefaultHoldTTL
+	}
+	if ttl < 0 || ttl > models.MaxHoldTTL {
+		return models.ErrInvalidHoldTTL
+	}
+	if err := hold.Validate(); err != nil {
+		return err
+	}
+	if err := s.transactionService.Check(hold.Transaction(hold.Amount)); err != nil {
+		return err
+	}
+
+	hold.Status = models.HoldActive
+	hold.CapturedAmount = 0
+	hold.ExpiresAt = time.Now().Add(ttl)
+	return s.repo.Create(hold)
+}
+
+// Get retrieves a hold by ID
+func (s *HoldService) Get(id uuid.UUID) (*models.Hold, error) {
+	return s.repo.GetByID(id)
+}
+
+// GetForUser retrieves a hold on the user's balance
+func (s *HoldService) GetForUser(id, userID uuid.UUID) (*models.Hold, error) {
+	hold, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if hold.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return hold, nil
+}
+
+// List retrieves holds, optionally for one user and status
+func (s *HoldService) List(userID *uuid.UUID, status models.HoldStatus, page, pageSize int) ([]models.Hold, int64, error) {
+	return s.repo.List(userID, status, page, pageSize)
+}
+
+// Capture books amount of an active hold as a real transaction; a zero
+// amount captures the full hold. The transaction is checked and priced like
+// any other when it is booked.
+func (s *HoldService) Capture(id uuid.UUID, amount float64) (*models.Hold, *models.Transaction, error) {
+	hold, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	transaction := hold.Transaction(amount)
+	if err := s.transactionService.Check(transaction); err != nil {
+		return nil, nil, err
+	}
+	if hold, err = s.repo.Capture(id, transaction, time.Now()); err != nil {
+		return nil, nil, err
+	}
+	return hold, transaction, nil
+}
+
+// Release lifts an active hold, returning the funds to the available balance
+func (s *HoldService) Release(id uuid.UUID) (*models.Hold, error) {
+	return s.repo.Release(id, models.HoldReleased, time.Now())
+}
+
+// ExpireDue releases holds past their expiry. It matches the worker's poll
+// job signature.
+func (s *HoldService) ExpireDue(ctx context.Context, limit int) (int, error) {
+	now := time.Now()
+	ids, err := s.repo.ListExpiredIDs(now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, err := s.repo.Release(id, models.HoldExpired, now); err != nil {
+			// Captured or released concurrently
+			if err != models.ErrHoldNotActive {
+				log.Printf("expiring hold %s: %v", id, err)
+			}
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
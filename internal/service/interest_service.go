@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/takadao/banking/internal/lock"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+)
+
+const (
+	interestLockKey = "lock:interest"
+	interestLockTTL = 30 * time.Minute
+	// capitalizationBatch bounds the balances capitalized per run
+	capitalizationBatch = 500
+)
+
+// ErrRateNotInFuture is returned for rates that would change days already accrued
+var ErrRateNotInFuture = errors.New("effective_from must be after the last accrued day")
+
+type InterestService struct {
+	repo            *repository.InterestRepository
+	transactionRepo *repository.TransactionRepository
+	redis           *redis.Client
+}
+
+func NewInterestService(repo *repository.InterestRepository, transactionRepo *repository.TransactionRepository, redisClient *redis.Client) *InterestService {
+	return &InterestService{
+		repo:            repo,
+		transactionRepo: transactionRepo,
+		redis:           redisClient,
+	}
+}
+
+// InterestSummary is the interest position of a balance
+type InterestSummary struct {
+	Currency string                   `json:"currency"`
+	Accrued  float64                  `json:"accrued"` // earned but not yet posted
+	Accruals []models.InterestAccrual `json:"accruals"`
+}
+
+// CreateRate schedules a new interest rate. Rates only take effect after the
+// last accrued day so that accruals can always be recomputed identically.
+func (s *InterestService) CreateRate(rate *models.InterestRate) error {
+	if err := rate.Validate(); err != nil {
+		return err
+	}
+	rate.EffectiveFrom = truncateDay(rate.EffectiveFrom)
+
+	last, err := s.repo.LastRunDate()
+	if err != nil {
+		return err
+	}
+	if last != nil && !rate.EffectiveFrom.After(truncateDay(*last)) {
+		return ErrRateNotInFuture
+	}
+	return s.repo.CreateRate(rate)
+}
+
+// ListRates retrieves all interest rates
+func (s *InterestService) ListRates() ([]models.InterestRate, error) {
+	return s.repo.ListRates()
+}
+
+// Summary returns the accrued interest and recent accruals of a balance
+func (s *InterestService) Summary(userID uuid.UUID, currency string) (*InterestSummary, error) {
+	accrued, err := s.repo.SumUncapitalized(userID, currency)
+	if err != nil {
+		return nil, err
+	}
+	accruals, err := s.repo.ListAccruals(userID, currency, 31)
+	if err != nil {
+		return nil, err
+	}
+	return &InterestSummary{Currency: currency, Accrued: accrued, Accruals: accruals}, nil
+}
+
+// AccrueDay computes the interest every customer balance earned on a day from
+// its end-of-day ledger balance and the rate effective that day. It only
+// depends on the transaction history and the rates, so recomputing a day
+// gives the same result; capitalized days are left untouched.
+func (s *InterestService) AccrueDay(day time.Time) (int, error) {
+	day = truncateDay(day)
+	rates, err := s.repo.ListRates()
+	if err != nil {
+		return 0, err
+	}
+
+	// Rates are ordered latest first, so the first one in effect wins
+	effective := make(map[string]*models.InterestRate)
+	var currencies []string
+	for i := range rates {
+		rate := &rates[i]
+		if _, ok := effective[rate.Currency]; ok || rate.EffectiveFrom.After(day) {
+			continue
+		}
+		effective[rate.Currency] = rate
+		currencies = append(currencies, rate.Currency)
+	}
+	if len(currencies) == 0 {
+		return 0, nil
+	}
+
+	endOfDay := day.AddDate(0, 0, 1)
+	balances, err := s.repo.ListBalances(currencies, endOfDay)
+	if err != nil {
+		return 0, err
+	}
+
+	accrued := 0
+	for _, b := range balances {
+		rate := effective[b.Currency]
+		balance, err := s.transactionRepo.GetBalanceAtTime(b.UserID, b.Currency, endOfDay.Add(-time.Microsecond))
+		if err != nil {
+			return accrued, err
+		}
+		amount := rate.DailyInterest(balance, day)
+		if amount <= 0 {
+			// A recomputed day may have earned interest before
+			if err := s.repo.DeleteAccrual(b.UserID, b.Currency, day); err != nil {
+				return accrued, err
+			}
+			continue
+		}
+		err = s.repo.UpsertAccrual(&models.InterestAccrual{
+			UserID:   b.UserID,
+			Currency: b.Currency,
+			Date:     day,
+			Balance:  balance,
+			Amount:   amount,
+			RateID:   rate.ID,
+			DayCount: rate.DayCount,
+		})
+		if err != nil {
+			return accrued, err
+		}
+		accrued++
+	}
+	return accrued, nil
+}
+
+// AccrueRange accrues every day from from to to inclusive and records the
+// runs. It is used to backfill or recompute past days.
+func (s *InterestService) AccrueRange(ctx context.Context, from, to time.Time) (int, error) {
+	total := 0
+	for day := truncateDay(from); !day.After(truncateDay(to)); day = day.AddDate(0, 0, 1) {
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+		n, err := s.accrueAndRecord(day)
+		if err != nil {
+			return total, err
+		}
+		log.Printf("accrued interest for %s on %d balances", day.Format("2006-01-02"), n)
+		total += n
+	}
+	return total, nil
+}
+
+func (s *InterestService) accrueAndRecord(day time.Time) (int, error) {
+	n, err := s.AccrueDay(day)
+	if err != nil {
+		return n, err
+	}
+	return n, s.repo.SaveRun(&models.InterestRun{Date: day, Accruals: n, CompletedAt: time.Now()})
+}
+
+// CapitalizeDue posts the interest of every fully accrued month that has not
+// been capitalized yet
+func (s *InterestService) CapitalizeDue(ctx context.Context) (int, error) {
+	last, err := s.repo.LastRunDate()
+	if err != nil || last == nil {
+		return 0, err
+	}
+	// A month is complete once its last day has been accrued
+	next := truncateDay(*last).AddDate(0, 0, 1)
+	before := time.Date(next.Year(), next.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	capitalized := 0
+	for {
+		groups, err := s.repo.ListCapitalizable(before, capitalizationBatch)
+		if err != nil {
+			return capitalized, err
+		}
+		for _, group := range groups {
+			if ctx.Err() != nil {
+				return capitalized, ctx.Err()
+			}
+			if _, err := s.repo.Capitalize(group, time.Now()); err != nil {
+				return capitalized, err
+			}
+			capitalized++
+		}
+		if len(groups) < capitalizationBatch {
+			return capitalized, nil
+		}
+	}
+}
+
+// RunDue accrues up to limit days that have ended since the last run, then
+// capitalizes completed months. It matches the worker's poll job signature
+// and does nothing when another instance holds the interest lock.
+func (s *InterestService) RunDue(ctx context.Context, limit int) (int, error) {
+	l, err := lock.Acquire(ctx, s.redis, interestLockKey, interestLockTTL)
+	if err != nil || l == nil {
+		return 0, err
+	}
+	defer func() {
+		if err := l.Release(context.Background()); err != nil {
+			log.Printf("failed to release interest lock: %v", err)
+		}
+	}()
+
+	yesterday := truncateDay(time.Now()).AddDate(0, 0, -1)
+	last, err := s.repo.LastRunDate()
+	if err != nil {
+		return 0, err
+	}
+	next := yesterday
+	if last != nil {
+		next = truncateDay(*last).AddDate(0, 0, 1)
+	}
+
+	days := 0
+	for ; days < limit && !next.After(yesterday); next = next.AddDate(0, 0, 1) {
+		if ctx.Err() != nil {
+			return days, ctx.Err()
+		}
+		if _, err := s.accrueAndRecord(next); err != nil {
+			return days, err
+		}
+		days++
+	}
+	if days < limit {
+		// Caught up: post completed months
+		if _, err := s.CapitalizeDue(ctx); err != nil {
+			return days, err
+		}
+	}
+	return days, nil
+}
+
+// truncateDay returns the UTC midnight starting the day of t
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
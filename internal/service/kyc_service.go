@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+type KYCService struct {
+	repo *repository.ProfileRepository
+}
+
+func NewKYCService(repo *repository.ProfileRepository) *KYCService {
+	return &KYCService{repo: repo}
+}
+
+// GetProfile retrieves a user's profile. Users who never submitted one get
+// an empty unverified profile.
+func (s *KYCService) GetProfile(userID uuid.UUID) (*models.UserProfile, error) {
+	profile, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.UserProfile{UserID: userID, KYCStatus: models.KYCStatusUnverified}, nil
+		}
+		return nil, err
+	}
+	return profile, nil
+}
+
+// SubmitProfile stores the customer's profile data and queues it for review.
+// Any change to the identity data requires a fresh review.
+func (s *KYCService) SubmitProfile(userID uuid.UUID, input *models.UserProfile) (*models.UserProfile, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	profile, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		profile = &models.UserProfile{UserID: userID}
+	}
+
+	now := time.Now()
+	profile.LegalName = input.LegalName
+	profile.DateOfBirth = input.DateOfBirth
+	profile.Phone = input.Phone
+	profile.AddressLine1 = input.AddressLine1
+	profile.AddressLine2 = input.AddressLine2
+	profile.City = input.City
+	profile.PostalCode = input.PostalCode
+	profile.Country = input.Country
+	profile.Nationality = input.Nationality
+	profile.KYCStatus = models.KYCStatusPending
+	profile.RejectionReason = ""
+	profile.SubmittedAt = &now
+
+	if err := s.repo.Save(profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// ListByStatus retrieves profiles awaiting or past review
+func (s *KYCService) ListByStatus(status models.KYCStatus, page, pageSize int) ([]models.UserProfile, int64, error) {
+	return s.repo.ListByStatus(status, page, pageSize)
+}
+
+// Approve marks a pending profile as verified at the given level
+func (s *KYCService) Approve(userID, reviewerID uuid.UUID, level models.KYCLevel) (*models.UserProfile, error) {
+	if level != models.KYCLevelBasic && level != models.KYCLevelFull {
+		return nil, models.ErrInvalidKYCLevel
+	}
+	profile, err := s.pendingProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	profile.KYCStatus = models.KYCStatusVerified
+	profile.KYCLevel = level
+	profile.RejectionReason = ""
+	profile.ReviewedAt = &now
+	profile.ReviewedBy = &reviewerID
+	if err := s.repo.Save(profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Reject marks a pending profile as rejected with the given reason
+func (s *KYCService) Reject(userID, reviewerID uuid.UUID, reason string) (*models.UserProfile, error) {
+	if reason == "" {
+		return nil, errors.New("rejection reason is required")
+	}
+	profile, err := s.pendingProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	profile.KYCStatus = models.KYCStatusRejected
+	profile.KYCLevel = models.KYCLevelNone
+	profile.RejectionReason = reason
+	profile.ReviewedAt = &now
+	profile.ReviewedBy = &reviewerID
+	if err := s.repo.Save(profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// CheckTransaction verifies the user's KYC level permits the transaction
+func (s *KYCService) CheckTransaction(userID uuid.UUID, txType models.TransactionType, amount float64) error {
+	profile, err := s.GetProfile(userID)
+	if err != nil {
+		return err
+	}
+	return models.LimitsForLevel(profile.EffectiveLevel()).Allows(txType, amount)
+}
+
+func (s *KYCService) pendingProfile(userID uuid.UUID) (*models.UserProfile, error) {
+	profile, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if profile.KYCStatus != models.KYCStatusPending {
+		return nil, models.ErrKYCNotPending
+	}
+	return profile, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/events"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/realtime"
+)
+
+// NotificationService turns domain events into real-time notifications for
+// the users involved
+type NotificationService struct {
+	broadcaster *realtime.Broadcaster
+}
+
+func NewNotificationService(broadcaster *realtime.Broadcaster) *NotificationService {
+	return &NotificationService{broadcaster: broadcaster}
+}
+
+type transactionNotification struct {
+	EventID   uuid.UUID `json:"event_id"`
+	Event     string    `json:"event"`
+	Direction string    `json:"direction"` // incoming or outgoing
+	models.TransactionEventData
+}
+
+type balanceNotification struct {
+	EventID       uuid.UUID  `json:"event_id"`
+	AccountID     uuid.UUID  `json:"account_id"`
+	Currency      string     `json:"currency"`
+	Amount        float64    `json:"amount"`
+	Available     float64    `json:"available"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	HoldID        *uuid.UUID `json:"hold_id,omitempty"`
+}
+
+// HandleEvent notifies the users affected by a domain event. Redelivered
+// events produce duplicate notifications which clients can skip by event_id.
+func (s *NotificationService) HandleEvent(ctx context.Context, event events.Event) error {
+	switch {
+	case event.Type == models.EventBalanceUpdated:
+		var balance models.BalanceEventData
+		if err := event.Decode(&balance); err != nil {
+			return err
+		}
+		return s.broadcaster.Notify(ctx, balance.UserID, realtime.NotificationBalance, balanceNotification{
+			EventID:       event.ID,
+			AccountID:     balance.AccountID,
+			Currency:      balance.Currency,
+			Amount:        balance.Amount,
+			Available:     balance.Available,
+			TransactionID: balance.TransactionID,
+			HoldID:        balance.HoldID,
+		})
+
+	case event.AggregateType == models.AggregateTransaction:
+		var tx models.TransactionEventData
+		if err := event.Decode(&tx); err != nil {
+			return err
+		}
+		for _, userID := range tx.Parties() {
+			direction := "outgoing"
+			if userID != tx.UserID || tx.Type == models.TransactionTypeDeposit {
+				direction = "incoming"
+			}
+			err := s.broadcaster.Notify(ctx, userID, realtime.NotificationTransaction, transactionNotification{
+				EventID:              event.ID,
+				Event:                event.Type,
+				Direction:            direction,
+				TransactionEventData: tx,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+
+	"github.com/takadao/banking/internal/events"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+)
+
+// OutboxRelay moves committed domain events from the outbox table to the
+// event stream
+type OutboxRelay struct {
+	repo      *repository.OutboxRepository
+	publisher *events.Publisher
+}
+
+func NewOutboxRelay(repo *repository.OutboxRepository, publisher *events.Publisher) *OutboxRelay {
+	return &OutboxRelay{
+		repo:      repo,
+		publisher: publisher,
+	}
+}
+
+// RelayBatch publishes up to limit pending events and returns how many were
+// published
+func (r *OutboxRelay) RelayBatch(ctx context.Context, limit int) (int, error) {
+	return r.repo.PublishBatch(limit, func(event *models.OutboxEvent) error {
+		_, err := r.publisher.Publish(ctx, events.FromOutbox(event))
+		return err
+	})
+}
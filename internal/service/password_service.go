@@ -0,0 +1,65 @@
+package service
+
+import (
+	"github.com/takadao/banking/internal/breach"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+)
+
+// PasswordService checks new passwords against the password policy, the
+// breached password list and the user's previous passwords
+type PasswordService struct {
+	userRepo *repository.UserRepository
+	policy   models.PasswordPolicy
+	breached breach.Checker
+}
+
+// NewPasswordService creates the service; breached may be nil to skip the
+// breached password check
+func NewPasswordService(userRepo *repository.UserRepository, policy models.PasswordPolicy, breached breach.Checker) *PasswordService {
+	return &PasswordService{
+		userRepo: userRepo,
+		policy:   policy,
+		breached: breached,
+	}
+}
+
+// Check verifies the user may set the plain text password. The user is the
+// one the password is for, with the email they will have; users who are
+// being registered have no previous passwords.
+func (s *PasswordService) Check(user *models.User, password string) error {
+	if err := s.policy.Validate(password, user.Email); err != nil {
+		return err
+	}
+	if s.breached != nil {
+		breached, err := s.breached.Breached(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			return models.ErrBreachedPassword
+		}
+	}
+	return s.checkReuse(user, password)
+}
+
+// checkReuse rejects the user's current password and the previous ones the
+// policy remembers
+func (s *PasswordService) checkReuse(user *models.User, password string) error {
+	if s.policy.History <= 0 || user.Password == "" {
+		return nil
+	}
+	hashes, err := s.userRepo.ListPreviousPasswords(user.ID, s.policy.History)
+	if err != nil {
+		return err
+	}
+	if user.CheckPassword(password) == nil {
+		return models.ErrReusedPassword
+	}
+	for _, hash := range hashes {
+		if hash != user.Password && (&models.User{Password: hash}).CheckPassword(password) == nil {
+			return models.ErrReusedPassword
+		}
+	}
+	return nil
+}
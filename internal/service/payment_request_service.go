@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+type PaymentRequestService struct {
+	repo            *repository.PaymentRequestRepository
+	approvalService *ApprovalService
+}
+
+func NewPaymentRequestService(repo *repository.PaymentRequestRepository, approvalService *ApprovalService) *PaymentRequestService {
+	return &PaymentRequestService{
+		repo:            repo,
+		approvalService: approvalService,
+	}
+}
+
+// SplitShare is one participant of a split bill. A zero amount means the
+// participant pays an equal share of the total.
+type SplitShare struct {
+	PayerID uuid.UUID
+	Amount  float64
+}
+
+// Create sends a payment request to a payer. A zero ttl uses the default expiry.
+func (s *PaymentRequestService) Create(request *models.PaymentRequest, ttl time.Duration) error {
+	if err := s.prepare(request, ttl, time.Now()); err != nil {
+		return err
+	}
+	return s.repo.CreateBatch([]models.PaymentRequest{*request})
+}
+
+// Split divides a bill between several users and sends each of them a
+// payment request for their share. When includeRequester is set the requester
+// keeps an equal share for themselves and is not sent a request. Shares are
+// either all explicit, in which case they must add up to the total, or all
+// equal.
+func (s *PaymentRequestService) Split(requesterID uuid.UUID, total float64, currency, memo string, ttl time.Duration, shares []SplitShare, includeRequester bool) ([]models.PaymentRequest, error) {
+	if len(shares) == 0 {
+		return nil, models.ErrInvalidSplit
+	}
+	seen := make(map[uuid.UUID]bool, len(shares))
+	explicit := shares[0].Amount > 0
+	for _, share := range shares {
+		if seen[share.PayerID] {
+			return nil, models.ErrDuplicateSplitUser
+		}
+		seen[share.PayerID] = true
+		if (share.Amount > 0) != explicit {
+			return nil, models.ErrSplitMismatch
+		}
+	}
+
+	amounts := make([]float64, len(shares))
+	if explicit {
+		var sum int64
+		for i, share := range shares {
+			amounts[i] = share.Amount
+			sum += int64(math.Round(share.Amount * 100))
+		}
+		// With explicit shares the requester's part is whatever is left
+		requested := int64(math.Round(total * 100))
+		if sum > requested || (!includeRequester && sum != requested) {
+			return nil, models.ErrSplitMismatch
+		}
+	} else {
+		participants := len(shares)
+		if includeRequester {
+			participants++
+		}
+		equal, err := models.SplitAmount(total, participants)
+		if err != nil {
+			return nil, err
+		}
+		// The requester absorbs the rounding remainder by taking the first share
+		copy(amounts, equal[participants-len(shares):])
+	}
+
+	splitID := uuid.New()
+	now := time.Now()
+	requests := make([]models.PaymentRequest, len(shares))
+	for i, share := range shares {
+		requests[i] = models.PaymentRequest{
+			RequesterID: requesterID,
+			PayerID:     share.PayerID,
+			Amount:      amounts[i],
+			Currency:    currency,
+			Memo:        memo,
+			SplitID:     &splitID,
+		}
+		if err := s.prepare(&requests[i], ttl, now); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.CreateBatch(requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (s *PaymentRequestService) prepare(request *models.PaymentRequest, ttl time.Duration, now time.Time) error {
+	if ttl == 0 {
+		ttl = models.DefaultPaymentRequestTTL
+	}
+	if ttl < 0 || ttl > models.MaxPaymentRequestTTL {
+		return models.ErrInvalidRequestTTL
+	}
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	request.Status = models.PaymentRequestPending
+	request.ExpiresAt = now.Add(ttl)
+	return nil
+}
+
+// Get retrieves a payment request visible to the user as requester or payer
+func (s *PaymentRequestService) Get(id, userID uuid.UUID) (*models.PaymentRequest, error) {
+	request, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if request.RequesterID != userID && request.PayerID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return request, nil
+}
+
+// List retrieves the user's incoming, outgoing or all payment requests
+func (s *PaymentRequestService) List(userID uuid.UUID, direction string, status models.PaymentRequestStatus) ([]models.PaymentRequest, error) {
+	return s.repo.ListByUserID(userID, direction, status)
+}
+
+// Accept pays a pending request by transferring the amount from the payer to
+// the requester. The request is claimed before the transfer so it can only be
+// paid once; if the transfer fails the claim is released and the request
+// stays pending.
+func (s *PaymentRequestService) Accept(id, payerID uuid.UUID) (*models.PaymentRequest, error) {
+	request, err := s.forPayer(id, payerID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := s.checkPending(request, now); err != nil {
+		return nil, err
+	}
+
+	claimed, err := s.repo.Transition(id, models.PaymentRequestPending, models.PaymentRequestAccepted, map[string]interface{}{"responded_at": now})
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, models.ErrRequestNotPending
+	}
+
+	description := fmt.Sprintf("Payment request %s", request.ID)
+	if request.Memo != "" {
+		description = request.Memo
+	}
+	transaction, err := s.approvalService.Transfer(payerID, request.RequesterID, request.Amount, request.Currency, description)
+	if err != nil {
+		if _, releaseErr := s.repo.Transition(id, models.PaymentRequestAccepted, models.PaymentRequestPending, map[string]interface{}{"responded_at": nil}); releaseErr != nil {
+			return nil, fmt.Errorf("%v (releasing request: %v)", err, releaseErr)
+		}
+		return nil, err
+	}
+
+	if _, err := s.repo.Transition(id, models.PaymentRequestAccepted, models.PaymentRequestAccepted, map[string]interface{}{"transaction_id": transaction.ID}); err != nil {
+		return nil, err
+	}
+	request.Status = models.PaymentRequestAccepted
+	request.RespondedAt = &now
+	request.TransactionID = &transaction.ID
+	return request, nil
+}
+
+// Decline refuses a pending request as the payer
+func (s *PaymentRequestService) Decline(id, payerID uuid.UUID) (*models.PaymentRequest, error) {
+	request, err := s.forPayer(id, payerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.close(request, models.PaymentRequestDeclined)
+}
+
+// Cancel withdraws a pending request as the requester
+func (s *PaymentRequestService) Cancel(id, requesterID uuid.UUID) (*models.PaymentRequest, error) {
+	request, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if request.RequesterID != requesterID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.close(request, models.PaymentRequestCancelled)
+}
+
+// ExpireDue marks pending requests past their expiry as expired. It matches
+// the worker's poll job signature.
+func (s *PaymentRequestService) ExpireDue(ctx context.Context, limit int) (int, error) {
+	return s.repo.ExpireDue(time.Now(), limit)
+}
+
+func (s *PaymentRequestService) forPayer(id, payerID uuid.UUID) (*models.PaymentRequest, error) {
+	request, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if request.PayerID != payerID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return request, nil
+}
+
+// checkPending rejects requests that are no longer pending, expiring them on
+// the spot when the sweeper has not caught up yet
+func (s *PaymentRequestService) checkPending(request *models.PaymentRequest, now time.Time) error {
+	if request.IsExpired(now) {
+		if _, err := s.repo.Transition(request.ID, models.PaymentRequestPending, models.PaymentRequestExpired, nil); err != nil {
+			return err
+		}
+		return models.ErrRequestExpired
+	}
+	if request.Status != models.PaymentRequestPending {
+		return models.ErrRequestNotPending
+	}
+	return nil
+}
+
+func (s *PaymentRequestService) close(request *models.PaymentRequest, status models.PaymentRequestStatus) (*models.PaymentRequest, error) {
+	now := time.Now()
+	if err := s.checkPending(request, now); err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.Transition(request.ID, models.PaymentRequestPending, status, map[string]interface{}{"responded_at": now})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, models.ErrRequestNotPending
+	}
+	request.Status = status
+	request.RespondedAt = &now
+	return request, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/takadao/banking/internal/bankfile"
+	"github.com/takadao/banking/internal/lock"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+)
+
+const (
+	payoutLockKey = "lock:payout-batching"
+	payoutLockTTL = 5 * time.Minute
+	// payoutBatchDelay is how long the oldest pending payout waits before a
+	// batch is written, so payouts are sent in files rather than one by one
+	payoutBatchDelay = 10 * time.Minute
+)
+
+// ErrPayoutsNotConfigured is returned when no account to pay out from is set
+var ErrPayoutsNotConfigured = errors.New("payouts are not configured: PAYOUT_DEBTOR_NAME and PAYOUT_DEBTOR_IBAN are required")
+
+// PayoutService sends withdrawals to external accounts in pain.001 batch
+// files and settles them from the bank's pain.002 status reports
+type PayoutService struct {
+	repo   *repository.PayoutRepository
+	redis  *redis.Client
+	debtor bankfile.Debtor
+}
+
+func NewPayoutService(repo *repository.PayoutRepository, redisClient *redis.Client, debtor bankfile.Debtor) *PayoutService {
+	return &PayoutService{repo: repo, redis: redisClient, debtor: debtor}
+}
+
+// BatchDue writes up to limit pending payouts to a pain.001 batch once the
+// oldest has waited the batch delay, returning how many it batched. It matches
+// the worker's poll job signature and does nothing when another instance holds
+// the batching lock.
+func (s *PayoutService) BatchDue(ctx context.Context, limit int) (int, error) {
+	if s.debtor.Name == "" || s.debtor.IBAN == "" {
+		return 0, ErrPayoutsNotConfigured
+	}
+	l, err := lock.Acquire(ctx, s.redis, payoutLockKey, payoutLockTTL)
+	if err != nil || l == nil {
+		return 0, err
+	}
+	defer func() {
+		if err := l.Release(context.Background()); err != nil {
+			log.Printf("failed to release payout batching lock: %v", err)
+		}
+	}()
+
+	now := time.Now()
+	batch, err := s.repo.BatchPending(limit, now.Add(-payoutBatchDelay), func(batch *models.PayoutBatch, payouts []models.Payout) error {
+		document, err := bankfile.Pain001(batch.MessageID, now, s.debtor, payouts)
+		if err != nil {
+			return err
+		}
+		batch.Document = string(document)
+		return nil
+	})
+	if err != nil || batch == nil {
+		return 0, err
+	}
+	log.Printf("payout batch %s: %d payouts", batch.MessageID, batch.Count)
+	return batch.Count, nil
+}
+
+// ImportStatusReport applies a pain.002 status report to the batch it refers
+// to. Settled payouts are completed; rejected ones fail and their withdrawal
+// is refunded with its fees on behalf of the admin. Payouts without a final
+// status stay batched, and reports can be imported again without effect.
+func (s *PayoutService) ImportStatusReport(data []byte, adminID uuid.UUID) (*models.PayoutBatch, error) {
+	report, err := bankfile.ParsePain002(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	batch, settled, err := s.repo.ApplyStatusReport(report.MessageID, adminID, time.Now(), func(payout *models.Payout) (models.PayoutStatus, string) {
+		status := report.StatusOf(bankfile.PaymentInfoID(report.MessageID, payout.Currency), payout.EndToEndID)
+		switch {
+		case status.Rejected():
+			return models.PayoutFailed, status.Reason
+		case status.Settled():
+			return models.PayoutCompleted, status.Reason
+		default:
+			return models.PayoutBatched, ""
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("payout batch %s: status report settled %d payouts", batch.MessageID, settled)
+	return s.repo.GetBatch(batch.ID)
+}
+
+// Get retrieves a payout
+func (s *PayoutService) Get(id uuid.UUID) (*models.Payout, error) {
+	return s.repo.Get(id)
+}
+
+// List retrieves payouts, optionally only those with a status, newest first
+func (s *PayoutService) List(status models.PayoutStatus, page, pageSize int) ([]models.Payout, int64, error) {
+	return s.repo.List(status, page, pageSize)
+}
+
+// GetBatch retrieves a batch with its payouts
+func (s *PayoutService) GetBatch(id uuid.UUID) (*models.PayoutBatch, error) {
+	return s.repo.GetBatch(id)
+}
+
+// ListBatches retrieves batches, newest first
+func (s *PayoutService) ListBatches(page, pageSize int) ([]models.PayoutBatch, int64, error) {
+	return s.repo.ListBatches(page, pageSize)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+type PricingService struct {
+	repo            *repository.PricingRepository
+	transactionRepo *repository.TransactionRepository
+}
+
+func NewPricingService(repo *repository.PricingRepository, transactionRepo *repository.TransactionRepository) *PricingService {
+	return &PricingService{
+		repo:            repo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// Quote computes the fee the user would pay for an operation under their
+// pricing plan. Users without a plan and operations without a matching rule
+// are free.
+func (s *PricingService) Quote(userID uuid.UUID, txType models.TransactionType, amount float64, currency string) (*models.FeeQuote, error) {
+	quote := &models.FeeQuote{
+		Type:     txType,
+		Amount:   amount,
+		Currency: currency,
+		Total:    amount,
+	}
+
+	plan, err := s.planFor(userID)
+	if err != nil || plan == nil {
+		return quote, err
+	}
+	quote.PlanID = &plan.ID
+	quote.PlanName = plan.Name
+
+	kind, ok := models.FeeKindFor(txType)
+	if !ok {
+		return quote, nil
+	}
+
+	// Transfers within the monthly allowance are free
+	if kind == models.FeeKindTransfer && plan.FreeTransfersPerMonth > 0 {
+		now := time.Now().UTC()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		used, err := s.transactionRepo.CountSince(userID, models.TransactionTypeTransfer, monthStart)
+		if err != nil {
+			return nil, err
+		}
+		remaining := plan.FreeTransfersPerMonth - int(used)
+		if remaining < 0 {
+			remaining = 0
+		}
+		quote.FreeTransfersRemaining = &remaining
+		if remaining > 0 {
+			return quote, nil
+		}
+	}
+
+	rule := plan.RuleFor(kind, currency)
+	if rule == nil {
+		return quote, nil
+	}
+	quote.Fee = rule.Compute(amount)
+	quote.Total = math.Round((amount+quote.Fee)*100) / 100
+	return quote, nil
+}
+
+// Price sets the fee of a transaction under the user's pricing plan. The
+// free transfer allowance is counted when the transaction is booked, with
+// the user's other transfers held back, so concurrent transfers cannot all
+// use its last free transfer.
+func (s *PricingService) Price(transaction *models.Transaction) error {
+	transaction.Fee = 0
+	transaction.FreeTransfers = 0
+
+	plan, err := s.planFor(transaction.UserID)
+	if err != nil || plan == nil {
+		return err
+	}
+	kind, ok := models.FeeKindFor(transaction.Type)
+	if !ok {
+		return nil
+	}
+	if kind == models.FeeKindTransfer {
+		transaction.FreeTransfers = plan.FreeTransfersPerMonth
+	}
+	if rule := plan.RuleFor(kind, transaction.Currency); rule != nil {
+		transaction.Fee = rule.Compute(transaction.Amount)
+	}
+	return nil
+}
+
+// planFor retrieves the pricing plan of a user, nil when they have none
+func (s *PricingService) planFor(userID uuid.UUID) (*models.PricingPlan, error) {
+	plan, err := s.repo.GetPlanForUser(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return plan, err
+}
+
+// CreatePlan creates a pricing plan with its fee rules
+func (s *PricingService) CreatePlan(plan *models.PricingPlan) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	return s.repo.CreatePlan(plan)
+}
+
+// UpdatePlan replaces a pricing plan's settings and fee rules
+func (s *PricingService) UpdatePlan(id uuid.UUID, input *models.PricingPlan) (*models.PricingPlan, error) {
+	plan, err := s.repo.GetPlan(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	plan.Name = input.Name
+	plan.Description = input.Description
+	plan.IsDefault = input.IsDefault
+	plan.FreeTransfersPerMonth = input.FreeTransfersPerMonth
+	plan.Rules = input.Rules
+	if err := s.repo.UpdatePlan(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// GetPlan retrieves a pricing plan
+func (s *PricingService) GetPlan(id uuid.UUID) (*models.PricingPlan, error) {
+	return s.repo.GetPlan(id)
+}
+
+// ListPlans retrieves all pricing plans
+func (s *PricingService) ListPlans() ([]models.PricingPlan, error) {
+	return s.repo.ListPlans()
+}
+
+// AssignPlan puts a user on a pricing plan
+func (s *PricingService) AssignPlan(userID, planID, adminID uuid.UUID) (*models.UserPricingPlan, error) {
+	if _, err := s.repo.GetPlan(planID); err != nil {
+		return nil, err
+	}
+	assignment := &models.UserPricingPlan{
+		UserID:     userID,
+		PlanID:     planID,
+		AssignedBy: adminID,
+	}
+	if err := s.repo.AssignPlan(assignment); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
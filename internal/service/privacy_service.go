@@ -0,0 +1,159 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+)
+
+// PrivacyService exports users' data to them and erases it at their request,
+// keeping what the retention policy requires until it is due to be purged
+type PrivacyService struct {
+	repo     *repository.PrivacyRepository
+	userRepo *repository.UserRepository
+	policy   models.RetentionPolicy
+}
+
+func NewPrivacyService(repo *repository.PrivacyRepository, userRepo *repository.UserRepository, policy models.RetentionPolicy) *PrivacyService {
+	return &PrivacyService{
+		repo:     repo,
+		userRepo: userRepo,
+		policy:   policy,
+	}
+}
+
+// exportManifest describes the files of an export archive
+type exportManifest struct {
+	UserID          uuid.UUID              `json:"user_id"`
+	ExportedAt      time.Time              `json:"exported_at"`
+	Files           []string               `json:"files"`
+	RetentionPolicy models.RetentionPolicy `json:"retention_policy"`
+}
+
+// Export returns a zip archive of the user's data with one JSON file per kind
+// of record and a manifest.json listing them
+func (s *PrivacyService) Export(userID uuid.UUID) ([]byte, error) {
+	export, err := s.repo.Export(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"user.json", export.User},
+		{"kyc_profile.json", export.Profile},
+		{"accounts.json", export.Accounts},
+		{"balances.json", export.Balances},
+		{"transactions.json", export.Transactions},
+		{"beneficiaries.json", export.Beneficiaries},
+		{"standing_orders.json", export.StandingOrders},
+		{"payment_requests.json", export.PaymentRequests},
+		{"webhooks.json", export.Webhooks},
+	}
+	manifest := exportManifest{UserID: userID, ExportedAt: time.Now(), RetentionPolicy: s.policy}
+	for _, file := range files {
+		manifest.Files = append(manifest.Files, file.name)
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	write := func(name string, data interface{}) error {
+		w, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	}
+	if err := write("manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if err := write(file.name, file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Erase pseudonymizes a user's contact data and schedules the purge of the
+// data kept for the retention period. Users who are not closed yet are
+// closed first, so their balances must be empty. requestedBy is nil when the
+// retention job erases the user.
+func (s *PrivacyService) Erase(userID uuid.UUID, requestedBy *uuid.UUID, reason string) (*models.DataErasure, error) {
+	user, err := s.userRepo.GetByIDUnscoped(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	var closure *models.AuditLog
+	if user.Status != models.UserStatusClosed {
+		if err := user.Close(reason, now); err != nil {
+			return nil, err
+		}
+		closure = user.NewAuditLog(models.AuditUserClosed, requestedBy, user.StatusReason)
+	}
+	closedAt := now
+	if user.StatusChangedAt != nil {
+		closedAt = *user.StatusChangedAt
+	}
+
+	erasure := models.NewDataErasure(user.ID, requestedBy, closedAt, now, s.policy)
+	user.Pseudonymize()
+	if err := s.repo.Erase(user, erasure, closure, user.NewAuditLog(models.AuditUserErased, requestedBy, reason)); err != nil {
+		return nil, err
+	}
+	return erasure, nil
+}
+
+// GetErasure retrieves the erasure of a user
+func (s *PrivacyService) GetErasure(userID uuid.UUID) (*models.DataErasure, error) {
+	return s.repo.GetErasure(userID)
+}
+
+// ListErasures retrieves erasures, newest first
+func (s *PrivacyService) ListErasures(page, pageSize int) ([]models.DataErasure, int64, error) {
+	return s.repo.ListErasures(page, pageSize)
+}
+
+// PurgeDue erases users closed for longer than contact data is kept and
+// purges the data of erasures whose retention period ended, up to limit of
+// each. It matches the worker's poll job signature.
+func (s *PrivacyService) PurgeDue(ctx context.Context, limit int) (int, error) {
+	now := time.Now()
+	users, err := s.repo.ListUnerased(s.policy.Cutoff(models.DataContact, now), limit)
+	if err != nil {
+		return 0, err
+	}
+	erased := 0
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return erased, ctx.Err()
+		}
+		_, err := s.Erase(user.ID, nil, "Retention period of contact data ended")
+		if errors.Is(err, models.ErrAlreadyErased) {
+			continue
+		}
+		if err != nil {
+			return erased, err
+		}
+		erased++
+	}
+
+	purged, err := s.repo.PurgeDue(now, limit)
+	return erased + purged, err
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+// RecipientService finds the users transfers are sent to and checks they may
+// receive them
+type RecipientService struct {
+	userRepo    *repository.UserRepository
+	profileRepo *repository.ProfileRepository
+}
+
+func NewRecipientService(userRepo *repository.UserRepository, profileRepo *repository.ProfileRepository) *RecipientService {
+	return &RecipientService{userRepo: userRepo, profileRepo: profileRepo}
+}
+
+// RecipientQuery identifies a recipient by exactly one of its fields
+type RecipientQuery struct {
+	ID     *uuid.UUID
+	Email  string
+	Handle string
+}
+
+// Preview finds a recipient for the payer to confirm before paying, with a
+// masked display name. Unknown and deleted users are not found; users who
+// cannot receive transfers are reported as blocked.
+func (s *RecipientService) Preview(payerID uuid.UUID, query RecipientQuery) (*models.PayeePreview, error) {
+	user, profile, err := s.find(query)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == payerID {
+		return nil, models.ErrSelfTransfer
+	}
+	if err := models.CheckRecipient(user, profile); err != nil {
+		return nil, err
+	}
+	return models.NewPayeePreview(user, profile), nil
+}
+
+// Resolve returns the ID of the user a query identifies
+func (s *RecipientService) Resolve(query RecipientQuery) (uuid.UUID, error) {
+	user, _, err := s.find(query)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return user.ID, nil
+}
+
+// Check verifies a user exists and may receive transfers
+func (s *RecipientService) Check(id uuid.UUID) error {
+	user, profile, err := s.find(RecipientQuery{ID: &id})
+	if err != nil {
+		return err
+	}
+	return models.CheckRecipient(user, profile)
+}
+
+// find looks up the user of a query with their KYC profile, if any
+func (s *RecipientService) find(query RecipientQuery) (*models.User, *models.UserProfile, error) {
+	given := 0
+	if query.ID != nil {
+		given++
+	}
+	if query.Email != "" {
+		given++
+	}
+	if query.Handle != "" {
+		given++
+	}
+	if given != 1 {
+		return nil, nil, models.ErrRecipientQuery
+	}
+
+	var user *models.User
+	var err error
+	switch {
+	case query.ID != nil:
+		user, err = s.userRepo.GetByID(*query.ID)
+	case query.Email != "":
+		user, err = s.userRepo.GetByEmail(models.NormalizeEmail(query.Email))
+	default:
+		user, err = s.userRepo.GetByHandle(models.NormalizeHandle(query.Handle))
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, models.ErrRecipientNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	profile, err := s.profileRepo.GetByUserID(user.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, profile, nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/takadao/banking/internal/lock"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	reconciliationLockKey = "lock:reconciliation"
+	reconciliationLockTTL = 30 * time.Minute
+)
+
+// ErrReconciliationRunning is returned when another reconciliation holds the lock
+var ErrReconciliationRunning = errors.New("a reconciliation is already running")
+
+// ReconciliationService verifies the stored balances against the transaction
+// history
+type ReconciliationService struct {
+	repo  *repository.ReconciliationRepository
+	redis *redis.Client
+}
+
+func NewReconciliationService(repo *repository.ReconciliationRepository, redisClient *redis.Client) *ReconciliationService {
+	return &ReconciliationService{repo: repo, redis: redisClient}
+}
+
+// Run recomputes every balance from the transaction history, compares it with
+// the stored balance and the per currency totals, and records the run with its
+// discrepancies. A failure to read the ledger is recorded as a failed run.
+func (s *ReconciliationService) Run(ctx context.Context) (*models.ReconciliationRun, error) {
+	l, err := lock.Acquire(ctx, s.redis, reconciliationLockKey, reconciliationLockTTL)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return nil, ErrReconciliationRunning
+	}
+	defer func() {
+		if err := l.Release(context.Background()); err != nil {
+			log.Printf("failed to release reconciliation lock: %v", err)
+		}
+	}()
+	return s.run()
+}
+
+// RunDue runs the daily reconciliation unless one already started today
+// (UTC). It matches the worker's poll job signature and does nothing when
+// another instance holds the reconciliation lock.
+func (s *ReconciliationService) RunDue(ctx context.Context, limit int) (int, error) {
+	l, err := lock.Acquire(ctx, s.redis, reconciliationLockKey, reconciliationLockTTL)
+	if err != nil || l == nil {
+		return 0, err
+	}
+	defer func() {
+		if err := l.Release(context.Background()); err != nil {
+			log.Printf("failed to release reconciliation lock: %v", err)
+		}
+	}()
+
+	last, err := s.repo.LastRun()
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	if last != nil && !last.StartedAt.Before(truncateDay(time.Now())) {
+		return 0, nil
+	}
+
+	run, err := s.run()
+	if err != nil {
+		return 0, err
+	}
+	if run.Status != models.ReconciliationBalanced {
+		log.Printf("reconciliation run %s: %s, %d discrepancies", run.ID, run.Status, run.DiscrepancyCount)
+	}
+	return 1, nil
+}
+
+// Get retrieves a run with its discrepancies
+func (s *ReconciliationService) Get(id uuid.UUID) (*models.ReconciliationRun, error) {
+	return s.repo.GetRun(id)
+}
+
+// List retrieves runs, newest first
+func (s *ReconciliationService) List(page, pageSize int) ([]models.ReconciliationRun, int64, error) {
+	return s.repo.ListRuns(page, pageSize)
+}
+
+// run performs a reconciliation; the caller holds the lock
+func (s *ReconciliationService) run() (*models.ReconciliationRun, error) {
+	run := &models.ReconciliationRun{
+		Status:    models.ReconciliationRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.repo.CreateRun(run); err != nil {
+		return nil, err
+	}
+
+	stored, computed, err := s.repo.LedgerSnapshot()
+	if err != nil {
+		run.Fail(err, time.Now())
+	} else {
+		run.Complete(stored, computed, time.Now())
+	}
+	if err := s.repo.FinishRun(run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ServiceAccountService manages the service accounts of users, their API
+// keys and the OAuth2 client credentials grant
+type ServiceAccountService struct {
+	repo     *repository.ServiceAccountRepository
+	userRepo *repository.UserRepository
+}
+
+func NewServiceAccountService(repo *repository.ServiceAccountRepository, userRepo *repository.UserRepository) *ServiceAccountService {
+	return &ServiceAccountService{repo: repo, userRepo: userRepo}
+}
+
+// Create creates a service account acting as the user
+func (s *ServiceAccountService) Create(userID uuid.UUID, name string) (*models.ServiceAccount, error) {
+	account := &models.ServiceAccount{UserID: userID, Name: strings.TrimSpace(name)}
+	if err := s.repo.Create(account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// List retrieves the user's service accounts
+func (s *ServiceAccountService) List(userID uuid.UUID) ([]models.ServiceAccount, error) {
+	return s.repo.ListByUserID(userID)
+}
+
+// Delete deletes a service account of the user, revoking its keys
+func (s *ServiceAccountService) Delete(id, userID uuid.UUID) error {
+	account, err := s.repo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(account, time.Now())
+}
+
+// CreateKey creates an API key of a service account of the user, valid for
+// ttl or DefaultAPIKeyTTL when zero. It returns the key to hand to the
+// client, which cannot be retrieved again.
+func (s *ServiceAccountService) CreateKey(id, userID uuid.UUID, scopes []string, ttl time.Duration) (*models.APIKey, string, error) {
+	if ttl == 0 {
+		ttl = models.DefaultAPIKeyTTL
+	}
+	if ttl < 0 || ttl > models.MaxAPIKeyTTL {
+		return nil, "", models.ErrAPIKeyTTL
+	}
+	account, user, err := s.account(id, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	scopes, err = models.ValidateScopes(scopes, user.Role)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key, raw, err := models.NewAPIKey(account, scopes, ttl, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.repo.CreateKey(key, user.NewAuditLog(models.AuditAPIKeyCreated, &user.ID, keyComment(account, key))); err != nil {
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+// ListKeys retrieves the API keys of a service account of the user
+func (s *ServiceAccountService) ListKeys(id, userID uuid.UUID) ([]models.APIKey, error) {
+	if _, err := s.repo.GetByIDAndUserID(id, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListKeys(id)
+}
+
+// RotateKey replaces an API key with a new one of the same scopes and
+// lifetime; the old key keeps working for the rotation grace period
+func (s *ServiceAccountService) RotateKey(id, keyID, userID uuid.UUID) (*models.APIKey, string, error) {
+	account, user, err := s.account(id, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := s.repo.GetKey(keyID, account.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	key.ServiceAccount = account
+
+	replacement, raw, err := key.Rotate(time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+	entry := user.NewAuditLog(models.AuditAPIKeyRotated, &user.ID, keyComment(account, key)+" -> "+replacement.Prefix)
+	if err := s.repo.RotateKey(key, replacement, entry); err != nil {
+		return nil, "", err
+	}
+	return replacement, raw, nil
+}
+
+// RevokeKey revokes an API key of a service account of the user at once
+func (s *ServiceAccountService) RevokeKey(id, keyID, userID uuid.UUID) error {
+	account, user, err := s.account(id, userID)
+	if err != nil {
+		return err
+	}
+	key, err := s.repo.GetKey(keyID, account.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	revoked, err := s.repo.RevokeKey(key, user.NewAuditLog(models.AuditAPIKeyRevoked, &user.ID, keyComment(account, key)))
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return models.ErrAPIKeyRevoked
+	}
+	return nil
+}
+
+// AuthenticateClient verifies the credentials of the client credentials
+// grant: the client ID is the service account's and the secret one of its
+// API keys. It returns the key with the requested scopes, or all of its
+// scopes when none are requested.
+func (s *ServiceAccountService) AuthenticateClient(clientID, secret string, requested []string) (*models.APIKey, []string, error) {
+	prefix, ok := models.ParseAPIKeyPrefix(secret)
+	if !ok {
+		return nil, nil, models.ErrAPIKeyInvalid
+	}
+	key, err := s.repo.GetKeyByPrefix(prefix)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, models.ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if key.ServiceAccount == nil || key.ServiceAccount.ID.String() != clientID {
+		return nil, nil, models.ErrAPIKeyInvalid
+	}
+	if err := key.Verify(secret, time.Now()); err != nil {
+		return nil, nil, err
+	}
+	user, err := s.userRepo.GetByID(key.ServiceAccount.UserID)
+	if err != nil {
+		return nil, nil, models.ErrAPIKeyInvalid
+	}
+	if err := user.CheckLogin(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(requested) == 0 {
+		return key, key.Scopes, nil
+	}
+	for _, scope := range requested {
+		if !models.HasScope(key.Scopes, scope) {
+			return nil, nil, models.ErrInvalidScope
+		}
+	}
+	return key, requested, nil
+}
+
+// account retrieves a service account of the user with the user
+func (s *ServiceAccountService) account(id, userID uuid.UUID) (*models.ServiceAccount, *models.User, error) {
+	account, err := s.repo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return account, user, nil
+}
+
+// keyComment names a key in the audit trail by its account and prefix
+func keyComment(account *models.ServiceAccount, key *models.APIKey) string {
+	return account.Name + ": " + models.APIKeyPrefix + key.Prefix
+}
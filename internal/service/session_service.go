@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+// staleSessionAge is how long ended sessions are kept before they are deleted
+const staleSessionAge = 90 * 24 * time.Hour
+
+// SessionService manages the devices users are logged in on
+type SessionService struct {
+	repo *repository.SessionRepository
+}
+
+func NewSessionService(repo *repository.SessionRepository) *SessionService {
+	return &SessionService{repo: repo}
+}
+
+// Start creates a session for a user who just logged in
+func (s *SessionService) Start(user *models.User, deviceName, userAgent, ip string) (*models.Session, error) {
+	session := models.NewSession(user, deviceName, userAgent, ip, time.Now())
+	if err := s.repo.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// List retrieves the user's active sessions, marking the current one
+func (s *SessionService) List(userID, currentID uuid.UUID) ([]models.Session, error) {
+	sessions, err := s.repo.ListActive(userID, time.Now().Add(-models.SessionIdleTimeout))
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// Revoke logs the user out of one of their sessions
+func (s *SessionService) Revoke(id, userID uuid.UUID) error {
+	revoked, err := s.repo.Revoke(id, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeAll logs the user out everywhere, returning how many sessions ended
+func (s *SessionService) RevokeAll(userID uuid.UUID) (int, error) {
+	return s.repo.RevokeAll(userID, time.Now())
+}
+
+// RevokeOthers logs the user out everywhere but the current session,
+// returning how many sessions ended
+func (s *SessionService) RevokeOthers(userID, currentID uuid.UUID) (int, error) {
+	return s.repo.RevokeOthers(userID, currentID, time.Now())
+}
+
+// DeleteStale deletes up to limit sessions that ended more than 90 days ago
+func (s *SessionService) DeleteStale(ctx context.Context, limit int) (int, error) {
+	cutoff := time.Now().Add(-staleSessionAge)
+	return s.repo.DeleteStale(cutoff, cutoff.Add(-models.SessionIdleTimeout), limit)
+}
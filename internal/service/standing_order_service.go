@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/takadao/banking/internal/lock"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	// standingOrderLockKey makes sure only one scheduler instance executes
+	// standing orders at a time
+	standingOrderLockKey = "lock:standing-orders"
+	standingOrderLockTTL = 5 * time.Minute
+	// standingOrderRetryInterval is the wait before retrying an occurrence
+	// that failed for insufficient funds
+	standingOrderRetryInterval = 6 * time.Hour
+)
+
+type StandingOrderService struct {
+	repo            *repository.StandingOrderRepository
+	approvalService *ApprovalService
+	redis           *redis.Client
+}
+
+func NewStandingOrderService(repo *repository.StandingOrderRepository, approvalService *ApprovalService, redisClient *redis.Client) *StandingOrderService {
+	return &StandingOrderService{
+		repo:            repo,
+		approvalService: approvalService,
+		redis:           redisClient,
+	}
+}
+
+// StandingOrderUpdate holds the fields of a standing order a user may change;
+// nil fields are left untouched
+type StandingOrderUpdate struct {
+	Amount              *float64
+	Description         *string
+	EndDate             *time.Time
+	MaxOccurrences      *int
+	OnInsufficientFunds *models.InsufficientFundsPolicy
+	MaxRetries          *int
+	Paused              *bool
+}
+
+// Create schedules a new standing order
+func (s *StandingOrderService) Create(order *models.StandingOrder) error {
+	if order.OnInsufficientFunds == "" {
+		order.OnInsufficientFunds = models.InsufficientFundsRetry
+	}
+	if err := order.Validate(); err != nil {
+		return err
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if order.StartDate.Before(today) {
+		return errors.New("start date cannot be in the past")
+	}
+
+	order.Occurrence = 0
+	order.NextRunAt = order.StartDate
+	order.NextAttemptAt = order.StartDate
+	order.Status = models.StandingOrderActive
+	return s.repo.Create(order)
+}
+
+// Get retrieves one of the user's standing orders
+func (s *StandingOrderService) Get(id, userID uuid.UUID) (*models.StandingOrder, error) {
+	return s.repo.GetByIDAndUserID(id, userID)
+}
+
+// List retrieves the user's standing orders
+func (s *StandingOrderService) List(userID uuid.UUID) ([]models.StandingOrder, error) {
+	return s.repo.ListByUserID(userID)
+}
+
+// ListExecutions retrieves the execution history of one of the user's standing orders
+func (s *StandingOrderService) ListExecutions(id, userID uuid.UUID) ([]models.StandingOrderExecution, error) {
+	if _, err := s.repo.GetByIDAndUserID(id, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListExecutions(id)
+}
+
+// Update changes an active or paused standing order
+func (s *StandingOrderService) Update(id, userID uuid.UUID, update StandingOrderUpdate) (*models.StandingOrder, error) {
+	order, err := s.repo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.StandingOrderActive && order.Status != models.StandingOrderPaused {
+		return nil, models.ErrOrderNotEditable
+	}
+
+	if update.Amount != nil {
+		order.Amount = *update.Amount
+	}
+	if update.Description != nil {
+		order.Description = *update.Description
+	}
+	if update.EndDate != nil {
+		order.EndDate = update.EndDate
+	}
+	if update.MaxOccurrences != nil {
+		order.MaxOccurrences = *update.MaxOccurrences
+	}
+	if update.OnInsufficientFunds != nil {
+		order.OnInsufficientFunds = *update.OnInsufficientFunds
+	}
+	if update.MaxRetries != nil {
+		order.MaxRetries = *update.MaxRetries
+	}
+	status, resumed := order.Status, false
+	if update.Paused != nil {
+		if *update.Paused {
+			order.Status = models.StandingOrderPaused
+		} else if order.Status == models.StandingOrderPaused {
+			order.Resume(time.Now())
+			resumed = true
+		}
+	}
+	if err := order.Validate(); err != nil {
+		return nil, err
+	}
+
+	// The scheduler may have completed or failed the order since it was read
+	if err := s.repo.UpdateSettings(order, status, resumed); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrOrderNotEditable
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// Cancel stops a standing order for good
+func (s *StandingOrderService) Cancel(id, userID uuid.UUID) error {
+	order, err := s.repo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return err
+	}
+	if order.Status != models.StandingOrderActive && order.Status != models.StandingOrderPaused {
+		return models.ErrOrderNotEditable
+	}
+	status := order.Status
+	order.Status = models.StandingOrderCancelled
+	if err := s.repo.UpdateSettings(order, status, false); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrOrderNotEditable
+		}
+		return err
+	}
+	return nil
+}
+
+// RunDue executes up to limit due standing orders. It does nothing when
+// another instance holds the scheduler lock.
+func (s *StandingOrderService) RunDue(ctx context.Context, limit int) (int, error) {
+	l, err := lock.Acquire(ctx, s.redis, standingOrderLockKey, standingOrderLockTTL)
+	if err != nil || l == nil {
+		return 0, err
+	}
+	defer func() {
+		if err := l.Release(context.Background()); err != nil {
+			log.Printf("failed to release standing order lock: %v", err)
+		}
+	}()
+
+	orders, err := s.repo.ListDue(time.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+	for i := range orders {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+		if err := s.execute(&orders[i]); err != nil {
+			return i, err
+		}
+		// Keep the lock for as long as the batch runs, and stop if another
+		// instance may have taken it over
+		if err := l.Extend(ctx, standingOrderLockTTL); err != nil {
+			return i + 1, err
+		}
+	}
+	return len(orders), nil
+}
+
+// execute runs the current occurrence of a standing order. The execution
+// record is claimed before the transfer, so an occurrence is never paid twice
+// even if the order could not be advanced afterwards.
+func (s *StandingOrderService) execute(order *models.StandingOrder) error {
+	execution, err := s.repo.GetOrCreateExecution(order.ID, order.NextRunAt)
+	if err != nil {
+		return err
+	}
+
+	switch execution.Status {
+	case models.ExecutionSucceeded, models.ExecutionSkipped, models.ExecutionFailed:
+		// Settled by an earlier run that stopped before advancing the order
+		order.Advance()
+		return s.repo.UpdateSchedule(order)
+	case models.ExecutionProcessing:
+		// An earlier run stopped during the transfer; whether money moved is
+		// unknown, so leave it to an operator rather than risk paying twice
+		execution.Status = models.ExecutionFailed
+		execution.Error = "execution interrupted, verify the transfer manually"
+		if err := s.repo.UpdateExecution(execution); err != nil {
+			return err
+		}
+		order.Status = models.StandingOrderFailed
+		order.LastError = execution.Error
+		return s.repo.UpdateSchedule(order)
+	}
+
+	execution.Status = models.ExecutionProcessing
+	execution.Attempts++
+	if err := s.repo.UpdateExecution(execution); err != nil {
+		return err
+	}
+
+	description := order.Description
+	if description == "" {
+		description = "Standing order"
+	}
+	tx, transferErr := s.approvalService.Transfer(order.UserID, order.RecipientID, order.Amount, order.Currency, description)
+
+	switch {
+	case transferErr == nil:
+		execution.Status = models.ExecutionSucceeded
+		execution.TransactionID = &tx.ID
+		execution.Error = ""
+		if err := s.repo.UpdateExecution(execution); err != nil {
+			return err
+		}
+		order.Advance()
+
+	case errors.Is(transferErr, models.ErrInsufficientFunds):
+		execution.Error = transferErr.Error()
+		if order.OnInsufficientFunds == models.InsufficientFundsRetry && execution.Attempts <= order.MaxRetries {
+			execution.Status = models.ExecutionPending
+			if err := s.repo.UpdateExecution(execution); err != nil {
+				return err
+			}
+			order.LastError = transferErr.Error()
+			order.NextAttemptAt = time.Now().Add(standingOrderRetryInterval)
+			break
+		}
+		execution.Status = models.ExecutionSkipped
+		if err := s.repo.UpdateExecution(execution); err != nil {
+			return err
+		}
+		order.Advance()
+		order.LastError = "occurrence skipped: " + transferErr.Error()
+
+	default:
+		execution.Status = models.ExecutionFailed
+		execution.Error = transferErr.Error()
+		if err := s.repo.UpdateExecution(execution); err != nil {
+			return err
+		}
+		order.Status = models.StandingOrderFailed
+		order.LastError = transferErr.Error()
+	}
+
+	return s.repo.UpdateSchedule(order)
+}
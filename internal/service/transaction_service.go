@@ -1,7 +1,6 @@
 package service
 
 import (
-	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,21 +9,135 @@ import (
 )
 
 type TransactionService struct {
-	repo *repository.TransactionRepository
+	repo             *repository.TransactionRepository
+	userRepo         *repository.UserRepository
+	kycService       *KYCService
+	pricingService   *PricingService
+	accountService   *AccountService
+	recipientService *RecipientService
 }
 
-func NewTransactionService(repo *repository.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+func NewTransactionService(repo *repository.TransactionRepository, userRepo *repository.UserRepository, kycService *KYCService, pricingService *PricingService, accountService *AccountService, recipientService *RecipientService) *TransactionService {
+	return &TransactionService{
+		repo:             repo,
+		userRepo:         userRepo,
+		kycService:       kycService,
+		pricingService:   pricingService,
+		accountService:   accountService,
+		recipientService: recipientService,
+	}
+}
+
+// Initiate creates a transaction on behalf of the initiating user. When it
+// names an account of someone else, the initiator must be a member allowed
+// to spend from or pay into it, and the transaction is booked for the owner.
+func (s *TransactionService) Initiate(initiatorID uuid.UUID, transaction *models.Transaction) error {
+	if err := s.prepare(initiatorID, transaction); err != nil {
+		return err
+	}
+	return s.repo.Create(transaction)
+}
+
+// prepare checks and prices a transaction the initiating user makes, setting
+// whom it is booked for, without booking it
+func (s *TransactionService) prepare(initiatorID uuid.UUID, transaction *models.Transaction) error {
+	transaction.UserID = initiatorID
+	transaction.InitiatedBy = &initiatorID
+
+	if transaction.Debits() && transaction.SourceAccountID != nil {
+		account, err := s.accountService.AuthorizeDebit(*transaction.SourceAccountID, initiatorID)
+		if err != nil {
+			return err
+		}
+		transaction.UserID = account.UserID
+	}
+	if transaction.Type == models.TransactionTypeDeposit && transaction.DestinationAccountID != nil {
+		account, err := s.accountService.AuthorizeCredit(*transaction.DestinationAccountID, initiatorID)
+		if err != nil {
+			return err
+		}
+		transaction.UserID = account.UserID
+	}
+	return s.Check(transaction)
 }
 
 // Create creates a new transaction
 func (s *TransactionService) Create(transaction *models.Transaction) error {
-	if err := transaction.Validate(); err != nil {
+	if err := s.Check(transaction); err != nil {
 		return err
 	}
 	return s.repo.Create(transaction)
 }
 
+// Check verifies the transaction may be booked and prices its fee, without
+// booking it
+func (s *TransactionService) Check(transaction *models.Transaction) error {
+	if err := transaction.Validate(); err != nil {
+		return err
+	}
+	// Frozen, dormant and closed users are held back, as are members acting
+	// for them, and unverified users from transfers
+	if err := s.checkUser(transaction.UserID, transaction); err != nil {
+		return err
+	}
+	if transaction.InitiatedBy != nil && *transaction.InitiatedBy != transaction.UserID {
+		if err := s.checkUser(*transaction.InitiatedBy, transaction); err != nil {
+			return err
+		}
+	}
+	// Transfers only go to existing users who may receive them
+	if transaction.Type == models.TransactionTypeTransfer {
+		if err := s.recipientService.Check(*transaction.RecipientID); err != nil {
+			return err
+		}
+	}
+	// The initiating user's KYC level gates what they may do
+	if err := s.kycService.CheckTransaction(transaction.UserID, transaction.Type, transaction.Amount); err != nil {
+		return err
+	}
+	// The fee is priced up front and booked with the transaction
+	return s.pricingService.Price(transaction)
+}
+
+// PayOutAndClose withdraws the whole balance of each account to an external
+// account without a fee and closes the user, all in one database
+// transaction. The payouts of a closed user that fail are refunded to them
+// and reopen them frozen.
+func (s *TransactionService) PayOutAndClose(user *models.User, entry *models.AuditLog, accounts []models.Account, destination models.PayoutDestination, description string) error {
+	var payouts []*models.Transaction
+	for i := range accounts {
+		account := &accounts[i]
+		if account.Balance == nil || account.Balance.Amount == 0 {
+			continue
+		}
+		transaction := &models.Transaction{
+			UserID:          account.UserID,
+			Type:            models.TransactionTypeWithdraw,
+			Amount:          account.Balance.Amount,
+			Currency:        account.Currency,
+			Description:     description,
+			SourceAccountID: &account.ID,
+			Payout:          models.NewPayout(destination),
+		}
+		if err := transaction.Validate(); err != nil {
+			return err
+		}
+		payouts = append(payouts, transaction)
+	}
+	return s.repo.PayOutAndClose(payouts, user, entry)
+}
+
+// Reverse undoes a transaction and refunds its fees on behalf of the given
+// admin, recording a transaction.reversed event
+func (s *TransactionService) Reverse(id uuid.UUID, reason string, adminID uuid.UUID) (*models.Transaction, error) {
+	return s.repo.Reverse(id, "Reversal of "+id.String()+": "+reason, adminID)
+}
+
+// Quote returns the fee the user would pay for a transaction
+func (s *TransactionService) Quote(userID uuid.UUID, txType models.TransactionType, amount float64, currency string) (*models.FeeQuote, error) {
+	return s.pricingService.Quote(userID, txType, amount, currency)
+}
+
 // ListByUserID retrieves all transactions for a specific user
 func (s *TransactionService) ListByUserID(userID uuid.UUID) ([]models.Transaction, error) {
 	return s.repo.ListByUserID(userID)
@@ -70,9 +183,9 @@ func (s *TransactionService) Withdraw(userID uuid.UUID, amount float64, currency
 }
 
 // Transfer creates a transfer transaction
-func (s *TransactionService) Transfer(fromUserID, toUserID uuid.UUID, amount float64, currency, description string) error {
+func (s *TransactionService) Transfer(fromUserID, toUserID uuid.UUID, amount float64, currency, description string) (*models.Transaction, error) {
 	if fromUserID == toUserID {
-		return errors.New("cannot transfer to the same account")
+		return nil, models.ErrSelfTransfer
 	}
 
 	transaction := &models.Transaction{
@@ -83,7 +196,10 @@ func (s *TransactionService) Transfer(fromUserID, toUserID uuid.UUID, amount flo
 		RecipientID: &toUserID,
 		Description: description,
 	}
-	return s.Create(transaction)
+	if err := s.Create(transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
 }
 
 func (s *TransactionService) GetByUserID(userID uuid.UUID, page, pageSize int) ([]models.Transaction, int64, error) {
@@ -97,3 +213,19 @@ func (s *TransactionService) GetAll(page, pageSize int) ([]models.Transaction, i
 func (s *TransactionService) GetBalanceAtTime(userID uuid.UUID, currency string, atTime time.Time) (float64, error) {
 	return s.repo.GetBalanceAtTime(userID, currency, atTime)
 }
+
+// checkUser verifies the lifecycle status of a user lets them take part in
+// the transaction. Only users who verified their email may send transfers.
+func (s *TransactionService) checkUser(id uuid.UUID, transaction *models.Transaction) error {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := user.CheckTransaction(transaction); err != nil {
+		return err
+	}
+	if transaction.Type == models.TransactionTypeTransfer && !user.EmailVerified() {
+		return models.ErrEmailNotVerified
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+)
+
+// UserLifecycleService moves users between the active, frozen, dormant and
+// closed states, auditing every change
+type UserLifecycleService struct {
+	userRepo           *repository.UserRepository
+	accountRepo        *repository.AccountRepository
+	transactionService *TransactionService
+}
+
+func NewUserLifecycleService(userRepo *repository.UserRepository, accountRepo *repository.AccountRepository, transactionService *TransactionService) *UserLifecycleService {
+	return &UserLifecycleService{
+		userRepo:           userRepo,
+		accountRepo:        accountRepo,
+		transactionService: transactionService,
+	}
+}
+
+// Freeze blocks a user's outgoing or all transactions on behalf of an admin
+func (s *UserLifecycleService) Freeze(id, adminID uuid.UUID, scope models.FreezeScope, reason string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := user.Freeze(scope, reason, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetStatus(user, user.NewAuditLog(models.AuditUserFrozen, &adminID, string(scope)+": "+user.StatusReason)); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Unfreeze lifts a freeze on behalf of an admin
+func (s *UserLifecycleService) Unfreeze(id, adminID uuid.UUID, reason string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := user.Unfreeze(reason, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetStatus(user, user.NewAuditLog(models.AuditUserUnfrozen, &adminID, user.StatusReason)); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Close closes the user's account at their request. Their balances must be
+// empty, or a destination is given and what remains on each of their
+// accounts is paid out to it without a fee, in the same database transaction
+// as the closure. Frozen users cannot close.
+func (s *UserLifecycleService) Close(id uuid.UUID, reason string, destination *models.PayoutDestination) (*models.User, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := user.Close(reason, time.Now()); err != nil {
+		return nil, err
+	}
+
+	entry := user.NewAuditLog(models.AuditUserClosed, &id, user.StatusReason)
+	if destination == nil {
+		err = s.userRepo.Close(user, entry)
+	} else {
+		err = s.payOutAndClose(user, entry, *destination)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// MarkDormant marks up to limit customers dormant who were inactive for the
+// dormancy period. It matches the worker's poll job signature.
+func (s *UserLifecycleService) MarkDormant(ctx context.Context, limit int) (int, error) {
+	now := time.Now()
+	return s.userRepo.MarkDormant(now.Add(-models.DormancyPeriod), now, limit)
+}
+
+// payOutAndClose withdraws the balance of each account the user owns to the
+// destination and closes the user. Reserved funds cannot be paid out, so
+// held balances fail.
+func (s *UserLifecycleService) payOutAndClose(user *models.User, entry *models.AuditLog, destination models.PayoutDestination) error {
+	destination.Normalize()
+	if err := destination.Validate(); err != nil {
+		return err
+	}
+	accounts, err := s.accountRepo.ListByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	var owned []models.Account
+	for _, account := range accounts {
+		if account.UserID != user.ID || account.Balance == nil {
+			continue
+		}
+		if account.Balance.Held != 0 || account.Balance.Amount < 0 {
+			return models.ErrUserNotEmpty
+		}
+		owned = append(owned, account)
+	}
+	return s.transactionService.PayOutAndClose(user, entry, owned, destination, "Account closure")
+}
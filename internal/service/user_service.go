@@ -3,18 +3,22 @@ package service
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/takadao/banking/internal/models"
 	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
 )
 
 type UserService struct {
-	repo *repository.UserRepository
+	repo            *repository.UserRepository
+	tokenService    *UserTokenService
+	passwordService *PasswordService
 }
 
-func NewUserService(repo *repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo *repository.UserRepository, tokenService *UserTokenService, passwordService *PasswordService) *UserService {
+	return &UserService{repo: repo, tokenService: tokenService, passwordService: passwordService}
 }
 
 // Register creates a new user
@@ -27,16 +31,46 @@ func (s *UserService) Register(email, password, role string) (*models.User, erro
 		Email:    email,
 		Password: password,
 		Role:     role,
+		Status:   models.UserStatusActive,
+	}
+	if err := s.passwordService.Check(&models.User{Email: email}, password); err != nil {
+		return nil, err
 	}
 	if err := user.HashPassword(); err != nil {
 		return nil, err
 	}
-	if err := s.repo.Create(user); err != nil {
+	if err := s.create(user); err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
+// CreateAdmin creates the admin of an approved change request with the ID
+// and password hash it was requested with
+func (s *UserService) CreateAdmin(id uuid.UUID, email, hashedPassword string) (*models.User, error) {
+	user := &models.User{
+		ID:       id,
+		Email:    email,
+		Password: hashedPassword,
+		Role:     "admin",
+		Status:   models.UserStatusActive,
+	}
+	if err := s.create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// create saves a new user and mails them the link to verify their email
+func (s *UserService) create(user *models.User) error {
+	if err := s.repo.Create(user); err != nil {
+		return err
+	}
+	// A failed mail is logged; the user can ask for the link again
+	_ = s.tokenService.SendEmailVerification(user)
+	return nil
+}
+
 // Authenticate verifies user credentials
 func (s *UserService) Authenticate(email, password string) (*models.User, error) {
 	user, err := s.repo.GetByEmail(email)
@@ -46,6 +80,20 @@ func (s *UserService) Authenticate(email, password string) (*models.User, error)
 	if err := user.CheckPassword(password); err != nil {
 		return nil, errors.New("invalid credentials")
 	}
+	if err := user.CheckLogin(); err != nil {
+		return nil, err
+	}
+
+	// Logging in is activity, and brings dormant users back
+	now := time.Now()
+	user.LastLoginAt = &now
+	var reactivation *models.AuditLog
+	if user.Reactivate(now) {
+		reactivation = user.NewAuditLog(models.AuditUserReactivated, &user.ID, user.StatusReason)
+	}
+	if err := s.repo.RecordLogin(user, reactivation); err != nil {
+		return nil, err
+	}
 	return user, nil
 }
 
@@ -67,8 +115,34 @@ func (s *UserService) Update(user *models.User) (*models.User, error) {
 		user.Role = existingUser.Role
 	}
 
-	// If password is being updated, hash it
+	// The handle is changed through SetHandle only, the lifecycle status
+	// through UserLifecycleService
+	user.Handle = existingUser.Handle
+	user.Status = existingUser.Status
+	user.FreezeScope = existingUser.FreezeScope
+	user.StatusReason = existingUser.StatusReason
+	user.StatusChangedAt = existingUser.StatusChangedAt
+	user.LastLoginAt = existingUser.LastLoginAt
+	user.CreatedAt = existingUser.CreatedAt
+
+	// A new email must be verified again
+	emailChanged := user.Email != "" && user.Email != existingUser.Email
+	if user.Email == "" {
+		user.Email = existingUser.Email
+	}
+	user.EmailVerifiedAt = existingUser.EmailVerifiedAt
+	if emailChanged {
+		user.EmailVerifiedAt = nil
+	}
+
+	// If password is being updated, check it against the policy and the
+	// user's previous passwords and hash it
 	if user.Password != "" {
+		current := *existingUser
+		current.Email = user.Email
+		if err := s.passwordService.Check(&current, user.Password); err != nil {
+			return nil, err
+		}
 		if err := user.HashPassword(); err != nil {
 			return nil, err
 		}
@@ -80,16 +154,68 @@ func (s *UserService) Update(user *models.User) (*models.User, error) {
 	if err := s.repo.Update(user); err != nil {
 		return nil, err
 	}
+	if emailChanged {
+		_ = s.tokenService.SendEmailVerification(user)
+	}
 
 	return user, nil
 }
 
+// SetHandle sets the handle others can pay the user by, or clears it when
+// empty
+func (s *UserService) SetHandle(id uuid.UUID, handle string) (*models.User, error) {
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	handle = models.NormalizeHandle(handle)
+	if handle == "" {
+		user.Handle = nil
+	} else {
+		if !models.ValidHandle(handle) {
+			return nil, models.ErrInvalidHandle
+		}
+		owner, err := s.repo.GetByHandle(handle)
+		if err == nil && owner.ID != id {
+			return nil, models.ErrHandleTaken
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		user.Handle = &handle
+	}
+
+	if err := s.repo.SetHandle(id, user.Handle); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 // ListAll retrieves all users
 func (s *UserService) ListAll() ([]models.User, error) {
 	return s.repo.ListAll()
 }
 
-// Delete removes a user
-func (s *UserService) Delete(id uuid.UUID) error {
-	return s.repo.Delete(id)
+// Delete closes a user whose balances are empty and removes them
+func (s *UserService) Delete(id, adminID uuid.UUID, reason string) error {
+	return deleteUser(s.repo, id, adminID, reason)
+}
+
+// deleteUser closes a user, unless they are already closed, and removes them.
+// Closing fails while any of their balances holds money.
+func deleteUser(repo *repository.UserRepository, id, adminID uuid.UUID, reason string) error {
+	user, err := repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if user.Status != models.UserStatusClosed {
+		if err := user.Close(reason, time.Now()); err != nil {
+			return err
+		}
+		if err := repo.Close(user, user.NewAuditLog(models.AuditUserClosed, &adminID, reason)); err != nil {
+			return err
+		}
+	}
+	return repo.Delete(id)
 }
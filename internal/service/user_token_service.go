@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/mail"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+// mailTimeout bounds how long sending a token mail may hold up a request
+const mailTimeout = 10 * time.Second
+
+// ErrMailFailed is returned when a token was issued but could not be mailed;
+// the cause is logged
+var ErrMailFailed = errors.New("could not send email, please try again later")
+
+// UserTokenService mails users signed single-use tokens to reset their
+// password or verify their email, and redeems them
+type UserTokenService struct {
+	repo            *repository.UserTokenRepository
+	userRepo        *repository.UserRepository
+	passwordService *PasswordService
+	sessionService  *SessionService
+	mailer          mail.Mailer
+	key             []byte
+	baseURL         string
+}
+
+// NewUserTokenService creates the service. Tokens are signed with key, and
+// the mailed links point to baseURL.
+func NewUserTokenService(repo *repository.UserTokenRepository, userRepo *repository.UserRepository, passwordService *PasswordService, sessionService *SessionService, mailer mail.Mailer, key []byte, baseURL string) *UserTokenService {
+	return &UserTokenService{
+		repo:            repo,
+		userRepo:        userRepo,
+		passwordService: passwordService,
+		sessionService:  sessionService,
+		mailer:          mailer,
+		key:             key,
+		baseURL:         strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// SendEmailVerification mails the user a link to verify their current email
+func (s *UserTokenService) SendEmailVerification(user *models.User) error {
+	if user.EmailVerified() {
+		return models.ErrEmailAlreadyVerified
+	}
+	return s.send(user, models.TokenEmailVerification, "Verify your email address",
+		"Please confirm this is your email address by opening the link below within %s:\n\n%s/verify-email?token=%s\n\n"+
+			"You need a verified email address to make transfers.\n")
+}
+
+// ResendEmailVerification mails the user a new verification link, which
+// replaces the links sent before
+func (s *UserTokenService) ResendEmailVerification(userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	return s.SendEmailVerification(user)
+}
+
+// ForgotPassword mails a password reset link if a user who may log in has
+// the email. It does not tell whether one has, so it cannot be used to find
+// out who is a customer: the user is looked up and mailed in the background,
+// so neither the answer nor how long it takes depends on it.
+func (s *UserTokenService) ForgotPassword(email string) {
+	go func() {
+		// A failed mail is logged already
+		if err := s.mailPasswordReset(strings.TrimSpace(email)); err != nil && !errors.Is(err, ErrMailFailed) {
+			log.Printf("Failed to request a password reset: %v", err)
+		}
+	}()
+}
+
+// mailPasswordReset mails a password reset link to the user with the email,
+// if any may log in
+func (s *UserTokenService) mailPasswordReset(email string) error {
+	user, err := s.userRepo.GetByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.CheckLogin() != nil {
+		return nil
+	}
+	return s.send(user, models.TokenPasswordReset, "Reset your password",
+		"Someone asked to reset the password of your account. If it was you, open the link below within %s to choose a new password:\n\n%s/reset-password?token=%s\n\n"+
+			"If it was not you, ignore this email; your password stays the same.\n")
+}
+
+// ResetPassword redeems a password reset token and sets the new password,
+// which must satisfy the password policy, then logs the user out everywhere.
+// Receiving the token proves the user controls the email, so it is
+// verified too.
+func (s *UserTokenService) ResetPassword(token, password string) error {
+	stored, user, now, err := s.check(models.TokenPasswordReset, token)
+	if err != nil {
+		return err
+	}
+	if err := s.passwordService.Check(user, password); err != nil {
+		return err
+	}
+	user.Password = password
+	if err := user.HashPassword(); err != nil {
+		return err
+	}
+	if !user.EmailVerified() {
+		user.EmailVerifiedAt = &now
+	}
+	if err := s.repo.Redeem(stored, user, user.NewAuditLog(models.AuditPasswordReset, &user.ID, ""), "password", "email_verified_at"); err != nil {
+		return err
+	}
+	_, err = s.sessionService.RevokeAll(user.ID)
+	return err
+}
+
+// VerifyEmail redeems an email verification token
+func (s *UserTokenService) VerifyEmail(token string) (*models.User, error) {
+	stored, user, now, err := s.check(models.TokenEmailVerification, token)
+	if err != nil {
+		return nil, err
+	}
+	if user.EmailVerified() {
+		return nil, models.ErrEmailAlreadyVerified
+	}
+	user.EmailVerifiedAt = &now
+	if err := s.repo.Redeem(stored, user, user.NewAuditLog(models.AuditEmailVerified, &user.ID, user.Email), "email_verified_at"); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// send issues a token of the purpose and mails it with the body, which is
+// formatted with the validity, base URL and token
+func (s *UserTokenService) send(user *models.User, purpose models.TokenPurpose, subject, body string) error {
+	token := models.NewUserToken(user, purpose, time.Now())
+	if err := s.repo.Issue(token); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+	defer cancel()
+	err := s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf(body, validity(purpose.TTL()), s.baseURL, token.Sign(s.key)),
+	})
+	if err != nil {
+		log.Printf("Failed to mail %s token to user %s: %v", purpose, user.ID, err)
+		return ErrMailFailed
+	}
+	return nil
+}
+
+// validity renders how long a token is valid for in whole hours
+func validity(ttl time.Duration) string {
+	if hours := int(ttl.Hours()); hours != 1 {
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return "1 hour"
+}
+
+// check verifies a token's signature and expiry and that it is unused and
+// was mailed to the user's current email, returning it with the user
+func (s *UserTokenService) check(purpose models.TokenPurpose, token string) (*models.UserToken, *models.User, time.Time, error) {
+	now := time.Now()
+	id, err := models.ParseUserToken(s.key, purpose, token, now)
+	if err != nil {
+		return nil, nil, now, err
+	}
+	stored, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, now, models.ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, nil, now, err
+	}
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, now, models.ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, nil, now, err
+	}
+	if stored.Purpose != purpose {
+		return nil, nil, now, models.ErrTokenInvalid
+	}
+	if err := stored.Check(user, now); err != nil {
+		return nil, nil, now, err
+	}
+	if err := user.CheckLogin(); err != nil {
+		return nil, nil, now, err
+	}
+	stored.UsedAt = &now
+	return stored, user, now, nil
+}
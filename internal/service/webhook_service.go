@@ -0,0 +1,379 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/takadao/banking/internal/events"
+	"github.com/takadao/banking/internal/models"
+	"github.com/takadao/banking/internal/repository"
+)
+
+const (
+	// webhookDeliveryLease keeps a claimed delivery away from other workers
+	// while it is being sent
+	webhookDeliveryLease   = 2 * time.Minute
+	webhookRequestTimeout  = 10 * time.Second
+	webhookMaxResponseBody = 1024
+)
+
+type WebhookService struct {
+	repo   *repository.WebhookRepository
+	client *http.Client
+}
+
+func NewWebhookService(repo *repository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		repo:   repo,
+		client: newWebhookClient(),
+	}
+}
+
+// newWebhookClient returns the client deliveries are sent with. Subscribers
+// choose the URL, so it only connects to public addresses, checked after the
+// host is resolved so DNS cannot point it elsewhere, and does not follow
+// redirects.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookRequestTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !isPublicAddr(addr) {
+				return fmt.Errorf("%w: %s", models.ErrWebhookAddressBlocked, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: webhookRequestTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookRequestTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// nonPublicPrefixes are ranges webhooks may not be sent to besides the
+// loopback, private, link-local (cloud metadata) and multicast ones
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // this network
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64 of any IPv4 address
+}
+
+// isPublicAddr reports whether webhooks may be sent to addr
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWebhookURL verifies a subscriber's URL is an absolute http or https
+// URL that does not name a host on a non-public network. Host names are
+// checked again once resolved, whenever a delivery is sent.
+func checkWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return models.ErrInvalidWebhookURL
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return models.ErrWebhookAddressBlocked
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !isPublicAddr(addr) {
+		return models.ErrWebhookAddressBlocked
+	}
+	return nil
+}
+
+// webhookEvent is the JSON envelope sent to subscribers
+type webhookEvent struct {
+	ID        uuid.UUID   `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// webhookEventTypes maps domain events to the webhook event delivered for them
+var webhookEventTypes = map[string]bool{
+	models.EventTransactionCreated:  true,
+	models.EventTransactionReversed: true,
+	models.EventUserUpdated:         true,
+}
+
+// SignWebhookPayload computes the hex HMAC-SHA256 of "timestamp.body".
+// Receivers recompute it from the X-Webhook-Timestamp header and raw body.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CreateSubscription registers a webhook endpoint for the user. The returned
+// subscription carries the signing secret, which is only revealed here.
+func (s *WebhookService) CreateSubscription(userID uuid.UUID, rawURL string, events []string, lowBalanceThreshold float64, description string) (*models.WebhookSubscription, error) {
+	if err := checkWebhookURL(rawURL); err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if !isWebhookEventType(event) {
+			return nil, fmt.Errorf("%w: %s", models.ErrInvalidWebhookEvent, event)
+		}
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &models.WebhookSubscription{
+		UserID:              userID,
+		URL:                 rawURL,
+		Secret:              secret,
+		Events:              events,
+		LowBalanceThreshold: lowBalanceThreshold,
+		Description:         description,
+		Active:              true,
+	}
+	if err := s.repo.CreateSubscription(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// ListSubscriptions retrieves the user's subscriptions
+func (s *WebhookService) ListSubscriptions(userID uuid.UUID) ([]models.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(userID)
+}
+
+// DeleteSubscription removes one of the user's subscriptions
+func (s *WebhookService) DeleteSubscription(id, userID uuid.UUID) error {
+	return s.repo.DeleteSubscription(id, userID)
+}
+
+// ListDeliveries retrieves deliveries for one of the user's subscriptions
+func (s *WebhookService) ListDeliveries(subscriptionID, userID uuid.UUID, status string, page, pageSize int) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.repo.GetSubscription(subscriptionID, userID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListDeliveries(subscriptionID, status, page, pageSize)
+}
+
+// ReplayDelivery queues a fresh delivery of the same event payload
+func (s *WebhookService) ReplayDelivery(deliveryID, userID uuid.UUID) (*models.WebhookDelivery, error) {
+	original, err := s.repo.GetDelivery(deliveryID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	replay := models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		ReplayOf:       &original.ID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}
+	if err := s.repo.CreateDeliveries([]models.WebhookDelivery{replay}); err != nil {
+		return nil, err
+	}
+	return &replay, nil
+}
+
+// HandleEvent fans a domain event from the event stream out to matching
+// subscriptions. It is safe to call more than once for the same event.
+func (s *WebhookService) HandleEvent(ctx context.Context, event events.Event) error {
+	switch {
+	case event.Type == models.EventBalanceUpdated:
+		var balance models.BalanceEventData
+		if err := event.Decode(&balance); err != nil {
+			return err
+		}
+		return s.publishLowBalance(event, balance)
+
+	case event.AggregateType == models.AggregateTransaction && webhookEventTypes[event.Type]:
+		var tx models.TransactionEventData
+		if err := event.Decode(&tx); err != nil {
+			return err
+		}
+		for _, userID := range tx.Parties() {
+			if err := s.publish(userID, event.ID, event.Type, event.OccurredAt, event.Data); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case event.Type == models.EventUserUpdated:
+		return s.publish(event.AggregateID, event.ID, event.Type, event.OccurredAt, event.Data)
+	}
+	return nil
+}
+
+// publish queues a delivery of the event to each of the user's matching subscriptions
+func (s *WebhookService) publish(userID, eventID uuid.UUID, eventType string, occurredAt time.Time, data interface{}) error {
+	subs, err := s.repo.ListActiveSubscriptions(userID, eventType)
+	if err != nil {
+		return err
+	}
+	return s.enqueue(subs, eventID, eventType, occurredAt, data)
+}
+
+// publishLowBalance notifies subscriptions whose threshold the available
+// balance fell below
+func (s *WebhookService) publishLowBalance(event events.Event, balance models.BalanceEventData) error {
+	subs, err := s.repo.ListActiveSubscriptions(balance.UserID, models.EventBalanceLow)
+	if err != nil {
+		return err
+	}
+
+	var below []models.WebhookSubscription
+	for _, sub := range subs {
+		if sub.LowBalanceThreshold > 0 && balance.Available < sub.LowBalanceThreshold {
+			below = append(below, sub)
+		}
+	}
+	return s.enqueue(below, event.ID, models.EventBalanceLow, event.OccurredAt, balance)
+}
+
+func (s *WebhookService) enqueue(subs []models.WebhookSubscription, eventID uuid.UUID, eventType string, occurredAt time.Time, data interface{}) error {
+	if len(subs) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(webhookEvent{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: occurredAt.UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+	return s.repo.CreateDeliveries(deliveries)
+}
+
+// DeliverDue sends up to limit deliveries that are due and returns how many
+// were attempted
+func (s *WebhookService) DeliverDue(ctx context.Context, limit int) (int, error) {
+	deliveries, err := s.repo.ClaimDueDeliveries(time.Now(), limit, webhookDeliveryLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+		delivery := &deliveries[i]
+		s.deliver(ctx, delivery)
+		if err := s.repo.UpdateDelivery(delivery); err != nil {
+			return i, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+func (s *WebhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	now := time.Now()
+
+	sub, err := s.repo.GetSubscriptionByID(delivery.SubscriptionID)
+	if err != nil || !sub.Active {
+		delivery.MarkDead(now, "subscription no longer active")
+		return
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := now.Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.RecordFailure(now, 0, err.Error())
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TakaDAO-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", delivery.ID.String())
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", "v1="+SignWebhookPayload(sub.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		delivery.RecordFailure(now, 0, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		delivery.RecordSuccess(now, resp.StatusCode)
+		return
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxResponseBody))
+	delivery.RecordFailure(now, resp.StatusCode, fmt.Sprintf("unexpected status %d: %s", resp.StatusCode, snippet))
+}
+
+func isWebhookEventType(eventType string) bool {
+	for _, e := range models.WebhookEventTypes {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/takadao/banking/internal/models"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"type":"transaction.created"}`)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte(`1700000000.{"type":"transaction.created"}`))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, expected, SignWebhookPayload("whsec_test", 1700000000, body))
+	assert.NotEqual(t, expected, SignWebhookPayload("whsec_other", 1700000000, body))
+	assert.NotEqual(t, expected, SignWebhookPayload("whsec_test", 1700000001, body))
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::248": true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::ffff:127.0.0.1":     false,
+		"64:ff9b::a9fe:a9fe":   false,
+		"224.0.0.1":            false,
+		"255.255.255.255":      false,
+	} {
+		assert.Equal(t, public, isPublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	assert.NoError(t, checkWebhookURL("https://hooks.example.com/banking"))
+	assert.NoError(t, checkWebhookURL("http://93.184.216.34:8080/hook"))
+	assert.Equal(t, models.ErrInvalidWebhookURL, checkWebhookURL("ftp://example.com"))
+	assert.Equal(t, models.ErrInvalidWebhookURL, checkWebhookURL("/relative"))
+	assert.Equal(t, models.ErrWebhookAddressBlocked, checkWebhookURL("http://localhost:8080/hook"))
+	assert.Equal(t, models.ErrWebhookAddressBlocked, checkWebhookURL("http://127.0.0.1/hook"))
+	assert.Equal(t, models.ErrWebhookAddressBlocked, checkWebhookURL("http://[::1]/hook"))
+	assert.Equal(t, models.ErrWebhookAddressBlocked, checkWebhookURL("http://169.254.169.254/latest/meta-data"))
+}
+
+func TestWebhookClientRefusesLocalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	_, err := newWebhookClient().Post(server.URL, "application/json", nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrWebhookAddressBlocked)
+}
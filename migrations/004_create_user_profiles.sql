@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS user_profiles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID UNIQUE NOT NULL REFERENCES users(id),
+    legal_name VARCHAR(255) NOT NULL,
+    date_of_birth DATE,
+    phone VARCHAR(32),
+    address_line1 VARCHAR(255),
+    address_line2 VARCHAR(255),
+    city VARCHAR(100),
+    postal_code VARCHAR(20),
+    country VARCHAR(2),
+    nationality VARCHAR(2),
+    kyc_status VARCHAR(20) NOT NULL DEFAULT 'unverified',
+    kyc_level INTEGER NOT NULL DEFAULT 0,
+    rejection_reason TEXT,
+    submitted_at TIMESTAMP,
+    reviewed_at TIMESTAMP,
+    reviewed_by UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_profiles_kyc_status ON user_profiles(kyc_status);
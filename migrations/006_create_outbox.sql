@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMP,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox(created_at) WHERE published_at IS NULL;
+
+-- Webhook fan-out now consumes the event stream at least once, so a
+-- redelivered event must not queue a second delivery. Replays keep a link to
+-- the delivery they repeat and are exempt from the constraint.
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS replay_of UUID REFERENCES webhook_deliveries(id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries(subscription_id, event_id) WHERE replay_of IS NULL;
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS standing_orders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    recipient_id UUID NOT NULL REFERENCES users(id),
+    amount NUMERIC(20,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    description TEXT,
+    frequency VARCHAR(20) NOT NULL,
+    start_date TIMESTAMP NOT NULL,
+    end_date TIMESTAMP,
+    max_occurrences INTEGER NOT NULL DEFAULT 0,
+    occurrence INTEGER NOT NULL DEFAULT 0,
+    next_run_at TIMESTAMP NOT NULL,
+    next_attempt_at TIMESTAMP NOT NULL,
+    on_insufficient_funds VARCHAR(20) NOT NULL DEFAULT 'retry',
+    max_retries INTEGER NOT NULL DEFAULT 3,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_standing_orders_user_id ON standing_orders(user_id);
+CREATE INDEX IF NOT EXISTS idx_standing_orders_due ON standing_orders(status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS standing_order_executions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    standing_order_id UUID NOT NULL REFERENCES standing_orders(id),
+    scheduled_for TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    transaction_id UUID REFERENCES transactions(id),
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(standing_order_id, scheduled_for)
+);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS payment_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    requester_id UUID NOT NULL REFERENCES users(id),
+    payer_id UUID NOT NULL REFERENCES users(id),
+    amount NUMERIC(20,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    memo TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMP NOT NULL,
+    split_id UUID,
+    transaction_id UUID REFERENCES transactions(id),
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_requests_requester_id ON payment_requests(requester_id);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_payer_id ON payment_requests(payer_id);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_split_id ON payment_requests(split_id);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_pending_expiry ON payment_requests(expires_at) WHERE status = 'pending';
@@ -0,0 +1,23 @@
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS held NUMERIC(20,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS holds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    currency VARCHAR(3) NOT NULL,
+    amount NUMERIC(20,2) NOT NULL,
+    captured_amount NUMERIC(20,2) NOT NULL DEFAULT 0,
+    recipient_id UUID REFERENCES users(id),
+    reference VARCHAR(100),
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMP NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    created_by UUID NOT NULL REFERENCES users(id),
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_holds_user_id ON holds(user_id);
+CREATE INDEX IF NOT EXISTS idx_holds_active_expiry ON holds(expires_at) WHERE status = 'active';
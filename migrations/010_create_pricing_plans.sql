@@ -0,0 +1,48 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee NUMERIC(20,2) NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES transactions(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_parent_id ON transactions(parent_id);
+
+-- System account collecting fee revenue. The password is not a bcrypt hash,
+-- so nobody can log in as it.
+INSERT INTO users (id, email, password, role)
+VALUES ('00000000-0000-0000-0000-000000000fee', 'fee-revenue@system.takadao.local', '!', 'system')
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS pricing_plans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    free_transfers_per_month INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pricing_plans_default ON pricing_plans(is_default) WHERE is_default AND deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS fee_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    plan_id UUID NOT NULL REFERENCES pricing_plans(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    currency VARCHAR(3),
+    flat NUMERIC(20,2) NOT NULL DEFAULT 0,
+    percentage NUMERIC(8,4) NOT NULL DEFAULT 0,
+    tiers JSONB,
+    min_fee NUMERIC(20,2) NOT NULL DEFAULT 0,
+    max_fee NUMERIC(20,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fee_rules_plan_id ON fee_rules(plan_id);
+
+CREATE TABLE IF NOT EXISTS user_pricing_plans (
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    plan_id UUID NOT NULL REFERENCES pricing_plans(id),
+    assigned_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_pricing_plans_plan_id ON user_pricing_plans(plan_id);
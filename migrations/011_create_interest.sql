@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS interest_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    currency VARCHAR(3) NOT NULL,
+    effective_from DATE NOT NULL,
+    tiers JSONB NOT NULL,
+    day_count VARCHAR(10) NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(currency, effective_from)
+);
+
+CREATE TABLE IF NOT EXISTS interest_accruals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    currency VARCHAR(3) NOT NULL,
+    date DATE NOT NULL,
+    balance NUMERIC(20,2) NOT NULL,
+    amount NUMERIC(20,8) NOT NULL,
+    rate_id UUID NOT NULL REFERENCES interest_rates(id),
+    day_count VARCHAR(10) NOT NULL,
+    capitalized_at TIMESTAMP,
+    transaction_id UUID REFERENCES transactions(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, currency, date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_interest_accruals_uncapitalized ON interest_accruals(date) WHERE capitalized_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS interest_runs (
+    date DATE PRIMARY KEY,
+    accruals INTEGER NOT NULL,
+    completed_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    number VARCHAR(34) NOT NULL UNIQUE,
+    target_amount NUMERIC(20,2),
+    target_date DATE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_user_id ON accounts(user_id);
+-- Money without an explicit account lands on the main account, so there must
+-- be exactly one per user and currency
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_main ON accounts(user_id, currency)
+    WHERE type = 'main' AND deleted_at IS NULL;
+
+-- Every existing balance becomes the balance of a main account. Numbers follow
+-- NewAccountNumber: XT, mod 97 check digits, TAKA and ten random digits, where
+-- the check input is the digits followed by TAKA (29102010) and XT00 (332900).
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS account_id UUID REFERENCES accounts(id);
+
+WITH numbered AS (
+    SELECT b.id AS balance_id, b.user_id, b.currency,
+           lpad(floor(random() * 10000000000)::bigint::text, 10, '0') AS digits
+    FROM balances b
+    WHERE b.account_id IS NULL
+), created AS (
+    INSERT INTO accounts (user_id, name, type, currency, number)
+    SELECT user_id, 'Main', 'main', currency,
+           'XT' || lpad((98 - mod(('29102010' || digits || '332900')::numeric, 97))::text, 2, '0') || 'TAKA' || digits
+    FROM numbered
+    RETURNING id, user_id, currency
+)
+UPDATE balances b SET account_id = created.id
+FROM created
+WHERE b.user_id = created.user_id AND b.currency = created.currency AND b.account_id IS NULL;
+
+ALTER TABLE balances ALTER COLUMN account_id SET NOT NULL;
+ALTER TABLE balances DROP CONSTRAINT IF EXISTS balances_user_id_currency_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_balances_account_id ON balances(account_id);
+CREATE INDEX IF NOT EXISTS idx_balances_user_currency ON balances(user_id, currency);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS source_account_id UUID REFERENCES accounts(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS destination_account_id UUID REFERENCES accounts(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_source_account_id ON transactions(source_account_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_destination_account_id ON transactions(destination_account_id);
+
+ALTER TABLE holds ADD COLUMN IF NOT EXISTS account_id UUID REFERENCES accounts(id);
+UPDATE holds h SET account_id = b.account_id
+FROM balances b
+WHERE h.account_id IS NULL AND b.user_id = h.user_id AND b.currency = h.currency;
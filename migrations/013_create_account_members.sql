@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS account_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    role VARCHAR(20) NOT NULL,
+    spend_limit NUMERIC(20,2),
+    added_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+-- Removed members are soft-deleted and may be invited again
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_member ON account_members(account_id, user_id)
+    WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_account_members_user_id ON account_members(user_id);
+
+CREATE TABLE IF NOT EXISTS account_invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    spend_limit NUMERIC(20,2),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    invited_by UUID NOT NULL REFERENCES users(id),
+    expires_at TIMESTAMP NOT NULL,
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_invitations_account_id ON account_invitations(account_id);
+CREATE INDEX IF NOT EXISTS idx_account_invitations_email ON account_invitations(email) WHERE status = 'pending';
+
+-- Who made a transaction, which differs from user_id when a member acts on
+-- someone else's account
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS initiated_by UUID REFERENCES users(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_initiated_by ON transactions(initiated_by);
@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS approval_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    min_amount NUMERIC(20,2) NOT NULL DEFAULT 0,
+    max_amount NUMERIC(20,2),
+    required_approvals INTEGER NOT NULL CHECK (required_approvals > 0),
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_policies_account_id ON approval_policies(account_id);
+
+CREATE TABLE IF NOT EXISTS pending_payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    type VARCHAR(20) NOT NULL,
+    amount NUMERIC(20,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    recipient_id UUID REFERENCES users(id),
+    destination_account_id UUID REFERENCES accounts(id),
+    description TEXT,
+    initiated_by UUID NOT NULL REFERENCES users(id),
+    policy_id UUID NOT NULL REFERENCES approval_policies(id),
+    required_approvals INTEGER NOT NULL,
+    approvals INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMP NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    error TEXT,
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_payments_account_id ON pending_payments(account_id);
+CREATE INDEX IF NOT EXISTS idx_pending_payments_initiated_by ON pending_payments(initiated_by);
+CREATE INDEX IF NOT EXISTS idx_pending_payments_open_expiry ON pending_payments(expires_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS payment_approvals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pending_payment_id UUID NOT NULL REFERENCES pending_payments(id),
+    approver_id UUID NOT NULL REFERENCES users(id),
+    decision VARCHAR(10) NOT NULL,
+    comment TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(pending_payment_id, approver_id)
+);
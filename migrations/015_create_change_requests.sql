@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS change_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    action VARCHAR(40) NOT NULL,
+    target_id UUID NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    secret TEXT,
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    requested_by UUID NOT NULL REFERENCES users(id),
+    decided_by UUID REFERENCES users(id),
+    decision_comment TEXT,
+    decided_at TIMESTAMP,
+    error TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_change_requests_status ON change_requests(status);
+CREATE INDEX IF NOT EXISTS idx_change_requests_target_id ON change_requests(target_id);
+
+-- Append-only: rows are never updated or deleted
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID REFERENCES users(id),
+    action VARCHAR(50) NOT NULL,
+    change_request_id UUID REFERENCES change_requests(id),
+    target_type VARCHAR(30) NOT NULL,
+    target_id UUID NOT NULL,
+    comment TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_change_request_id ON audit_logs(change_request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target_id ON audit_logs(target_id);
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS adjustment_reasons (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(40) NOT NULL UNIQUE,
+    description TEXT NOT NULL,
+    direction VARCHAR(10),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Starting catalog; operations can add and deactivate codes
+INSERT INTO adjustment_reasons (code, description, direction) VALUES
+    ('BANK_ERROR', 'Correction of a booking error', NULL),
+    ('DUPLICATE_BOOKING', 'Removal of a transaction booked twice', 'debit'),
+    ('FEE_REFUND', 'Refund of a fee charged in error', 'credit'),
+    ('GOODWILL', 'Goodwill credit', 'credit'),
+    ('CHARGEBACK', 'Card or bank chargeback', 'debit')
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS adjustments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL UNIQUE REFERENCES transactions(id),
+    change_request_id UUID NOT NULL REFERENCES change_requests(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    direction VARCHAR(10) NOT NULL,
+    amount NUMERIC(20,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reason_code VARCHAR(40) NOT NULL REFERENCES adjustment_reasons(code),
+    note TEXT NOT NULL,
+    attachments JSONB NOT NULL DEFAULT '[]',
+    requested_by UUID NOT NULL REFERENCES users(id),
+    approved_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_adjustments_user_id ON adjustments(user_id);
+CREATE INDEX IF NOT EXISTS idx_adjustments_reason_code ON adjustments(reason_code);
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    balances_checked INTEGER NOT NULL DEFAULT 0,
+    discrepancy_count INTEGER NOT NULL DEFAULT 0,
+    totals JSONB NOT NULL DEFAULT '[]',
+    error TEXT,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_runs_started_at ON reconciliation_runs(started_at);
+
+-- A discrepancy without user_id is a currency whose stored total differs
+CREATE TABLE IF NOT EXISTS reconciliation_discrepancies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id UUID NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id),
+    currency VARCHAR(3) NOT NULL,
+    stored NUMERIC(20,2) NOT NULL,
+    computed NUMERIC(20,2) NOT NULL,
+    difference NUMERIC(20,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_run_id ON reconciliation_discrepancies(run_id);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_user_id ON reconciliation_discrepancies(user_id);
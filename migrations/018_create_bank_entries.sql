@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS bank_files (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    format VARCHAR(20) NOT NULL,
+    filename VARCHAR(255),
+    checksum VARCHAR(64) NOT NULL,
+    imported_by UUID NOT NULL REFERENCES users(id),
+    entries INTEGER NOT NULL DEFAULT 0,
+    booked INTEGER NOT NULL DEFAULT 0,
+    suspense INTEGER NOT NULL DEFAULT 0,
+    duplicates INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bank_files_checksum ON bank_files(checksum);
+
+-- entry_id is the bank's reference; its uniqueness makes imports idempotent
+CREATE TABLE IF NOT EXISTS bank_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_id UUID NOT NULL REFERENCES bank_files(id),
+    entry_id VARCHAR(100) NOT NULL UNIQUE,
+    booking_date DATE NOT NULL,
+    amount NUMERIC(20,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reference TEXT,
+    debtor_name VARCHAR(140),
+    debtor_account VARCHAR(34),
+    status VARCHAR(20) NOT NULL,
+    suspense_reason TEXT,
+    account_id UUID REFERENCES accounts(id),
+    user_id UUID REFERENCES users(id),
+    transaction_id UUID REFERENCES transactions(id),
+    allocated_by UUID REFERENCES users(id),
+    allocated_at TIMESTAMP,
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bank_entries_file_id ON bank_entries(file_id);
+CREATE INDEX IF NOT EXISTS idx_bank_entries_status ON bank_entries(status);
+CREATE INDEX IF NOT EXISTS idx_bank_entries_user_id ON bank_entries(user_id);
@@ -0,0 +1,41 @@
+-- message_id identifies a pain.001 file in the bank's status reports
+CREATE TABLE IF NOT EXISTS payout_batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    message_id VARCHAR(35) NOT NULL UNIQUE,
+    count INTEGER NOT NULL,
+    control_sum NUMERIC(20,2) NOT NULL,
+    document TEXT NOT NULL,
+    completed INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    reported_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- A payout belongs to the withdrawal that booked its money; end_to_end_id
+-- travels with the credit transfer and comes back in status reports
+CREATE TABLE IF NOT EXISTS payouts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL UNIQUE REFERENCES transactions(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    amount NUMERIC(20,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    iban VARCHAR(34) NOT NULL,
+    bic VARCHAR(11),
+    creditor_name VARCHAR(140) NOT NULL,
+    remittance_info VARCHAR(140),
+    end_to_end_id VARCHAR(35) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    batch_id UUID REFERENCES payout_batches(id),
+    status_reason TEXT,
+    refund_transaction_id UUID REFERENCES transactions(id),
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payouts_user_id ON payouts(user_id);
+CREATE INDEX IF NOT EXISTS idx_payouts_status ON payouts(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_payouts_batch_id ON payouts(batch_id);
+
+-- External account of a withdrawal waiting for approval
+ALTER TABLE pending_payments ADD COLUMN IF NOT EXISTS payout JSONB;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS beneficiaries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    nickname VARCHAR(50) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    iban VARCHAR(34),
+    bic VARCHAR(11),
+    name VARCHAR(140),
+    email VARCHAR(255),
+    phone VARCHAR(32),
+    recipient_id UUID REFERENCES users(id),
+    account_id UUID REFERENCES accounts(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_beneficiaries_user_nickname ON beneficiaries(user_id, LOWER(nickname));
+CREATE INDEX IF NOT EXISTS idx_beneficiaries_recipient_id ON beneficiaries(recipient_id);
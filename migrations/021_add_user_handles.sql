@@ -0,0 +1,4 @@
+-- Handles are unique among active users; a deleted user's handle can be taken
+ALTER TABLE users ADD COLUMN IF NOT EXISTS handle VARCHAR(30);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_handle ON users(handle) WHERE deleted_at IS NULL;
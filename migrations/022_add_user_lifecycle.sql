@@ -0,0 +1,9 @@
+-- Users are active, frozen, dormant or closed; the freeze scope says whether
+-- a freeze blocks outgoing or all transactions
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS freeze_scope VARCHAR(20);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_reason TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_status ON users(status);
@@ -0,0 +1,17 @@
+-- Erasures of users' personal data, with when the data kept for the
+-- retention period is due to be purged
+CREATE TABLE IF NOT EXISTS data_erasures (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id),
+    requested_by UUID REFERENCES users(id),
+    closed_at TIMESTAMP NOT NULL,
+    erased_at TIMESTAMP NOT NULL,
+    identity_purge_at TIMESTAMP NOT NULL,
+    identity_purged_at TIMESTAMP,
+    financial_purge_at TIMESTAMP NOT NULL,
+    financial_purged_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_erasures_identity_purge_at ON data_erasures(identity_purge_at) WHERE identity_purged_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_data_erasures_financial_purge_at ON data_erasures(financial_purge_at) WHERE financial_purged_at IS NULL;
@@ -0,0 +1,9 @@
+-- Hashes of the passwords users had, so recent ones are not reused
+CREATE TABLE IF NOT EXISTS previous_passwords (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_previous_passwords_user_id ON previous_passwords(user_id, created_at);
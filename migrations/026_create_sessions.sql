@@ -0,0 +1,13 @@
+-- Login sessions of users; the tokens issued at login are bound to them
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    device_name VARCHAR(100) NOT NULL,
+    user_agent VARCHAR(500),
+    ip VARCHAR(45),
+    last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id, last_seen_at) WHERE revoked_at IS NULL;